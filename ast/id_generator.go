@@ -0,0 +1,62 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package ast
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// IDGenerator 用于生成节点 ID。
+type IDGenerator interface {
+	// NewNodeID 为节点 node 生成 ID，node 可能为 nil。
+	NewNodeID(node *Node) string
+}
+
+// IDGeneratorFunc 将普通函数适配为 IDGenerator。
+type IDGeneratorFunc func(node *Node) string
+
+func (f IDGeneratorFunc) NewNodeID(node *Node) string {
+	return f(node)
+}
+
+// SeededIDGenerator 使用固定的创建时间和随机数种子生成可重现的节点 ID，种子相同时生成的 ID 序列相同。
+type SeededIDGenerator struct {
+	created string     // 创建时间，作为 ID 的时间部分
+	rand    *rand.Rand // 随机数生成器
+	mutex   sync.Mutex
+}
+
+// NewSeededIDGenerator 使用随机数种子 seed 和创建时间 created 构造一个 SeededIDGenerator。
+func NewSeededIDGenerator(seed int64, created time.Time) *SeededIDGenerator {
+	return &SeededIDGenerator{created: created.Format("20060102150405"), rand: rand.New(rand.NewSource(seed))}
+}
+
+func (g *SeededIDGenerator) NewNodeID(node *Node) string {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	letter := "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, 7)
+	for i := range b {
+		b[i] = letter[g.rand.Intn(len(letter))]
+	}
+	return g.created + "-" + string(b)
+}
+
+// NodeIDUpdated 返回节点 ID id 中的时间部分作为更新时间，id 不是 20060102150405-1a2b3c4 形式时返回当前时间。
+func NodeIDUpdated(id string) string {
+	if IsNodeIDPattern(id) {
+		return id[:14]
+	}
+	return time.Now().Format("20060102150405")
+}
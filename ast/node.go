@@ -146,6 +146,10 @@ type Node struct {
 	CalloutTitle    string `json:",omitempty"` // 提示块标题
 	CalloutIcon     string `json:",omitempty"` // 提示块图标（从 Title 中第一个空格前面的部分进行解析）
 	CalloutIconType int    `json:",omitempty"` // 提示块图标类型，0：Emoji Unicode，1：自定义图标
+
+	// 源码位置
+
+	Position *Position `json:",omitempty"` // 节点在原始输入中的位置，仅在解析选项 SourcePos 开启时记录
 }
 
 const (
@@ -659,17 +663,7 @@ func (n *Node) Stat() (runeCnt, wordCnt, linkCnt, imgCnt, refCnt int) {
 			NodeGitConflictContent:
 			buf = append(buf, n.Tokens...)
 		case NodeTextMark:
-			if 0 < len(n.TextMarkTextContent) {
-				buf = append(buf, n.TextMarkTextContent...)
-			} else if 0 < len(n.TextMarkInlineMathContent) {
-				content := n.TextMarkInlineMathContent
-				content = strings.ReplaceAll(content, editor.IALValEscNewLine, " ")
-				buf = append(buf, content...)
-			} else if "" != n.TextMarkInlineMemoContent {
-				content := n.TextMarkInlineMemoContent
-				content = strings.ReplaceAll(content, editor.IALValEscNewLine, " ")
-				buf = append(buf, content...)
-			}
+			buf = append(buf, n.statText()...)
 
 			if n.IsTextMarkType("a") {
 				linkCnt++
@@ -695,6 +689,22 @@ func (n *Node) Stat() (runeCnt, wordCnt, linkCnt, imgCnt, refCnt int) {
 	return
 }
 
+// statText 返回统计字数时 n 自身（不包括子节点）的文本。
+func (n *Node) statText() string {
+	if NodeTextMark != n.Type {
+		return string(n.Tokens)
+	}
+
+	if 0 < len(n.TextMarkTextContent) {
+		return n.TextMarkTextContent
+	} else if 0 < len(n.TextMarkInlineMathContent) {
+		return strings.ReplaceAll(n.TextMarkInlineMathContent, editor.IALValEscNewLine, " ")
+	} else if "" != n.TextMarkInlineMemoContent {
+		return strings.ReplaceAll(n.TextMarkInlineMemoContent, editor.IALValEscNewLine, " ")
+	}
+	return ""
+}
+
 // TokenLen 返回 n 及其子节点 tokens 累计长度。
 func (n *Node) TokenLen() (ret int) {
 	Walk(n, func(n *Node, entering bool) WalkStatus {
@@ -892,19 +902,20 @@ func (n *Node) IsBlock() bool {
 	case NodeDocument, NodeParagraph, NodeHeading, NodeThematicBreak, NodeBlockquote, NodeList, NodeListItem, NodeHTMLBlock,
 		NodeCodeBlock, NodeTable, NodeMathBlock, NodeFootnotesDefBlock, NodeFootnotesDef, NodeToC, NodeYamlFrontMatter,
 		NodeBlockQueryEmbed, NodeKramdownBlockIAL, NodeSuperBlock, NodeGitConflict, NodeAudio, NodeVideo, NodeIFrame, NodeWidget,
-		NodeAttributeView, NodeCustomBlock, NodeCallout:
+		NodeAttributeView, NodeCustomBlock, NodeCallout, NodeGitConflictOurs, NodeGitConflictBase, NodeGitConflictTheirs:
 		return true
 	}
-	return false
+	return IsExtBlockType(n.Type)
 }
 
 // IsContainerBlock 判断 n 是否为容器块。
 func (n *Node) IsContainerBlock() bool {
 	switch n.Type {
-	case NodeDocument, NodeBlockquote, NodeList, NodeListItem, NodeFootnotesDefBlock, NodeFootnotesDef, NodeSuperBlock, NodeCallout:
+	case NodeDocument, NodeBlockquote, NodeList, NodeListItem, NodeFootnotesDefBlock, NodeFootnotesDef, NodeSuperBlock, NodeCallout,
+		NodeGitConflictOurs, NodeGitConflictBase, NodeGitConflictTheirs:
 		return true
 	}
-	return false
+	return IsExtContainerBlockType(n.Type)
 }
 
 // IsMarker 判断 n 是否为节点标记符。
@@ -941,7 +952,7 @@ func (n *Node) AcceptLines() bool {
 		NodeGitConflict, NodeIFrame, NodeWidget, NodeVideo, NodeAudio, NodeAttributeView, NodeCustomBlock:
 		return true
 	}
-	return false
+	return IsExtLeafBlockType(n.Type)
 }
 
 // CanContain 判断是否能够包含 NodeType 指定类型的节点。 比如列表节点（块级容器）只能包含列表项节点，
@@ -964,9 +975,32 @@ func (n *Node) CanContain(nodeType NodeType) bool {
 		}
 		return true
 	}
+	if IsExtLeafBlockType(n.Type) {
+		return false
+	}
 	return NodeListItem != nodeType
 }
 
+// IsExtBlockType 判断 typ 是否为块级语法扩展使用的节点类型。
+func IsExtBlockType(typ NodeType) bool {
+	return NodeExtContainerBlock <= typ && NodeExtBlockMaxVal >= typ
+}
+
+// IsExtContainerBlockType 判断 typ 是否为块级语法扩展使用的容器块节点类型。
+func IsExtContainerBlockType(typ NodeType) bool {
+	return NodeExtContainerBlock <= typ && NodeExtLeafBlock > typ
+}
+
+// IsExtLeafBlockType 判断 typ 是否为块级语法扩展使用的叶子块节点类型。
+func IsExtLeafBlockType(typ NodeType) bool {
+	return NodeExtLeafBlock <= typ && NodeExtBlockMaxVal >= typ
+}
+
+// IsExtInlineType 判断 typ 是否为行级语法扩展使用的节点类型。
+func IsExtInlineType(typ NodeType) bool {
+	return NodeExtInline <= typ && NodeExtInlineMaxVal >= typ
+}
+
 //go:generate stringer -type=NodeType
 type NodeType int
 
@@ -1151,7 +1185,7 @@ const (
 
 	NodeGitConflict            NodeType = 495 // Git 冲突标记
 	NodeGitConflictOpenMarker  NodeType = 496 // 开始 Git 冲突标记标记符 <<<<<<<
-	NodeGitConflictContent     NodeType = 497 // Git 冲突标记内容
+	NodeGitConflictContent     NodeType = 497 // Git 冲突标记内容，解析时已经细化为 NodeGitConflictOurs、NodeGitConflictBase 和 NodeGitConflictTheirs，仅用于兼容旧的语法树
 	NodeGitConflictCloseMarker NodeType = 498 // 结束 Git 冲突标记标记符 >>>>>>>
 
 	// <iframe> 标签
@@ -1215,5 +1249,22 @@ const (
 
 	NodeCallout NodeType = 580 // 提示块
 
+	// Git 冲突标记内容，NodeGitConflict 中按照冲突各方的内容分组的块
+
+	NodeGitConflictOurs   NodeType = 585 // Git 冲突中本地原来的内容，位于 <<<<<<< 和 ||||||| 或者 ======= 之间
+	NodeGitConflictBase   NodeType = 586 // Git 冲突中共同祖先的内容（diff3 风格），位于 ||||||| 和 ======= 之间，Tokens 为 ||||||| 所在行
+	NodeGitConflictTheirs NodeType = 587 // Git 冲突中拉取下来的内容，位于 ======= 和 >>>>>>> 之间
+
+	// 块级语法扩展 parse.BlockExtension 使用的保留节点类型，[800, 850) 为容器块，[850, 899] 为叶子块
+
+	NodeExtContainerBlock NodeType = 800 // 扩展容器块节点类型起始值
+	NodeExtLeafBlock      NodeType = 850 // 扩展叶子块节点类型起始值
+	NodeExtBlockMaxVal    NodeType = 899 // 扩展块节点类型最大值
+
+	// 行级语法扩展 parse.InlineExtension 使用的保留节点类型，[900, 949]
+
+	NodeExtInline       NodeType = 900 // 扩展行级节点类型起始值
+	NodeExtInlineMaxVal NodeType = 949 // 扩展行级节点类型最大值
+
 	NodeTypeMaxVal NodeType = 1024 // 节点类型最大值
 )
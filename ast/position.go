@@ -0,0 +1,38 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package ast
+
+import "strconv"
+
+// Position 描述了节点在原始输入中的位置。
+//
+// 行号和列号从 1 开始，列号按字节计算；偏移量从 0 开始。节点范围为左闭右开区间，即结束位置指向节点最后一个字节之后。
+type Position struct {
+	StartLine   int // 起始行号
+	StartColumn int // 起始列号
+	StartOffset int // 起始字节偏移
+	EndLine     int // 结束行号
+	EndColumn   int // 结束列号
+	EndOffset   int // 结束字节偏移
+}
+
+// String 返回 "startLine:startColumn-endLine:endColumn" 形式的位置描述。
+func (p *Position) String() string {
+	if nil == p {
+		return ""
+	}
+	return strconv.Itoa(p.StartLine) + ":" + strconv.Itoa(p.StartColumn) + "-" + strconv.Itoa(p.EndLine) + ":" + strconv.Itoa(p.EndColumn)
+}
+
+// Contains 判断原始输入中的偏移 offset 是否位于 p 范围内。
+func (p *Position) Contains(offset int) bool {
+	return nil != p && p.StartOffset <= offset && offset < p.EndOffset
+}
@@ -0,0 +1,176 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package ast
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+)
+
+// 估算阅读时间使用的阅读速度。
+var (
+	ReadingCJKCharsPerMinute   = 300 // 每分钟阅读的中日韩字符数
+	ReadingLatinWordsPerMinute = 200 // 每分钟阅读的拉丁文单词数
+)
+
+// DocStats 描述了文档的扩展统计信息。
+type DocStats struct {
+	RuneCount  int `json:"runeCount"`  // 字符数，同 Stat
+	WordCount  int `json:"wordCount"`  // 字数，同 Stat
+	LinkCount  int `json:"linkCount"`  // 链接数，同 Stat
+	ImageCount int `json:"imageCount"` // 图片数，同 Stat
+	RefCount   int `json:"refCount"`   // 引用数，同 Stat
+
+	CJKCharCount   int `json:"cjkCharCount"`   // 中日韩字符数
+	LatinWordCount int `json:"latinWordCount"` // 非中日韩文字的单词数
+	ReadingMinutes int `json:"readingMinutes"` // 估算的阅读时间（分钟，向上取整）
+
+	HeadingCounts     map[int]int    `json:"headingCounts"`     // 各级标题数，键为标题级别
+	CodeBlockCounts   map[string]int `json:"codeBlockCounts"`   // 各语言代码块数，键为语言，未指定语言时为 ""
+	TableCount        int            `json:"tableCount"`        // 表格数
+	MathBlockCount    int            `json:"mathBlockCount"`    // 公式块数
+	TaskDoneCount     int            `json:"taskDoneCount"`     // 已完成任务列表项数
+	TaskUndoneCount   int            `json:"taskUndoneCount"`   // 未完成任务列表项数
+	FootnoteCount     int            `json:"footnoteCount"`     // 脚注定义数
+	ParagraphCount    int            `json:"paragraphCount"`    // 段落数
+	SentenceCount     int            `json:"sentenceCount"`     // 段落中的句子数
+	AvgSentenceWords  float64        `json:"avgSentenceWords"`  // 平均句子长度（字数）
+	AvgParagraphWords float64        `json:"avgParagraphWords"` // 平均段落长度（字数）
+	MaxHeadingDepth   int            `json:"maxHeadingDepth"`   // 标题大纲的最大嵌套深度
+}
+
+// DocStats 返回 n 的扩展统计信息，支持 Markdown 语法树和基于 TextMark 的 Protyle 语法树。
+func (n *Node) DocStats() (ret *DocStats) {
+	ret = &DocStats{HeadingCounts: map[int]int{}, CodeBlockCounts: map[string]int{}}
+	ret.RuneCount, ret.WordCount, ret.LinkCount, ret.ImageCount, ret.RefCount = n.Stat()
+
+	var headingLevels []int // 当前标题大纲路径上的标题级别
+	var paragraphWords int
+	Walk(n, func(n *Node, entering bool) WalkStatus {
+		if !entering {
+			return WalkContinue
+		}
+
+		switch n.Type {
+		case NodeText, NodeLinkText, NodeBlockRefText, NodeBlockRefDynamicText, NodeFileAnnotationRefText, NodeFootnotesRef,
+			NodeCodeSpanContent, NodeCodeBlockCode, NodeInlineMathContent, NodeMathBlockContent,
+			NodeHTMLEntity, NodeEmojiAlias, NodeEmojiUnicode, NodeBackslashContent, NodeYamlFrontMatterContent,
+			NodeGitConflictContent, NodeTextMark:
+			cjk, latin := scriptCount(n.statText())
+			ret.CJKCharCount += cjk
+			ret.LatinWordCount += latin
+		case NodeHeading:
+			ret.HeadingCounts[n.HeadingLevel]++
+			for 0 < len(headingLevels) && headingLevels[len(headingLevels)-1] >= n.HeadingLevel {
+				headingLevels = headingLevels[:len(headingLevels)-1]
+			}
+			headingLevels = append(headingLevels, n.HeadingLevel)
+			if ret.MaxHeadingDepth < len(headingLevels) {
+				ret.MaxHeadingDepth = len(headingLevels)
+			}
+		case NodeCodeBlock:
+			var language string
+			if info := n.ChildByType(NodeCodeBlockFenceInfoMarker); nil != info {
+				if fields := bytes.Fields(info.CodeBlockInfo); 0 < len(fields) {
+					language = string(fields[0])
+				}
+			}
+			ret.CodeBlockCounts[language]++
+		case NodeTable:
+			ret.TableCount++
+		case NodeMathBlock:
+			ret.MathBlockCount++
+		case NodeTaskListItemMarker:
+			if n.TaskListItemChecked {
+				ret.TaskDoneCount++
+			} else {
+				ret.TaskUndoneCount++
+			}
+		case NodeFootnotesDef:
+			ret.FootnoteCount++
+		case NodeParagraph:
+			content := n.Content()
+			if "" == strings.TrimSpace(content) {
+				return WalkContinue
+			}
+			ret.ParagraphCount++
+			for _, sentence := range splitSentences(content) {
+				cjk, latin := scriptCount(sentence)
+				if words := cjk + latin; 0 < words {
+					ret.SentenceCount++
+					paragraphWords += words
+				}
+			}
+		}
+		return WalkContinue
+	})
+
+	if 0 < ret.SentenceCount {
+		ret.AvgSentenceWords = float64(paragraphWords) / float64(ret.SentenceCount)
+	}
+	if 0 < ret.ParagraphCount {
+		ret.AvgParagraphWords = float64(paragraphWords) / float64(ret.ParagraphCount)
+	}
+	minutes := float64(ret.CJKCharCount)/float64(ReadingCJKCharsPerMinute) + float64(ret.LatinWordCount)/float64(ReadingLatinWordsPerMinute)
+	ret.ReadingMinutes = int(minutes)
+	if float64(ret.ReadingMinutes) < minutes {
+		ret.ReadingMinutes++
+	}
+	return
+}
+
+// scriptCount 统计文本 text 中的中日韩字符数 cjk 和其他文字的单词数 latin。
+func scriptCount(text string) (cjk, latin int) {
+	inWord := false
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				latin++
+				inWord = true
+			}
+		case '\'' == r || '’' == r || '-' == r:
+			// 单词内部的撇号和连字符不分隔单词
+		default:
+			inWord = false
+		}
+	}
+	return
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// splitSentences 按照中西文句末标点将文本 text 切分为句子。
+func splitSentences(text string) (ret []string) {
+	var start int
+	runes := []rune(text)
+	for i, r := range runes {
+		if !strings.ContainsRune(".!?。！？…\n", r) {
+			continue
+		}
+		if '.' == r && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			// 小数点、缩写和网址中的点不作为句末
+			continue
+		}
+		ret = append(ret, string(runes[start:i+1]))
+		start = i + 1
+	}
+	if start < len(runes) {
+		ret = append(ret, string(runes[start:]))
+	}
+	return
+}
@@ -0,0 +1,277 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+// Package diff 提供了两棵语法树之间的结构化差异比较。
+package diff
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+)
+
+// Op 描述了差异操作类型。
+type Op int
+
+const (
+	OpEqual  Op = iota // 未修改
+	OpInsert           // 新增
+	OpDelete           // 删除
+	OpModify           // 修改，块位置不变
+	OpMove             // 移动，块内容可能也有修改
+)
+
+func (op Op) String() string {
+	switch op {
+	case OpEqual:
+		return "equal"
+	case OpInsert:
+		return "insert"
+	case OpDelete:
+		return "delete"
+	case OpModify:
+		return "modify"
+	case OpMove:
+		return "move"
+	}
+	return "Op(" + strconv.Itoa(int(op)) + ")"
+}
+
+// minSimilarity 为按内容匹配块时要求的最低相似度。
+const minSimilarity = 0.5
+
+// Result 描述了两棵语法树之间的差异。
+type Result struct {
+	Old, New *parse.Tree    // 比较的新旧语法树
+	Changes  []*BlockChange // 顶层块的变化，按照新语法树中的顺序排列，删除的块位于其在旧语法树中的相对位置
+}
+
+// BlockChange 描述了一个顶层块的变化。
+type BlockChange struct {
+	Op       Op
+	Old      *ast.Node     // 旧语法树中的块，新增时为 nil
+	New      *ast.Node     // 新语法树中的块，删除时为 nil
+	Modified bool          // 块内容是否有修改，OpModify 时总是为 true，OpMove 时表示移动后内容是否也有修改
+	Texts    []*TextChange // 块内容有修改时的行级文本变化
+}
+
+// TextChange 描述了一段文本的变化。
+type TextChange struct {
+	Op   Op        // OpEqual、OpInsert 或者 OpDelete
+	Text string    // 文本内容
+	Node *ast.Node // 文本所在的节点，删除时为旧语法树中的节点，否则为新语法树中的节点
+}
+
+// Diff 比较新旧语法树 oldTree 和 newTree 的顶层块。
+//
+// 两个块都有 ID（比如开启了 KramdownBlockIAL）时按照 ID 匹配，其余的块先按照内容完全一致匹配，再在相同类型的块中按照文本相似度匹配。
+// 匹配上的块中，相对顺序发生变化的块视为移动，其余的块视为未修改或者修改；没有匹配上的块视为新增或者删除。
+func Diff(oldTree, newTree *parse.Tree) (ret *Result) {
+	ret = &Result{Old: oldTree, New: newTree}
+	olds, news := newBlocks(oldTree), newBlocks(newTree)
+	oldMatches, newMatches := match(olds, news)
+
+	// 匹配的块对中旧块下标的最长递增子序列保持了相对顺序，其余的块视为移动
+	var pairs []int
+	for j := range news {
+		if -1 < newMatches[j] {
+			pairs = append(pairs, j)
+		}
+	}
+	stable := map[int]bool{}
+	for _, j := range longestIncreasing(pairs, func(j int) int { return newMatches[j] }) {
+		stable[j] = true
+	}
+
+	i := 0
+	for j, n := range news {
+		k := newMatches[j]
+		if -1 == k {
+			ret.Changes = append(ret.Changes, &BlockChange{Op: OpInsert, New: n.node})
+			continue
+		}
+
+		change := newChange(olds[k], n)
+		if stable[j] {
+			for ; i < k; i++ {
+				if -1 == oldMatches[i] {
+					ret.Changes = append(ret.Changes, &BlockChange{Op: OpDelete, Old: olds[i].node})
+				}
+			}
+			i = k + 1
+			if change.Modified {
+				change.Op = OpModify
+			}
+		} else {
+			change.Op = OpMove
+		}
+		ret.Changes = append(ret.Changes, change)
+	}
+	for ; i < len(olds); i++ {
+		if -1 == oldMatches[i] {
+			ret.Changes = append(ret.Changes, &BlockChange{Op: OpDelete, Old: olds[i].node})
+		}
+	}
+	return
+}
+
+// block 描述了参与比较的顶层块。
+type block struct {
+	node      *ast.Node
+	signature string   // 块的结构和内容签名，签名一致说明块没有修改
+	tokens    []*token // 块的文本切分结果
+}
+
+func newBlocks(tree *parse.Tree) (ret []*block) {
+	for n := tree.Root.FirstChild; nil != n; n = n.Next {
+		if ast.NodeKramdownBlockIAL == n.Type {
+			continue
+		}
+		ret = append(ret, &block{node: n, signature: signature(n), tokens: tokenize(n)})
+	}
+	return
+}
+
+func newChange(old, new *block) *BlockChange {
+	ret := &BlockChange{Op: OpEqual, Old: old.node, New: new.node}
+	if old.signature != new.signature {
+		ret.Modified = true
+		ret.Texts = diffTokens(old.tokens, new.tokens)
+	}
+	return ret
+}
+
+// match 匹配新旧块，返回每个块匹配的另一个块的下标，没有匹配时为 -1。
+func match(olds, news []*block) (oldMatches, newMatches []int) {
+	oldMatches, newMatches = make([]int, len(olds)), make([]int, len(news))
+	for i := range oldMatches {
+		oldMatches[i] = -1
+	}
+	for j := range newMatches {
+		newMatches[j] = -1
+	}
+	pair := func(i, j int) {
+		oldMatches[i], newMatches[j] = j, i
+	}
+
+	ids := map[string]int{}
+	for j, n := range news {
+		if "" != n.node.ID {
+			ids[n.node.ID] = j
+		}
+	}
+	for i, o := range olds {
+		if "" == o.node.ID {
+			continue
+		}
+		if j, ok := ids[o.node.ID]; ok && -1 == newMatches[j] {
+			pair(i, j)
+		}
+	}
+
+	signatures := map[string][]int{}
+	for j, n := range news {
+		if -1 == newMatches[j] {
+			signatures[n.signature] = append(signatures[n.signature], j)
+		}
+	}
+	for i, o := range olds {
+		if -1 != oldMatches[i] {
+			continue
+		}
+		if candidates := signatures[o.signature]; 0 < len(candidates) {
+			pair(i, candidates[0])
+			signatures[o.signature] = candidates[1:]
+		}
+	}
+
+	for i, o := range olds {
+		if -1 != oldMatches[i] {
+			continue
+		}
+		best, bestSimilarity := -1, minSimilarity
+		for j, n := range news {
+			if -1 != newMatches[j] || o.node.Type != n.node.Type {
+				continue
+			}
+			if s := similarity(o.tokens, n.tokens, bestSimilarity); s >= bestSimilarity && (-1 == best || s > bestSimilarity) {
+				best, bestSimilarity = j, s
+			}
+		}
+		if -1 < best {
+			pair(i, best)
+		}
+	}
+	return
+}
+
+// signature 返回块的签名，包括所有子节点的类型和内容，不包括 IAL。
+func signature(node *ast.Node) string {
+	buf := &bytes.Buffer{}
+	ast.Walk(node, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if ast.NodeKramdownBlockIAL == n.Type || ast.NodeKramdownSpanIAL == n.Type {
+			return ast.WalkSkipChildren
+		}
+		if !entering {
+			buf.WriteByte(')')
+			return ast.WalkContinue
+		}
+		buf.WriteString(strconv.Itoa(int(n.Type)))
+		buf.WriteByte('(')
+		if ast.NodeHeading == n.Type {
+			buf.WriteString(strconv.Itoa(n.HeadingLevel))
+		}
+		buf.Write(n.CodeBlockInfo)
+		buf.WriteString(n.TextMarkType)
+		buf.WriteString(n.TextMarkTextContent)
+		buf.Write(n.Tokens)
+		return ast.WalkContinue
+	})
+	return buf.String()
+}
+
+// longestIncreasing 返回 items 中按照 key 严格递增的最长子序列。
+func longestIncreasing(items []int, key func(int) int) (ret []int) {
+	if 1 > len(items) {
+		return
+	}
+
+	tails := []int{}                // tails[l] 为长度为 l+1 的递增子序列的最后一个元素在 items 中的下标
+	prev := make([]int, len(items)) // 子序列中前一个元素在 items 中的下标
+	for i, item := range items {
+		k := key(item)
+		lo, hi := 0, len(tails)
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if key(items[tails[mid]]) < k {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+		prev[i] = -1
+		if 0 < lo {
+			prev[i] = tails[lo-1]
+		}
+		if lo == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[lo] = i
+		}
+	}
+
+	ret = make([]int, len(tails))
+	for i, k := len(tails)-1, tails[len(tails)-1]; 0 <= i; i, k = i-1, prev[k] {
+		ret[i] = items[k]
+	}
+	return
+}
@@ -0,0 +1,175 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package diff
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/88250/lute/ast"
+)
+
+// maxLCSCells 为计算最长公共子序列时允许的最大表格大小，超过时不再逐词比较，整段视为删除后新增。
+const maxLCSCells = 1 << 22
+
+// token 描述了文本比较的最小单位：一个单词、一段连续的空白或者一个其他字符（比如中文字符、标点符号）。
+type token struct {
+	text string
+	node *ast.Node // 所在的文本节点
+}
+
+// tokenize 将块中所有文本节点的内容切分为 token。
+func tokenize(block *ast.Node) (ret []*token) {
+	ast.Walk(block, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.WalkContinue
+		}
+
+		var text string
+		switch n.Type {
+		case ast.NodeKramdownBlockIAL, ast.NodeKramdownSpanIAL:
+			return ast.WalkSkipChildren
+		case ast.NodeText, ast.NodeLinkText, ast.NodeBlockRefText, ast.NodeBlockRefDynamicText, ast.NodeFileAnnotationRefText,
+			ast.NodeCodeSpanContent, ast.NodeCodeBlockCode, ast.NodeInlineMathContent, ast.NodeMathBlockContent,
+			ast.NodeHTMLBlock, ast.NodeInlineHTML, ast.NodeHTMLEntity, ast.NodeEmojiAlias, ast.NodeBackslashContent,
+			ast.NodeYamlFrontMatterContent, ast.NodeGitConflictContent:
+			text = n.TokensStr()
+		case ast.NodeTextMark:
+			text = n.TextMarkTextContent
+		default:
+			return ast.WalkContinue
+		}
+
+		for i := 0; i < len(text); {
+			r, size := utf8.DecodeRuneInString(text[i:])
+			end := i + size
+			if isWord(r) || unicode.IsSpace(r) {
+				space := unicode.IsSpace(r)
+				for end < len(text) {
+					next, nextSize := utf8.DecodeRuneInString(text[end:])
+					if space != unicode.IsSpace(next) || (!space && !isWord(next)) {
+						break
+					}
+					end += nextSize
+				}
+			}
+			ret = append(ret, &token{text: text[i:end], node: n})
+			i = end
+		}
+		return ast.WalkContinue
+	})
+	return
+}
+
+// isWord 判断 r 是否为可以组成单词的字符，中日韩字符不组成单词，单独比较。
+func isWord(r rune) bool {
+	if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+		return false
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || '_' == r
+}
+
+// diffTokens 比较新旧 token 序列，相邻的同一节点上的相同操作会合并为一个 TextChange。
+func diffTokens(olds, news []*token) (ret []*TextChange) {
+	add := func(op Op, t *token) {
+		if last := len(ret) - 1; 0 <= last && op == ret[last].Op && t.node == ret[last].Node {
+			ret[last].Text += t.text
+			return
+		}
+		ret = append(ret, &TextChange{Op: op, Text: t.text, Node: t.node})
+	}
+
+	prefix, suffix := commonAffix(olds, news)
+	for _, t := range news[:prefix] {
+		add(OpEqual, t)
+	}
+
+	a, b := olds[prefix:len(olds)-suffix], news[prefix:len(news)-suffix]
+	table := lcsTable(a, b)
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case nil != table && i < len(a) && j < len(b) && a[i].text == b[j].text:
+			add(OpEqual, b[j])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || nil == table || table[i+1][j] >= table[i][j+1]):
+			add(OpDelete, a[i])
+			i++
+		default:
+			add(OpInsert, b[j])
+			j++
+		}
+	}
+
+	for _, t := range news[len(news)-suffix:] {
+		add(OpEqual, t)
+	}
+	return
+}
+
+// similarity 返回新旧 token 序列的相似度，取值范围为 [0, 1]。相似度不可能达到 min 时直接返回 0。
+func similarity(olds, news []*token, min float64) float64 {
+	total := len(olds) + len(news)
+	if 1 > total {
+		return 1
+	}
+	shorter := len(olds)
+	if len(news) < shorter {
+		shorter = len(news)
+	}
+	if float64(2*shorter)/float64(total) < min {
+		return 0
+	}
+
+	prefix, suffix := commonAffix(olds, news)
+	a, b := olds[prefix:len(olds)-suffix], news[prefix:len(news)-suffix]
+	common := prefix + suffix
+	if table := lcsTable(a, b); nil != table {
+		common += table[0][0]
+	}
+	return float64(2*common) / float64(total)
+}
+
+// commonAffix 返回新旧 token 序列的公共前缀和公共后缀长度，两者不重叠。
+func commonAffix(olds, news []*token) (prefix, suffix int) {
+	for prefix < len(olds) && prefix < len(news) && olds[prefix].text == news[prefix].text {
+		prefix++
+	}
+	for suffix < len(olds)-prefix && suffix < len(news)-prefix && olds[len(olds)-1-suffix].text == news[len(news)-1-suffix].text {
+		suffix++
+	}
+	return
+}
+
+// lcsTable 计算最长公共子序列表，table[i][j] 为 a[i:] 和 b[j:] 的最长公共子序列长度。表格过大时返回 nil。
+func lcsTable(a, b []*token) (table [][]int) {
+	if (len(a)+1)*(len(b)+1) > maxLCSCells {
+		return nil
+	}
+
+	table = make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; 0 <= i; i-- {
+		for j := len(b) - 1; 0 <= j; j-- {
+			if a[i].text == b[j].text {
+				table[i][j] = table[i+1][j+1] + 1
+			} else if table[i+1][j] >= table[i][j+1] {
+				table[i][j] = table[i+1][j]
+			} else {
+				table[i][j] = table[i][j+1]
+			}
+		}
+	}
+	return
+}
@@ -5,6 +5,7 @@ go 1.18
 require (
 	github.com/alecthomas/chroma v0.10.0
 	github.com/gopherjs/gopherjs v1.17.2
+	github.com/microcosm-cc/bluemonday v1.0.27
 	golang.org/x/text v0.21.0
 )
 
@@ -12,6 +13,5 @@ require (
 	github.com/aymerick/douceur v0.2.0 // indirect
 	github.com/dlclark/regexp2 v1.11.4 // indirect
 	github.com/gorilla/css v1.0.1 // indirect
-	github.com/microcosm-cc/bluemonday v1.0.27 // indirect
 	golang.org/x/net v0.26.0 // indirect
 )
@@ -14,6 +14,7 @@ import (
 	"bytes"
 	"fmt"
 	"path"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -44,8 +45,23 @@ func (lute *Lute) HTML2Markdown(htmlStr string) (markdown string, err error) {
 	return
 }
 
+// HTML2MarkdownE 和 HTML2Markdown 一样将 HTML 转换为 Markdown，但是转换过程中发生 panic 时返回 *parse.Error 而不会导致程序崩溃。
+func (lute *Lute) HTML2MarkdownE(htmlStr string) (markdown string, err error) {
+	defer parse.RecoverError(&err, "", nil)
+	return lute.HTML2Markdown(htmlStr)
+}
+
 // HTML2Tree 将 HTML 转换为 AST。
 func (lute *Lute) HTML2Tree(dom string) (ret *parse.Tree) {
+	if lute.ParseOptions.HTML2MarkdownReadability {
+		// 提取正文时需要读取 head 中的元数据，所以这里解析整个文档
+		doc, err := html.Parse(strings.NewReader(dom))
+		if nil != err {
+			return nil
+		}
+		return lute.HTMLNode2Tree(doc)
+	}
+
 	htmlRoot := util.ParseHTML(dom)
 	return lute.HTMLNode2Tree(htmlRoot)
 }
@@ -55,6 +71,13 @@ func (lute *Lute) HTMLNode2Tree(n *html.Node) (ret *parse.Tree) {
 		return nil
 	}
 
+	var article *util.Article
+	if lute.ParseOptions.HTML2MarkdownReadability {
+		// 仅转换正文
+		article = util.ExtractArticle(n)
+		n = article.Content
+	}
+
 	// 调整 DOM 结构
 	lute.adjustVditorDOM(n)
 
@@ -81,15 +104,44 @@ func (lute *Lute) HTMLNode2Tree(n *html.Node) (ret *parse.Tree) {
 		}
 		return ast.WalkContinue
 	})
+
+	lute.downgradeFlavor(ret)
+	if nil != article {
+		prependArticleMeta(ret, article)
+	}
 	return ret
 }
 
+// prependArticleMeta 将提取的正文元数据作为 YAML Front Matter 插入到 tree 开头。
+func prependArticleMeta(tree *parse.Tree, article *util.Article) {
+	buf := &bytes.Buffer{}
+	for _, meta := range [][]string{{"title", article.Title}, {"author", article.Byline}, {"date", article.Published}} {
+		if "" != meta[1] {
+			buf.WriteString(meta[0] + ": " + strconv.Quote(meta[1]) + "\n")
+		}
+	}
+	if 1 > buf.Len() {
+		return
+	}
+
+	content := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	frontMatter := &ast.Node{Type: ast.NodeYamlFrontMatter, Tokens: content}
+	frontMatter.AppendChild(&ast.Node{Type: ast.NodeYamlFrontMatterOpenMarker})
+	frontMatter.AppendChild(&ast.Node{Type: ast.NodeYamlFrontMatterContent, Tokens: content})
+	frontMatter.AppendChild(&ast.Node{Type: ast.NodeYamlFrontMatterCloseMarker})
+	tree.Root.PrependChild(frontMatter)
+}
+
 // genASTByDOM 根据指定的 DOM 节点 n 进行深度优先遍历并逐步生成 Markdown 语法树 tree。
 func (lute *Lute) genASTByDOM(n *html.Node, tree *parse.Tree) {
 	if html.CommentNode == n.Type || atom.Meta == n.DataAtom {
 		return
 	}
 
+	if lute.genASTByRules(n, tree) {
+		return
+	}
+
 	if "svg" == n.Namespace {
 		return
 	}
@@ -115,11 +167,21 @@ func (lute *Lute) genASTByDOM(n *html.Node, tree *parse.Tree) {
 		return
 	}
 
-	if 0 == n.DataAtom && html.ElementNode == n.Type { // 自定义标签
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			lute.genASTByDOM(c, tree)
+	if h2mUnknown(n) {
+		switch lute.ParseOptions.HTML2MarkdownUnknown {
+		case parse.HTML2MdUnknownDrop:
+			return
+		case parse.HTML2MdUnknownHTML:
+			appendHTML(n, tree)
+			return
+		}
+
+		if 0 == n.DataAtom { // 自定义标签
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				lute.genASTByDOM(c, tree)
+			}
+			return
 		}
-		return
 	}
 
 	node := &ast.Node{Type: ast.NodeText, Tokens: util.StrToBytes(n.Data)}
@@ -0,0 +1,319 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package lute
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/html"
+	"github.com/88250/lute/html/atom"
+	"github.com/88250/lute/lex"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/render"
+	"github.com/88250/lute/util"
+)
+
+// HTML2MdAction 描述了 HTML 转换 Markdown 规则对元素的处理方式。
+type HTML2MdAction int
+
+const (
+	HTML2MdDefault HTML2MdAction = iota // 不处理，交由后续规则或者内置逻辑转换
+	HTML2MdDone                         // 规则已经完成转换（可以直接在 tree.Context.Tip 下添加节点），不再转换子元素
+	HTML2MdNode                         // 将规则返回的节点添加到 tree.Context.Tip 下，并将子元素转换到该节点中
+	HTML2MdUnwrap                       // 丢弃元素本身，继续转换其子元素
+	HTML2MdDrop                         // 丢弃元素及其子元素
+	HTML2MdHTML                         // 保留为 HTML，块级位置为 HTML 块，行级位置为行级 HTML
+	HTML2MdText                         // 仅保留元素的文本内容
+)
+
+// HTML2MdRuleFunc 定义了 HTML 转换 Markdown 规则的转换函数签名，n 为匹配到的元素，tree.Context.Tip 为当前插入位置。
+//
+// 返回 HTML2MdNode 时 node 不能为 nil，如果 node 的最后一个子节点是结束标记符（比如加粗的 **），子元素会转换到该标记符之前。
+type HTML2MdRuleFunc func(n *html.Node, tree *parse.Tree) (node *ast.Node, action HTML2MdAction)
+
+// HTML2MdRule 描述了 HTML 转换 Markdown 时的自定义规则，用于在不修改内置转换逻辑的情况下针对不同网站调整转换结果。
+//
+// 规则按照标签名 Tag 和类名 Class 匹配元素，两者都为空时匹配所有元素。
+type HTML2MdRule struct {
+	Tag     string          // 匹配的标签名，比如 div，为空时匹配所有标签
+	Class   string          // 匹配的类名，元素的 class 属性包含该类名时匹配，为空时不限制
+	Convert HTML2MdRuleFunc // 转换函数
+}
+
+// RegisterHTML2MdRule 注册 HTML 转换 Markdown 规则，后注册的规则优先匹配，所有规则均优先于内置的转换逻辑。
+func (lute *Lute) RegisterHTML2MdRule(rule *HTML2MdRule) error {
+	if nil == rule || nil == rule.Convert {
+		return errors.New("html2md rule must have convert function")
+	}
+
+	lute.HTML2MdRules = append([]*HTML2MdRule{rule}, lute.HTML2MdRules...)
+	return nil
+}
+
+func (rule *HTML2MdRule) match(n *html.Node) bool {
+	if html.ElementNode != n.Type {
+		return false
+	}
+	if "" != rule.Tag && !strings.EqualFold(rule.Tag, n.Data) {
+		return false
+	}
+	return "" == rule.Class || util.ContainsStr(rule.Class, strings.Fields(util.DomAttrValue(n, "class")))
+}
+
+// genASTByRules 使用注册的规则转换元素 n，没有规则处理该元素时返回 false。
+func (lute *Lute) genASTByRules(n *html.Node, tree *parse.Tree) bool {
+	for _, rule := range lute.HTML2MdRules {
+		if !rule.match(n) {
+			continue
+		}
+
+		node, action := rule.Convert(n, tree)
+		switch action {
+		case HTML2MdDefault:
+			continue
+		case HTML2MdNode:
+			if nil == node {
+				return false
+			}
+
+			tip := tree.Context.Tip
+			closeMarker := node.LastChild
+			if nil != closeMarker && closeMarker.IsCloseMarker() && closeMarker != node.FirstChild {
+				closeMarker.Unlink()
+			} else {
+				closeMarker = nil
+			}
+			tip.AppendChild(node)
+			tree.Context.Tip = node
+			for c := n.FirstChild; nil != c; c = c.NextSibling {
+				lute.genASTByDOM(c, tree)
+			}
+			if nil != closeMarker {
+				node.AppendChild(closeMarker)
+			}
+			tree.Context.Tip = tip
+		case HTML2MdUnwrap:
+			for c := n.FirstChild; nil != c; c = c.NextSibling {
+				lute.genASTByDOM(c, tree)
+			}
+		case HTML2MdHTML:
+			appendHTML(n, tree)
+		case HTML2MdText:
+			appendText(n, tree)
+		}
+		return true
+	}
+	return false
+}
+
+// appendHTML 将元素 n 保留为 HTML 添加到 tree.Context.Tip 下。
+func appendHTML(n *html.Node, tree *parse.Tree) {
+	tokens := bytes.TrimSpace(util.DomHTML(n))
+	if 1 > len(tokens) {
+		return
+	}
+
+	if tree.Context.Tip.IsContainerBlock() {
+		// HTML 块中不能出现空行
+		for bytes.Contains(tokens, []byte("\n\n")) {
+			tokens = bytes.ReplaceAll(tokens, []byte("\n\n"), []byte("\n"))
+		}
+		tree.Context.Tip.AppendChild(&ast.Node{Type: ast.NodeHTMLBlock, Tokens: tokens})
+		return
+	}
+
+	tokens = bytes.ReplaceAll(tokens, []byte("\n"), []byte(" "))
+	tree.Context.Tip.AppendChild(&ast.Node{Type: ast.NodeInlineHTML, Tokens: tokens})
+}
+
+// appendText 将元素 n 的文本内容添加到 tree.Context.Tip 下。
+func appendText(n *html.Node, tree *parse.Tree) {
+	text := strings.TrimSpace(strings.ReplaceAll(util.DomText(n), "\n", " "))
+	if "" == text {
+		return
+	}
+
+	node := &ast.Node{Type: ast.NodeText, Tokens: lex.EscapeCommonMarkers([]byte(text))}
+	if tree.Context.Tip.IsContainerBlock() {
+		p := &ast.Node{Type: ast.NodeParagraph}
+		p.AppendChild(node)
+		tree.Context.Tip.AppendChild(p)
+		return
+	}
+	tree.Context.Tip.AppendChild(node)
+}
+
+// h2mKnownAtoms 为内置转换逻辑可以识别的元素，其中 html、article、small 等元素仅作为容器，转换时会继续转换其子元素。
+var h2mKnownAtoms = map[atom.Atom]bool{
+	atom.Html: true, atom.Head: true, atom.Body: true, atom.Meta: true, atom.Article: true, atom.Main: true, atom.Header: true, atom.Footer: true,
+	atom.Nav: true, atom.Aside: true, atom.Hgroup: true, atom.Address: true, atom.Center: true,
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Hr: true, atom.Blockquote: true, atom.Ol: true, atom.Ul: true, atom.Li: true, atom.Pre: true, atom.Code: true, atom.Br: true,
+	atom.Em: true, atom.I: true, atom.Strong: true, atom.B: true, atom.Del: true, atom.S: true, atom.Strike: true, atom.U: true, atom.Ins: true,
+	atom.Mark: true, atom.Sup: true, atom.Sub: true, atom.Kbd: true, atom.Font: true, atom.Span: true, atom.Small: true, atom.Big: true, atom.Tt: true,
+	atom.Abbr: true, atom.Cite: true, atom.Q: true, atom.Time: true, atom.Var: true, atom.Samp: true, atom.Dfn: true,
+	atom.A: true, atom.Img: true, atom.Input: true, atom.Picture: true, atom.Source: true, atom.Figure: true, atom.Figcaption: true,
+	atom.Table: true, atom.Caption: true, atom.Thead: true, atom.Tbody: true, atom.Tfoot: true, atom.Tr: true, atom.Th: true, atom.Td: true,
+	atom.Colgroup: true, atom.Col: true, atom.Details: true, atom.Summary: true, atom.Iframe: true, atom.Audio: true, atom.Video: true,
+	atom.Noscript: true, atom.Script: true, atom.Math: true, atom.Annotation: true,
+}
+
+// h2mUnknown 判断元素 n 是否是转换时无法识别的元素（自定义标签以及 button、style 等不承载正文内容的标签）。
+func h2mUnknown(n *html.Node) bool {
+	return html.ElementNode == n.Type && "math" != n.Namespace && !h2mKnownAtoms[n.DataAtom]
+}
+
+// downgradeFlavor 按照解析选项 HTML2MarkdownFlavor 将转换结果中目标风格不支持的节点降级为 HTML 或者文本。
+func (lute *Lute) downgradeFlavor(tree *parse.Tree) {
+	flavor := lute.ParseOptions.HTML2MarkdownFlavor
+	if parse.HTML2MdFlavorLute == flavor {
+		return
+	}
+
+	var nodes []*ast.Node
+	ast.Walk(tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			nodes = append(nodes, n)
+		}
+		return ast.WalkContinue
+	})
+
+	for _, n := range nodes {
+		n.KramdownIAL = nil
+		switch n.Type {
+		case ast.NodeKramdownBlockIAL, ast.NodeKramdownSpanIAL:
+			n.Unlink()
+		case ast.NodeMark:
+			downgradeHTMLTag(n, "mark")
+		case ast.NodeSup:
+			downgradeHTMLTag(n, "sup")
+		case ast.NodeSub:
+			downgradeHTMLTag(n, "sub")
+		case ast.NodeKbd:
+			downgradeHTMLTag(n, "kbd")
+		case ast.NodeUnderline:
+			downgradeHTMLTag(n, "u")
+		case ast.NodeTag:
+			downgradeText(n, "#"+n.Text())
+		case ast.NodeTextMark:
+			downgradeText(n, n.TextMarkTextContent)
+		case ast.NodeBlockRef:
+			if text := n.ChildByType(ast.NodeBlockRefText); nil != text {
+				downgradeText(n, text.TokensStr())
+			} else if text = n.ChildByType(ast.NodeBlockRefDynamicText); nil != text {
+				downgradeText(n, text.TokensStr())
+			} else {
+				n.Unlink()
+			}
+		}
+
+		if parse.HTML2MdFlavorCommonMark != flavor {
+			continue
+		}
+		switch n.Type {
+		case ast.NodeList, ast.NodeListItem:
+			if nil != n.ListData && 3 == n.ListData.Typ {
+				// 任务列表降级为普通列表，任务标记降级为文本
+				n.ListData.Typ = 0
+				if 0 == n.ListData.BulletChar {
+					n.ListData.Typ = 1
+				}
+			}
+		case ast.NodeTaskListItemMarker:
+			// 转义方括号，避免被 GFM 解析器再次识别为任务标记
+			marker := "\\[ \\]"
+			if n.TaskListItemChecked {
+				marker = "\\[x\\]"
+			}
+			n.Type = ast.NodeText
+			n.Tokens = []byte(marker)
+		case ast.NodeStrikethrough:
+			downgradeHTMLTag(n, "del")
+		case ast.NodeInlineMath:
+			content := n.ChildByType(ast.NodeInlineMathContent)
+			n.Type = ast.NodeCodeSpan
+			removeChildren(n)
+			if nil == content {
+				n.Unlink()
+				break
+			}
+			if bytes.Contains(content.Tokens, []byte("`")) {
+				n.CodeMarkerLen = 2
+			}
+			n.AppendChild(&ast.Node{Type: ast.NodeCodeSpanOpenMarker, Tokens: []byte("`")})
+			n.AppendChild(&ast.Node{Type: ast.NodeCodeSpanContent, Tokens: content.Tokens})
+			n.AppendChild(&ast.Node{Type: ast.NodeCodeSpanCloseMarker, Tokens: []byte("`")})
+		case ast.NodeMathBlock:
+			content := n.ChildByType(ast.NodeMathBlockContent)
+			n.Type = ast.NodeCodeBlock
+			n.IsFencedCodeBlock = true
+			removeChildren(n)
+			if nil == content {
+				n.Unlink()
+				break
+			}
+			n.AppendChild(&ast.Node{Type: ast.NodeCodeBlockFenceOpenMarker, Tokens: []byte("```"), CodeBlockFenceLen: 3})
+			n.AppendChild(&ast.Node{Type: ast.NodeCodeBlockFenceInfoMarker, CodeBlockInfo: []byte("math")})
+			n.AppendChild(&ast.Node{Type: ast.NodeCodeBlockCode, Tokens: content.Tokens})
+			n.AppendChild(&ast.Node{Type: ast.NodeCodeBlockFenceCloseMarker, Tokens: []byte("```"), CodeBlockFenceLen: 3})
+		case ast.NodeTable:
+			// 表格降级为 HTML 块
+			htmlBlock := &ast.Node{Type: ast.NodeHTMLBlock}
+			n.InsertBefore(htmlBlock)
+			table := &parse.Tree{Root: &ast.Node{Type: ast.NodeDocument}, Context: &parse.Context{ParseOption: lute.ParseOptions}}
+			table.Context.Tree = table
+			table.Root.AppendChild(n)
+			htmlBlock.Tokens = bytes.TrimSpace(render.NewHtmlRenderer(table, lute.RenderOptions, lute.ParseOptions).Render())
+		}
+	}
+}
+
+// downgradeHTMLTag 将节点 n 降级为使用 HTML 标签 tag 包裹的行级 HTML。
+func downgradeHTMLTag(n *ast.Node, tag string) {
+	if first := n.FirstChild; nil != first && isFlavorMarker(first) {
+		first.Unlink()
+	}
+	if last := n.LastChild; nil != last && isFlavorMarker(last) {
+		last.Unlink()
+	}
+	n.Type = ast.NodeHTMLTag
+	n.PrependChild(&ast.Node{Type: ast.NodeHTMLTagOpen, Tokens: []byte("<" + tag + ">")})
+	n.AppendChild(&ast.Node{Type: ast.NodeHTMLTagClose, Tokens: []byte("</" + tag + ">")})
+}
+
+func isFlavorMarker(n *ast.Node) bool {
+	switch n.Type {
+	case ast.NodeKbdOpenMarker, ast.NodeKbdCloseMarker, ast.NodeUnderlineOpenMarker, ast.NodeUnderlineCloseMarker:
+		return true
+	}
+	return n.IsMarker()
+}
+
+// downgradeText 将节点 n 降级为文本 text。
+func downgradeText(n *ast.Node, text string) {
+	removeChildren(n)
+	if "" == text {
+		n.Unlink()
+		return
+	}
+	n.Type = ast.NodeText
+	n.Tokens = lex.EscapeCommonMarkers([]byte(text))
+}
+
+func removeChildren(n *ast.Node) {
+	for c := n.FirstChild; nil != c; c = n.FirstChild {
+		c.Unlink()
+	}
+}
@@ -10,7 +10,10 @@
 
 package lex
 
-import "unicode/utf8"
+import (
+	"sort"
+	"unicode/utf8"
+)
 
 // Lexer 描述了词法分析器结构。
 type Lexer struct {
@@ -18,25 +21,56 @@ type Lexer struct {
 	length int    // 输入的文本字节数组的长度
 	offset int    // 当前读取字节位置
 	width  int    // 最新一个字符的长度（字节数）
+	owned  bool   // input 是否为词法分析器持有的副本，修改输入前需要先复制一份，避免修改调用方传入的数据
+
+	SourcePos   bool          // 是否记录每一行在原始输入中的位置
+	lines       []*SourceLine // 已读取的行
+	sourceShift int           // 原始输入偏移与当前输入偏移的差值
+	sourceLen   int           // 原始输入的长度
 }
 
-// NewLexer 创建一个词法分析器。
+// SourceLine 描述了一行文本在词法分析输入和原始输入中的起始位置。
+//
+// 词法分析过程中会将 \r\n 和 \r 规范化为 \n，并将 \u0000 替换为 \uFFFD，所以两者的偏移量可能不一致。
+type SourceLine struct {
+	Offset       int   // 该行在词法分析输入中的起始偏移
+	SourceOffset int   // 该行在原始输入中的起始偏移
+	Nuls         []int // 该行中 \u0000 被替换为 \uFFFD 的位置（相对行首的偏移）
+	CRLF         bool  // 该行是否以 \r\n 结尾，\r 在词法分析输入中已被移除
+}
+
+// NewLexer 创建一个词法分析器，词法分析过程中不会修改 input。
 func NewLexer(input []byte) (ret *Lexer) {
-	ret = &Lexer{input: input, length: len(input)}
+	ret = &Lexer{input: input, length: len(input), sourceLen: len(input)}
 	if 0 < ret.length && ItemNewline != ret.input[ret.length-1] {
 		// 以 \n 结尾预处理
-		ret.input = append(ret.input, ItemNewline)
+		ret.input = append(input[:ret.length:ret.length], ItemNewline)
 		ret.length++
+		ret.owned = true
 	}
 	return
 }
 
+// own 在第一次修改输入前复制一份输入。
+func (l *Lexer) own() {
+	if !l.owned {
+		l.input = append([]byte{}, l.input...)
+		l.owned = true
+	}
+}
+
 // NextLine 返回下一行。
 func (l *Lexer) NextLine() (ret []byte) {
 	if l.offset >= l.length {
 		return
 	}
 
+	var line *SourceLine
+	if l.SourcePos {
+		line = &SourceLine{Offset: l.offset, SourceOffset: l.offset + l.sourceShift}
+		l.lines = append(l.lines, line)
+	}
+
 	var b, nb byte
 	i := l.offset
 	for ; i < l.length; i += l.width {
@@ -47,25 +81,36 @@ func (l *Lexer) NextLine() (ret []byte) {
 		} else if ItemCarriageReturn == b {
 			if i < l.length-1 {
 				nb = l.input[i+1]
+				l.own()
 				if ItemNewline == nb { // \r\n
 					l.input = append(l.input[:i], l.input[i+1:]...) // 移除 \r，依靠下一个的 \n 切行
 					l.length--                                      // 重新计算总长
+					l.sourceShift++
+					if nil != line {
+						line.CRLF = true
+					}
 				} else { // \rX
 					l.input[i] = ItemNewline // 将 \r 替换为 \n
 				}
 			} else { // \rEOF
+				l.own()
 				l.input[i] = ItemNewline // 将 \r 替换为 \n
 			}
 			i++
 			break
 		} else if '\u0000' == b {
 			// 将 \u0000 替换为 \uFFFD
+			l.own()
 			l.input = append(l.input, 0, 0)
 			copy(l.input[i+2:], l.input[i:])
 			// \uFFFD 的 UTF-8 编码为 \xEF\xBF\xBD 共三个字节
 			l.input[i], l.input[i+1], l.input[i+2] = '\xEF', '\xBF', '\xBD'
 			l.length += 2 // 重新计算总长
 			l.width = 3
+			if nil != line {
+				line.Nuls = append(line.Nuls, i-l.offset)
+				l.sourceShift -= 2
+			}
 			continue
 		}
 
@@ -79,3 +124,76 @@ func (l *Lexer) NextLine() (ret []byte) {
 	l.offset = i
 	return
 }
+
+// Input 返回词法分析后的输入文本字节数组。
+func (l *Lexer) Input() []byte {
+	return l.input[:l.length]
+}
+
+// SourceLines 返回已读取行的位置信息，仅在 SourcePos 开启时记录。
+func (l *Lexer) SourceLines() []*SourceLine {
+	return l.lines
+}
+
+// LineOffset 返回最近读取的一行在词法分析输入中的起始偏移。
+func (l *Lexer) LineOffset() int {
+	if 1 > len(l.lines) {
+		return 0
+	}
+	return l.lines[len(l.lines)-1].Offset
+}
+
+// SourceOffset 将词法分析输入中的偏移 offset 映射为原始输入中的偏移。
+func (l *Lexer) SourceOffset(offset int) int {
+	if 1 > len(l.lines) {
+		return offset
+	}
+
+	idx := sort.Search(len(l.lines), func(i int) bool { return l.lines[i].Offset > offset }) - 1
+	if 0 > idx {
+		idx = 0
+	}
+	line := l.lines[idx]
+	col := offset - line.Offset
+	ret := line.SourceOffset + col
+	for _, nul := range line.Nuls {
+		if nul >= col {
+			break
+		}
+		if col < nul+3 {
+			// 落在 \uFFFD 中间的偏移映射到原始的 \u0000 上
+			ret -= col - nul
+			break
+		}
+		ret -= 2
+	}
+	if line.CRLF {
+		// 行尾之后的偏移（比如文档的结束偏移）需要计入被移除的 \r，行中的偏移不受影响
+		end := l.length
+		if idx+1 < len(l.lines) {
+			end = l.lines[idx+1].Offset
+		}
+		if offset >= end {
+			ret++
+		}
+	}
+	return ret
+}
+
+// SourceLength 返回原始输入的长度。
+func (l *Lexer) SourceLength() int {
+	return l.sourceLen
+}
+
+// SourceLineColumn 返回原始输入中的偏移 sourceOffset 对应的行号和列号（均从 1 开始，列号按字节计算）。
+func (l *Lexer) SourceLineColumn(sourceOffset int) (line, column int) {
+	if 1 > len(l.lines) {
+		return 1, sourceOffset + 1
+	}
+
+	idx := sort.Search(len(l.lines), func(i int) bool { return l.lines[i].SourceOffset > sourceOffset }) - 1
+	if 0 > idx {
+		idx = 0
+	}
+	return idx + 1, sourceOffset - l.lines[idx].SourceOffset + 1
+}
@@ -0,0 +1,216 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+// Package lint 实现了基于语法树的 Markdown 检查，并支持通过格式化渲染器 FormatRenderer 自动修复。
+package lint
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/render"
+)
+
+// Severity 描述了检查结果的严重级别。
+type Severity int
+
+const (
+	SeverityError   Severity = iota // 错误，通常会导致渲染结果不符合预期
+	SeverityWarning                 // 警告，不影响渲染但是不符合规范
+	SeverityInfo                    // 提示
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	}
+	return "unknown"
+}
+
+// Diagnostic 描述了一条检查结果。
+type Diagnostic struct {
+	Rule     string        `json:"rule"`     // 规则 ID，比如 MD001
+	Name     string        `json:"name"`     // 规则名称，比如 heading-increment
+	Severity Severity      `json:"severity"` // 严重级别
+	Message  string        `json:"message"`  // 问题描述
+	Position *ast.Position `json:"position"` // 问题位置，解析选项 SourcePos 未开启时可能为 nil
+	Fixable  bool          `json:"fixable"`  // 是否可以自动修复
+	Node     *ast.Node     `json:"-"`        // 问题所在节点，按行检查的规则为 nil
+
+	fix func() // 修复函数，修改语法树后由 FormatRenderer 渲染出修复后的文本
+}
+
+func (d *Diagnostic) String() string {
+	ret := d.Position.String()
+	if "" == ret {
+		ret = "-"
+	}
+	return ret + " " + d.Severity.String() + " " + d.Rule + "/" + d.Name + " " + d.Message
+}
+
+// Rule 描述了一条检查规则。
+type Rule struct {
+	ID       string             // 规则 ID，和 markdownlint 对应的规则使用相同的 ID，Lute 特有的规则使用 LT 前缀
+	Name     string             // 规则名称
+	Severity Severity           // 严重级别
+	Check    func(ctx *Context) // 检查函数，通过 ctx.Report 报告问题
+}
+
+// Linter 使用一组规则检查 Markdown 语法树。
+type Linter struct {
+	Rules            []*Rule                 // 检查规则
+	HeadingIDSlugger render.HeadingIDSlugger // 检查重复标题 ID 时使用的标题 ID 生成策略，为 nil 时使用 Lute 默认策略
+	RenderOptions    *render.Options         // 自动修复时格式化渲染使用的选项
+	MaxFixPasses     int                     // 自动修复的最大轮数
+}
+
+// New 使用所有内置规则构造一个 Linter。
+func New() *Linter {
+	return &Linter{Rules: Rules(), RenderOptions: render.NewOptions(), MaxFixPasses: 8}
+}
+
+// Lint 检查语法树 tree，source 为解析 tree 时使用的原始 Markdown 文本，为 nil 时跳过按行检查的规则。
+//
+// 为了得到问题位置并进行按行检查，解析 tree 时需要开启解析选项 SourcePos。
+func (l *Linter) Lint(tree *parse.Tree, source []byte) (ret []*Diagnostic) {
+	ctx := &Context{Tree: tree, Source: source, Linter: l}
+	ctx.splitLines()
+	for _, rule := range l.Rules {
+		ctx.rule = rule
+		rule.Check(ctx)
+	}
+	ret = ctx.diagnostics
+	sort.SliceStable(ret, func(i, j int) bool {
+		pi, pj := ret[i].Position, ret[j].Position
+		if nil == pi || nil == pj {
+			return nil != pi
+		}
+		if pi.StartLine != pj.StartLine {
+			return pi.StartLine < pj.StartLine
+		}
+		return pi.StartColumn < pj.StartColumn
+	})
+	return
+}
+
+// Fix 检查语法树 tree 并修复所有可以自动修复的问题，返回修复后的 Markdown 文本和剩余的问题。
+//
+// 每一轮修复都会先修改语法树，然后使用 FormatRenderer 渲染并重新解析，直到没有可以修复的问题或者达到最大修复轮数。
+func (l *Linter) Fix(tree *parse.Tree, source []byte) (fixed []byte, diagnostics []*Diagnostic) {
+	options := tree.Context.ParseOption
+	fixed = source
+	for pass := 0; ; pass++ {
+		diagnostics = l.Lint(tree, fixed)
+		var fixes []func()
+		for _, diagnostic := range diagnostics {
+			if diagnostic.Fixable {
+				fixes = append(fixes, diagnostic.fix)
+			}
+		}
+		if 1 > len(fixes) || l.MaxFixPasses <= pass {
+			if nil == fixed {
+				fixed = l.format(tree)
+			}
+			return
+		}
+
+		for _, fix := range fixes {
+			fix()
+		}
+		formatted := l.format(tree)
+		if bytes.Equal(formatted, fixed) {
+			// 修复没有产生任何变化，避免重复修复
+			return
+		}
+		fixed = formatted
+		tree = parse.Parse(tree.Name, fixed, options)
+	}
+}
+
+func (l *Linter) format(tree *parse.Tree) []byte {
+	renderOptions := l.RenderOptions
+	if nil == renderOptions {
+		renderOptions = render.NewOptions()
+	}
+	return render.NewFormatRenderer(tree, renderOptions, tree.Context.ParseOption).Render()
+}
+
+// Context 描述了一次检查的上下文，规则通过它访问语法树和原始文本并报告问题。
+type Context struct {
+	Tree   *parse.Tree // 待检查的语法树
+	Source []byte      // 原始 Markdown 文本，可能为 nil
+	Linter *Linter     // 当前 Linter
+
+	lines       []sourceLine  // 原始文本按行切分的结果
+	rule        *Rule         // 正在执行的规则
+	diagnostics []*Diagnostic // 检查结果
+}
+
+// sourceLine 描述了原始文本中的一行，不包括行尾换行符。
+type sourceLine struct {
+	offset int    // 行首偏移
+	text   []byte // 行内容
+}
+
+func (ctx *Context) splitLines() {
+	if nil == ctx.Source {
+		return
+	}
+
+	offset := 0
+	for _, line := range bytes.Split(ctx.Source, []byte("\n")) {
+		ctx.lines = append(ctx.lines, sourceLine{offset: offset, text: bytes.TrimSuffix(line, []byte("\r"))})
+		offset += len(line) + 1
+	}
+	if 0 < len(ctx.lines) && 0 == len(ctx.lines[len(ctx.lines)-1].text) {
+		ctx.lines = ctx.lines[:len(ctx.lines)-1]
+	}
+}
+
+// Report 报告节点 node 上的问题，fix 为 nil 时表示该问题无法自动修复。
+func (ctx *Context) Report(node *ast.Node, message string, fix func()) {
+	ctx.diagnostics = append(ctx.diagnostics, &Diagnostic{
+		Rule: ctx.rule.ID, Name: ctx.rule.Name, Severity: ctx.rule.Severity, Message: message,
+		Position: node.Position, Fixable: nil != fix, Node: node, fix: fix,
+	})
+}
+
+// ReportLine 报告原始文本第 line 行（从 1 开始）第 startColumn 到 endColumn 列（列号从 1 开始，左闭右开）上的问题。
+func (ctx *Context) ReportLine(line, startColumn, endColumn int, message string, fix func()) {
+	offset := ctx.lines[line-1].offset
+	position := &ast.Position{
+		StartLine: line, StartColumn: startColumn, StartOffset: offset + startColumn - 1,
+		EndLine: line, EndColumn: endColumn, EndOffset: offset + endColumn - 1,
+	}
+	ctx.diagnostics = append(ctx.diagnostics, &Diagnostic{
+		Rule: ctx.rule.ID, Name: ctx.rule.Name, Severity: ctx.rule.Severity, Message: message,
+		Position: position, Fixable: nil != fix, fix: fix,
+	})
+}
+
+// hasPositions 判断语法树是否记录了节点位置。
+func (ctx *Context) hasPositions() bool {
+	return nil != ctx.Tree.Root.Position && nil != ctx.Source
+}
+
+// formatFix 用于格式化渲染本身就可以修复的问题，不需要修改语法树。
+func formatFix() {}
+
+func quote(s string) string {
+	return strconv.Quote(s)
+}
@@ -0,0 +1,343 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package lint
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/render"
+)
+
+// Rules 返回所有内置规则。
+func Rules() []*Rule {
+	return []*Rule{
+		{ID: "MD001", Name: "heading-increment", Severity: SeverityWarning, Check: checkHeadingIncrement},
+		{ID: "MD004", Name: "ul-style", Severity: SeverityWarning, Check: checkListMarker},
+		{ID: "MD009", Name: "no-trailing-spaces", Severity: SeverityInfo, Check: checkTrailingSpaces},
+		{ID: "MD024", Name: "no-duplicate-heading-id", Severity: SeverityWarning, Check: checkDuplicateHeadingID},
+		{ID: "MD034", Name: "no-bare-urls", Severity: SeverityInfo, Check: checkBareURL},
+		{ID: "MD042", Name: "no-empty-links", Severity: SeverityWarning, Check: checkEmptyLink},
+		{ID: "MD045", Name: "no-alt-text", Severity: SeverityWarning, Check: checkImageAlt},
+		{ID: "MD052", Name: "reference-links-images", Severity: SeverityError, Check: checkUndefinedReference},
+		{ID: "MD053", Name: "link-image-reference-definitions", Severity: SeverityWarning, Check: checkUnusedDefinition},
+		{ID: "LT001", Name: "no-unclosed-code-fence", Severity: SeverityError, Check: checkUnclosedCodeFence},
+	}
+}
+
+// checkHeadingIncrement 检查标题级别是否跳级，修复时将标题级别调整为上一个标题级别加一。
+func checkHeadingIncrement(ctx *Context) {
+	prevLevel := 0
+	ast.Walk(ctx.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering || ast.NodeHeading != n.Type {
+			return ast.WalkContinue
+		}
+
+		if 0 < prevLevel && n.HeadingLevel > prevLevel+1 {
+			heading, level := n, prevLevel+1
+			ctx.Report(n, "heading level should be "+strconv.Itoa(level)+" but got "+strconv.Itoa(n.HeadingLevel), func() {
+				heading.HeadingLevel = level
+				if 2 < level {
+					heading.HeadingSetext = false
+				}
+			})
+		}
+		prevLevel = n.HeadingLevel
+		return ast.WalkContinue
+	})
+}
+
+// checkListMarker 检查无序列表标记符是否一致，以文档中第一个无序列表的标记符为准。
+func checkListMarker(ctx *Context) {
+	var marker byte
+	ast.Walk(ctx.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering || ast.NodeList != n.Type || nil == n.ListData || 1 == n.ListData.Typ || 0 == n.ListData.BulletChar {
+			return ast.WalkContinue
+		}
+
+		if 0 == marker {
+			marker = n.ListData.BulletChar
+			return ast.WalkContinue
+		}
+		if marker != n.ListData.BulletChar {
+			list, bullet := n, marker
+			ctx.Report(n, "unordered list marker should be "+quote(string(marker))+" but got "+quote(string(n.ListData.BulletChar)), func() {
+				setListMarker(list, bullet)
+			})
+		}
+		return ast.WalkContinue
+	})
+}
+
+func setListMarker(list *ast.Node, bullet byte) {
+	list.ListData.BulletChar = bullet
+	list.ListData.Marker = []byte{bullet}
+	for li := list.FirstChild; nil != li; li = li.Next {
+		if ast.NodeListItem != li.Type || nil == li.ListData {
+			continue
+		}
+		li.ListData.BulletChar = bullet
+		li.ListData.Marker = []byte{bullet}
+		li.Tokens = []byte{bullet}
+	}
+
+	// 相邻的列表使用相同标记符后格式化时会被解析为一个松散列表，所以这里直接合并，两者都是紧凑列表时合并后仍然是紧凑列表
+	if prev := list.Previous; isSameList(prev, list) {
+		mergeList(prev, list)
+		list = prev
+	}
+	if next := list.Next; isSameList(list, next) {
+		mergeList(list, next)
+	}
+}
+
+func isSameList(list, next *ast.Node) bool {
+	return nil != list && nil != next && ast.NodeList == list.Type && ast.NodeList == next.Type && nil != list.ListData && nil != next.ListData &&
+		list.ListData.Typ == next.ListData.Typ && list.ListData.BulletChar == next.ListData.BulletChar
+}
+
+func mergeList(list, next *ast.Node) {
+	list.ListData.Tight = list.ListData.Tight && next.ListData.Tight
+	for li := next.FirstChild; nil != li; li = next.FirstChild {
+		list.AppendChild(li)
+	}
+	next.Unlink()
+}
+
+// checkTrailingSpaces 检查行尾空白，代码块、HTML 块和数学公式块中的行以及用于硬换行的两个空格除外。
+func checkTrailingSpaces(ctx *Context) {
+	if !ctx.hasPositions() {
+		return
+	}
+
+	skipLines := map[int]bool{}
+	ast.Walk(ctx.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering || nil == n.Position {
+			return ast.WalkContinue
+		}
+		switch n.Type {
+		case ast.NodeCodeBlock, ast.NodeHTMLBlock, ast.NodeMathBlock, ast.NodeYamlFrontMatter:
+			for line := n.Position.StartLine; line <= n.Position.EndLine; line++ {
+				skipLines[line] = true
+			}
+			return ast.WalkSkipChildren
+		}
+		return ast.WalkContinue
+	})
+
+	for i, line := range ctx.lines {
+		if skipLines[i+1] {
+			continue
+		}
+		content := bytes.TrimRight(line.text, " \t")
+		trailing := len(line.text) - len(content)
+		if 1 > trailing {
+			continue
+		}
+		if 2 == trailing && "  " == string(line.text[len(content):]) && 0 < len(bytes.TrimSpace(content)) &&
+			i+1 < len(ctx.lines) && 0 < len(bytes.TrimSpace(ctx.lines[i+1].text)) {
+			// 行尾两个空格表示硬换行
+			continue
+		}
+		ctx.ReportLine(i+1, len(content)+1, len(line.text)+1, strconv.Itoa(trailing)+" trailing whitespace characters", formatFix)
+	}
+}
+
+// checkDuplicateHeadingID 检查生成的标题 ID 是否重复，重复的标题 ID 会导致锚点链接无法定位到正确的标题。
+func checkDuplicateHeadingID(ctx *Context) {
+	slugger := ctx.Linter.HeadingIDSlugger
+	if nil == slugger {
+		slugger = render.LuteHeadingIDSlugger{}
+	}
+
+	ids := map[string]bool{}
+	ast.Walk(ctx.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering || ast.NodeHeading != n.Type {
+			return ast.WalkContinue
+		}
+
+		var id string
+		if headingID := n.ChildByType(ast.NodeHeadingID); nil != headingID {
+			id = string(headingID.Tokens)
+		} else {
+			id = slugger.Slug(n.Text())
+		}
+		if ids[id] {
+			ctx.Report(n, "duplicate heading id "+quote(id), nil)
+		}
+		ids[id] = true
+		return ast.WalkContinue
+	})
+}
+
+// checkBareURL 检查没有使用链接语法的网址，修复时转换为显式链接。
+func checkBareURL(ctx *Context) {
+	if !ctx.hasPositions() {
+		return
+	}
+
+	ast.Walk(ctx.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering || ast.NodeLink != n.Type || 2 != n.LinkType || nil == n.Position {
+			return ast.WalkContinue
+		}
+
+		if offset := n.Position.StartOffset; offset < len(ctx.Source) && '<' == ctx.Source[offset] {
+			// <https://example.com> 形式的自动链接
+			return ast.WalkSkipChildren
+		}
+		link := n
+		ctx.Report(n, "bare URL "+quote(string(n.ChildByType(ast.NodeLinkDest).Tokens))+" should be a link", func() {
+			link.LinkType = 0
+		})
+		return ast.WalkSkipChildren
+	})
+}
+
+// checkEmptyLink 检查链接地址为空或者仅为 # 的链接，修复时仅保留链接文本。
+func checkEmptyLink(ctx *Context) {
+	ast.Walk(ctx.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering || ast.NodeLink != n.Type || 0 != n.LinkType {
+			return ast.WalkContinue
+		}
+
+		dest := n.ChildByType(ast.NodeLinkDest)
+		if nil == dest || (0 < len(dest.Tokens) && "#" != string(dest.Tokens)) {
+			return ast.WalkSkipChildren
+		}
+		link := n
+		ctx.Report(n, "link destination is empty", func() {
+			if text := link.ChildByType(ast.NodeLinkText); nil != text && 0 < len(text.Tokens) {
+				link.InsertBefore(&ast.Node{Type: ast.NodeText, Tokens: text.Tokens})
+			}
+			link.Unlink()
+		})
+		return ast.WalkSkipChildren
+	})
+}
+
+// checkImageAlt 检查没有替代文本的图片。
+func checkImageAlt(ctx *Context) {
+	ast.Walk(ctx.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering || ast.NodeImage != n.Type {
+			return ast.WalkContinue
+		}
+
+		if alt := n.ChildByType(ast.NodeLinkText); nil == alt || 0 == len(bytes.TrimSpace(alt.Tokens)) {
+			ctx.Report(n, "image has no alternate text", nil)
+		}
+		return ast.WalkSkipChildren
+	})
+}
+
+var (
+	fullReference     = regexp.MustCompile(`\[[^\[\]]+\]\[([^\[\]]+)\]`)
+	footnoteReference = regexp.MustCompile(`\[\^([^\[\]\s]+)\]`)
+)
+
+// checkUndefinedReference 检查使用了未定义标签的链接引用和脚注引用，这些引用无法被解析，会原样输出为文本。
+func checkUndefinedReference(ctx *Context) {
+	ast.Walk(ctx.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.WalkContinue
+		}
+		switch n.Type {
+		case ast.NodeCodeBlock, ast.NodeCodeSpan, ast.NodeMathBlock, ast.NodeInlineMath, ast.NodeHTMLBlock:
+			return ast.WalkSkipChildren
+		case ast.NodeText:
+			for _, match := range fullReference.FindAllSubmatch(n.Tokens, -1) {
+				ctx.Report(n, "link reference label "+quote(string(match[1]))+" is not defined", nil)
+			}
+			if ctx.Tree.Context.ParseOption.Footnotes {
+				for _, match := range footnoteReference.FindAllSubmatch(n.Tokens, -1) {
+					ctx.Report(n, "footnote label "+quote(string(match[1]))+" is not defined", nil)
+				}
+			}
+		}
+		return ast.WalkContinue
+	})
+}
+
+// checkUnusedDefinition 检查没有被引用的链接引用定义和脚注定义，修复时删除这些定义。
+func checkUnusedDefinition(ctx *Context) {
+	used := map[string]bool{}
+	ast.Walk(ctx.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering && (ast.NodeLink == n.Type || ast.NodeImage == n.Type) && 3 == n.LinkType {
+			used[normalizeLabel(n.LinkRefLabel)] = true
+		}
+		return ast.WalkContinue
+	})
+
+	ast.Walk(ctx.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.WalkContinue
+		}
+
+		switch n.Type {
+		case ast.NodeLinkRefDef:
+			if label := string(n.Tokens); !used[normalizeLabel(n.Tokens)] {
+				ctx.Report(n, "link reference definition "+quote(label)+" is unused", removeDefinition(n))
+			}
+			return ast.WalkSkipChildren
+		case ast.NodeFootnotesDef:
+			if 1 > len(n.FootnotesRefs) {
+				ctx.Report(n, "footnote definition "+quote(strings.TrimPrefix(string(n.Tokens), "^"))+" is unused", removeDefinition(n))
+			}
+			return ast.WalkSkipChildren
+		}
+		return ast.WalkContinue
+	})
+}
+
+// removeDefinition 返回删除定义 def 的修复函数，删除后定义块为空时一并删除定义块。
+func removeDefinition(def *ast.Node) func() {
+	return func() {
+		block := def.Parent
+		def.Unlink()
+		if nil != block && nil == block.FirstChild && (ast.NodeLinkRefDefBlock == block.Type || ast.NodeFootnotesDefBlock == block.Type) {
+			block.Unlink()
+		}
+	}
+}
+
+func normalizeLabel(label []byte) string {
+	return strings.ToLower(strings.Join(strings.Fields(string(label)), " "))
+}
+
+// checkUnclosedCodeFence 检查没有结束标记的围栏代码块，这样的代码块会一直延续到文档或者容器块结尾。
+func checkUnclosedCodeFence(ctx *Context) {
+	if !ctx.hasPositions() {
+		return
+	}
+
+	ast.Walk(ctx.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering || ast.NodeCodeBlock != n.Type {
+			return ast.WalkContinue
+		}
+		if !n.IsFencedCodeBlock || nil == n.Position || n.Position.EndLine > len(ctx.lines) {
+			return ast.WalkSkipChildren
+		}
+
+		open := n.ChildByType(ast.NodeCodeBlockFenceOpenMarker)
+		if nil == open || 1 > len(open.Tokens) {
+			return ast.WalkSkipChildren
+		}
+		last := bytes.TrimSpace(ctx.lines[n.Position.EndLine-1].text)
+		closed := n.Position.EndLine > n.Position.StartLine && len(last) >= len(open.Tokens) &&
+			0 == len(bytes.Trim(last, string(open.Tokens[0])))
+		if !closed {
+			ctx.Report(n, "code fence "+quote(string(open.Tokens))+" is not closed", formatFix)
+		}
+		return ast.WalkSkipChildren
+	})
+}
@@ -13,12 +13,16 @@ package lute
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"io"
 	"strings"
-	"sync"
 
 	"github.com/88250/lute/ast"
+	"github.com/88250/lute/diff"
 	"github.com/88250/lute/lex"
+	"github.com/88250/lute/lint"
+	"github.com/88250/lute/merge"
 	"github.com/88250/lute/parse"
 	"github.com/88250/lute/render"
 	"github.com/88250/lute/util"
@@ -28,6 +32,9 @@ import (
 const Version = "1.7.6"
 
 // Lute 描述了 Lute 引擎的顶层使用入口。
+//
+// 引擎配置完成（调用 Set*、Put*、Register* 等方法以及设置各个渲染函数字典）后可以被多个 goroutine 并发使用：每次调用都会创建独立的语法树和渲染器，
+// 调用过程中不会修改引擎的解析选项和渲染选项。配置方法不是并发安全的，需要在共用引擎之前完成配置。
 type Lute struct {
 	ParseOptions  *parse.Options  // 解析选项
 	RenderOptions *render.Options // 渲染选项
@@ -42,6 +49,9 @@ type Lute struct {
 	Md2VditorIRDOMRendererFuncs   map[ast.NodeType]render.ExtRendererFunc // 用户自定义的 Md2VditorIRDOM 渲染器函数
 	Md2BlockDOMRendererFuncs      map[ast.NodeType]render.ExtRendererFunc // 用户自定义的 Md2BlockDOM 渲染器函数
 	Md2VditorSVDOMRendererFuncs   map[ast.NodeType]render.ExtRendererFunc // 用户自定义的 Md2VditorSVDOM 渲染器函数
+	FormatRendererFuncs           map[ast.NodeType]render.ExtRendererFunc // 用户自定义的 Format 渲染器函数
+
+	HTML2MdRules []*HTML2MdRule // 通过 RegisterHTML2MdRule 注册的 HTML 转换 Markdown 规则，后注册的规则在前
 }
 
 // New 创建一个新的 Lute 引擎。
@@ -75,9 +85,29 @@ func New(opts ...ParseOption) (ret *Lute) {
 	ret.Md2VditorIRDOMRendererFuncs = map[ast.NodeType]render.ExtRendererFunc{}
 	ret.Md2BlockDOMRendererFuncs = map[ast.NodeType]render.ExtRendererFunc{}
 	ret.Md2VditorSVDOMRendererFuncs = map[ast.NodeType]render.ExtRendererFunc{}
+	ret.FormatRendererFuncs = map[ast.NodeType]render.ExtRendererFunc{}
 	return ret
 }
 
+// withParseOptions 返回引擎的浅拷贝，其解析选项为经过 modify 修改的副本。用于在单次调用中调整解析选项，避免修改共用的解析选项。
+func (lute *Lute) withParseOptions(modify func(options *parse.Options)) *Lute {
+	ret := *lute
+	options := *lute.ParseOptions
+	modify(&options)
+	ret.ParseOptions = &options
+	return &ret
+}
+
+// RegisterBlockExtension 注册块级语法扩展，扩展节点的渲染函数需要通过 Md2HTMLRendererFuncs、FormatRendererFuncs 等提供。
+func (lute *Lute) RegisterBlockExtension(ext *parse.BlockExtension) error {
+	return lute.ParseOptions.RegisterBlockExtension(ext)
+}
+
+// RegisterInlineExtension 注册行级语法扩展，扩展节点的渲染函数需要通过 Md2HTMLRendererFuncs、FormatRendererFuncs 等提供。
+func (lute *Lute) RegisterInlineExtension(ext *parse.InlineExtension) error {
+	return lute.ParseOptions.RegisterInlineExtension(ext)
+}
+
 // Markdown 将 markdown 文本字节数组处理为相应的 html 字节数组。name 参数仅用于标识文本，比如可传入 id 或者标题，也可以传入 ""。
 func (lute *Lute) Markdown(name string, markdown []byte) (html []byte) {
 	tree := parse.Parse(name, markdown, lute.ParseOptions)
@@ -89,6 +119,88 @@ func (lute *Lute) Markdown(name string, markdown []byte) (html []byte) {
 	return
 }
 
+// DiffHTML 比较 markdown 文本 oldMarkdown 和 newMarkdown，返回使用 <ins> 和 <del> 标记差异的 html，差异的计算规则参考 diff.Diff。
+func (lute *Lute) DiffHTML(oldMarkdown, newMarkdown []byte) (html []byte) {
+	oldTree := parse.Parse("", oldMarkdown, lute.ParseOptions)
+	newTree := parse.Parse("", newMarkdown, lute.ParseOptions)
+	renderer := render.NewDiffHtmlRenderer(diff.Diff(oldTree, newTree), lute.RenderOptions, lute.ParseOptions)
+	for nodeType, rendererFunc := range lute.Md2HTMLRendererFuncs {
+		renderer.ExtRendererFuncs[nodeType] = rendererFunc
+	}
+	html = renderer.Render()
+	return
+}
+
+// Merge 以 markdown 文本 base 为共同祖先对 ours 和 theirs 进行三方合并，返回合并后格式化的 markdown 文本和冲突数量，合并规则参考 merge.Merge。
+func (lute *Lute) Merge(base, ours, theirs []byte) (merged []byte, conflicts int) {
+	baseTree := parse.Parse("", base, lute.ParseOptions)
+	oursTree := parse.Parse("", ours, lute.ParseOptions)
+	theirsTree := parse.Parse("", theirs, lute.ParseOptions)
+	tree, conflicts := merge.Merge(baseTree, oursTree, theirsTree)
+	renderer := render.NewFormatRenderer(tree, lute.RenderOptions, lute.ParseOptions)
+	for nodeType, rendererFunc := range lute.FormatRendererFuncs {
+		renderer.ExtRendererFuncs[nodeType] = rendererFunc
+	}
+	merged = renderer.Render()
+	return
+}
+
+// MarkdownE 和 Markdown 一样将 markdown 处理为 html，但是处理过程中发生 panic 时返回 *parse.Error 而不会导致程序崩溃。
+func (lute *Lute) MarkdownE(name string, markdown []byte) (html []byte, err error) {
+	return lute.MarkdownContext(context.Background(), name, markdown)
+}
+
+// MarkdownContext 和 MarkdownE 一样处理 markdown，并且在 ctx 取消后尽快中止处理。
+//
+// 目前只有 Markdown 和 Format 提供 Context 变体。Md2BlockDOM、SpinBlockDOM、HTML2Markdown、HTML2Md 和 Md2VditorDOM 等编辑器接口
+// 仅提供不会导致程序崩溃的 E 变体；Docx、LaTeX、RST 等本身返回 error 的导出接口已经会将 panic 转换为 *parse.Error。
+func (lute *Lute) MarkdownContext(ctx context.Context, name string, markdown []byte) (html []byte, err error) {
+	tree, err := parse.ParseContext(ctx, name, markdown, lute.ParseOptions)
+	if nil != err {
+		return
+	}
+	renderer := render.NewHtmlRenderer(tree, lute.RenderOptions, lute.ParseOptions)
+	for nodeType, rendererFunc := range lute.Md2HTMLRendererFuncs {
+		renderer.ExtRendererFuncs[nodeType] = rendererFunc
+	}
+	return render.RenderContext(ctx, renderer)
+}
+
+// recoverE 调用 fn 并将其中发生的 panic 转换为 *parse.Error 返回，用于实现编辑器接口的 E 变体。
+func recoverE(fn func() string) (ret string, err error) {
+	defer parse.RecoverError(&err, "", nil)
+	ret = fn()
+	return
+}
+
+// MarkdownTo 从 r 中流式读取 markdown，每当一个顶层块解析完成后立即将其渲染为 html 并写入 w，适用于处理大文档。
+//
+// 链接引用定义和脚注的处理策略参考 parse.ParseStream：引用了后面才出现的定义的块会暂缓渲染，直到定义出现或者读取结束；
+// 脚注定义和 Markdown 一样统一在文档结尾渲染。
+func (lute *Lute) MarkdownTo(w io.Writer, r io.Reader) (err error) {
+	defer parse.RecoverError(&err, "", nil)
+
+	var renderer *render.HtmlRenderer
+	_, err = parse.ParseStream("", r, lute.ParseOptions, func(tree *parse.Tree, block *ast.Node) error {
+		if nil == renderer {
+			renderer = render.NewHtmlRenderer(tree, lute.RenderOptions, lute.ParseOptions)
+			for nodeType, rendererFunc := range lute.Md2HTMLRendererFuncs {
+				renderer.ExtRendererFuncs[nodeType] = rendererFunc
+			}
+			renderer.LastOut = lex.ItemNewline
+		}
+		renderer.RenderNode(block)
+		_, writeErr := w.Write(renderer.Writer.Bytes())
+		renderer.Writer.Reset()
+		return writeErr
+	})
+	if nil != err || nil == renderer {
+		return
+	}
+	_, err = w.Write(renderer.RenderFootnotes())
+	return
+}
+
 // MarkdownStr 接受 string 类型的 markdown 后直接调用 Markdown 进行处理。
 func (lute *Lute) MarkdownStr(name, markdown string) (html string) {
 	htmlBytes := lute.Markdown(name, []byte(markdown))
@@ -100,10 +212,31 @@ func (lute *Lute) MarkdownStr(name, markdown string) (html string) {
 func (lute *Lute) Format(name string, markdown []byte) (formatted []byte) {
 	tree := parse.Parse(name, markdown, lute.ParseOptions)
 	renderer := render.NewFormatRenderer(tree, lute.RenderOptions, lute.ParseOptions)
+	for nodeType, rendererFunc := range lute.FormatRendererFuncs {
+		renderer.ExtRendererFuncs[nodeType] = rendererFunc
+	}
 	formatted = renderer.Render()
 	return
 }
 
+// FormatE 和 Format 一样格式化 markdown，但是处理过程中发生 panic 时返回 *parse.Error 而不会导致程序崩溃。
+func (lute *Lute) FormatE(name string, markdown []byte) (formatted []byte, err error) {
+	return lute.FormatContext(context.Background(), name, markdown)
+}
+
+// FormatContext 和 FormatE 一样格式化 markdown，并且在 ctx 取消后尽快中止处理。
+func (lute *Lute) FormatContext(ctx context.Context, name string, markdown []byte) (formatted []byte, err error) {
+	tree, err := parse.ParseContext(ctx, name, markdown, lute.ParseOptions)
+	if nil != err {
+		return
+	}
+	renderer := render.NewFormatRenderer(tree, lute.RenderOptions, lute.ParseOptions)
+	for nodeType, rendererFunc := range lute.FormatRendererFuncs {
+		renderer.ExtRendererFuncs[nodeType] = rendererFunc
+	}
+	return render.RenderContext(ctx, renderer)
+}
+
 // FormatStr 接受 string 类型的 markdown 后直接调用 Format 进行处理。
 func (lute *Lute) FormatStr(name, markdown string) (formatted string) {
 	formattedBytes := lute.Format(name, []byte(markdown))
@@ -111,6 +244,32 @@ func (lute *Lute) FormatStr(name, markdown string) (formatted string) {
 	return
 }
 
+// Lint 使用所有内置规则检查 markdown，返回按位置排序的检查结果。
+func (lute *Lute) Lint(name string, markdown []byte) (diagnostics []*lint.Diagnostic) {
+	tree := parse.Parse(name, markdown, lute.lintParseOptions())
+	return lute.newLinter().Lint(tree, markdown)
+}
+
+// LintFix 检查 markdown 并自动修复可以修复的问题，返回修复后的 markdown 和剩余的检查结果。
+func (lute *Lute) LintFix(name string, markdown []byte) (fixed []byte, diagnostics []*lint.Diagnostic) {
+	tree := parse.Parse(name, markdown, lute.lintParseOptions())
+	return lute.newLinter().Fix(tree, markdown)
+}
+
+// lintParseOptions 返回检查时使用的解析选项，为了定位问题会开启 SourcePos。
+func (lute *Lute) lintParseOptions() *parse.Options {
+	options := *lute.ParseOptions
+	options.SourcePos = true
+	return &options
+}
+
+func (lute *Lute) newLinter() *lint.Linter {
+	ret := lint.New()
+	ret.RenderOptions = lute.RenderOptions
+	ret.HeadingIDSlugger = lute.RenderOptions.HeadingIDSlugger
+	return ret
+}
+
 // TextBundle 将 markdown 文本字节数组进行 TextBundle 处理。
 func (lute *Lute) TextBundle(name string, markdown []byte, linkPrefixes []string) (textbundle []byte, originalLinks []string) {
 	tree := parse.Parse(name, markdown, lute.ParseOptions)
@@ -126,6 +285,150 @@ func (lute *Lute) TextBundleStr(name, markdown string, linkPrefixes []string) (t
 	return
 }
 
+// Docx 将 markdown 文本字节数组渲染为 Word 文档（.docx）的内容，assetsDir 为图片相对路径的基础目录。
+func (lute *Lute) Docx(name string, markdown []byte, assetsDir string) (docx []byte, err error) {
+	tree, err := parse.ParseE(name, markdown, lute.ParseOptions)
+	if nil != err {
+		return
+	}
+	return lute.Tree2Docx(tree, assetsDir, lute.RenderOptions, lute.ParseOptions)
+}
+
+// Tree2Docx 使用指定的 options 渲染 tree 为 Word 文档（.docx）的内容，assetsDir 为图片相对路径的基础目录。
+func (lute *Lute) Tree2Docx(tree *parse.Tree, assetsDir string, options *render.Options, parseOptions *parse.Options) (docx []byte, err error) {
+	renderer := render.NewDocxRenderer(tree, assetsDir, options, parseOptions)
+	return render.RenderE(renderer)
+}
+
+// LaTeX 将 markdown 文本字节数组渲染为 LaTeX 文档，latexOptions 为 nil 时使用 render.NewLaTeXOptions 返回的默认选项。
+func (lute *Lute) LaTeX(name string, markdown []byte, latexOptions *render.LaTeXOptions) (latex []byte, err error) {
+	tree, err := parse.ParseE(name, markdown, lute.ParseOptions)
+	if nil != err {
+		return
+	}
+	return lute.Tree2LaTeX(tree, latexOptions, lute.RenderOptions, lute.ParseOptions)
+}
+
+// Tree2LaTeX 使用指定的 options 渲染 tree 为 LaTeX 文档。
+func (lute *Lute) Tree2LaTeX(tree *parse.Tree, latexOptions *render.LaTeXOptions, options *render.Options, parseOptions *parse.Options) (latex []byte, err error) {
+	renderer := render.NewLaTeXRenderer(tree, latexOptions, options, parseOptions)
+	return render.RenderE(renderer)
+}
+
+// Epub 将 chapters 中的 markdown 文本字节数组依次作为各章渲染为 EPUB 电子书（.epub）的内容，assetsDir 为图片相对路径的基础目录。
+func (lute *Lute) Epub(chapters [][]byte, metadata *render.EpubMetadata, assetsDir string) (epub []byte, err error) {
+	var trees []*parse.Tree
+	for _, markdown := range chapters {
+		tree, parseErr := parse.ParseE("", markdown, lute.ParseOptions)
+		if nil != parseErr {
+			return nil, parseErr
+		}
+		trees = append(trees, tree)
+	}
+	return lute.Trees2Epub(trees, metadata, assetsDir, lute.RenderOptions, lute.ParseOptions)
+}
+
+// Trees2Epub 使用指定的 options 将 trees 依次作为各章渲染为 EPUB 电子书（.epub）的内容，assetsDir 为图片相对路径的基础目录。
+func (lute *Lute) Trees2Epub(trees []*parse.Tree, metadata *render.EpubMetadata, assetsDir string, options *render.Options, parseOptions *parse.Options) (epub []byte, err error) {
+	renderer := render.NewEpubRenderer(trees, metadata, assetsDir, options, parseOptions)
+	return render.RenderE(renderer)
+}
+
+// Text 将 markdown 文本字节数组渲染为适合在终端或者邮件中显示的纯文本，textOptions 为 nil 时使用 render.NewTextOptions 返回的默认选项。
+func (lute *Lute) Text(name string, markdown []byte, textOptions *render.TextOptions) (text []byte, err error) {
+	tree, err := parse.ParseE(name, markdown, lute.ParseOptions)
+	if nil != err {
+		return
+	}
+	return lute.Tree2Text(tree, textOptions, lute.RenderOptions, lute.ParseOptions)
+}
+
+// Tree2Text 使用指定的 options 渲染 tree 为纯文本。
+func (lute *Lute) Tree2Text(tree *parse.Tree, textOptions *render.TextOptions, options *render.Options, parseOptions *parse.Options) (text []byte, err error) {
+	renderer := render.NewTextRenderer(tree, textOptions, options, parseOptions)
+	return render.RenderE(renderer)
+}
+
+// RST 将 markdown 文本字节数组渲染为 reStructuredText 文档。
+func (lute *Lute) RST(name string, markdown []byte) (rst []byte, err error) {
+	tree, err := parse.ParseE(name, markdown, lute.ParseOptions)
+	if nil != err {
+		return
+	}
+	return lute.Tree2RST(tree, lute.RenderOptions, lute.ParseOptions)
+}
+
+// Tree2RST 使用指定的 options 渲染 tree 为 reStructuredText 文档。
+func (lute *Lute) Tree2RST(tree *parse.Tree, options *render.Options, parseOptions *parse.Options) (rst []byte, err error) {
+	renderer := render.NewRSTRenderer(tree, options, parseOptions)
+	return render.RenderE(renderer)
+}
+
+// AsciiDoc 将 markdown 文本字节数组渲染为 AsciiDoc 文档。
+func (lute *Lute) AsciiDoc(name string, markdown []byte) (asciiDoc []byte, err error) {
+	tree, err := parse.ParseE(name, markdown, lute.ParseOptions)
+	if nil != err {
+		return
+	}
+	return lute.Tree2AsciiDoc(tree, lute.RenderOptions, lute.ParseOptions)
+}
+
+// Tree2AsciiDoc 使用指定的 options 渲染 tree 为 AsciiDoc 文档。
+func (lute *Lute) Tree2AsciiDoc(tree *parse.Tree, options *render.Options, parseOptions *parse.Options) (asciiDoc []byte, err error) {
+	renderer := render.NewAsciiDocRenderer(tree, options, parseOptions)
+	return render.RenderE(renderer)
+}
+
+// Jira 将 markdown 文本字节数组渲染为 Jira/Confluence wiki 标记。
+func (lute *Lute) Jira(name string, markdown []byte) (jira []byte, err error) {
+	tree, err := parse.ParseE(name, markdown, lute.ParseOptions)
+	if nil != err {
+		return
+	}
+	return lute.Tree2Jira(tree, lute.RenderOptions, lute.ParseOptions)
+}
+
+// Tree2Jira 使用指定的 options 渲染 tree 为 Jira/Confluence wiki 标记。
+func (lute *Lute) Tree2Jira(tree *parse.Tree, options *render.Options, parseOptions *parse.Options) (jira []byte, err error) {
+	renderer := render.NewJiraRenderer(tree, options, parseOptions)
+	return render.RenderE(renderer)
+}
+
+// ADF 将 markdown 文本字节数组渲染为 Atlassian Document Format（ADF）JSON。
+func (lute *Lute) ADF(name string, markdown []byte) (adf []byte, err error) {
+	tree, err := parse.ParseE(name, markdown, lute.ParseOptions)
+	if nil != err {
+		return
+	}
+	return lute.Tree2ADF(tree, lute.RenderOptions, lute.ParseOptions)
+}
+
+// Tree2ADF 使用指定的 options 渲染 tree 为 Atlassian Document Format（ADF）JSON。
+func (lute *Lute) Tree2ADF(tree *parse.Tree, options *render.Options, parseOptions *parse.Options) (adf []byte, err error) {
+	renderer := render.NewADFRenderer(tree, options, parseOptions)
+	return render.RenderE(renderer)
+}
+
+// Mdast 将 markdown 文本字节数组渲染为 mdast（https://github.com/syntax-tree/mdast）JSON。
+func (lute *Lute) Mdast(name string, markdown []byte) (mdast []byte, err error) {
+	tree, err := parse.ParseE(name, markdown, lute.ParseOptions)
+	if nil != err {
+		return
+	}
+	return lute.Tree2Mdast(tree, lute.RenderOptions, lute.ParseOptions)
+}
+
+// Tree2Mdast 使用指定的 options 渲染 tree 为 mdast JSON。
+func (lute *Lute) Tree2Mdast(tree *parse.Tree, options *render.Options, parseOptions *parse.Options) (mdast []byte, err error) {
+	renderer := render.NewMdastRenderer(tree, options, parseOptions)
+	return render.RenderE(renderer)
+}
+
+// Mdast2Tree 将 mdast JSON 解析为语法树。
+func (lute *Lute) Mdast2Tree(name string, mdast []byte) (tree *parse.Tree, err error) {
+	return parse.ParseMdast(name, mdast, lute.ParseOptions)
+}
+
 // HTML2Text 将指定的 HTMl dom 转换为文本。
 func (lute *Lute) HTML2Text(dom string) string {
 	tree := lute.HTML2Tree(dom)
@@ -144,6 +447,18 @@ func (lute *Lute) RenderJSON(markdown string) (json string) {
 	return
 }
 
+// RenderJSONE 和 RenderJSON 一样渲染 JSON 格式数据，但是处理过程中发生 panic 时返回 *parse.Error 而不会导致程序崩溃。
+func (lute *Lute) RenderJSONE(markdown string) (json string, err error) {
+	tree, err := parse.ParseE("", []byte(markdown), lute.ParseOptions)
+	if nil != err {
+		return
+	}
+	renderer := render.NewJSONRenderer(tree, lute.RenderOptions, lute.ParseOptions)
+	output, err := render.RenderE(renderer)
+	json = util.BytesToStr(output)
+	return
+}
+
 // Space 用于在 text 中的中西文之间插入空格。
 func (lute *Lute) Space(text string) string {
 	return render.Space0(text)
@@ -194,9 +509,6 @@ func (lute *Lute) GetLinkDest(str string) string {
 
 // GetEmojis 返回 Emoji 别名和对应 Unicode 字符的字典列表。
 func (lute *Lute) GetEmojis() (ret map[string]string) {
-	parse.EmojiLock.Lock()
-	defer parse.EmojiLock.Unlock()
-
 	ret = make(map[string]string, len(lute.ParseOptions.AliasEmoji))
 	placeholder := util.BytesToStr(parse.EmojiSitePlaceholder)
 	for k, v := range lute.ParseOptions.AliasEmoji {
@@ -209,21 +521,26 @@ func (lute *Lute) GetEmojis() (ret map[string]string) {
 }
 
 // PutEmojis 将指定的 emojiMap 合并覆盖已有的 Emoji 字典。
+//
+// 字典可能和其他引擎共用（默认为内置的 Emoji 字典），所以这里会复制一份新的字典进行合并，不会影响其他引擎。
 func (lute *Lute) PutEmojis(emojiMap map[string]string) {
-	parse.EmojiLock.Lock()
-	defer parse.EmojiLock.Unlock()
-
+	aliasEmoji := make(map[string]string, len(lute.ParseOptions.AliasEmoji)+len(emojiMap))
+	for k, v := range lute.ParseOptions.AliasEmoji {
+		aliasEmoji[k] = v
+	}
+	emojiAlias := make(map[string]string, len(lute.ParseOptions.EmojiAlias)+len(emojiMap))
+	for k, v := range lute.ParseOptions.EmojiAlias {
+		emojiAlias[k] = v
+	}
 	for k, v := range emojiMap {
-		lute.ParseOptions.AliasEmoji[k] = v
-		lute.ParseOptions.EmojiAlias[v] = k
+		aliasEmoji[k] = v
+		emojiAlias[v] = k
 	}
+	lute.ParseOptions.AliasEmoji, lute.ParseOptions.EmojiAlias = aliasEmoji, emojiAlias
 }
 
 // RemoveEmoji 用于删除 str 中的 Emoji Unicode。
 func (lute *Lute) RemoveEmoji(str string) string {
-	parse.EmojiLock.Lock()
-	defer parse.EmojiLock.Unlock()
-
 	for u := range lute.ParseOptions.EmojiAlias {
 		str = strings.ReplaceAll(str, u, "")
 	}
@@ -242,73 +559,38 @@ func (lute *Lute) PutTerms(termMap map[string]string) {
 	}
 }
 
-var (
-	formatRendererSync = render.NewFormatRenderer(nil, nil, nil)
-	formatRendererLock = sync.Mutex{}
-)
-
+// FormatNodeSync 使用指定的 options 将 node 格式化为 Markdown。每次调用都会创建新的渲染器，可以被多个 goroutine 并发调用。
 func FormatNodeSync(node *ast.Node, parseOptions *parse.Options, renderOptions *render.Options) (ret string, err error) {
-	formatRendererLock.Lock()
-	defer formatRendererLock.Unlock()
 	defer util.RecoverPanic(&err)
 
-	root := &ast.Node{Type: ast.NodeDocument}
-	tree := &parse.Tree{Root: root, Context: &parse.Context{ParseOption: parseOptions}}
-	formatRendererSync.Tree = tree
-	formatRendererSync.Options = renderOptions
-	formatRendererSync.ParseOptions = parseOptions
-	formatRendererSync.LastOut = lex.ItemNewline
-	formatRendererSync.NodeWriterStack = []*bytes.Buffer{formatRendererSync.Writer}
-
-	ast.Walk(node, func(n *ast.Node, entering bool) ast.WalkStatus {
-		rendererFunc := formatRendererSync.RendererFuncs[n.Type]
-		if nil == rendererFunc {
-			err = errors.New("not found renderer for node [type=" + n.Type.String() + "]")
-			return ast.WalkStop
-		}
-		return rendererFunc(n, entering)
-	})
-
-	ret = strings.TrimSpace(formatRendererSync.Writer.String())
-	formatRendererSync.Tree = nil
-	formatRendererSync.Options = nil
-	formatRendererSync.Writer.Reset()
-	formatRendererSync.NodeWriterStack = nil
-	return
+	tree := &parse.Tree{Root: &ast.Node{Type: ast.NodeDocument}, Context: &parse.Context{ParseOption: parseOptions}}
+	renderer := render.NewFormatRenderer(tree, renderOptions, parseOptions)
+	renderer.NodeWriterStack = []*bytes.Buffer{renderer.Writer}
+	return renderNodeSync(node, renderer.BaseRenderer)
 }
 
-var (
-	protyleExportMdRendererSync = render.NewProtyleExportMdRenderer(nil, nil, nil)
-	protyleExportMdRendererLock = sync.Mutex{}
-)
-
+// ProtyleExportMdNodeSync 使用指定的 options 将 node 导出为 Markdown。每次调用都会创建新的渲染器，可以被多个 goroutine 并发调用。
 func ProtyleExportMdNodeSync(node *ast.Node, parseOptions *parse.Options, renderOptions *render.Options) (ret string, err error) {
-	protyleExportMdRendererLock.Lock()
-	defer protyleExportMdRendererLock.Unlock()
 	defer util.RecoverPanic(&err)
 
-	root := &ast.Node{Type: ast.NodeDocument}
-	tree := &parse.Tree{Root: root, Context: &parse.Context{ParseOption: parseOptions}}
-	protyleExportMdRendererSync.Tree = tree
-	protyleExportMdRendererSync.Options = renderOptions
-	protyleExportMdRendererSync.ParseOptions = parseOptions
-	protyleExportMdRendererSync.LastOut = lex.ItemNewline
-	protyleExportMdRendererSync.NodeWriterStack = []*bytes.Buffer{protyleExportMdRendererSync.Writer}
+	tree := &parse.Tree{Root: &ast.Node{Type: ast.NodeDocument}, Context: &parse.Context{ParseOption: parseOptions}}
+	renderer := render.NewProtyleExportMdRenderer(tree, renderOptions, parseOptions)
+	renderer.NodeWriterStack = []*bytes.Buffer{renderer.Writer}
+	return renderNodeSync(node, renderer.BaseRenderer)
+}
 
+// renderNodeSync 使用 renderer 渲染 node 及其子节点。
+func renderNodeSync(node *ast.Node, renderer *render.BaseRenderer) (ret string, err error) {
+	renderer.LastOut = lex.ItemNewline
 	ast.Walk(node, func(n *ast.Node, entering bool) ast.WalkStatus {
-		rendererFunc := protyleExportMdRendererSync.RendererFuncs[n.Type]
+		rendererFunc := renderer.RendererFuncs[n.Type]
 		if nil == rendererFunc {
 			err = errors.New("not found renderer for node [type=" + n.Type.String() + "]")
 			return ast.WalkStop
 		}
 		return rendererFunc(n, entering)
 	})
-
-	ret = strings.TrimSpace(protyleExportMdRendererSync.Writer.String())
-	protyleExportMdRendererSync.Tree = nil
-	protyleExportMdRendererSync.Options = nil
-	protyleExportMdRendererSync.Writer.Reset()
-	protyleExportMdRendererSync.NodeWriterStack = nil
+	ret = strings.TrimSpace(renderer.Writer.String())
 	return
 }
 
@@ -388,6 +670,27 @@ func (lute *Lute) SetToC(b bool) {
 	lute.RenderOptions.ToC = b
 }
 
+// SetToCLevels 设置目录中包含的标题级别范围 [min, max]。
+func (lute *Lute) SetToCLevels(min, max int) {
+	lute.RenderOptions.ToCMinLevel = min
+	lute.RenderOptions.ToCMaxLevel = max
+}
+
+// SetToCNumbering 设置是否对目录条目自动编号。
+func (lute *Lute) SetToCNumbering(b bool) {
+	lute.RenderOptions.ToCNumbering = b
+}
+
+// SetToCNumberHeadings 设置是否将目录编号同时加在标题前。
+func (lute *Lute) SetToCNumberHeadings(b bool) {
+	lute.RenderOptions.ToCNumberHeadings = b
+}
+
+// SetToCExcludeClass 设置目录排除类名，标题内联属性列表中 class 包含该类名时不会出现在目录中。
+func (lute *Lute) SetToCExcludeClass(class string) {
+	lute.RenderOptions.ToCExcludeClass = class
+}
+
 func (lute *Lute) SetHeadingID(b bool) {
 	lute.ParseOptions.HeadingID = b
 	lute.RenderOptions.HeadingID = b
@@ -417,6 +720,16 @@ func (lute *Lute) SetHeadingAnchor(b bool) {
 	lute.RenderOptions.HeadingAnchor = b
 }
 
+// SetHeadingIDSlugger 设置标题 ID 生成策略，为 nil 时使用 Lute 默认策略。
+func (lute *Lute) SetHeadingIDSlugger(slugger render.HeadingIDSlugger) {
+	lute.RenderOptions.HeadingIDSlugger = slugger
+}
+
+// SetHeadingIDSluggerName 按名称设置内置的标题 ID 生成策略，可选值为 lute、github、gitlab、hugo 和 pandoc，未知名称时使用 Lute 默认策略。
+func (lute *Lute) SetHeadingIDSluggerName(name string) {
+	lute.RenderOptions.HeadingIDSlugger = render.HeadingIDSluggers[strings.ToLower(name)]
+}
+
 func (lute *Lute) SetTerms(terms map[string]string) {
 	lute.RenderOptions.Terms = terms
 }
@@ -518,6 +831,11 @@ func (lute *Lute) SetKramdownIAL(b bool) {
 	lute.RenderOptions.KramdownSpanIAL = b
 }
 
+// SetIDGenerator 设置节点 ID 生成器，为 nil 时使用 ast.NewNodeID。
+func (lute *Lute) SetIDGenerator(generator ast.IDGenerator) {
+	lute.ParseOptions.IDGenerator = generator
+}
+
 func (lute *Lute) SetKramdownBlockIAL(b bool) {
 	lute.ParseOptions.KramdownBlockIAL = b
 	lute.RenderOptions.KramdownBlockIAL = b
@@ -589,6 +907,18 @@ func (lute *Lute) SetHTML2MarkdownAttrs(attrs []string) {
 	lute.ParseOptions.HTML2MarkdownAttrs = attrs
 }
 
+func (lute *Lute) SetHTML2MarkdownUnknown(unknown int) {
+	lute.ParseOptions.HTML2MarkdownUnknown = unknown
+}
+
+func (lute *Lute) SetHTML2MarkdownFlavor(flavor int) {
+	lute.ParseOptions.HTML2MarkdownFlavor = flavor
+}
+
+func (lute *Lute) SetHTML2MarkdownReadability(b bool) {
+	lute.ParseOptions.HTML2MarkdownReadability = b
+}
+
 func (lute *Lute) SetHTMLTag2TextMark(b bool) {
 	lute.ParseOptions.HTMLTag2TextMark = b
 }
@@ -622,6 +952,10 @@ func (lute *Lute) SetCallout(b bool) {
 	lute.ParseOptions.Callout = b
 }
 
+func (lute *Lute) SetSourcePos(b bool) {
+	lute.ParseOptions.SourcePos = b
+}
+
 func (lute *Lute) SetJSRenderers(options map[string]map[string]*js.Object) {
 	for rendererType, extRenderer := range options["renderers"] {
 		switch extRenderer.Interface().(type) { // 稍微进行一点格式校验
@@ -652,6 +986,8 @@ func (lute *Lute) SetJSRenderers(options map[string]map[string]*js.Object) {
 			rendererFuncs = lute.Md2BlockDOMRendererFuncs
 		} else if "Md2VditorSVDOM" == rendererType {
 			rendererFuncs = lute.Md2VditorSVDOMRendererFuncs
+		} else if "Format" == rendererType {
+			rendererFuncs = lute.FormatRendererFuncs
 		} else {
 			panic("unknown ext renderer func [" + rendererType + "]")
 		}
@@ -0,0 +1,275 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+// Package merge 提供了 Markdown 语法树的三方合并。
+package merge
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/diff"
+	"github.com/88250/lute/lex"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/render"
+)
+
+const (
+	OursMarker   = "<<<<<<< ours"   // 冲突开始标记
+	BaseMarker   = "||||||| base"   // 冲突中共同祖先部分的开始标记
+	Separator    = "======="        // 冲突分隔标记
+	TheirsMarker = ">>>>>>> theirs" // 冲突结束标记
+)
+
+// block 描述了参与合并的顶层块。
+type block struct {
+	node    *ast.Node
+	ial     *ast.Node // 块后面的块级 IAL 节点，没有时为 nil
+	key     int       // 共同祖先中的块的下标，双方新增的块为 -1
+	content string    // 块的 Markdown 内容，不包括块级 IAL
+}
+
+// merger 描述了一棵参与合并的语法树。
+type merger struct {
+	tree     *parse.Tree
+	blocks   []*block
+	renderer *render.FormatRenderer
+}
+
+// Merge 以 base 为共同祖先对 ours 和 theirs 进行块级三方合并，返回合并后的语法树和冲突数量。
+//
+// 块按照 ID（开启 KramdownBlockIAL 时）或者内容进行匹配，匹配规则参考 diff.Diff。和 diff3 一样，只有一方修改的地方采用修改的一方，
+// 两方修改一致的地方采用任意一方，两方都修改且修改不一致的地方生成一个 ast.NodeGitConflict 节点，节点中依次是 ours、base 和 theirs 中对应的块。
+// 块的移动视为在原位置删除后在新位置新增。
+//
+// 合并结果中的块节点来自 base、ours 和 theirs，合并后不应该再使用这三棵语法树。
+func Merge(base, ours, theirs *parse.Tree) (ret *parse.Tree, conflicts int) {
+	o, a, b := newMerger(base), newMerger(ours), newMerger(theirs)
+	for i, blk := range o.blocks {
+		blk.key = i
+	}
+	alignA, alignB := align(o, a), align(o, b)
+	movedA, movedB := movedDeleted(a, alignA, b), movedDeleted(b, alignB, a)
+
+	var nodes []*ast.Node
+	conflict := func(olds, ours, theirs []*block) {
+		nodes = append(nodes, newConflict(olds, ours, theirs))
+		conflicts++
+	}
+	emitted := map[int]*block{}
+	emit := func(blocks []*block) {
+		for _, blk := range blocks {
+			// 一方移动了块而另一方删除了该块时在移动后的位置生成冲突
+			if movedA[blk] {
+				conflict(o.blocks[blk.key].list(), blk.list(), nil)
+				continue
+			}
+			if movedB[blk] {
+				conflict(o.blocks[blk.key].list(), nil, blk.list())
+				continue
+			}
+			if -1 < blk.key {
+				// 双方把同一个块移动到了不同的位置时只保留一个
+				if prev := emitted[blk.key]; nil != prev && prev.content == blk.content {
+					continue
+				}
+				emitted[blk.key] = blk
+			}
+			nodes = append(nodes, blk.node)
+			if nil != blk.ial {
+				nodes = append(nodes, blk.ial)
+			}
+		}
+	}
+
+	// 找出在双方中都保持了相对位置的块，这些块之间的部分按照 diff3 的规则进行合并
+	io, ia, ib := 0, 0, 0
+	chunk := func(io2, ia2, ib2 int) {
+		olds, ours, theirs := o.blocks[io:io2], a.blocks[ia:ia2], b.blocks[ib:ib2]
+		switch {
+		case unchanged(olds, ours):
+			emit(theirs)
+		case unchanged(olds, theirs), same(ours, theirs):
+			emit(ours)
+		default:
+			conflict(olds, ours, theirs)
+		}
+	}
+	for i, old := range o.blocks {
+		j, okA := alignA[i]
+		k, okB := alignB[i]
+		if !okA || !okB {
+			continue
+		}
+
+		chunk(i, j, k)
+		ours, theirs := a.blocks[j], b.blocks[k]
+		switch {
+		case old.content == ours.content:
+			emit(theirs.list())
+		case old.content == theirs.content, ours.content == theirs.content:
+			emit(ours.list())
+		default:
+			conflict(old.list(), ours.list(), theirs.list())
+		}
+		io, ia, ib = i+1, j+1, k+1
+	}
+	chunk(len(o.blocks), len(a.blocks), len(b.blocks))
+
+	// 文档级别的 IAL 沿用 ours 中的
+	for n := ours.Root.FirstChild; nil != n; n = n.Next {
+		if ast.NodeKramdownBlockIAL == n.Type && (nil == n.Previous || ast.NodeKramdownBlockIAL == n.Previous.Type) {
+			nodes = append(nodes, n)
+		}
+	}
+
+	ret = &parse.Tree{Name: ours.Name, ID: ours.ID, Box: ours.Box, Path: ours.Path, HPath: ours.HPath, Marks: ours.Marks,
+		Created: ours.Created, Updated: ours.Updated, Context: ours.Context}
+	ret.Root = &ast.Node{Type: ast.NodeDocument, ID: ours.Root.ID, KramdownIAL: ours.Root.KramdownIAL}
+	for _, n := range nodes {
+		ret.Root.AppendChild(n)
+	}
+	return
+}
+
+func newMerger(tree *parse.Tree) (ret *merger) {
+	ret = &merger{tree: tree}
+	options := render.NewOptions()
+	options.KramdownBlockIAL = tree.Context.ParseOption.KramdownBlockIAL
+	options.KramdownSpanIAL = tree.Context.ParseOption.KramdownSpanIAL
+	ret.renderer = render.NewFormatRenderer(tree, options, tree.Context.ParseOption)
+
+	for n := tree.Root.FirstChild; nil != n; n = n.Next {
+		if ast.NodeKramdownBlockIAL == n.Type {
+			continue
+		}
+		blk := &block{node: n, key: -1}
+		if nil != n.Next && ast.NodeKramdownBlockIAL == n.Next.Type {
+			blk.ial = n.Next
+		}
+		blk.content = ret.markdown(n)
+		ret.blocks = append(ret.blocks, blk)
+	}
+	return
+}
+
+// markdown 返回节点 nodes 的 Markdown 内容。
+func (m *merger) markdown(nodes ...*ast.Node) string {
+	m.renderer.Writer = &bytes.Buffer{}
+	m.renderer.LastOut = lex.ItemNewline
+	for _, n := range nodes {
+		m.renderer.RenderNode(n)
+	}
+	return strings.TrimSpace(m.renderer.Writer.String())
+}
+
+// align 比较共同祖先 base 和 side，设置 side 中每个块对应的共同祖先中的块，返回相对位置没有变化的块在 side 中的下标。
+func align(base, side *merger) (ret map[int]int) {
+	ret = map[int]int{}
+	oldIndexes, newIndexes := map[*ast.Node]int{}, map[*ast.Node]int{}
+	for i, blk := range base.blocks {
+		oldIndexes[blk.node] = i
+	}
+	for j, blk := range side.blocks {
+		newIndexes[blk.node] = j
+	}
+
+	for _, change := range diff.Diff(base.tree, side.tree).Changes {
+		if nil == change.Old || nil == change.New {
+			continue
+		}
+		i, j := oldIndexes[change.Old], newIndexes[change.New]
+		side.blocks[j].key = i
+		if diff.OpMove != change.Op {
+			ret[i] = j
+		}
+	}
+	return
+}
+
+// movedDeleted 返回 side 中移动了位置但是在 other 中被删除的块，aligned 为 side 中相对位置没有变化的块。
+func movedDeleted(side *merger, aligned map[int]int, other *merger) (ret map[*block]bool) {
+	ret = map[*block]bool{}
+	kept := map[int]bool{}
+	for _, blk := range other.blocks {
+		if -1 < blk.key {
+			kept[blk.key] = true
+		}
+	}
+	for j, blk := range side.blocks {
+		if -1 < blk.key && !kept[blk.key] {
+			if k, ok := aligned[blk.key]; !ok || k != j {
+				ret[blk] = true
+			}
+		}
+	}
+	return
+}
+
+// unchanged 判断 side 和共同祖先 olds 相比是否没有修改。
+func unchanged(olds, side []*block) bool {
+	if len(olds) != len(side) {
+		return false
+	}
+	for i, old := range olds {
+		if old.key != side[i].key || old.content != side[i].content {
+			return false
+		}
+	}
+	return true
+}
+
+// same 判断双方的内容是否一致。
+func same(ours, theirs []*block) bool {
+	if len(ours) != len(theirs) {
+		return false
+	}
+	for i, blk := range ours {
+		if blk.content != theirs[i].content {
+			return false
+		}
+	}
+	return true
+}
+
+// newConflict 使用共同祖先和双方的块构造一个冲突节点，块节点会被移动到冲突节点中。
+func newConflict(olds, ours, theirs []*block) (ret *ast.Node) {
+	ret = &ast.Node{Type: ast.NodeGitConflict}
+	ret.AppendChild(&ast.Node{Type: ast.NodeGitConflictOpenMarker, Tokens: []byte(OursMarker)})
+	ret.AppendChild(newSection(ast.NodeGitConflictOurs, ours))
+	base := newSection(ast.NodeGitConflictBase, olds)
+	base.Tokens = []byte(BaseMarker)
+	ret.AppendChild(base)
+	ret.AppendChild(newSection(ast.NodeGitConflictTheirs, theirs))
+	ret.AppendChild(&ast.Node{Type: ast.NodeGitConflictCloseMarker, Tokens: []byte(TheirsMarker)})
+	return
+}
+
+func newSection(typ ast.NodeType, blocks []*block) (ret *ast.Node) {
+	ret = &ast.Node{Type: typ}
+	for _, blk := range blocks {
+		for _, n := range blk.nodes() {
+			ret.AppendChild(n)
+		}
+	}
+	return
+}
+
+func (blk *block) nodes() []*ast.Node {
+	if nil == blk.ial {
+		return []*ast.Node{blk.node}
+	}
+	return []*ast.Node{blk.node, blk.ial}
+}
+
+func (blk *block) list() []*block {
+	return []*block{blk}
+}
@@ -22,7 +22,6 @@ import (
 // parseBlocks 解析并生成块级节点。
 func (t *Tree) parseBlocks() {
 	t.Context.Tip = t.Root
-	lines := 0
 	for line := t.lexer.NextLine(); nil != line; line = t.lexer.NextLine() {
 		if t.Context.ParseOption.VditorWYSIWYG || t.Context.ParseOption.VditorIR || t.Context.ParseOption.VditorSV || t.Context.ParseOption.ProtyleWYSIWYG {
 			if !bytes.Equal(line, editor.CaretNewlineTokens) && t.Context.Tip.ParentIs(ast.NodeListItem) && bytes.HasPrefix(line, editor.CaretTokens) {
@@ -38,12 +37,14 @@ func (t *Tree) parseBlocks() {
 			}
 		}
 
+		t.Context.line++
+		t.checkCancellation()
 		t.incorporateLine(line)
-		lines++
 	}
 	for nil != t.Context.Tip {
 		t.Context.finalize(t.Context.Tip)
 	}
+	t.Context.line = 0
 }
 
 func (t *Tree) BlockCount() (ret int) {
@@ -103,8 +104,10 @@ func (t *Tree) incorporateLine(line []byte) {
 			allMatched = false
 			break
 		case 2: // 匹配围栏代码块闭合，处理下一行
+			t.Context.touchLine(container)
 			return
 		case 3: // 匹配超级块闭合，处理下一行
+			t.Context.touchLine(container)
 			t.Context.closeSuperBlockChildren() // 闭合超级块下的子节点
 			if ast.NodeSuperBlock != t.Context.Tip.Type {
 				sb := t.Context.Tip.Parent
@@ -131,17 +134,19 @@ func (t *Tree) incorporateLine(line []byte) {
 	t.Context.lastMatchedContainer = container
 
 	matchedLeaf := container.Type != ast.NodeParagraph && container.AcceptLines()
-	blockParsers := blockStarts()
+	blockParsers := blockStarts(t.Context.ParseOption)
 	startsLen := len(blockParsers)
 
 	// 除非最后一个匹配到的是代码块，否则的话就起始一个新的块级节点
 	for !matchedLeaf {
 		t.Context.findNextNonspace()
+		t.Context.markSourceStart()
 
 		// 如果不由潜在的节点标记符开头 ^[#`~*+_=<>0-9-${]，则说明不用继续迭代生成子节点
-		// 这里仅做简单判断的话可以提升一些性能
+		// 这里仅做简单判断的话可以提升一些性能，注册了块级语法扩展的话无法预知其标记符，所以不做该判断
 		maybeMarker := t.Context.currentLine[t.Context.nextNonspace]
-		if !t.Context.indented && // 缩进代码块
+		if (1 > len(t.Context.ParseOption.BlockExtensions) || t.Context.blank) &&
+			!t.Context.indented && // 缩进代码块
 			lex.ItemHyphen != maybeMarker && lex.ItemAsterisk != maybeMarker && lex.ItemPlus != maybeMarker && // 无序列表
 			!lex.IsDigit(maybeMarker) && // 有序列表
 			lex.ItemBacktick != maybeMarker && lex.ItemTilde != maybeMarker && // 代码块
@@ -256,6 +261,7 @@ func (t *Tree) incorporateLine(line []byte) {
 			t.addLine()
 		}
 	}
+	t.Context.touchLine(container)
 }
 
 // addLine 用于在当前的末梢节点 context.Tip 上添加迭代行剩余的所有 Tokens。
@@ -309,5 +315,8 @@ func _continue(n *ast.Node, context *Context) int {
 		ast.NodeIFrame, ast.NodeVideo, ast.NodeAudio, ast.NodeWidget, ast.NodeAttributeView:
 		return 1
 	}
+	if ext := context.ParseOption.blockExtension(n.Type); nil != ext {
+		return ext.Continue(n, context)
+	}
 	return 0
 }
@@ -274,6 +274,7 @@ func (t *Tree) processEmphasis(stackBottom *delimiter, ctx *InlineContext) {
 
 			emStrongDelMark.PrependChild(openMarker) // 插入起始标记符
 			emStrongDelMark.AppendChild(closeMarker) // 插入结束标记符
+			delimSourcePos(openerInl, closerInl, openMarker, closeMarker, emStrongDelMark, useDelims)
 			openerInl.InsertAfter(emStrongDelMark)
 
 			// remove elts between opener and closer in delimiters stack
@@ -80,9 +80,7 @@ func (t *Tree) emoji0(node *ast.Node) {
 			continue
 		}
 
-		EmojiLock.Lock()
 		emoji, ok := t.Context.ParseOption.AliasEmoji[util.BytesToStr(maybeEmoji)]
-		EmojiLock.Unlock()
 		if ok {
 			emojiNode := &ast.Node{Type: ast.NodeEmoji}
 			emojiUnicodeOrImg := &ast.Node{Type: ast.NodeEmojiUnicode}
@@ -109,6 +107,7 @@ func (t *Tree) emoji0(node *ast.Node) {
 
 			emojiUnicodeOrImg.AppendChild(&ast.Node{Type: ast.NodeEmojiAlias, Tokens: tokens[i : pos+1]})
 			node.InsertAfter(emojiNode)
+			first.Position = nil // 拆分后的位置在解析结束时重新计算
 
 			if pos+1 < length {
 				// 在 Emoji 节点后插入一个内容为空的文本节点，留作下次迭代
@@ -0,0 +1,133 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package parse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/lex"
+	"github.com/88250/lute/util"
+)
+
+// Error 描述了解析或者渲染文档时发生的错误。
+type Error struct {
+	Name     string        // 文档名称，即传入 Parse 等函数的 name
+	Position *ast.Position // 出错位置，无法确定时为 nil。行号和列号总是有效的，字节偏移仅在解析选项 SourcePos 开启时有效
+	Err      error         // 原始错误，context 取消时为 context.Canceled 或者 context.DeadlineExceeded
+	Stack    []byte        // 发生 panic 时的调用栈
+}
+
+func (e *Error) Error() string {
+	ret := "document [" + e.Name + "]"
+	if nil != e.Position {
+		ret += " at [" + e.Position.String() + "]"
+	}
+	return ret + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// RecoverError 恢复 panic 并将其转换为 *Error 设置到 err 上，position 用于获取出错位置，可以为 nil。
+//
+// 该函数需要通过 defer 直接调用。
+func RecoverError(err *error, name string, position func() *ast.Position) {
+	e := recover()
+	if nil == e {
+		return
+	}
+
+	var cause error
+	switch x := e.(type) {
+	case *Error:
+		*err = x
+		return
+	case error:
+		cause = x
+	case string:
+		cause = errors.New(x)
+	default:
+		cause = fmt.Errorf("%v", x)
+	}
+
+	ret := &Error{Name: name, Err: cause, Stack: util.Stack()}
+	if nil != position {
+		ret.Position = position()
+	}
+	*err = ret
+}
+
+// ParseE 和 Parse 一样将 markdown 解析为语法树，但是解析过程中发生 panic 时返回 *Error 而不会导致程序崩溃。
+func ParseE(name string, markdown []byte, options *Options) (tree *Tree, err error) {
+	return ParseContext(context.Background(), name, markdown, options)
+}
+
+// ParseContext 和 ParseE 一样解析 markdown，并且在 ctx 取消后尽快中止解析，此时返回的 *Error 包装了 ctx.Err()。
+func ParseContext(ctx context.Context, name string, markdown []byte, options *Options) (tree *Tree, err error) {
+	tree = &Tree{Name: name, Context: &Context{ParseOption: options}}
+	tree.Context.Tree = tree
+	tree.Context.cancellation = ctx
+	defer func() {
+		if nil != err {
+			tree = nil
+		}
+	}()
+	defer RecoverError(&err, name, tree.errorPosition)
+
+	if err = ctx.Err(); nil != err {
+		return nil, &Error{Name: name, Err: err}
+	}
+
+	tree.parse(markdown)
+	tree.Context.cancellation = nil
+	return
+}
+
+// checkCancellation 检查解析是否已经被取消，取消的话通过 panic 中止解析，由 ParseContext 负责恢复。
+func (t *Tree) checkCancellation() {
+	if nil == t.Context.cancellation {
+		return
+	}
+	if err := t.Context.cancellation.Err(); nil != err {
+		panic(err)
+	}
+}
+
+// errorPosition 返回解析出错时的位置：块级解析阶段返回当前行，行级解析阶段返回当前解析的块节点位置（需要开启 SourcePos），其他阶段返回 nil。
+func (t *Tree) errorPosition() *ast.Position {
+	if nil != t.Context.inlineBlock {
+		if nil == t.Context.inlineBlock.Position || nil == t.lexer {
+			return nil
+		}
+		// 行级解析阶段块节点的位置还未映射到原始输入
+		ret := *t.Context.inlineBlock.Position
+		t.convertSourcePos(&ret)
+		return &ret
+	}
+	if 1 > t.Context.line {
+		return nil
+	}
+
+	end := len(t.Context.currentLine)
+	if 0 < end && lex.ItemNewline == t.Context.currentLine[end-1] {
+		end--
+	}
+	ret := &ast.Position{StartLine: t.Context.line, StartColumn: 1, EndLine: t.Context.line, EndColumn: end + 1}
+	if nil != t.lexer && t.lexer.SourcePos {
+		ret.StartOffset = t.lexer.SourceOffset(t.lexer.LineOffset())
+		ret.EndOffset = ret.StartOffset + end
+	}
+	return ret
+}
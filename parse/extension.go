@@ -0,0 +1,236 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package parse
+
+import (
+	"errors"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/lex"
+)
+
+// BlockExtension 描述了块级语法扩展，用于在不修改解析器的情况下支持自定义的块级语法，比如 ::: 提示容器或者 @startuml 围栏。
+//
+// 扩展的节点类型需要位于 ast 包预留的区间内：
+//   - [ast.NodeExtContainerBlock, ast.NodeExtLeafBlock) 为容器块，可以包含其他块级节点
+//   - [ast.NodeExtLeafBlock, ast.NodeExtBlockMaxVal] 为叶子块，接受文本行，行内容（包括起始行余下的内容）会追加到节点 Tokens 上
+//
+// 叶子块不会进行行级解析，如果需要解析行级内容，可以在 Finalize 中为其添加段落子节点并将 Tokens 移动到段落上。
+// 渲染时各渲染器没有内置这些节点类型的渲染函数，需要通过 ExtRendererFuncs 提供。
+type BlockExtension struct {
+	Type     ast.NodeType      // 节点类型
+	Start    BlockStartFunc    // 判断块是否开始，一般通过 Context.OpenBlock 创建节点
+	Continue BlockContinueFunc // 判断块是否可以继续接受当前行
+	Finalize BlockFinalizeFunc // 最终化块，可以为 nil
+}
+
+// BlockContinueFunc 定义了用于判断块是否可以继续处理的函数签名，返回值：
+//
+//	0：可以继续处理
+//	1：不能继续处理
+//	2：块已经闭合（需要调用 Context.Finalize），当前行处理完毕
+type BlockContinueFunc func(n *ast.Node, context *Context) int
+
+// BlockFinalizeFunc 定义了最终化块的函数签名。
+type BlockFinalizeFunc func(n *ast.Node, context *Context)
+
+// RegisterBlockExtension 注册块级语法扩展，后注册的扩展优先匹配，所有扩展均优先于内置的块级语法。
+func (options *Options) RegisterBlockExtension(ext *BlockExtension) error {
+	if nil == ext || nil == ext.Start || nil == ext.Continue {
+		return errors.New("block extension must have start and continue functions")
+	}
+	if !ast.IsExtBlockType(ext.Type) {
+		return errors.New("invalid block extension node type [" + ext.Type.String() + "]")
+	}
+	for _, registered := range options.BlockExtensions {
+		if registered.Type == ext.Type {
+			return errors.New("duplicated block extension node type [" + ext.Type.String() + "]")
+		}
+	}
+
+	options.BlockExtensions = append([]*BlockExtension{ext}, options.BlockExtensions...)
+	return nil
+}
+
+// blockExtension 返回节点类型 typ 对应的块级语法扩展。
+func (options *Options) blockExtension(typ ast.NodeType) *BlockExtension {
+	if !ast.IsExtBlockType(typ) {
+		return nil
+	}
+	for _, ext := range options.BlockExtensions {
+		if ext.Type == typ {
+			return ext
+		}
+	}
+	return nil
+}
+
+// InlinePriorityBuiltin 是内置行级语法的优先级。
+const InlinePriorityBuiltin = 0
+
+// InlineExtension 描述了行级语法扩展，用于在不修改解析器的情况下支持自定义的行级语法，比如 [[Page]] 维基链接、@mention 提及或者 {{< shortcode >}} 短代码。
+//
+// 行级解析遇到触发字节 Trigger 时按照优先级从高到低依次调用扩展的 Parse 函数：
+//   - Priority 大于 InlinePriorityBuiltin 的扩展先于内置语法尝试
+//   - 其余扩展仅在内置语法不处理该字节（即该字节会被作为普通文本）时尝试
+//
+// 扩展生成的节点和其他行级节点一样参与强调、链接等分隔符的匹配，所以 *see @bob* 这类写法可以正确解析。
+// 新节点类型建议使用 ast 包预留的区间 [ast.NodeExtInline, ast.NodeExtInlineMaxVal]，也可以直接生成内置节点（比如链接）。
+type InlineExtension struct {
+	Trigger  byte            // 触发字节
+	Parse    InlineParseFunc // 解析函数
+	Priority int             // 优先级，相同优先级时后注册的扩展优先匹配
+}
+
+// InlineParseFunc 定义了行级语法扩展的解析函数签名。
+//
+// 解析时从 ctx.Pos() 处（即触发字节）开始，匹配成功时通过 ctx.Advance 越过已经解析的内容并返回生成的节点；
+// 匹配失败时返回 nil，此时解析位置会被恢复。
+type InlineParseFunc func(t *Tree, block *ast.Node, ctx *InlineContext) *ast.Node
+
+// RegisterInlineExtension 注册行级语法扩展。
+func (options *Options) RegisterInlineExtension(ext *InlineExtension) error {
+	if nil == ext || nil == ext.Parse {
+		return errors.New("inline extension must have parse function")
+	}
+	if lex.ItemNewline == ext.Trigger {
+		return errors.New("newline can not be used as inline extension trigger")
+	}
+
+	i := 0
+	for ; i < len(options.InlineExtensions) && options.InlineExtensions[i].Priority > ext.Priority; i++ {
+	}
+	options.InlineExtensions = append(options.InlineExtensions, nil)
+	copy(options.InlineExtensions[i+1:], options.InlineExtensions[i:])
+	options.InlineExtensions[i] = ext
+	return nil
+}
+
+// isInlineTrigger 判断 token 是否是行级语法扩展的触发字节。
+func (options *Options) isInlineTrigger(token byte) bool {
+	for _, ext := range options.InlineExtensions {
+		if token == ext.Trigger {
+			return true
+		}
+	}
+	return false
+}
+
+// parseInlineExtension 使用行级语法扩展解析当前位置，beforeBuiltin 指定使用优先级高于还是不高于内置语法的扩展。
+func (t *Tree) parseInlineExtension(block *ast.Node, ctx *InlineContext, beforeBuiltin bool) (ret *ast.Node) {
+	exts := t.Context.ParseOption.InlineExtensions
+	if 1 > len(exts) {
+		return
+	}
+
+	start := ctx.pos
+	token := ctx.tokens[start]
+	for _, ext := range exts {
+		if token != ext.Trigger || beforeBuiltin != (InlinePriorityBuiltin < ext.Priority) {
+			continue
+		}
+
+		if ret = ext.Parse(t, block, ctx); nil != ret && start < ctx.pos && ctx.pos <= ctx.tokensLen {
+			return
+		}
+		// 未匹配或者没有越过任何内容时视为匹配失败
+		ret = nil
+		ctx.pos = start
+	}
+	return
+}
+
+// 以下方法供行级语法扩展使用。
+
+// Tokens 返回当前解析的行级 Tokens。
+func (ctx *InlineContext) Tokens() []byte {
+	return ctx.tokens
+}
+
+// Pos 返回当前解析到的位置。
+func (ctx *InlineContext) Pos() int {
+	return ctx.pos
+}
+
+// Remains 返回从当前解析位置开始余下的 Tokens。
+func (ctx *InlineContext) Remains() []byte {
+	return ctx.tokens[ctx.pos:]
+}
+
+// Advance 将解析位置向后移动 count 个字节。
+func (ctx *InlineContext) Advance(count int) {
+	ctx.pos += count
+}
+
+// 以下方法供块级语法扩展使用。
+
+// CurrentLine 返回当前行。
+func (context *Context) CurrentLine() []byte {
+	return context.currentLine
+}
+
+// Offset 返回当前行已经处理到的位置。
+func (context *Context) Offset() int {
+	return context.offset
+}
+
+// NextNonspace 返回当前行下一个非空字符的位置。
+func (context *Context) NextNonspace() int {
+	return context.nextNonspace
+}
+
+// Indent 返回当前行下一个非空字符前的缩进空格数。
+func (context *Context) Indent() int {
+	return context.indent
+}
+
+// Indented 判断当前行是否是缩进行（缩进超过 3 个空格）。
+func (context *Context) Indented() bool {
+	return context.indented
+}
+
+// Blank 判断当前行剩余部分是否是空行。
+func (context *Context) Blank() bool {
+	return context.blank
+}
+
+// AdvanceOffset 用于移动 count 个字符位置，columns 指定了遇到 tab 时是否需要空格进行补偿偏移。
+// 移动不会越过行尾换行符。
+func (context *Context) AdvanceOffset(count int, columns bool) {
+	if max := context.currentLineLen - 1 - context.offset; count > max {
+		count = max
+	}
+	context.advanceOffset(count, columns)
+}
+
+// AdvanceNextNonspace 用于移动到下一个非空字符位置。
+func (context *Context) AdvanceNextNonspace() {
+	context.advanceNextNonspace()
+}
+
+// OpenBlock 最终化未匹配的块，然后构造一个 nodeType 节点并作为新的末梢节点。
+func (context *Context) OpenBlock(nodeType ast.NodeType) *ast.Node {
+	context.closeUnmatchedBlocks()
+	return context.addChild(nodeType)
+}
+
+// Finalize 最终化块节点 n，n 下未闭合的子块会先被最终化。
+func (context *Context) Finalize(n *ast.Node) {
+	for tip := context.Tip; nil != tip; tip = tip.Parent {
+		if tip == n {
+			for context.Tip != n {
+				context.finalize(context.Tip)
+			}
+			break
+		}
+	}
+	context.finalize(n)
+}
@@ -12,7 +12,9 @@ package parse
 
 import (
 	"bytes"
+
 	"github.com/88250/lute/ast"
+	"github.com/88250/lute/lex"
 )
 
 // 判断 Git 冲突标记是否开始。
@@ -48,17 +50,160 @@ func GitConflictContinue(gitConflictBlock *ast.Node, context *Context) int {
 
 func (context *Context) gitConflictFinalize(gitConflictBlock *ast.Node) {
 	tokens := gitConflictBlock.Tokens
-	contentParts := bytes.Split(tokens, []byte("\n"))
-	openMarkerTokens := contentParts[0]
-	content := bytes.Join(contentParts[1:], []byte("\n"))
-	content = bytes.TrimSpace(content)
+	openMarkerEnd := bytes.IndexByte(tokens, lex.ItemNewline)
+	if 0 > openMarkerEnd {
+		openMarkerEnd = len(tokens)
+	}
+	openMarkerTokens := bytes.TrimRight(tokens[:openMarkerEnd], "\r")
 	closeMarkerTokens := bytes.TrimSpace(context.currentLine)
 	gitConflictBlock.Tokens = nil
 	gitConflictBlock.AppendChild(&ast.Node{Type: ast.NodeGitConflictOpenMarker, Tokens: openMarkerTokens})
-	gitConflictBlock.AppendChild(&ast.Node{Type: ast.NodeGitConflictContent, Tokens: content})
+
+	// 按照 ||||||| 和 ======= 分隔行将内容拆分为本地、共同祖先和拉取下来的三个部分
+	var offsets []int
+	if nil != gitConflictBlock.Position && nil != context.Tree.lexer {
+		offsets = context.Tree.alignSource(tokens, gitConflictBlock.Position.StartOffset, len(context.Tree.lexer.Input()))
+	}
+	section := &ast.Node{Type: ast.NodeGitConflictOurs}
+	start := openMarkerEnd + 1
+	for pos := start; pos < len(tokens); {
+		end := bytes.IndexByte(tokens[pos:], lex.ItemNewline)
+		if 0 > end {
+			end = len(tokens)
+		} else {
+			end += pos + 1
+		}
+		line := bytes.TrimRight(tokens[pos:end], " \t\r\n")
+		if bytes.HasPrefix(line, []byte("|||||||")) && ast.NodeGitConflictOurs == section.Type {
+			context.gitConflictSection(gitConflictBlock, section, tokens, start, pos, offsets)
+			section = &ast.Node{Type: ast.NodeGitConflictBase, Tokens: line}
+			start = end
+		} else if bytes.Equal(line, []byte("=======")) && ast.NodeGitConflictTheirs != section.Type {
+			context.gitConflictSection(gitConflictBlock, section, tokens, start, pos, offsets)
+			section = &ast.Node{Type: ast.NodeGitConflictTheirs}
+			start = end
+		}
+		pos = end
+	}
+	if start > len(tokens) {
+		start = len(tokens)
+	}
+	context.gitConflictSection(gitConflictBlock, section, tokens, start, len(tokens), offsets)
+	if ast.NodeGitConflictTheirs != section.Type {
+		// 缺少 ======= 分隔行时拉取下来的内容为空
+		gitConflictBlock.AppendChild(&ast.Node{Type: ast.NodeGitConflictTheirs})
+	}
 	gitConflictBlock.AppendChild(&ast.Node{Type: ast.NodeGitConflictCloseMarker, Tokens: closeMarkerTokens})
 }
 
+// gitConflictSection 将 tokens[start:end] 解析为块级节点后挂到 section 下，再将 section 挂到 gitConflictBlock 下。
+//
+// 这里只进行块级解析，行级解析和普通的块一样在整棵树块级解析结束后进行。offsets 为 tokens 中每个字节在词法分析输入中的偏移，
+// 用于将子树中的位置映射到整棵树的词法分析输入上。
+func (context *Context) gitConflictSection(gitConflictBlock, section *ast.Node, tokens []byte, start, end int, offsets []int) {
+	gitConflictBlock.AppendChild(section)
+	if start >= end {
+		return
+	}
+
+	subTree := &Tree{Name: context.Tree.Name, Context: &Context{ParseOption: context.ParseOption}}
+	subTree.Context.Tree = subTree
+	subTree.lexer = lex.NewLexer(append([]byte{}, tokens[start:end]...))
+	subTree.lexer.SourcePos = context.ParseOption.SourcePos
+	subTree.Root = &ast.Node{Type: ast.NodeDocument}
+	subTree.parseBlocks()
+
+	converted := map[*ast.Position]bool{}
+	ast.Walk(subTree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering || nil == n.Position || converted[n.Position] {
+			return ast.WalkContinue
+		}
+		converted[n.Position] = true
+		if nil == offsets {
+			n.Position = nil
+			return ast.WalkContinue
+		}
+
+		pos := n.Position
+		startOffset, endOffset := start+pos.StartOffset, start+pos.EndOffset
+		if startOffset > end {
+			startOffset = end
+		}
+		if endOffset > end {
+			endOffset = end
+		}
+		pos.StartOffset = offsets[startOffset]
+		pos.EndOffset = pos.StartOffset
+		if endOffset > startOffset {
+			pos.EndOffset = offsets[endOffset-1] + 1
+		}
+		return ast.WalkContinue
+	})
+
+	for n := subTree.Root.FirstChild; nil != n; {
+		next := n.Next
+		section.AppendChild(n)
+		n = next
+	}
+}
+
+// GitConflicts 返回树上所有的 Git 冲突节点。
+func (t *Tree) GitConflicts() (ret []*ast.Node) {
+	ast.Walk(t.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering && ast.NodeGitConflict == n.Type {
+			ret = append(ret, n)
+			return ast.WalkSkipChildren
+		}
+		return ast.WalkContinue
+	})
+	return
+}
+
+// AcceptOurs 采用本地的内容解决冲突 gitConflict，冲突节点会被替换为本地部分中的块。
+//
+// gitConflict 不是拆分了本地、共同祖先和拉取下来的三个部分的冲突节点时不做处理并返回 false。
+func AcceptOurs(gitConflict *ast.Node) bool {
+	return acceptGitConflict(gitConflict, ast.NodeGitConflictOurs)
+}
+
+// AcceptTheirs 采用拉取下来的内容解决冲突 gitConflict，冲突节点会被替换为拉取下来的部分中的块。
+//
+// gitConflict 不是拆分了本地、共同祖先和拉取下来的三个部分的冲突节点时不做处理并返回 false。
+func AcceptTheirs(gitConflict *ast.Node) bool {
+	return acceptGitConflict(gitConflict, ast.NodeGitConflictTheirs)
+}
+
+// AcceptBoth 同时采用双方的内容解决冲突 gitConflict，冲突节点会被依次替换为本地和拉取下来的部分中的块。
+//
+// gitConflict 不是拆分了本地、共同祖先和拉取下来的三个部分的冲突节点时不做处理并返回 false。
+func AcceptBoth(gitConflict *ast.Node) bool {
+	return acceptGitConflict(gitConflict, ast.NodeGitConflictOurs, ast.NodeGitConflictTheirs)
+}
+
+func acceptGitConflict(gitConflict *ast.Node, sections ...ast.NodeType) bool {
+	if nil == gitConflict || ast.NodeGitConflict != gitConflict.Type || nil == gitConflict.ChildByType(ast.NodeGitConflictOurs) {
+		return false
+	}
+
+	for _, typ := range sections {
+		for section := gitConflict.FirstChild; nil != section; section = section.Next {
+			if typ != section.Type {
+				continue
+			}
+			for n := section.FirstChild; nil != n; {
+				next := n.Next
+				gitConflict.InsertBefore(n)
+				n = next
+			}
+		}
+	}
+	if nil != gitConflict.Next && ast.NodeKramdownBlockIAL == gitConflict.Next.Type {
+		gitConflict.Next.Unlink()
+	}
+	gitConflict.Unlink()
+	return true
+}
+
 func (t *Tree) parseGitConflict() (ok bool) {
 	return bytes.HasPrefix(t.Context.currentLine, []byte("<<<<<<<"))
 }
@@ -33,6 +33,7 @@ func ATXHeadingStart(t *Tree, container *ast.Node) int {
 		heading.HeadingLevel = level
 		heading.Tokens = content
 		crosshatchMarker := &ast.Node{Type: ast.NodeHeadingC8hMarker, Tokens: markers}
+		t.Context.prefixSourcePos(heading, crosshatchMarker)
 		heading.AppendChild(crosshatchMarker)
 		t.Context.advanceOffset(t.Context.currentLineLen-t.Context.offset, false)
 		return 2
@@ -86,7 +87,7 @@ func SetextHeadingStart(t *Tree, container *ast.Node) int {
 	}
 
 	if 0 < len(container.Tokens) {
-		child := &ast.Node{Type: ast.NodeHeading, HeadingLevel: level, HeadingSetext: true}
+		child := &ast.Node{Type: ast.NodeHeading, HeadingLevel: level, HeadingSetext: true, Position: container.Position}
 		child.Tokens = lex.TrimWhitespace(container.Tokens)
 		container.InsertAfter(child)
 		container.Unlink()
@@ -71,7 +71,7 @@ func HtmlBlockStart(t *Tree, container *ast.Node) int {
 					avIdEndIdx := avIdIdx + bytes.Index(tokens[avIdIdx:], []byte("\""))
 					av.AttributeViewID = string(tokens[avIdIdx:avIdEndIdx])
 				} else {
-					av.AttributeViewID = ast.NewNodeID()
+					av.AttributeViewID = t.Context.ParseOption.NewNodeID(av)
 
 				}
 				return 2
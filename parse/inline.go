@@ -24,6 +24,13 @@ import (
 // parseInline 解析并生成块节点 block 的行级子节点。
 func (t *Tree) parseInline(block *ast.Node, ctx *InlineContext) {
 	for ctx.pos < ctx.tokensLen {
+		start, last := ctx.pos, block.LastChild
+		if n := t.parseInlineExtension(block, ctx, true); nil != n {
+			// 优先级高于内置语法的行级语法扩展
+			t.appendInline(block, ctx, n, start, last)
+			continue
+		}
+
 		token := ctx.tokens[ctx.pos]
 		var n *ast.Node
 		switch token {
@@ -36,7 +43,7 @@ func (t *Tree) parseInline(block *ast.Node, ctx *InlineContext) {
 		case lex.ItemCaret:
 			if t.Context.ParseOption.Sup {
 				t.handleDelim(block, ctx)
-			} else {
+			} else if n = t.parseInlineExtension(block, ctx, false); nil == n {
 				n = t.parseText(ctx)
 			}
 		case lex.ItemNewline:
@@ -68,26 +75,37 @@ func (t *Tree) parseInline(block *ast.Node, ctx *InlineContext) {
 		case lex.ItemOpenParen:
 			n = t.parseBlockRef(ctx)
 		default:
-			n = t.parseText(ctx)
+			if n = t.parseInlineExtension(block, ctx, false); nil == n {
+				n = t.parseText(ctx)
+			}
 		}
 
-		if nil != n {
-			var nodes []*ast.Node
-			first := n
-			for ; ; first = first.Previous {
-				if nil == first.Previous {
-					break
-				}
-			}
-			for node := first; nil != node; node = node.Next {
-				nodes = append(nodes, node)
-			}
-			for _, node := range nodes {
-				block.AppendChild(node)
+		t.appendInline(block, ctx, n, start, last)
+	}
+	block.Tokens = nil
+}
+
+// appendInline 将一轮行级解析生成的节点 n（包括 n 前面的兄弟节点）挂到块节点 block 下。
+func (t *Tree) appendInline(block *ast.Node, ctx *InlineContext, n *ast.Node, start int, last *ast.Node) {
+	if nil != n {
+		var nodes []*ast.Node
+		first := n
+		for ; ; first = first.Previous {
+			if nil == first.Previous {
+				break
 			}
 		}
+		for node := first; nil != node; node = node.Next {
+			nodes = append(nodes, node)
+		}
+		for _, node := range nodes {
+			block.AppendChild(node)
+		}
+	}
+
+	if nil != ctx.offsets {
+		t.setInlineSourcePos(block, ctx, start, last)
 	}
-	block.Tokens = nil
 }
 
 func (t *Tree) parseEntity(ctx *InlineContext) (ret *ast.Node) {
@@ -300,6 +318,7 @@ func (t *Tree) parseCloseBracket(ctx *InlineContext) *ast.Node {
 						refId += ":" + strconv.Itoa(refsLen+1)
 					}
 					ref := &ast.Node{Type: ast.NodeFootnotesRef, Tokens: reflabel, FootnotesRefId: refId, FootnotesRefLabel: bytes.ReplaceAll(reflabel, editor.CaretTokens, nil)}
+					ref.Position = ctx.spanSourcePos(opener.node, ctx.pos)
 					footnotesDef.FootnotesRefs = append(footnotesDef.FootnotesRefs, ref)
 					return ref
 				}
@@ -314,18 +333,29 @@ func (t *Tree) parseCloseBracket(ctx *InlineContext) *ast.Node {
 				}
 				matched = true
 				linkType = 3
+			} else {
+				t.Context.unresolvedRef = true
 			}
 		}
 	}
 
 	if matched {
 		node := &ast.Node{Type: ast.NodeLink, LinkType: linkType, LinkRefLabel: reflabel}
+		node.Position = ctx.spanSourcePos(opener.node, ctx.pos)
 		if isImage {
 			node.Type = ast.NodeImage
-			node.AppendChild(&ast.Node{Type: ast.NodeBang, Tokens: opener.node.Tokens[:1]})
+			bang := &ast.Node{Type: ast.NodeBang, Tokens: opener.node.Tokens[:1]}
+			if nil != node.Position {
+				bang.Position = &ast.Position{StartOffset: node.Position.StartOffset, EndOffset: node.Position.StartOffset + 1}
+			}
+			node.AppendChild(bang)
 			opener.node.Tokens = opener.node.Tokens[1:]
 		}
-		node.AppendChild(&ast.Node{Type: ast.NodeOpenBracket, Tokens: opener.node.Tokens})
+		openBracket := &ast.Node{Type: ast.NodeOpenBracket, Tokens: opener.node.Tokens}
+		if nil != node.Position {
+			openBracket.Position = &ast.Position{StartOffset: opener.node.Position.EndOffset - 1, EndOffset: opener.node.Position.EndOffset}
+		}
+		node.AppendChild(openBracket)
 
 		var tmp, next *ast.Node
 		tmp = opener.node.Next
@@ -344,7 +374,11 @@ func (t *Tree) parseCloseBracket(ctx *InlineContext) *ast.Node {
 			node.AppendChild(tmp)
 			tmp = next
 		}
-		node.AppendChild(&ast.Node{Type: ast.NodeCloseBracket, Tokens: closeBracket})
+		closeBracketNode := &ast.Node{Type: ast.NodeCloseBracket, Tokens: closeBracket}
+		if nil != node.Position {
+			closeBracketNode.Position = ctx.sourcePos(startPos-1, startPos)
+		}
+		node.AppendChild(closeBracketNode)
 		node.AppendChild(&ast.Node{Type: ast.NodeOpenParen, Tokens: openParen})
 		if t.Context.ParseOption.ProtyleWYSIWYG {
 			if bytes.Contains(dest, editor.CaretTokens) {
@@ -17,6 +17,7 @@ import (
 // parseInlines 解析并生成行级节点。
 func (t *Tree) parseInlines() {
 	t.walkParseInline(t.Root)
+	t.Context.inlineBlock = nil
 
 	if t.Context.ParseOption.KramdownSpanIAL {
 		t.parseKramdownSpanIAL()
@@ -82,7 +83,12 @@ func (t *Tree) walkParseInline(node *ast.Node) {
 			return
 		}
 
+		t.Context.inlineBlock = node
+		t.checkCancellation()
 		ctx := &InlineContext{tokens: tokens, tokensLen: length}
+		if t.Context.ParseOption.SourcePos {
+			ctx.offsets = t.inlineSourceOffsets(node, tokens)
+		}
 
 		// 生成该块节点的行级子节点
 		t.parseInline(node, ctx)
@@ -113,6 +119,7 @@ func (t *Tree) walkParseInline(node *ast.Node) {
 			node.PrependChild(openMarker)
 			info := &ast.Node{Type: ast.NodeCodeBlockFenceInfoMarker, CodeBlockInfo: node.CodeBlockInfo}
 			node.AppendChild(info)
+			t.codeBlockInfoSourcePos(node, info)
 			code := &ast.Node{Type: ast.NodeCodeBlockCode, Tokens: node.Tokens}
 			node.AppendChild(code)
 			if nil == node.CodeBlockCloseFence {
@@ -24,6 +24,7 @@ func (context *Context) parseLinkRefDef(tokens []byte) []byte {
 		return nil
 	}
 
+	paragraphTokens := tokens
 	_, tokens = lex.TrimLeft(tokens)
 	if 1 > len(tokens) {
 		return nil
@@ -86,6 +87,7 @@ func (context *Context) parseLinkRefDef(tokens []byte) []byte {
 	link := context.Tree.newLink(ast.NodeLink, label, destination, title, 1)
 	def := &ast.Node{Type: ast.NodeLinkRefDef, Tokens: label}
 	def.AppendChild(link)
+	context.linkRefDefSourcePos(context.Tip, def, paragraphTokens[:len(paragraphTokens)-len(remains)])
 	defBlock := context.Tip
 	if ast.NodeLinkRefDefBlock != defBlock.Type {
 		defBlock = &ast.Node{Type: ast.NodeLinkRefDefBlock}
@@ -99,15 +99,15 @@ func (context *Context) listFinalize(list *ast.Node) {
 		for li := list.FirstChild; nil != li; li = li.Next {
 			if nil == li.FirstChild {
 				if ast.NodeKramdownBlockIAL != li.Type {
-					id := ast.NewNodeID()
+					id := context.ParseOption.NewNodeID(li)
 					ialTokens := []byte("{: id=\"" + id + "\"}")
 					li.KramdownIAL = [][]string{{"id", id}}
 					li.ID = id
 					li.InsertAfter(&ast.Node{Type: ast.NodeKramdownBlockIAL, Tokens: ialTokens})
 
-					id = ast.NewNodeID()
+					p := &ast.Node{Type: ast.NodeParagraph}
+					id = context.ParseOption.NewNodeID(p)
 					ialTokens = []byte("{: id=\"" + id + "\"}")
-					p := &ast.Node{Type: ast.NodeParagraph, ID: id}
 					p.KramdownIAL = [][]string{{"id", id}}
 					p.ID = id
 					p.InsertAfter(&ast.Node{Type: ast.NodeKramdownBlockIAL, Tokens: ialTokens})
@@ -132,7 +132,7 @@ func (context *Context) listFinalize(list *ast.Node) {
 			} else {
 				var ialTokens []byte
 				if nil == li.KramdownIAL {
-					id := ast.NewNodeID()
+					id := context.ParseOption.NewNodeID(li)
 					ialTokens = []byte("{: id=\"" + id + "\"}")
 					li.KramdownIAL = [][]string{{"id", id}}
 					li.ID = id
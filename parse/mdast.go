@@ -0,0 +1,673 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package parse
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/88250/lute/ast"
+)
+
+// MdastNamespace 为 Lute 特有节点在 mdast 中的节点类型前缀。
+const MdastNamespace = "lute:"
+
+// MdastCustomType 返回节点类型 typ 对应的 mdast 自定义节点类型，比如 NodeSuperBlock 对应 lute:superBlock。
+func MdastCustomType(typ ast.NodeType) string {
+	name := strings.TrimPrefix(typ.String(), "Node")
+	return MdastNamespace + strings.ToLower(name[:1]) + name[1:]
+}
+
+// mdastNodeType 返回 mdast 自定义节点类型 typ 对应的节点类型，不是 Lute 节点时返回 -1。
+func mdastNodeType(typ string) ast.NodeType {
+	name := strings.TrimPrefix(typ, MdastNamespace)
+	if typ == name || "" == name {
+		return -1
+	}
+	return ast.Str2NodeType("Node" + strings.ToUpper(name[:1]) + name[1:])
+}
+
+// ParseMdast 将 mdast（https://github.com/syntax-tree/mdast）JSON 解析为一棵语法树。
+//
+// 支持 CommonMark、GFM 和 remark-math 节点，"lute:" 命名空间下的自定义节点还原为对应的 Lute 节点，其他未知节点仅保留其子节点或者文本值。
+// 节点 data.kramdownIAL 上的 IAL 会还原为节点的 IAL 以及后面的 IAL 节点。
+// 链接引用 linkReference 和 imageReference 会使用 definition 节点解析出链接地址。
+func ParseMdast(name string, mdast []byte, options *Options) (tree *Tree, err error) {
+	tree = &Tree{Name: name, Context: &Context{ParseOption: options}}
+	tree.Context.Tree = tree
+	defer func() {
+		if nil != err {
+			tree = nil
+		}
+	}()
+	defer RecoverError(&err, name, nil)
+
+	var root map[string]interface{}
+	if err = json.Unmarshal(mdast, &root); nil != err {
+		return nil, &Error{Name: name, Err: err}
+	}
+	if "root" != mdastString(root, "type") {
+		return nil, &Error{Name: name, Err: errors.New("mdast root node type must be [root]")}
+	}
+
+	p := &mdastParser{tree: tree, definitions: map[string]map[string]interface{}{}}
+	p.collectDefinitions(root)
+	tree.Root = &ast.Node{Type: ast.NodeDocument, Position: mdastPosition(root)}
+	p.appendChildren(tree.Root, root)
+	p.ial(tree.Root, root)
+	p.finalize()
+	return
+}
+
+// mdastParser 用于将 mdast 节点转换为语法树节点。
+type mdastParser struct {
+	tree              *Tree
+	definitions       map[string]map[string]interface{} // 链接引用定义，identifier -> definition
+	footnotesDefBlock *ast.Node                         // 脚注定义都放在第一个脚注定义所在位置的脚注定义块中
+}
+
+func mdastString(node map[string]interface{}, key string) string {
+	ret, _ := node[key].(string)
+	return ret
+}
+
+func mdastInt(node map[string]interface{}, key string) (ret int, ok bool) {
+	f, ok := node[key].(float64)
+	return int(f), ok
+}
+
+func mdastBool(node map[string]interface{}, key string) (ret, ok bool) {
+	ret, ok = node[key].(bool)
+	return
+}
+
+func mdastChildren(node map[string]interface{}) (ret []map[string]interface{}) {
+	children, _ := node["children"].([]interface{})
+	for _, child := range children {
+		if c, ok := child.(map[string]interface{}); ok {
+			ret = append(ret, c)
+		}
+	}
+	return
+}
+
+// mdastIdentifier 返回链接引用和脚注节点的 identifier，没有的话使用规范化后的 label。
+func mdastIdentifier(node map[string]interface{}) string {
+	if ret := mdastString(node, "identifier"); "" != ret {
+		return ret
+	}
+	return strings.ToLower(strings.Join(strings.Fields(mdastString(node, "label")), " "))
+}
+
+// mdastLabel 返回链接引用和脚注节点的 label，没有的话使用 identifier。
+func mdastLabel(node map[string]interface{}) string {
+	if ret := mdastString(node, "label"); "" != ret {
+		return ret
+	}
+	return mdastString(node, "identifier")
+}
+
+// mdastText 返回 mdast 节点 node 的纯文本值。
+func mdastText(node map[string]interface{}) string {
+	if value, ok := node["value"].(string); ok {
+		return value
+	}
+	if alt, ok := node["alt"].(string); ok {
+		return alt
+	}
+	buf := bytes.Buffer{}
+	for _, child := range mdastChildren(node) {
+		buf.WriteString(mdastText(child))
+	}
+	return buf.String()
+}
+
+// mdastPosition 返回 mdast 节点 node 的位置，没有位置信息时返回 nil。
+func mdastPosition(node map[string]interface{}) *ast.Position {
+	position, _ := node["position"].(map[string]interface{})
+	start, _ := position["start"].(map[string]interface{})
+	end, _ := position["end"].(map[string]interface{})
+	if nil == start || nil == end {
+		return nil
+	}
+
+	ret := &ast.Position{}
+	ret.StartLine, _ = mdastInt(start, "line")
+	ret.StartColumn, _ = mdastInt(start, "column")
+	ret.StartOffset, _ = mdastInt(start, "offset")
+	ret.EndLine, _ = mdastInt(end, "line")
+	ret.EndColumn, _ = mdastInt(end, "column")
+	ret.EndOffset, _ = mdastInt(end, "offset")
+	return ret
+}
+
+// collectDefinitions 收集 node 下所有的链接引用定义。
+func (p *mdastParser) collectDefinitions(node map[string]interface{}) {
+	if "definition" == mdastString(node, "type") {
+		if identifier := mdastIdentifier(node); nil == p.definitions[identifier] {
+			p.definitions[identifier] = node
+		}
+		return
+	}
+	for _, child := range mdastChildren(node) {
+		p.collectDefinitions(child)
+	}
+}
+
+// appendChildren 将 mdast 节点 node 的子节点转换后添加到 parent 中。
+func (p *mdastParser) appendChildren(parent *ast.Node, node map[string]interface{}) {
+	for _, child := range mdastChildren(node) {
+		p.appendNode(parent, child)
+	}
+}
+
+// appendNode 将 mdast 节点 node 转换后添加到 parent 中。
+func (p *mdastParser) appendNode(parent *ast.Node, node map[string]interface{}) {
+	typ := mdastString(node, "type")
+	var n *ast.Node
+	switch typ {
+	case "paragraph":
+		n = &ast.Node{Type: ast.NodeParagraph}
+		p.appendChildren(n, node)
+	case "heading":
+		n = &ast.Node{Type: ast.NodeHeading}
+		n.HeadingLevel, _ = mdastInt(node, "depth")
+		if 1 > n.HeadingLevel {
+			n.HeadingLevel = 1
+		} else if 6 < n.HeadingLevel {
+			n.HeadingLevel = 6
+		}
+		n.AppendChild(&ast.Node{Type: ast.NodeHeadingC8hMarker, Tokens: []byte(strings.Repeat("#", n.HeadingLevel) + " ")})
+		p.appendChildren(n, node)
+		if id := mdastHeadingID(node); "" != id && id != IAL2Map(mdastIAL(node))["id"] {
+			n.AppendChild(&ast.Node{Type: ast.NodeHeadingID, Tokens: []byte("#" + id)})
+		}
+	case "thematicBreak":
+		n = &ast.Node{Type: ast.NodeThematicBreak}
+	case "blockquote":
+		n = &ast.Node{Type: ast.NodeBlockquote}
+		n.AppendChild(&ast.Node{Type: ast.NodeBlockquoteMarker, Tokens: []byte("> ")})
+		p.appendChildren(n, node)
+	case "list":
+		n = p.list(node)
+	case "listItem":
+		// 列表项不在列表中时作为只有一项的列表
+		n = p.list(map[string]interface{}{"type": "list", "children": []interface{}{node}})
+	case "code":
+		n = mdastCodeBlock(mdastString(node, "value"), strings.TrimSpace(mdastString(node, "lang")+" "+mdastString(node, "meta")))
+	case "math":
+		n = &ast.Node{Type: ast.NodeMathBlock}
+		n.AppendChild(&ast.Node{Type: ast.NodeMathBlockOpenMarker})
+		n.AppendChild(&ast.Node{Type: ast.NodeMathBlockContent, Tokens: []byte(mdastString(node, "value"))})
+		n.AppendChild(&ast.Node{Type: ast.NodeMathBlockCloseMarker})
+	case "inlineMath":
+		n = &ast.Node{Type: ast.NodeInlineMath}
+		n.AppendChild(&ast.Node{Type: ast.NodeInlineMathOpenMarker})
+		n.AppendChild(&ast.Node{Type: ast.NodeInlineMathContent, Tokens: []byte(mdastString(node, "value"))})
+		n.AppendChild(&ast.Node{Type: ast.NodeInlineMathCloseMarker})
+	case "html":
+		n = &ast.Node{Type: ast.NodeInlineHTML, Tokens: []byte(mdastString(node, "value"))}
+		if parent.IsContainerBlock() {
+			n.Type = ast.NodeHTMLBlock
+		}
+	case "yaml":
+		value := []byte(mdastString(node, "value"))
+		n = &ast.Node{Type: ast.NodeYamlFrontMatter, Tokens: value}
+		n.AppendChild(&ast.Node{Type: ast.NodeYamlFrontMatterOpenMarker})
+		n.AppendChild(&ast.Node{Type: ast.NodeYamlFrontMatterContent, Tokens: value})
+		n.AppendChild(&ast.Node{Type: ast.NodeYamlFrontMatterCloseMarker})
+	case "table":
+		n = p.table(node)
+	case "text":
+		p.appendText(parent, node, mdastString(node, "value"))
+		return
+	case "emphasis":
+		n = mdastDelimited(ast.NodeEmphasis, ast.NodeEmA6kOpenMarker, ast.NodeEmA6kCloseMarker, "*")
+		p.appendContent(n, node)
+	case "strong":
+		n = mdastDelimited(ast.NodeStrong, ast.NodeStrongA6kOpenMarker, ast.NodeStrongA6kCloseMarker, "**")
+		p.appendContent(n, node)
+	case "delete":
+		n = mdastDelimited(ast.NodeStrikethrough, ast.NodeStrikethrough2OpenMarker, ast.NodeStrikethrough2CloseMarker, "~~")
+		p.appendContent(n, node)
+	case "inlineCode":
+		value := mdastString(node, "value")
+		marker := "`"
+		if strings.Contains(value, "`") {
+			marker = "``"
+		}
+		n = mdastDelimited(ast.NodeCodeSpan, ast.NodeCodeSpanOpenMarker, ast.NodeCodeSpanCloseMarker, marker)
+		n.CodeMarkerLen = len(marker)
+		n.FirstChild.InsertAfter(&ast.Node{Type: ast.NodeCodeSpanContent, Tokens: []byte(value)})
+	case "break":
+		n = &ast.Node{Type: ast.NodeHardBreak, Tokens: []byte("\n")}
+	case "link":
+		n = &ast.Node{Type: ast.NodeLink}
+		n.AppendChild(&ast.Node{Type: ast.NodeOpenBracket, Tokens: []byte("[")})
+		p.appendChildren(n, node)
+		n.AppendChild(&ast.Node{Type: ast.NodeCloseBracket, Tokens: []byte("]")})
+		mdastLinkDest(n, mdastString(node, "url"), mdastString(node, "title"))
+	case "image":
+		n = mdastImage(mdastString(node, "url"), mdastString(node, "title"), mdastString(node, "alt"))
+	case "linkReference", "imageReference":
+		definition := p.definitions[mdastIdentifier(node)]
+		if nil == definition {
+			// 没有对应的链接引用定义时作为文本
+			p.appendText(parent, node, "["+mdastText(node)+"]")
+			return
+		}
+		if "imageReference" == typ {
+			n = mdastImage(mdastString(definition, "url"), mdastString(definition, "title"), mdastString(node, "alt"))
+			break
+		}
+		label := mdastLabel(node)
+		if "full" != mdastString(node, "referenceType") {
+			label = mdastText(node)
+		}
+		n = &ast.Node{Type: ast.NodeLink, LinkType: 3, LinkRefLabel: []byte(label)}
+		n.AppendChild(&ast.Node{Type: ast.NodeOpenBracket, Tokens: []byte("[")})
+		p.appendChildren(n, node)
+		n.AppendChild(&ast.Node{Type: ast.NodeCloseBracket, Tokens: []byte("]")})
+		mdastLinkDest(n, mdastString(definition, "url"), mdastString(definition, "title"))
+	case "definition":
+		label := mdastLabel(node)
+		link := &ast.Node{Type: ast.NodeLink, LinkType: 1, LinkRefLabel: []byte(label)}
+		link.AppendChild(&ast.Node{Type: ast.NodeOpenBracket})
+		link.AppendChild(&ast.Node{Type: ast.NodeLinkText, Tokens: []byte(label)})
+		link.AppendChild(&ast.Node{Type: ast.NodeCloseBracket})
+		mdastLinkDest(link, mdastString(node, "url"), mdastString(node, "title"))
+		n = &ast.Node{Type: ast.NodeLinkRefDef, Tokens: []byte(label), Position: mdastPosition(node)}
+		n.AppendChild(link)
+		if last := parent.LastChild; nil != last && ast.NodeLinkRefDefBlock == last.Type {
+			last.AppendChild(n)
+			return
+		}
+		block := &ast.Node{Type: ast.NodeLinkRefDefBlock}
+		block.AppendChild(n)
+		parent.AppendChild(block)
+		return
+	case "footnoteReference":
+		label := mdastLabel(node)
+		n = &ast.Node{Type: ast.NodeFootnotesRef, Tokens: []byte("^" + label), FootnotesRefLabel: []byte("^" + label)}
+	case "footnoteDefinition":
+		n = &ast.Node{Type: ast.NodeFootnotesDef, Tokens: []byte("^" + mdastLabel(node)), Position: mdastPosition(node)}
+		p.appendChildren(n, node)
+		if nil == p.footnotesDefBlock {
+			p.footnotesDefBlock = &ast.Node{Type: ast.NodeFootnotesDefBlock}
+			parent.AppendChild(p.footnotesDefBlock)
+		}
+		p.footnotesDefBlock.AppendChild(n)
+		return
+	default:
+		if n = p.custom(node); nil == n {
+			// 未知节点仅保留其子节点或者文本值
+			if _, ok := node["children"]; ok {
+				p.appendChildren(parent, node)
+			} else if value, ok := node["value"].(string); ok {
+				p.appendText(parent, node, value)
+			}
+			return
+		}
+	}
+	if nil == n.Position {
+		n.Position = mdastPosition(node)
+	}
+	parent.AppendChild(n)
+	p.ial(n, node)
+}
+
+// mdastIAL 返回 mdast 节点 node 的 data.kramdownIAL 上的 IAL。
+func mdastIAL(node map[string]interface{}) (ret [][]string) {
+	data, _ := node["data"].(map[string]interface{})
+	pairs, _ := data["kramdownIAL"].([]interface{})
+	for _, pair := range pairs {
+		kv, _ := pair.([]interface{})
+		if 2 != len(kv) {
+			continue
+		}
+		k, _ := kv[0].(string)
+		v, _ := kv[1].(string)
+		if "" != k {
+			ret = append(ret, []string{k, v})
+		}
+	}
+	return
+}
+
+// ial 将 mdast 节点 node 上的 IAL 设置到 n 上，并在 n 后面插入 IAL 节点，文档的 IAL 节点插入到文档末尾。
+func (p *mdastParser) ial(n *ast.Node, node map[string]interface{}) {
+	ial := mdastIAL(node)
+	if 1 > len(ial) {
+		return
+	}
+
+	n.KramdownIAL = ial
+	if id := IAL2Map(ial)["id"]; "" != id {
+		n.ID = id
+		if ast.NodeDocument == n.Type {
+			p.tree.ID = id
+		}
+	}
+
+	tokens := IAL2Tokens(ial)
+	switch {
+	case ast.NodeDocument == n.Type:
+		n.AppendChild(&ast.Node{Type: ast.NodeKramdownBlockIAL, Tokens: append(tokens, '\n')})
+	case ast.NodeListItem == n.Type:
+		n.InsertAfter(&ast.Node{Type: ast.NodeKramdownBlockIAL, Tokens: tokens})
+	case n.IsBlock():
+		n.InsertAfter(&ast.Node{Type: ast.NodeKramdownBlockIAL, Tokens: append(tokens, '\n')})
+	default:
+		n.InsertAfter(&ast.Node{Type: ast.NodeKramdownSpanIAL, Tokens: tokens})
+	}
+}
+
+// mdastHeadingID 返回标题节点 data.hProperties.id 或者 data.id 上的自定义 ID。
+func mdastHeadingID(node map[string]interface{}) string {
+	data, _ := node["data"].(map[string]interface{})
+	if properties, ok := data["hProperties"].(map[string]interface{}); ok {
+		if id := mdastString(properties, "id"); "" != id {
+			return id
+		}
+	}
+	return mdastString(data, "id")
+}
+
+// appendContent 将 mdast 节点 node 的子节点转换后插入到 n 的结束标记符前。
+func (p *mdastParser) appendContent(n *ast.Node, node map[string]interface{}) {
+	closeMarker := n.LastChild
+	closeMarker.Unlink()
+	p.appendChildren(n, node)
+	n.AppendChild(closeMarker)
+}
+
+// appendText 将文本 text 添加到 parent 中，换行转换为软换行。在容器块中时使用段落包裹。
+func (p *mdastParser) appendText(parent *ast.Node, node map[string]interface{}, text string) {
+	if parent.IsContainerBlock() {
+		paragraph := &ast.Node{Type: ast.NodeParagraph, Position: mdastPosition(node)}
+		parent.AppendChild(paragraph)
+		parent = paragraph
+	}
+
+	typ := ast.NodeText
+	if ast.NodeLink == parent.Type || parent.ParentIs(ast.NodeLink) {
+		typ = ast.NodeLinkText
+	}
+	inTable := ast.NodeTableCell == parent.Type || parent.ParentIs(ast.NodeTableCell)
+	for i, line := range strings.Split(text, "\n") {
+		if 0 < i {
+			parent.AppendChild(&ast.Node{Type: ast.NodeSoftBreak, Tokens: []byte("\n")})
+		}
+		if !inTable {
+			if "" != line {
+				parent.AppendChild(&ast.Node{Type: typ, Tokens: []byte(line)})
+			}
+			continue
+		}
+
+		// 表格单元格中的 | 需要转义
+		for j, part := range strings.Split(line, "|") {
+			if 0 < j {
+				backslash := &ast.Node{Type: ast.NodeBackslash}
+				backslash.AppendChild(&ast.Node{Type: ast.NodeBackslashContent, Tokens: []byte("|")})
+				parent.AppendChild(backslash)
+			}
+			if "" != part {
+				parent.AppendChild(&ast.Node{Type: typ, Tokens: []byte(part)})
+			}
+		}
+	}
+}
+
+// mdastDelimited 创建类型为 typ 的节点，并使用 marker 作为其开始和结束标记符。
+func mdastDelimited(typ, openMarkerType, closeMarkerType ast.NodeType, marker string) (ret *ast.Node) {
+	ret = &ast.Node{Type: typ}
+	ret.AppendChild(&ast.Node{Type: openMarkerType, Tokens: []byte(marker)})
+	ret.AppendChild(&ast.Node{Type: closeMarkerType, Tokens: []byte(marker)})
+	return
+}
+
+// mdastLinkDest 为链接或者图片 link 添加地址 url 和标题 title。
+func mdastLinkDest(link *ast.Node, url, title string) {
+	link.AppendChild(&ast.Node{Type: ast.NodeOpenParen, Tokens: []byte("(")})
+	link.AppendChild(&ast.Node{Type: ast.NodeLinkDest, Tokens: []byte(url)})
+	if "" != title {
+		link.AppendChild(&ast.Node{Type: ast.NodeLinkSpace, Tokens: []byte(" ")})
+		link.AppendChild(&ast.Node{Type: ast.NodeLinkTitle, Tokens: []byte(title)})
+	}
+	link.AppendChild(&ast.Node{Type: ast.NodeCloseParen, Tokens: []byte(")")})
+}
+
+// mdastImage 创建地址为 url、标题为 title、替代文本为 alt 的图片节点。
+func mdastImage(url, title, alt string) (ret *ast.Node) {
+	ret = &ast.Node{Type: ast.NodeImage}
+	ret.AppendChild(&ast.Node{Type: ast.NodeBang, Tokens: []byte("!")})
+	ret.AppendChild(&ast.Node{Type: ast.NodeOpenBracket, Tokens: []byte("[")})
+	if "" != alt {
+		ret.AppendChild(&ast.Node{Type: ast.NodeLinkText, Tokens: []byte(alt)})
+	}
+	ret.AppendChild(&ast.Node{Type: ast.NodeCloseBracket, Tokens: []byte("]")})
+	mdastLinkDest(ret, url, title)
+	return
+}
+
+// mdastCodeBlock 创建代码为 code、信息为 info 的围栏代码块节点。
+func mdastCodeBlock(code, info string) (ret *ast.Node) {
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	if "" != code {
+		code += "\n"
+	}
+
+	ret = &ast.Node{Type: ast.NodeCodeBlock, IsFencedCodeBlock: true, CodeBlockFenceChar: '`', CodeBlockFenceLen: len(fence),
+		CodeBlockOpenFence: []byte(fence), CodeBlockInfo: []byte(info), CodeBlockCloseFence: []byte(fence)}
+	ret.AppendChild(&ast.Node{Type: ast.NodeCodeBlockFenceOpenMarker, Tokens: []byte(fence), CodeBlockFenceLen: len(fence)})
+	ret.AppendChild(&ast.Node{Type: ast.NodeCodeBlockFenceInfoMarker, CodeBlockInfo: []byte(info)})
+	ret.AppendChild(&ast.Node{Type: ast.NodeCodeBlockCode, Tokens: []byte(code)})
+	ret.AppendChild(&ast.Node{Type: ast.NodeCodeBlockFenceCloseMarker, Tokens: []byte(fence), CodeBlockFenceLen: len(fence)})
+	return
+}
+
+// list 将 mdast 列表节点 node 转换为列表节点，列表项中有 checked 时转换为任务列表。
+func (p *mdastParser) list(node map[string]interface{}) (ret *ast.Node) {
+	ordered, _ := mdastBool(node, "ordered")
+	spread, _ := mdastBool(node, "spread")
+	start, ok := mdastInt(node, "start")
+	if !ok {
+		start = 1
+	}
+	items := mdastChildren(node)
+	task := false
+	for _, item := range items {
+		if _, ok := mdastBool(item, "checked"); ok {
+			task = true
+			break
+		}
+	}
+
+	listData := &ast.ListData{Tight: !spread, Num: -1, BulletChar: '*', Marker: []byte("*")}
+	if ordered {
+		listData.Typ, listData.Start, listData.Num, listData.BulletChar, listData.Delimiter = 1, start, start, 0, '.'
+		listData.Marker = []byte(strconv.Itoa(start) + ".")
+	}
+	if task {
+		listData.Typ = 3
+	}
+	listData.Padding = len(listData.Marker) + 1
+	ret = &ast.Node{Type: ast.NodeList, ListData: listData}
+
+	num := start
+	for _, item := range items {
+		if "listItem" != mdastString(item, "type") {
+			p.appendNode(ret, item)
+			continue
+		}
+
+		itemData := *listData
+		if ordered {
+			itemData.Num = num
+			num++
+			itemData.Marker = []byte(strconv.Itoa(itemData.Num) + ".")
+			itemData.Padding = len(itemData.Marker) + 1
+		}
+		li := &ast.Node{Type: ast.NodeListItem, ListData: &itemData, Tokens: itemData.Marker, Position: mdastPosition(item)}
+		p.appendChildren(li, item)
+		if checked, ok := mdastBool(item, "checked"); ok {
+			itemData.Checked = checked
+			paragraph := li.FirstChild
+			if nil == paragraph || ast.NodeParagraph != paragraph.Type {
+				paragraph = &ast.Node{Type: ast.NodeParagraph}
+				li.PrependChild(paragraph)
+			}
+			marker := &ast.Node{Type: ast.NodeTaskListItemMarker, TaskListItemChecked: checked, Tokens: []byte("[ ]")}
+			if checked {
+				marker.Tokens = []byte("[x]")
+			}
+			paragraph.PrependChild(marker)
+		}
+		ret.AppendChild(li)
+		p.ial(li, item)
+	}
+	return
+}
+
+// mdastAligns 为 mdast 对齐方式对应的表格对齐方式。
+var mdastAligns = map[string]int{"left": 1, "center": 2, "right": 3}
+
+// table 将 mdast 表格节点 node 转换为表格节点，第一行作为表头。
+func (p *mdastParser) table(node map[string]interface{}) (ret *ast.Node) {
+	var aligns []int
+	values, _ := node["align"].([]interface{})
+	for _, value := range values {
+		align, _ := value.(string)
+		aligns = append(aligns, mdastAligns[align])
+	}
+
+	ret = &ast.Node{Type: ast.NodeTable, TableAligns: aligns}
+	for i, row := range mdastChildren(node) {
+		tableRow := &ast.Node{Type: ast.NodeTableRow, TableAligns: aligns, Position: mdastPosition(row)}
+		for j, cell := range mdastChildren(row) {
+			tableCell := &ast.Node{Type: ast.NodeTableCell, Position: mdastPosition(cell)}
+			if j < len(aligns) {
+				tableCell.TableCellAlign = aligns[j]
+			}
+			p.appendChildren(tableCell, cell)
+			tableRow.AppendChild(tableCell)
+		}
+		if 0 == i {
+			head := &ast.Node{Type: ast.NodeTableHead}
+			head.AppendChild(tableRow)
+			ret.AppendChild(head)
+			continue
+		}
+		ret.AppendChild(tableRow)
+	}
+	return
+}
+
+// custom 将 "lute:" 命名空间下的自定义节点 node 还原为 Lute 节点，不是 Lute 节点时返回 nil。
+func (p *mdastParser) custom(node map[string]interface{}) (ret *ast.Node) {
+	typ := mdastNodeType(mdastString(node, "type"))
+	if 0 > typ {
+		return nil
+	}
+
+	ret = &ast.Node{}
+	if data, ok := node["data"].(map[string]interface{}); ok {
+		// data 上是节点的属性
+		buf, err := json.Marshal(data)
+		if nil != err {
+			panic(err)
+		}
+		if err = json.Unmarshal(buf, ret); nil != err {
+			panic(err)
+		}
+	}
+	ret.Type, ret.TypeStr, ret.Children = typ, "", nil
+	if value, ok := node["value"].(string); ok {
+		ret.Tokens = []byte(value)
+	}
+	p.appendChildren(ret, node)
+
+	// data.markers 上是节点的标记符，开始标记符插入到子节点前，结束标记符添加到子节点后
+	data, _ := node["data"].(map[string]interface{})
+	markers, _ := data["markers"].([]interface{})
+	var previous *ast.Node
+	for _, m := range markers {
+		marker, _ := m.(map[string]interface{})
+		markerType := mdastNodeType(mdastString(marker, "type"))
+		if 0 > markerType {
+			continue
+		}
+		n := &ast.Node{Type: markerType, Tokens: []byte(mdastString(marker, "value"))}
+		switch {
+		case n.IsCloseMarker():
+			ret.AppendChild(n)
+		case nil == previous:
+			ret.PrependChild(n)
+		default:
+			previous.InsertAfter(n)
+		}
+		if !n.IsCloseMarker() {
+			previous = n
+		}
+	}
+	return
+}
+
+// finalize 设置节点的 IAL 以及脚注引用的序号。
+func (p *mdastParser) finalize() {
+	var refs []*ast.Node
+	ast.Walk(p.tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.WalkContinue
+		}
+
+		switch n.Type {
+		case ast.NodeKramdownBlockIAL, ast.NodeKramdownSpanIAL:
+			ial := Tokens2IAL(n.Tokens)
+			target := n.Previous
+			if ast.NodeDocument == n.Parent.Type && "doc" == IAL2Map(ial)["type"] {
+				target = n.Parent
+			}
+			if nil == target {
+				break
+			}
+			target.KramdownIAL = ial
+			if id := IAL2Map(ial)["id"]; "" != id {
+				target.ID = id
+				if ast.NodeDocument == target.Type {
+					p.tree.ID = id
+				}
+			}
+		case ast.NodeFootnotesRef:
+			refs = append(refs, n)
+		}
+		return ast.WalkContinue
+	})
+
+	for _, ref := range refs {
+		idx, def := p.tree.FindFootnotesDef(ref.Tokens)
+		if nil == def {
+			continue
+		}
+		ref.FootnotesRefId = strconv.Itoa(idx)
+		if refsLen := len(def.FootnotesRefs); 0 < refsLen {
+			ref.FootnotesRefId += ":" + strconv.Itoa(refsLen+1)
+		}
+		def.FootnotesRefs = append(def.FootnotesRefs, ref)
+	}
+}
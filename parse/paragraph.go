@@ -97,6 +97,7 @@ func paragraphFinalize(p *ast.Node, context *Context) (insertTable bool) {
 							}
 						}
 						taskListItemMarker := &ast.Node{Type: ast.NodeTaskListItemMarker, Tokens: tokens[:3], TaskListItemChecked: listItem.ListData.Checked}
+						context.prefixSourcePos(p, taskListItemMarker)
 						if context.ParseOption.ProtyleWYSIWYG {
 							p.InsertBefore(taskListItemMarker)
 						} else {
@@ -144,6 +145,7 @@ func paragraphFinalize(p *ast.Node, context *Context) (insertTable bool) {
 									blocks = append(blocks, b)
 								}
 								for _, b := range blocks {
+									clearSourcePos(b) // 子树中的位置是相对于子树输入的
 									p.InsertAfter(b)
 								}
 
@@ -160,6 +162,7 @@ func paragraphFinalize(p *ast.Node, context *Context) (insertTable bool) {
 		if paragraph, table := context.parseTable(p); nil != table {
 			if nil != paragraph {
 				p.Tokens = paragraph.Tokens
+				context.splitSourcePos(p, table, p.Tokens)
 				p.InsertAfter(table)
 				// 设置末梢及其状态
 				table.Close = true
@@ -183,6 +186,7 @@ func paragraphFinalize(p *ast.Node, context *Context) (insertTable bool) {
 		if toc := context.parseToC(p); nil != toc {
 			// 将该段落节点转换成目录节点
 			p.Type = ast.NodeToC
+			p.Tokens = toc.Tokens
 			return
 		}
 	}
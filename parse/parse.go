@@ -11,6 +11,7 @@
 package parse
 
 import (
+	"context"
 	"sync"
 
 	"github.com/88250/lute/ast"
@@ -22,15 +23,22 @@ import (
 func Parse(name string, markdown []byte, options *Options) (tree *Tree) {
 	tree = &Tree{Name: name, Context: &Context{ParseOption: options}}
 	tree.Context.Tree = tree
-	tree.lexer = lex.NewLexer(markdown)
-	tree.Root = &ast.Node{Type: ast.NodeDocument}
-	tree.parseBlocks()
-	tree.parseInlines()
-	tree.finalParseBlockIAL()
-	tree.lexer = nil
+	tree.parse(markdown)
 	return
 }
 
+// parse 对 markdown 进行块级和行级解析，生成语法树。
+func (t *Tree) parse(markdown []byte) {
+	t.lexer = lex.NewLexer(markdown)
+	t.lexer.SourcePos = t.Context.ParseOption.SourcePos
+	t.Root = &ast.Node{Type: ast.NodeDocument}
+	t.parseBlocks()
+	t.parseInlines()
+	t.finalizeSourcePos()
+	t.finalParseBlockIAL()
+	t.lexer = nil
+}
+
 func (t *Tree) finalParseBlockIAL() {
 	if !t.Context.ParseOption.KramdownBlockIAL {
 		return
@@ -51,7 +59,7 @@ func (t *Tree) finalParseBlockIAL() {
 		if "" == n.ID {
 			id := n.IALAttr("id")
 			if "" == id {
-				id = ast.NewNodeID()
+				id = t.Context.ParseOption.NewNodeID(n)
 			}
 			n.ID = id
 
@@ -61,12 +69,12 @@ func (t *Tree) finalParseBlockIAL() {
 				n.ID = n.Next.ID
 				n.KramdownIAL = n.Next.KramdownIAL
 				if "" == n.IALAttr("updated") {
-					n.SetIALAttr("updated", n.ID[:14])
+					n.SetIALAttr("updated", ast.NodeIDUpdated(n.ID))
 				}
-				n.Next.ID = ast.NewNodeID()
+				n.Next.ID = t.Context.ParseOption.NewNodeID(n.Next)
 				n.Next.KramdownIAL = nil
 				n.Next.SetIALAttr("id", n.Next.ID)
-				n.Next.SetIALAttr("updated", n.Next.ID[:14])
+				n.Next.SetIALAttr("updated", ast.NodeIDUpdated(n.Next.ID))
 				if nil != n.Next.Next && ast.NodeKramdownBlockIAL == n.Next.Next.Type {
 					n.Next.Next.Tokens = IAL2Tokens(n.Next.KramdownIAL)
 				}
@@ -79,24 +87,24 @@ func (t *Tree) finalParseBlockIAL() {
 		if nil == ial || ast.NodeKramdownBlockIAL != ial.Type {
 			if t.Context.ParseOption.ProtyleWYSIWYG {
 				n.SetIALAttr("id", n.ID)
-				n.SetIALAttr("updated", n.ID[:14])
+				n.SetIALAttr("updated", ast.NodeIDUpdated(n.ID))
 			}
 			return ast.WalkContinue
 		}
 
 		n.KramdownIAL = Tokens2IAL(ial.Tokens)
 		if "" == n.IALAttr("updated") && t.Context.ParseOption.ProtyleWYSIWYG {
-			n.SetIALAttr("updated", n.ID[:14])
+			n.SetIALAttr("updated", ast.NodeIDUpdated(n.ID))
 			ial.Tokens = IAL2Tokens(n.KramdownIAL)
 		}
 		return ast.WalkContinue
 	})
 
 	for _, n := range appends {
-		id := ast.NewNodeID()
+		p := &ast.Node{Type: ast.NodeParagraph}
+		id := t.Context.ParseOption.NewNodeID(p)
 		ialTokens := []byte("{: id=\"" + id + "\"}")
-		p := &ast.Node{Type: ast.NodeParagraph, ID: id}
-		p.KramdownIAL = [][]string{{"id", id}, {"updated", id[:14]}}
+		p.KramdownIAL = [][]string{{"id", id}, {"updated", ast.NodeIDUpdated(id)}}
 		p.ID = id
 		p.InsertAfter(&ast.Node{Type: ast.NodeKramdownBlockIAL, Tokens: ialTokens})
 		if nil != n.Next && ast.NodeKramdownBlockIAL == n.Next.Type &&
@@ -113,8 +121,8 @@ func (t *Tree) finalParseBlockIAL() {
 	if nil != t.Context.rootIAL {
 		docIAL = t.Context.rootIAL
 	} else {
-		id = ast.NewNodeID()
-		docIAL = &ast.Node{Type: ast.NodeKramdownBlockIAL, Tokens: []byte("{: id=\"" + id + "\" updated=\"" + id[:14] + "\" type=\"doc\"}")}
+		id = t.Context.ParseOption.NewNodeID(t.Root)
+		docIAL = &ast.Node{Type: ast.NodeKramdownBlockIAL, Tokens: []byte("{: id=\"" + id + "\" updated=\"" + ast.NodeIDUpdated(id) + "\" type=\"doc\"}")}
 		t.Root.ID = id
 		t.ID = id
 	}
@@ -126,8 +134,10 @@ func Block(name string, markdown []byte, options *Options) (tree *Tree) {
 	tree = &Tree{Name: name, Context: &Context{ParseOption: options}}
 	tree.Context.Tree = tree
 	tree.lexer = lex.NewLexer(markdown)
+	tree.lexer.SourcePos = options.SourcePos
 	tree.Root = &ast.Node{Type: ast.NodeDocument}
 	tree.parseBlocks()
+	tree.finalizeSourcePos()
 	tree.finalParseBlockIAL()
 	tree.lexer = nil
 	return
@@ -138,8 +148,19 @@ func Inline(name string, markdown []byte, options *Options) (tree *Tree) {
 	tree = &Tree{Name: name, Context: &Context{ParseOption: options}}
 	tree.Context.Tree = tree
 	tree.Root = &ast.Node{Type: ast.NodeDocument}
-	tree.Root.AppendChild(&ast.Node{Type: ast.NodeParagraph, Tokens: markdown})
+	paragraph := &ast.Node{Type: ast.NodeParagraph, Tokens: markdown}
+	tree.Root.AppendChild(paragraph)
+	if options.SourcePos {
+		// 行级解析不经过词法分析，这里单独扫描一遍以便计算行号和列号
+		tree.lexer = lex.NewLexer(append([]byte{}, markdown...))
+		tree.lexer.SourcePos = true
+		for nil != tree.lexer.NextLine() {
+		}
+		_, remains := lex.TrimRight(tree.lexer.Input())
+		paragraph.Position = &ast.Position{StartOffset: 0, EndOffset: len(remains)}
+	}
 	tree.parseInlines()
+	tree.finalizeSourcePos()
 	tree.lexer = nil
 	return
 }
@@ -158,6 +179,15 @@ type Context struct {
 	lastMatchedContainer                                     *ast.Node // 最后一个匹配的块节点
 
 	rootIAL *ast.Node // 根节点 kramdown IAL
+
+	unresolvedRef bool // 行级解析时是否存在找不到定义的链接引用或者脚注引用，用于流式解析时等待后面出现的定义
+
+	sourceStart  int // 接下来创建的块级节点在词法分析输入中的起始偏移
+	sourceCursor int // 表格单元格行级解析时对齐到的偏移
+
+	cancellation context.Context // 用于取消解析，仅通过 ParseContext 解析时设置
+	line         int             // 块级解析时当前行的行号，用于错误定位
+	inlineBlock  *ast.Node       // 行级解析时当前解析的块节点，用于错误定位
 }
 
 // InlineContext 描述了行级元素解析上下文。
@@ -167,6 +197,7 @@ type InlineContext struct {
 	pos        int        // 当前解析到的 token 位置
 	delimiters *delimiter // 分隔符栈，用于强调解析
 	brackets   *delimiter // 括号栈，用于图片和链接解析
+	offsets    []int      // Tokens 中每个字节在词法分析输入中的偏移，仅在解析选项 SourcePos 开启时计算
 }
 
 // advanceOffset 用于移动 count 个字符位置，columns 指定了遇到 tab 时是否需要空格进行补偿偏移。
@@ -287,6 +318,10 @@ func (context *Context) finalize(block *ast.Node) {
 		context.calloutFinalize(block)
 	case ast.NodeBlockquote:
 		context.blockquoteFinalize(block)
+	default:
+		if ext := context.ParseOption.blockExtension(block.Type); nil != ext && nil != ext.Finalize {
+			ext.Finalize(block, context)
+		}
 	}
 
 	context.Tip = parent
@@ -295,6 +330,9 @@ func (context *Context) finalize(block *ast.Node) {
 // addChildMarker 将构造一个 NodeType 节点并作为子节点添加到末梢节点 context.Tip 上。
 func (context *Context) addChildMarker(nodeType ast.NodeType, tokens []byte) (ret *ast.Node) {
 	ret = &ast.Node{Type: nodeType, Tokens: tokens, Close: true}
+	if ret.Position = context.newSourcePos(); nil != ret.Position {
+		ret.Position.EndOffset += len(tokens)
+	}
 	context.Tip.AppendChild(ret)
 	return
 }
@@ -306,7 +344,7 @@ func (context *Context) addChild(nodeType ast.NodeType) (ret *ast.Node) {
 		context.finalize(context.Tip) // 注意调用 finalize 会向父节点方向进行迭代
 	}
 
-	ret = &ast.Node{Type: nodeType}
+	ret = &ast.Node{Type: nodeType, Position: context.newSourcePos()}
 	context.Tip.AppendChild(ret)
 	context.Tip = ret
 	return
@@ -427,12 +465,51 @@ type Options struct {
 	Spin bool
 	// HTML2MarkdownAttrs 设置将 HTML 转换为 Markdown 时保留的属性列表
 	HTML2MarkdownAttrs []string
+	// HTML2MarkdownUnknown 设置将 HTML 转换为 Markdown 时无法识别的元素的处理方式，取值为 HTML2MdUnknown* 常量。
+	HTML2MarkdownUnknown int
+	// HTML2MarkdownFlavor 设置将 HTML 转换为 Markdown 时输出的 Markdown 风格，取值为 HTML2MdFlavor* 常量。
+	HTML2MarkdownFlavor int
+	// HTML2MarkdownReadability 设置将 HTML 转换为 Markdown 时是否先提取网页正文，并将标题、作者和发布时间转换为 YAML Front Matter。
+	HTML2MarkdownReadability bool
 	// Callout 设置是否开启提示块支持。
 	Callout bool
 	// KeepEscaped 设置是否保留转义内容（不进行反转义）。
 	KeepEscaped bool
+	// SourcePos 设置是否记录节点在原始输入中的位置（行号、列号和字节偏移）。
+	SourcePos bool
+	// BlockExtensions 存储通过 RegisterBlockExtension 注册的块级语法扩展。
+	BlockExtensions []*BlockExtension
+	// InlineExtensions 存储通过 RegisterInlineExtension 注册的行级语法扩展，按优先级从高到低排列。
+	InlineExtensions []*InlineExtension
+	// IDGenerator 设置节点 ID 生成器，为 nil 时使用 ast.NewNodeID。
+	IDGenerator ast.IDGenerator
+}
+
+// NewNodeID 使用节点 ID 生成器为节点 node 生成 ID，node 可能为 nil。
+func (options *Options) NewNodeID(node *ast.Node) string {
+	if nil == options || nil == options.IDGenerator {
+		return ast.NewNodeID()
+	}
+	return options.IDGenerator.NewNodeID(node)
 }
 
+// 将 HTML 转换为 Markdown 时无法识别的元素（自定义标签以及 script、button 等不承载正文内容的标签）的处理方式。
+const (
+	HTML2MdUnknownUnwrap = iota // 丢弃元素本身，继续转换其子元素
+	HTML2MdUnknownDrop          // 丢弃元素及其子元素
+	HTML2MdUnknownHTML          // 保留为 HTML，块级位置为 HTML 块，行级位置为行级 HTML
+)
+
+// 将 HTML 转换为 Markdown 时输出的 Markdown 风格。
+const (
+	HTML2MdFlavorLute       = iota // Lute 风格，可以使用 kramdown IAL、标记、上下标、键盘、标签、块引用等扩展语法
+	HTML2MdFlavorGFM               // GFM 风格，Lute 扩展语法降级为 HTML 或者文本
+	HTML2MdFlavorCommonMark        // CommonMark 严格风格，GFM 扩展语法（表格、删除线、数学公式）也进行降级
+)
+
+// EmojiLock 曾用于保护全局 Emoji 字典。
+//
+// Deprecated: Emoji 字典不再在解析过程中被修改，引擎的 PutEmojis 会复制字典后再合并，所以不再需要加锁。
 var EmojiLock = sync.Mutex{}
 
 func NewOptions() *Options {
@@ -0,0 +1,378 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package parse
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/lex"
+	"github.com/88250/lute/util"
+)
+
+// Edit 描述了对原始文本的一次编辑：将 [Start, End) 范围内的字节替换为 Text。
+type Edit struct {
+	Start int    // 起始字节偏移
+	End   int    // 结束字节偏移
+	Text  []byte // 替换文本
+}
+
+// Apply 将编辑应用到 source 上，返回编辑后的新文本，source 不会被修改。
+func (edit *Edit) Apply(source []byte) (ret []byte) {
+	ret = make([]byte, 0, len(source)-(edit.End-edit.Start)+len(edit.Text))
+	ret = append(ret, source[:edit.Start]...)
+	ret = append(ret, edit.Text...)
+	ret = append(ret, source[edit.End:]...)
+	return
+}
+
+// Reparse 将编辑 edit 应用到 source 上并增量更新语法树，返回编辑后的新文本。source 必须是解析生成 t 时使用的原始文本。
+//
+// 增量解析只会重新解析受编辑影响的顶层块，从受影响的块开始向后解析，直到某个未受影响的顶层块在新文本中仍然作为顶层块开始为止，
+// 该块及其后的节点（包括节点 ID）都会被复用，只更新它们的位置信息。重新解析的块和被替换的块类型和数量一致时会沿用原来的 ID。
+//
+// 开启 KramdownBlockIAL 时顶层块和紧随其后的块级 IAL 作为一个整体重新解析，文档块 IAL 始终保持在最后。
+// 受影响的块和前一个块之间没有空行时（比如表格、Setext 标题和段落的延续行），前一个块也会被重新解析。
+//
+// 增量解析依赖节点位置信息，以下情况会退化为全量解析：
+//   - 解析 t 时没有开启 SourcePos
+//   - 文档中存在脚注定义，或者重新解析的范围涉及链接引用定义
+//   - 编辑涉及文档块 IAL
+//   - 编辑涉及以 --- 开头的文档的第一个块，可能影响 YAML Front Matter
+func (t *Tree) Reparse(source []byte, edit *Edit) (newSource []byte, err error) {
+	if 0 > edit.Start || edit.Start > edit.End || edit.End > len(source) {
+		return nil, errors.New("invalid edit range")
+	}
+
+	newSource = edit.Apply(source)
+	// 统计换行数时带上编辑范围前后各一个字节，以便正确处理编辑拆分或者合并 \r\n 的情况
+	start, end := edit.Start, edit.End
+	if 0 < start {
+		start--
+	}
+	if end < len(source) {
+		end++
+	}
+	lineDelta := countLines(newSource[start:end+len(newSource)-len(source)]) - countLines(source[start:end])
+	if !t.reparse(source, newSource, edit, lineDelta) {
+		tree := Parse(t.Name, newSource, t.Context.ParseOption)
+		t.Root, t.Context = tree.Root, tree.Context
+		t.Context.Tree = t
+	}
+	return
+}
+
+// reparse 增量更新语法树，无法增量更新时返回 false。
+func (t *Tree) reparse(oldSource, source []byte, edit *Edit, lineDelta int) bool {
+	options := t.Context.ParseOption
+	if !options.SourcePos || nil == t.Root.Position {
+		return false
+	}
+
+	// 顶层的链接引用定义块挂在其所在段落之后，和源码顺序不一致，所以不参与范围计算，仅在最后更新位置
+	var blocks, defBlocks []*ast.Node
+	for n := t.Root.FirstChild; nil != n && !isDocIAL(n); n = n.Next {
+		if nil == n.Position {
+			return false
+		}
+		if ast.NodeLinkRefDefBlock == n.Type {
+			defBlocks = append(defBlocks, n)
+			continue
+		}
+		blocks = append(blocks, n)
+	}
+	if 1 > len(blocks) {
+		return false
+	}
+
+	var defs []*ast.Node
+	footnotes := false
+	ast.Walk(t.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.WalkContinue
+		}
+		switch n.Type {
+		case ast.NodeLinkRefDef:
+			defs = append(defs, n)
+			return ast.WalkSkipChildren
+		case ast.NodeFootnotesDef:
+			footnotes = true
+			return ast.WalkStop
+		}
+		return ast.WalkContinue
+	})
+	if footnotes {
+		return false
+	}
+
+	first := 0
+	for first < len(blocks)-1 && blocks[first].Position.EndOffset < edit.Start {
+		first++
+	}
+	first = groupStart(blocks, first)
+	if 0 < first {
+		// 编辑发生在块的第一行或者之前时可能导致其和前一个块合并（比如删除了中间的空行），所以需要从前一个块开始解析
+		lineEnd := len(oldSource)
+		if i := bytes.IndexAny(oldSource[blocks[first].Position.StartOffset:], "\r\n"); 0 <= i {
+			lineEnd = blocks[first].Position.StartOffset + i
+		}
+		if edit.Start <= lineEnd {
+			first = groupStart(blocks, first-1)
+		}
+	}
+	for 0 < first && !afterBlankLine(oldSource, blocks[first]) {
+		// 块的第一行可能是前一个块的延续（比如表格的表头、Setext 标题的内容），编辑后需要和前一个块一起解析
+		first = groupStart(blocks, first-1)
+	}
+	regionStart, lineBase := 0, 0
+	if 0 < first {
+		pos := blocks[first].Position
+		regionStart, lineBase = pos.StartOffset-pos.StartColumn+1, pos.StartLine-1
+	} else if options.YamlFrontMatter && bytes.HasPrefix(bytes.TrimLeft(source, " \t\r\n"), YamlFrontMatterMarker) {
+		// 未闭合的 YAML Front Matter 会一直延续到文档末尾
+		return false
+	}
+
+	// sync 为用于判断是否可以结束解析的块，其在新文本中仍然作为顶层块开始的话说明后续节点不受编辑影响
+	sync := first + 1
+	for sync < len(blocks) && blocks[sync].Position.StartOffset-blocks[sync].Position.StartColumn+1 <= edit.End {
+		sync++
+	}
+	sync = groupEnd(blocks, sync)
+
+	delta := len(edit.Text) - (edit.End - edit.Start)
+	for step := 1; ; step *= 2 {
+		end, syncStart, oldEnd := len(source), -1, len(oldSource)
+		if sync < len(blocks) {
+			// 只需要解析到 sync 块的第一行就可以判断其是否仍然作为顶层块开始
+			syncStart = blocks[sync].Position.StartOffset + delta
+			if i := bytes.IndexAny(source[syncStart:], "\r\n"); 0 <= i {
+				end = syncStart + i + 1
+			}
+			oldEnd = blocks[sync].Position.StartOffset
+		}
+		for _, def := range defs {
+			if pos := def.Position; nil == pos || (pos.StartOffset <= oldEnd && regionStart <= pos.EndOffset) {
+				// 链接引用定义变化后需要重新解析所有引用
+				return false
+			}
+		}
+
+		fragment := t.parseFragment(source[regionStart:end], 0 < regionStart, defs)
+		if nil != fragment.Context.rootIAL {
+			return false
+		}
+		var newBlocks []*ast.Node
+		synced := 0 > syncStart
+		for n := fragment.Root.FirstChild; nil != n; n = n.Next {
+			if !synced && nil != n.Position && regionStart+n.Position.StartOffset == syncStart {
+				synced = true
+				break
+			}
+			newBlocks = append(newBlocks, n)
+		}
+
+		if synced {
+			// 片段中的链接引用定义块挂在其所在段落之后，可能位于 sync 块之后，所以检查整个片段
+			if hasDefs(blocks[first:sync]) || hasDefs([]*ast.Node{fragment.Root}) {
+				return false
+			}
+			t.replaceBlocks(blocks, first, sync, newBlocks)
+			shiftSourcePos(newBlocks, regionStart, lineBase)
+			shiftSourcePos(blocks[sync:], delta, lineDelta)
+			for _, defBlock := range defBlocks {
+				if oldEnd <= defBlock.Position.StartOffset {
+					shiftSourcePos([]*ast.Node{defBlock}, delta, lineDelta)
+				}
+			}
+			if root := t.Root.Position; sync < len(blocks) {
+				root.EndOffset += delta
+				root.EndLine += lineDelta
+			} else {
+				end := fragment.Root.Position
+				root.EndOffset, root.EndLine, root.EndColumn = regionStart+end.EndOffset, lineBase+end.EndLine, end.EndColumn
+			}
+			return true
+		}
+
+		if sync += step; sync > len(blocks) {
+			sync = len(blocks)
+		}
+		sync = groupEnd(blocks, sync)
+	}
+}
+
+// parseFragment 解析文本片段 markdown，defs 为文档中已有的链接引用定义，片段中的链接引用可以引用这些定义。
+// inner 为 true 时片段不在文档开头，不会解析 YAML Front Matter。
+func (t *Tree) parseFragment(markdown []byte, inner bool, defs []*ast.Node) (ret *Tree) {
+	options := t.Context.ParseOption
+	if inner && options.YamlFrontMatter {
+		o := *options
+		o.YamlFrontMatter = false
+		options = &o
+	}
+	ret = &Tree{Name: t.Name, Context: &Context{ParseOption: options}}
+	ret.Context.Tree = ret
+	ret.lexer = lex.NewLexer(markdown)
+	ret.lexer.SourcePos = true
+	ret.Root = &ast.Node{Type: ast.NodeDocument}
+	ret.parseBlocks()
+
+	var refs *ast.Node
+	if 0 < len(defs) {
+		// 使用代理节点挂载已有的链接引用定义，不修改原有节点的父子关系
+		refs = &ast.Node{Type: ast.NodeLinkRefDefBlock, Close: true}
+		for _, def := range defs {
+			refs.AppendChild(&ast.Node{Type: ast.NodeLinkRefDef, Tokens: def.Tokens, FirstChild: def.FirstChild, LastChild: def.FirstChild})
+		}
+		ret.Root.AppendChild(refs)
+	}
+	ret.parseInlines()
+	if nil != refs {
+		refs.Unlink()
+	}
+	ret.finalizeSourcePos()
+	ret.finalParseBlockIAL()
+	if last := ret.Root.LastChild; nil != last && isDocIAL(last) {
+		last.Unlink()
+	}
+	ret.lexer = nil
+	return
+}
+
+// replaceBlocks 使用 newBlocks 替换 blocks[first:sync]，类型和数量一致时沿用原来的 ID。
+// 块级 IAL 中指定的 ID 以新文本为准，所以新块或者原来的块带有块级 IAL 时不沿用。
+func (t *Tree) replaceBlocks(blocks []*ast.Node, first, sync int, newBlocks []*ast.Node) {
+	olds := blocks[first:sync]
+	if oldMains, newMains := withoutIAL(olds), withoutIAL(newBlocks); len(oldMains) == len(newMains) {
+		for i, old := range oldMains {
+			if n := newMains[i]; n.Type == old.Type && "" != old.ID && !hasBlockIAL(old) && !hasBlockIAL(n) {
+				n.ID = old.ID
+				if "" != old.IALAttr("id") {
+					n.SetIALAttr("id", old.ID)
+				}
+			}
+		}
+	}
+
+	for _, old := range olds {
+		old.Unlink()
+	}
+	for _, n := range newBlocks {
+		if sync < len(blocks) {
+			blocks[sync].InsertBefore(n)
+		} else if last := t.Root.LastChild; nil != last && isDocIAL(last) {
+			last.InsertBefore(n)
+		} else {
+			t.Root.AppendChild(n)
+		}
+	}
+}
+
+// afterBlankLine 判断块 block 的前一行是否是空行，不是的话 block 可能是前一个块的延续。
+func afterBlankLine(source []byte, block *ast.Node) bool {
+	lineStart := block.Position.StartOffset - block.Position.StartColumn + 1
+	prevEnd := bytes.LastIndexAny(source[:lineStart], "\r\n")
+	if 0 > prevEnd {
+		return true
+	}
+	if lex.ItemNewline == source[prevEnd] && 0 < prevEnd && lex.ItemCarriageReturn == source[prevEnd-1] {
+		prevEnd--
+	}
+	prevStart := bytes.LastIndexAny(source[:prevEnd], "\r\n") + 1
+	return lex.IsBlankLine(source[prevStart:prevEnd])
+}
+
+// groupStart 返回 blocks[i] 所在块的下标，块级 IAL 和其前面的块作为一个整体。
+func groupStart(blocks []*ast.Node, i int) int {
+	for 0 < i && ast.NodeKramdownBlockIAL == blocks[i].Type {
+		i--
+	}
+	return i
+}
+
+// groupEnd 跳过 blocks[i] 开始的块级 IAL，返回下一个块的下标。
+func groupEnd(blocks []*ast.Node, i int) int {
+	for i < len(blocks) && ast.NodeKramdownBlockIAL == blocks[i].Type {
+		i++
+	}
+	return i
+}
+
+// withoutIAL 返回 blocks 中除块级 IAL 以外的块。
+func withoutIAL(blocks []*ast.Node) (ret []*ast.Node) {
+	for _, n := range blocks {
+		if ast.NodeKramdownBlockIAL != n.Type {
+			ret = append(ret, n)
+		}
+	}
+	return
+}
+
+// hasBlockIAL 判断块 n 后是否紧随块级 IAL。
+func hasBlockIAL(n *ast.Node) bool {
+	return nil != n.Next && ast.NodeKramdownBlockIAL == n.Next.Type && !isDocIAL(n.Next)
+}
+
+// isDocIAL 判断 n 是否是挂在文档末尾的文档块 IAL。
+func isDocIAL(n *ast.Node) bool {
+	return ast.NodeKramdownBlockIAL == n.Type && nil == n.Next && nil != n.Parent && ast.NodeDocument == n.Parent.Type && util.IsDocIAL(n.Tokens)
+}
+
+// hasDefs 判断 blocks 中是否包含链接引用定义或者脚注定义。
+func hasDefs(blocks []*ast.Node) (ret bool) {
+	for _, block := range blocks {
+		ast.Walk(block, func(n *ast.Node, entering bool) ast.WalkStatus {
+			if entering && (ast.NodeLinkRefDef == n.Type || ast.NodeFootnotesDef == n.Type) {
+				ret = true
+				return ast.WalkStop
+			}
+			return ast.WalkContinue
+		})
+		if ret {
+			return
+		}
+	}
+	return
+}
+
+// shiftSourcePos 将 blocks 及其所有子节点的位置偏移 offset 个字节和 lines 行。
+func shiftSourcePos(blocks []*ast.Node, offset, lines int) {
+	if 0 == offset && 0 == lines {
+		return
+	}
+
+	shifted := map[*ast.Position]bool{} // 节点之间可能共用位置
+	for _, block := range blocks {
+		ast.Walk(block, func(n *ast.Node, entering bool) ast.WalkStatus {
+			if !entering || nil == n.Position || shifted[n.Position] {
+				return ast.WalkContinue
+			}
+			shifted[n.Position] = true
+			n.Position.StartOffset += offset
+			n.Position.EndOffset += offset
+			n.Position.StartLine += lines
+			n.Position.EndLine += lines
+			return ast.WalkContinue
+		})
+	}
+}
+
+// countLines 返回 text 中换行的数量，\r\n、\r 和 \n 均视为换行。
+func countLines(text []byte) (ret int) {
+	for i, b := range text {
+		if lex.ItemNewline == b || (lex.ItemCarriageReturn == b && (i == len(text)-1 || lex.ItemNewline != text[i+1])) {
+			ret++
+		}
+	}
+	return
+}
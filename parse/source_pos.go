@@ -0,0 +1,528 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package parse
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/lex"
+)
+
+// 源码位置记录分为三个阶段：
+//
+//  1. 块级解析时节点位置使用词法分析输入中的偏移记录，起始位置在创建节点时确定，结束位置随着节点接受新行不断延伸
+//  2. 行级解析时将块节点的 Tokens 按顺序对齐到词法分析输入上，据此计算行级节点的偏移
+//  3. 解析结束后补全无法直接确定位置的节点，然后统一将偏移映射回原始输入并计算行号和列号
+
+// markSourceStart 记录当前行下一个非空字符的位置，作为接下来创建的块级节点的起始位置。
+func (context *Context) markSourceStart() {
+	if !context.ParseOption.SourcePos {
+		return
+	}
+	context.sourceStart = context.Tree.lexer.LineOffset() + context.nextNonspace
+}
+
+// newSourcePos 使用 markSourceStart 记录的起始位置构造一个新的位置。
+func (context *Context) newSourcePos() *ast.Position {
+	if !context.ParseOption.SourcePos {
+		return nil
+	}
+	return &ast.Position{StartOffset: context.sourceStart, EndOffset: context.sourceStart}
+}
+
+// touchLine 将接受了当前行的块节点及其祖先节点的结束位置延伸到当前行行尾。
+// 空行仅计入代码块、数学公式块等可以包含空行的叶子块，这样列表项等容器块的位置不会包含结尾的空行。
+// 因为不能包含该行起始的新块而被最终化的容器块（比如段落前的列表）没有接受该行，所以不延伸。
+func (context *Context) touchLine(container *ast.Node) {
+	if !context.ParseOption.SourcePos {
+		return
+	}
+
+	if lex.IsBlankLine(context.currentLine) && (ast.NodeParagraph == container.Type || !container.AcceptLines()) {
+		return
+	}
+
+	end := context.Tree.lexer.LineOffset() + context.currentLineLen
+	if 0 < context.currentLineLen && lex.ItemNewline == context.currentLine[context.currentLineLen-1] {
+		end--
+	}
+	for _, n := range []*ast.Node{container, context.Tip} {
+		if n.Close && !n.AcceptLines() {
+			continue
+		}
+		for p := n; nil != p; p = p.Parent {
+			if nil != p.Position && p.Position.EndOffset < end {
+				p.Position.EndOffset = end
+			}
+		}
+	}
+}
+
+// lineIndex 返回词法分析输入中的偏移 offset 所在行的下标。
+func (t *Tree) lineIndex(offset int) int {
+	lines := t.lexer.SourceLines()
+	ret := sort.Search(len(lines), func(i int) bool { return lines[i].Offset > offset }) - 1
+	if 0 > ret {
+		ret = 0
+	}
+	return ret
+}
+
+// lineStart 返回从 offset 所在行开始往后第 n 行的行首偏移，超出范围时返回输入结尾。
+func (t *Tree) lineStart(offset, n int) int {
+	lines := t.lexer.SourceLines()
+	idx := t.lineIndex(offset) + n
+	if idx >= len(lines) {
+		return len(t.lexer.Input())
+	}
+	return lines[idx].Offset
+}
+
+// linkRefDefSourcePos 在段落 p 开头的 consumed 被解析为链接引用定义 def 后，设置 def 的位置并将 p 的起始位置调整为剩余内容所在行的行首。
+func (context *Context) linkRefDefSourcePos(p, def *ast.Node, consumed []byte) {
+	if !context.ParseOption.SourcePos || nil == p.Position {
+		return
+	}
+
+	def.Position = &ast.Position{StartOffset: p.Position.StartOffset, EndOffset: p.Position.EndOffset}
+	if link := def.FirstChild; nil != link {
+		link.Position = def.Position
+		context.Tree.linkRefDefMarkerSourcePos(link, consumed, p.Position.StartOffset)
+	}
+	if lines := bytes.Count(consumed, []byte{lex.ItemNewline}); 0 < lines {
+		next := context.Tree.lineStart(p.Position.StartOffset, lines)
+		if next-1 < def.Position.EndOffset {
+			def.Position.EndOffset = next - 1
+		}
+		p.Position.StartOffset = next
+		if p.Position.EndOffset < p.Position.StartOffset {
+			p.Position.EndOffset = p.Position.StartOffset
+		}
+	}
+}
+
+// linkRefDefMarkerSourcePos 根据链接引用定义的源码 consumed 设置链接 link 子节点的位置，start 为 consumed 在词法分析输入中的起始偏移。
+//
+// 链接引用定义中没有圆括号，所以圆括号使用链接地址开头和定义结尾处的空位置。
+func (t *Tree) linkRefDefMarkerSourcePos(link *ast.Node, consumed []byte, start int) {
+	whitespaces, _ := lex.TrimLeft(consumed)
+	openBracket := len(whitespaces)
+	closeBracket := openBracket + 1
+	for ; closeBracket < len(consumed) && lex.ItemCloseBracket != consumed[closeBracket]; closeBracket++ {
+		if lex.ItemBackslash == consumed[closeBracket] {
+			closeBracket++
+		}
+	}
+	destStart := closeBracket + 2 // 跳过 ]:
+	for ; destStart < len(consumed) && lex.IsWhitespace(consumed[destStart]); destStart++ {
+	}
+	if destStart >= len(consumed) {
+		return
+	}
+	destEnd := destStart
+	if lex.ItemLess == consumed[destStart] {
+		for ; destEnd < len(consumed) && lex.ItemGreater != consumed[destEnd]; destEnd++ {
+			if lex.ItemBackslash == consumed[destEnd] {
+				destEnd++
+			}
+		}
+		destEnd++
+	} else {
+		for ; destEnd < len(consumed) && !lex.IsWhitespace(consumed[destEnd]); destEnd++ {
+		}
+	}
+	end := len(bytes.TrimRight(consumed, " \t\n"))
+	if destEnd > end {
+		destEnd = end
+	}
+	titleStart := destEnd
+	for ; titleStart < end && lex.IsWhitespace(consumed[titleStart]); titleStart++ {
+	}
+
+	offsets := t.alignSource(consumed, start, len(t.lexer.Input()))
+	sourcePos := func(from, to int) *ast.Position {
+		ret := &ast.Position{StartOffset: offsets[from], EndOffset: offsets[from]}
+		if to > from {
+			ret.EndOffset = offsets[to-1] + 1
+		}
+		return ret
+	}
+	link.Position.StartOffset = offsets[openBracket] // 后续定义的起始位置是行首，需要跳过引述标记符等前缀
+	for c := link.FirstChild; nil != c; c = c.Next {
+		switch c.Type {
+		case ast.NodeOpenBracket:
+			c.Position = sourcePos(openBracket, openBracket+1)
+		case ast.NodeLinkText:
+			c.Position = sourcePos(openBracket+1, closeBracket)
+		case ast.NodeCloseBracket:
+			c.Position = sourcePos(closeBracket, closeBracket+1)
+		case ast.NodeOpenParen:
+			c.Position = sourcePos(destStart, destStart)
+		case ast.NodeLinkDest:
+			c.Position = sourcePos(destStart, destEnd)
+		case ast.NodeLinkTitle:
+			if titleStart+1 < end {
+				c.Position = sourcePos(titleStart+1, end-1) // 不包含引号
+			}
+		case ast.NodeCloseParen:
+			c.Position = sourcePos(end, end)
+		}
+	}
+}
+
+// codeBlockInfoSourcePos 设置围栏代码块 block 的信息字符串节点 info 的位置，即开始标记符所在行去掉首尾空白后的剩余部分。
+func (t *Tree) codeBlockInfoSourcePos(block, info *ast.Node) {
+	if !t.Context.ParseOption.SourcePos || nil == t.lexer || nil == block.Position {
+		return
+	}
+
+	input := t.lexer.Input()
+	start := block.Position.StartOffset + len(block.CodeBlockOpenFence)
+	if start > len(input) {
+		return
+	}
+	end := start
+	for ; end < len(input) && lex.ItemNewline != input[end]; end++ {
+	}
+	for ; start < end && lex.IsWhitespace(input[start]); start++ {
+	}
+	for ; start < end && lex.IsWhitespace(input[end-1]); end-- {
+	}
+	info.Position = &ast.Position{StartOffset: start, EndOffset: end}
+}
+
+// splitSourcePos 在段落 p 后半部分被解析为表 table 时拆分两者的位置，p 保留前 len(lines(paragraphTokens)) 行。
+func (context *Context) splitSourcePos(p, table *ast.Node, paragraphTokens []byte) {
+	if !context.ParseOption.SourcePos || nil == p.Position {
+		return
+	}
+
+	lines := bytes.Count(lex.TrimWhitespace(paragraphTokens), []byte{lex.ItemNewline}) + 1
+	tableStart := context.Tree.lineStart(p.Position.StartOffset, lines)
+	table.Position = &ast.Position{StartOffset: tableStart, EndOffset: p.Position.EndOffset}
+	if p.Position.EndOffset = tableStart - 1; p.Position.EndOffset < p.Position.StartOffset {
+		p.Position.EndOffset = p.Position.StartOffset
+	}
+}
+
+// prefixSourcePos 为块节点开头的标记符节点 marker 设置位置，marker 的 Tokens 需要位于块节点的起始位置。
+func (context *Context) prefixSourcePos(block, marker *ast.Node) {
+	if !context.ParseOption.SourcePos || nil == block.Position {
+		return
+	}
+
+	start := block.Position.StartOffset
+	marker.Position = &ast.Position{StartOffset: start, EndOffset: start + len(marker.Tokens)}
+}
+
+// alignSource 将 tokens 按顺序对齐到词法分析输入的 [start, end) 区间上，返回 tokens 中每个字节对应的输入偏移，
+// 返回值长度为 len(tokens)+1，最后一个元素为对齐结束位置。
+//
+// 块级解析时 Tokens 只会剔除标记符、缩进和首尾空白，所以使用贪心的子序列匹配即可对齐。
+func (t *Tree) alignSource(tokens []byte, start, end int) (ret []int) {
+	input := t.lexer.Input()
+	if end > len(input) {
+		end = len(input)
+	}
+
+	ret = make([]int, len(tokens)+1)
+	j := start
+	for i, b := range tokens {
+		if j < end && input[j] == b {
+			ret[i] = j
+			j++
+			continue
+		}
+
+		if j < end && lex.ItemSpace == b && lex.ItemTab == input[j] {
+			// 制表符被展开为多个空格
+			ret[i] = j
+			continue
+		}
+
+		if j < end {
+			if k := bytes.IndexByte(input[j:end], b); -1 < k {
+				j += k
+				ret[i] = j
+				j++
+				continue
+			}
+		}
+
+		// 输入中不存在的字节（比如编辑器插入符）
+		ret[i] = j
+	}
+	ret[len(tokens)] = j
+	return
+}
+
+// inlineSourceOffsets 计算块节点 block 行级解析所用 Tokens 的偏移。
+func (t *Tree) inlineSourceOffsets(block *ast.Node, tokens []byte) []int {
+	if !t.Context.ParseOption.SourcePos || nil == t.lexer {
+		return nil
+	}
+
+	var start, end int
+	if ast.NodeTableCell == block.Type {
+		// 单元格按顺序依次对齐到表上
+		table := block.Parent
+		for ; nil != table && ast.NodeTable != table.Type; table = table.Parent {
+		}
+		if nil == table || nil == table.Position {
+			return nil
+		}
+		start, end = t.Context.sourceCursor, table.Position.EndOffset
+		if row := block.Parent; nil == block.Previous && nil != row {
+			// 每行的第一个单元格从该行行首开始对齐，表头和表体之间隔着分隔行
+			lines := 0
+			for r := table.FirstChild; nil != r && r != row; r = r.Next {
+				if ast.NodeTableHead == r.Type {
+					for hr := r.FirstChild; nil != hr; hr = hr.Next {
+						if hr == row {
+							break
+						}
+						lines++
+					}
+					if row.Parent == r {
+						break
+					}
+					lines++ // 分隔行
+					continue
+				}
+				lines++
+			}
+			start = t.lineStart(table.Position.StartOffset, lines)
+		}
+		if start < table.Position.StartOffset {
+			start = table.Position.StartOffset
+		}
+	} else {
+		if nil == block.Position {
+			return nil
+		}
+		start, end = block.Position.StartOffset, block.Position.EndOffset
+		for c := block.FirstChild; nil != c; c = c.Next {
+			// 跳过已经定位的前缀标记符，比如 ATX 标题的 # 和任务列表项的 [x]
+			if nil != c.Position && start < c.Position.EndOffset {
+				start = c.Position.EndOffset
+			}
+		}
+	}
+
+	ret := t.alignSource(tokens, start, end)
+	if ast.NodeTableCell == block.Type {
+		t.Context.sourceCursor = ret[len(ret)-1]
+		block.Position = &ast.Position{StartOffset: start, EndOffset: start}
+		if 0 < len(tokens) {
+			block.Position.StartOffset, block.Position.EndOffset = ret[0], ret[len(tokens)-1]+1
+		}
+	}
+	return ret
+}
+
+// sourcePos 返回行级 Tokens 区间 [start, end) 对应的位置。
+func (ctx *InlineContext) sourcePos(start, end int) *ast.Position {
+	if start > ctx.tokensLen {
+		start = ctx.tokensLen
+	}
+	if end > ctx.tokensLen {
+		end = ctx.tokensLen
+	}
+
+	ret := &ast.Position{StartOffset: ctx.offsets[start], EndOffset: ctx.offsets[start]}
+	if end > start {
+		ret.EndOffset = ctx.offsets[end-1] + 1
+	}
+	return ret
+}
+
+// setInlineSourcePos 为本轮行级解析新添加到 block 上的节点设置位置，start 为本轮解析的起始 Tokens 下标，last 为本轮解析前 block 的最后一个子节点。
+func (t *Tree) setInlineSourcePos(block *ast.Node, ctx *InlineContext, start int, last *ast.Node) {
+	var nodes []*ast.Node
+	for n := block.LastChild; nil != n && n != last && nil == n.Position; n = n.Previous {
+		nodes = append(nodes, n)
+	}
+	if 1 > len(nodes) {
+		return
+	}
+
+	if 1 == len(nodes) {
+		nodes[0].Position = ctx.sourcePos(start, ctx.pos)
+		return
+	}
+
+	// 一次生成多个节点时按照 Tokens 长度依次划分，无法划分的话留给 fillSourcePos 处理
+	total := 0
+	for _, n := range nodes {
+		total += len(n.Tokens)
+	}
+	if total != ctx.pos-start {
+		return
+	}
+	for i := len(nodes) - 1; 0 <= i; i-- {
+		end := start + len(nodes[i].Tokens)
+		nodes[i].Position = ctx.sourcePos(start, end)
+		start = end
+	}
+}
+
+// spanSourcePos 返回从 from 节点起始位置到行级 Tokens 下标 end 的位置，用于链接、图片和脚注引用这类由多轮解析结果组合而成的节点。
+func (ctx *InlineContext) spanSourcePos(from *ast.Node, end int) *ast.Position {
+	if nil == ctx.offsets || nil == from.Position {
+		return nil
+	}
+	ret := ctx.sourcePos(end, end)
+	ret.StartOffset = from.Position.StartOffset
+	if 0 < end {
+		ret.EndOffset = ctx.offsets[end-1] + 1
+	}
+	return ret
+}
+
+// delimSourcePos 在强调、加粗等分隔符匹配后拆分分隔符文本节点的位置。
+// 开始标记符取自 openerInl 的结尾，结束标记符取自 closerInl 的开头。
+func delimSourcePos(openerInl, closerInl, openMarker, closeMarker, node *ast.Node, useDelims int) {
+	if nil == openerInl.Position || nil == closerInl.Position {
+		return
+	}
+
+	openMarker.Position = &ast.Position{StartOffset: openerInl.Position.EndOffset - useDelims, EndOffset: openerInl.Position.EndOffset}
+	openerInl.Position.EndOffset -= useDelims
+	closeMarker.Position = &ast.Position{StartOffset: closerInl.Position.StartOffset, EndOffset: closerInl.Position.StartOffset + useDelims}
+	closerInl.Position.StartOffset += useDelims
+	node.Position = &ast.Position{StartOffset: openMarker.Position.StartOffset, EndOffset: closeMarker.Position.EndOffset}
+}
+
+// mergeSourcePos 在合并相邻文本节点 next 到 n 时合并位置。
+func mergeSourcePos(n, next *ast.Node) {
+	if nil == n.Position {
+		return
+	}
+	if nil == next.Position {
+		n.Position = nil
+		return
+	}
+	n.Position.EndOffset = next.Position.EndOffset
+}
+
+// clearSourcePos 清除 n 及其子节点的位置。
+func clearSourcePos(n *ast.Node) {
+	ast.Walk(n, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			n.Position = nil
+		}
+		return ast.WalkContinue
+	})
+}
+
+// finalizeSourcePos 补全位置并将所有节点的偏移映射回原始输入，同时计算行号和列号。
+func (t *Tree) finalizeSourcePos() {
+	if !t.Context.ParseOption.SourcePos || nil == t.lexer {
+		return
+	}
+
+	t.Root.Position = &ast.Position{StartOffset: 0, EndOffset: len(t.lexer.Input())}
+	t.fillSourcePos()
+
+	converted := map[*ast.Position]bool{}
+	ast.Walk(t.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.WalkContinue
+		}
+
+		if nil == n.Position {
+			if nil != n.Parent && nil != n.Parent.Position {
+				// 无法确定位置的节点（比如链接中被编码的地址）使用父节点的位置
+				pos := *n.Parent.Position
+				n.Position = &pos
+			}
+			return ast.WalkContinue
+		}
+
+		pos := n.Position
+		if converted[pos] {
+			return ast.WalkContinue
+		}
+		converted[pos] = true
+		t.convertSourcePos(pos)
+		return ast.WalkContinue
+	})
+}
+
+// convertSourcePos 将 pos 中词法分析输入的偏移映射回原始输入，同时计算行号和列号。
+func (t *Tree) convertSourcePos(pos *ast.Position) {
+	if pos.EndOffset < pos.StartOffset {
+		pos.EndOffset = pos.StartOffset
+	}
+	pos.StartOffset = t.lexer.SourceOffset(pos.StartOffset)
+	pos.EndOffset = t.lexer.SourceOffset(pos.EndOffset)
+	if srcLen := t.lexer.SourceLength(); pos.EndOffset > srcLen {
+		// 词法分析时在输入结尾补全的换行符不计入
+		pos.EndOffset = srcLen
+		if pos.StartOffset > srcLen {
+			pos.StartOffset = srcLen
+		}
+	}
+	pos.StartLine, pos.StartColumn = t.lexer.SourceLineColumn(pos.StartOffset)
+	pos.EndLine, pos.EndColumn = t.lexer.SourceLineColumn(pos.EndOffset)
+}
+
+// fillSourcePos 补全解析过程中没有直接设置位置的节点。
+//
+// 叶子节点按文档顺序在父节点范围内查找其 Tokens，找到的话即为其位置；非叶子节点使用其子节点位置的并集。
+func (t *Tree) fillSourcePos() {
+	input := t.lexer.Input()
+	var cursor int
+	var ends []int
+	ast.Walk(t.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			if nil != n.Position {
+				cursor = n.Position.StartOffset
+				ends = append(ends, n.Position.EndOffset)
+				return ast.WalkContinue
+			}
+
+			end := ends[len(ends)-1]
+			if end > len(input) {
+				end = len(input)
+			}
+			if nil == n.FirstChild && 0 < len(n.Tokens) && cursor < end {
+				if i := bytes.Index(input[cursor:end], n.Tokens); -1 < i {
+					n.Position = &ast.Position{StartOffset: cursor + i, EndOffset: cursor + i + len(n.Tokens)}
+				}
+			}
+			ends = append(ends, end)
+			return ast.WalkContinue
+		}
+
+		ends = ends[:len(ends)-1]
+		if nil == n.Position {
+			var first, last *ast.Position
+			for c := n.FirstChild; nil != c; c = c.Next {
+				if nil != c.Position {
+					if nil == first {
+						first = c.Position
+					}
+					last = c.Position
+				}
+			}
+			if nil != first {
+				n.Position = &ast.Position{StartOffset: first.StartOffset, EndOffset: last.EndOffset}
+			}
+		}
+		if nil != n.Position && cursor < n.Position.EndOffset {
+			cursor = n.Position.EndOffset
+		}
+		return ast.WalkContinue
+	})
+}
@@ -0,0 +1,164 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package parse
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/lex"
+)
+
+// BlockHandler 定义了流式解析时处理顶层块级节点的函数签名，block 已经完成行级解析。
+type BlockHandler func(tree *Tree, block *ast.Node) error
+
+// ParseStream 从 reader 中逐行读取 markdown 进行解析，每当一个顶层块级节点最终化后立即对其进行行级解析并调用 handler 处理。
+//
+// handler 返回后该节点会从语法树上移除，所以内存占用只和最大的顶层块以及定义的数量有关，而和文档长度无关。为了能够解析引用，
+// 链接引用定义和脚注定义会一直保留在语法树上。引用了尚未出现的定义的块会连同其后的块一起暂缓处理，直到定义出现或者读取结束，
+// 所以文档中存在找不到定义的引用（比如普通文本 [foo]）时，其后的块都会保留到读取结束。
+//
+// 流式解析不支持 SourcePos、KramdownBlockIAL 和 KramdownSpanIAL 等依赖整个文档的解析选项，解析时会忽略这些选项。
+//
+// 解析结束后返回的语法树上仅包含保留的定义节点。
+func ParseStream(name string, reader io.Reader, options *Options, handler BlockHandler) (tree *Tree, err error) {
+	streamOptions := *options
+	streamOptions.SourcePos = false
+	streamOptions.KramdownBlockIAL = false
+	streamOptions.KramdownSpanIAL = false
+	tree = &Tree{Name: name, Context: &Context{ParseOption: &streamOptions}}
+	tree.Context.Tree = tree
+	tree.Root = &ast.Node{Type: ast.NodeDocument}
+	tree.Context.Tip = tree.Root
+
+	var kept *ast.Node // 最后一个保留在语法树上的顶层节点
+	br := bufio.NewReader(reader)
+	for {
+		chunk, readErr := br.ReadBytes(lex.ItemNewline)
+		if 0 < len(chunk) {
+			// 每次读取的内容一定以 \n 结尾（或者已经读取结束），只有 \r 换行的情况下可能包含多行
+			lexer := lex.NewLexer(chunk)
+			for line := lexer.NextLine(); nil != line; line = lexer.NextLine() {
+				tree.Context.line++
+				tree.incorporateLine(line)
+				if kept, err = tree.handleClosedBlocks(kept, false, handler); nil != err {
+					return
+				}
+			}
+		}
+		if io.EOF == readErr {
+			break
+		}
+		if nil != readErr {
+			err = readErr
+			return
+		}
+	}
+
+	for nil != tree.Context.Tip {
+		tree.Context.finalize(tree.Context.Tip)
+	}
+	tree.Context.line = 0
+	_, err = tree.handleClosedBlocks(kept, true, handler)
+	return
+}
+
+// handleClosedBlocks 处理 kept 之后所有已经最终化的顶层块级节点，返回最后一个保留在语法树上的顶层节点。
+// 遇到引用了尚未出现的定义的块时暂停处理，eof 为 true 时说明已经读取结束，不再等待定义。
+func (t *Tree) handleClosedBlocks(kept *ast.Node, eof bool, handler BlockHandler) (*ast.Node, error) {
+	for {
+		block := t.Root.FirstChild
+		if nil != kept {
+			block = kept.Next
+		}
+		// 链接引用定义块是在段落最终化时直接挂到树上的，所以没有闭合标识
+		if nil == block || (!block.Close && ast.NodeLinkRefDefBlock != block.Type) {
+			return kept, nil
+		}
+		if !eof && t.unresolvedRefs(block) {
+			return kept, nil
+		}
+
+		t.walkParseInline(block)
+		t.Context.inlineBlock = nil
+		if nil == block.Parent {
+			// 行级解析时移除了空段落
+			continue
+		}
+
+		if err := handler(t, block); nil != err {
+			return kept, err
+		}
+
+		if ast.NodeLinkRefDefBlock == block.Type || ast.NodeFootnotesDefBlock == block.Type {
+			block.Close = true
+			kept = block
+			continue
+		}
+
+		// 保留嵌套在其他块中的定义，然后移除该块
+		var defs []*ast.Node
+		ast.Walk(block, func(n *ast.Node, entering bool) ast.WalkStatus {
+			if !entering {
+				return ast.WalkContinue
+			}
+			if ast.NodeLinkRefDef == n.Type || ast.NodeFootnotesDef == n.Type {
+				defs = append(defs, n)
+				return ast.WalkSkipChildren
+			}
+			return ast.WalkContinue
+		})
+		for _, def := range defs {
+			def.Close = true // 避免后续的行被当作该节点的延续
+			block.InsertBefore(def)
+			kept = def
+		}
+		block.Unlink()
+	}
+}
+
+// unresolvedRefs 判断块 block 中是否存在找不到定义的链接引用或者脚注引用。
+//
+// 行级解析会修改节点，所以使用 block 中段落、标题和表格单元格的副本进行试解析，并撤销试解析时脚注定义上记录的引用。
+func (t *Tree) unresolvedRefs(block *ast.Node) (ret bool) {
+	ast.Walk(block, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.WalkContinue
+		}
+		if ast.NodeParagraph != n.Type && ast.NodeHeading != n.Type && ast.NodeTableCell != n.Type {
+			return ast.WalkContinue
+		}
+		if 0 > bytes.IndexByte(n.Tokens, lex.ItemOpenBracket) {
+			return ast.WalkSkipChildren
+		}
+
+		leaf := &ast.Node{Type: n.Type, Tokens: append([]byte{}, n.Tokens...), Parent: n.Parent}
+		t.Context.unresolvedRef = false
+		t.walkParseInline(leaf)
+		ast.Walk(leaf, func(ref *ast.Node, entering bool) ast.WalkStatus {
+			if entering && ast.NodeFootnotesRef == ref.Type {
+				if _, def := t.FindFootnotesDef(ref.Tokens); nil != def {
+					def.FootnotesRefs = def.FootnotesRefs[:len(def.FootnotesRefs)-1]
+				}
+			}
+			return ast.WalkContinue
+		})
+		if ret = t.Context.unresolvedRef; ret {
+			return ast.WalkStop
+		}
+		return ast.WalkSkipChildren
+	})
+	t.Context.unresolvedRef = false
+	t.Context.inlineBlock = nil
+	return
+}
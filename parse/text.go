@@ -21,7 +21,8 @@ import (
 
 func (t *Tree) parseText(ctx *InlineContext) *ast.Node {
 	start := ctx.pos
-	for ; ctx.pos < ctx.tokensLen; ctx.pos++ {
+	// 起始字节总是作为文本处理（比如未能匹配的行级语法扩展触发字节），以免解析停滞
+	for ctx.pos++; ctx.pos < ctx.tokensLen; ctx.pos++ {
 		if t.isMarker(ctx.tokens[ctx.pos]) {
 			// 遇到潜在的标记符时需要跳出该文本节点，回到行级解析主循环
 			break
@@ -39,7 +40,7 @@ func (t *Tree) isMarker(token byte) bool {
 	if t.Context.ParseOption.Sup && lex.ItemCaret == token {
 		return true
 	}
-	return false
+	return t.Context.ParseOption.isInlineTrigger(token)
 }
 
 var backslash = util.StrToBytes("\\")
@@ -114,6 +115,9 @@ func (t *Tree) parseNewline(block *ast.Node, ctx *InlineContext) (ret *ast.Node)
 		tokens := lastc.Tokens
 		if valueLen := len(tokens); lex.ItemSpace == tokens[valueLen-1] {
 			_, lastc.Tokens = lex.TrimRight(tokens)
+			if nil != lastc.Position {
+				lastc.Position.EndOffset -= valueLen - len(lastc.Tokens)
+			}
 			if 1 < valueLen {
 				isHardBreak = lex.ItemSpace == tokens[len(tokens)-2]
 			}
@@ -145,12 +149,14 @@ func (t *Tree) mergeText(node *ast.Node) {
 			// 逐个合并后续兄弟节点
 			for nil != next && ast.NodeText == next.Type {
 				child.AppendTokens(next.Tokens)
+				mergeSourcePos(child, next)
 				next.Unlink()
 				next = child.Next
 			}
 		} else if ast.NodeLinkText == child.Type {
 			for nil != next && ast.NodeLinkText == next.Type {
 				child.AppendTokens(next.Tokens)
+				mergeSourcePos(child, next)
 				next.Unlink()
 				next = child.Next
 			}
@@ -28,8 +28,48 @@ func (context *Context) parseToC(paragraph *ast.Node) *ast.Node {
 	if context.ParseOption.VditorWYSIWYG || context.ParseOption.VditorIR || context.ParseOption.VditorSV {
 		content = bytes.ReplaceAll(content, editor.CaretTokens, nil)
 	}
-	if !bytes.EqualFold(content, []byte("[toc]")) {
+	if !isToC(content) {
 		return nil
 	}
-	return &ast.Node{Type: ast.NodeToC}
+	return &ast.Node{Type: ast.NodeToC, Tokens: content}
+}
+
+// isToC 判断 content 是否是目录标记 [toc] 或者带参数的目录标记，比如 [toc levels=2-3 numbered]。
+//
+// 参数仅支持 levels=N、levels=N-M（N、M 为 1 到 6 的标题级别）以及 numbered、numbered=true、numbered=false，
+// 包含其他内容时（比如 [toc is great]）不是目录标记。
+func isToC(content []byte) bool {
+	if bytes.EqualFold(content, []byte("[toc]")) {
+		return true
+	}
+	if 6 > len(content) || !bytes.EqualFold(content[:4], []byte("[toc")) || !lex.IsWhitespace(content[4]) || ']' != content[len(content)-1] {
+		return false
+	}
+
+	for _, field := range bytes.Fields(content[5 : len(content)-1]) {
+		if !isToCParam(field) {
+			return false
+		}
+	}
+	return true
+}
+
+// isToCParam 判断 field 是否是支持的目录参数。
+func isToCParam(field []byte) bool {
+	key, value, found := bytes.Cut(field, []byte("="))
+	switch string(bytes.ToLower(key)) {
+	case "levels":
+		if !found {
+			return false
+		}
+		from, to, ranged := bytes.Cut(value, []byte("-"))
+		return isHeadingLevel(from) && (!ranged || isHeadingLevel(to))
+	case "numbered":
+		return !found || "true" == string(value) || "false" == string(value)
+	}
+	return false
+}
+
+func isHeadingLevel(level []byte) bool {
+	return 1 == len(level) && '1' <= level[0] && '6' >= level[0]
 }
@@ -0,0 +1,169 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package parse
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/88250/lute/ast"
+)
+
+// TreeSpec 是 Tree.MarshalJSON 序列化格式的版本号，序列化格式发生不兼容变化时需要递增。
+const TreeSpec = "1"
+
+// treeJSON 描述了语法树的序列化格式。
+type treeJSON struct {
+	Spec    string    // 序列化格式版本号
+	Name    string    `json:",omitempty"`
+	ID      string    `json:",omitempty"`
+	Box     string    `json:",omitempty"`
+	Path    string    `json:",omitempty"`
+	HPath   string    `json:",omitempty"`
+	Marks   []string  `json:",omitempty"`
+	Created int64     `json:",omitempty"`
+	Updated int64     `json:",omitempty"`
+	Hash    string    `json:",omitempty"`
+	Root    *nodeJSON // 根节点
+}
+
+// nodeJSON 描述了节点的序列化格式。
+//
+// 节点字段默认按照 ast.Node 上的 json 标签序列化，这里覆盖那些被忽略或者无法直接序列化的字段。
+type nodeJSON struct {
+	*ast.Node
+
+	Box             string      `json:"Box,omitempty"`           // 容器
+	Path            string      `json:"Path,omitempty"`          // 路径
+	Type            string      `json:"Type"`                    // 类型字符串
+	Data            *string     `json:"Data,omitempty"`          // Tokens 字符串，区分 nil 和空 Tokens
+	Tokens          []byte      `json:"Tokens,omitempty"`        // Tokens 不是合法的 UTF-8 时使用，避免序列化为字符串时丢失数据
+	Close           bool        `json:"Close,omitempty"`         // 标识是否关闭
+	LastLineBlank   bool        `json:"LastLineBlank,omitempty"` // 标识最后一行是否是空行
+	LastLineChecked bool        `json:"LastLineChecked,omitempty"`
+	KramdownIAL     [][]string  `json:"KramdownIAL,omitempty"`   // Kramdown 内联属性列表
+	FootnotesRefs   []int       `json:"FootnotesRefs,omitempty"` // 脚注引用节点在语法树先序遍历中的序号
+	Children        []*nodeJSON `json:"Children,omitempty"`      // 所有子节点
+}
+
+// MarshalJSON 将语法树序列化为 JSON，序列化结果可以通过 UnmarshalTree 还原为完全相同的语法树。
+//
+// 和 JSONRenderer 不同，这里会保留所有节点（包括标记符节点和块级 IAL 节点）以及节点上的全部解析结果，适合用于缓存解析好的语法树。
+func (t *Tree) MarshalJSON() ([]byte, error) {
+	indexes := map[*ast.Node]int{}
+	ast.Walk(t.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			indexes[n] = len(indexes)
+		}
+		return ast.WalkContinue
+	})
+
+	ret := &treeJSON{Spec: TreeSpec, Name: t.Name, ID: t.ID, Box: t.Box, Path: t.Path, HPath: t.HPath, Marks: t.Marks, Created: t.Created, Updated: t.Updated, Hash: t.Hash}
+	if nil != t.Root {
+		root, err := marshalNode(t.Root, indexes)
+		if nil != err {
+			return nil, err
+		}
+		ret.Root = root
+	}
+	return json.Marshal(ret)
+}
+
+func marshalNode(node *ast.Node, indexes map[*ast.Node]int) (ret *nodeJSON, err error) {
+	ret = &nodeJSON{Node: node, Box: node.Box, Path: node.Path, Type: node.Type.String(), Close: node.Close, LastLineBlank: node.LastLineBlank, LastLineChecked: node.LastLineChecked, KramdownIAL: node.KramdownIAL}
+	if !utf8.Valid(node.Tokens) {
+		ret.Tokens = node.Tokens
+	} else if nil != node.Tokens {
+		data := string(node.Tokens)
+		ret.Data = &data
+	}
+	for _, ref := range node.FootnotesRefs {
+		index, ok := indexes[ref]
+		if !ok {
+			return nil, errors.New("footnotes ref of node [" + ret.Type + "] is not in the tree")
+		}
+		ret.FootnotesRefs = append(ret.FootnotesRefs, index)
+	}
+	for c := node.FirstChild; nil != c; c = c.Next {
+		child, err := marshalNode(c, indexes)
+		if nil != err {
+			return nil, err
+		}
+		ret.Children = append(ret.Children, child)
+	}
+	return
+}
+
+// UnmarshalTree 将 Tree.MarshalJSON 序列化的 JSON 还原为语法树，options 用于设置语法树的解析选项。
+func UnmarshalTree(data []byte, options *Options) (tree *Tree, err error) {
+	var t treeJSON
+	if err = json.Unmarshal(data, &t); nil != err {
+		return nil, &Error{Err: err}
+	}
+	if TreeSpec != t.Spec {
+		return nil, &Error{Name: t.Name, Err: errors.New("unsupported tree spec [" + t.Spec + "], expected [" + TreeSpec + "]")}
+	}
+	if nil == t.Root {
+		return nil, &Error{Name: t.Name, Err: errors.New("tree root is missing")}
+	}
+
+	tree = &Tree{Name: t.Name, ID: t.ID, Box: t.Box, Path: t.Path, HPath: t.HPath, Marks: t.Marks, Created: t.Created, Updated: t.Updated, Hash: t.Hash, Context: &Context{ParseOption: options}}
+	tree.Context.Tree = tree
+	var nodes []*ast.Node
+	var refs []*nodeJSON
+	if tree.Root, err = unmarshalNode(t.Root, &nodes, &refs); nil != err {
+		return nil, &Error{Name: t.Name, Err: err}
+	}
+	for _, n := range refs {
+		for _, index := range n.FootnotesRefs {
+			if 0 > index || len(nodes) <= index {
+				return nil, &Error{Name: t.Name, Err: errors.New("footnotes ref index [" + strconv.Itoa(index) + "] out of range")}
+			}
+			n.Node.FootnotesRefs = append(n.Node.FootnotesRefs, nodes[index])
+		}
+	}
+	return
+}
+
+func unmarshalNode(n *nodeJSON, nodes *[]*ast.Node, refs *[]*nodeJSON) (ret *ast.Node, err error) {
+	ret = n.Node
+	if nil == ret {
+		ret = &ast.Node{}
+		n.Node = ret
+	}
+	if ret.Type = ast.Str2NodeType(n.Type); 0 > ret.Type {
+		return nil, errors.New("unknown node type [" + n.Type + "]")
+	}
+	if nil != n.Tokens {
+		ret.Tokens = n.Tokens
+	} else if nil != n.Data {
+		ret.Tokens = []byte(*n.Data)
+	}
+	ret.Box, ret.Path = n.Box, n.Path
+	ret.Close, ret.LastLineBlank, ret.LastLineChecked = n.Close, n.LastLineBlank, n.LastLineChecked
+	ret.KramdownIAL = n.KramdownIAL
+	ret.Children, ret.FootnotesRefs = nil, nil
+	*nodes = append(*nodes, ret)
+	if 0 < len(n.FootnotesRefs) {
+		*refs = append(*refs, n)
+	}
+
+	for _, c := range n.Children {
+		child, err := unmarshalNode(c, nodes, refs)
+		if nil != err {
+			return nil, err
+		}
+		ret.AppendChild(child)
+	}
+	return
+}
@@ -26,14 +26,15 @@ import (
 	"github.com/88250/lute/util"
 )
 
+// SpinBlockDOME 和 SpinBlockDOM 一样自旋块 DOM，但是处理过程中发生 panic 时返回 *parse.Error 而不会导致程序崩溃。
+func (lute *Lute) SpinBlockDOME(ivHTML string) (ovHTML string, err error) {
+	return recoverE(func() string { return lute.SpinBlockDOM(ivHTML) })
+}
+
 func (lute *Lute) SpinBlockDOM(ivHTML string) (ovHTML string) {
 	//fmt.Println(ivHTML)
 
-	keepEscaped := lute.ParseOptions.KeepEscaped
-	lute.ParseOptions.KeepEscaped = true
-	defer func() {
-		lute.ParseOptions.KeepEscaped = keepEscaped
-	}()
+	lute = lute.withParseOptions(func(options *parse.Options) { options.KeepEscaped = true })
 
 	markdown := lute.blockDOM2Md(ivHTML)
 	markdown = strings.ReplaceAll(markdown, editor.Zwsp, "")
@@ -144,6 +145,11 @@ func (lute *Lute) Md2BlockDOM(markdown string, reserveEmptyParagraph bool) (vHTM
 	return
 }
 
+// Md2BlockDOME 和 Md2BlockDOM 一样将 markdown 转换为块 DOM，但是转换过程中发生 panic 时返回 *parse.Error 而不会导致程序崩溃。
+func (lute *Lute) Md2BlockDOME(markdown string, reserveEmptyParagraph bool) (vHTML string, err error) {
+	return recoverE(func() string { return lute.Md2BlockDOM(markdown, reserveEmptyParagraph) })
+}
+
 func (lute *Lute) Md2BlockDOMTree(markdown string, reserveEmptyParagraph bool) (vHTML string, tree *parse.Tree) {
 	tree = parse.Parse("", []byte(markdown), lute.ParseOptions)
 
@@ -194,11 +200,7 @@ func (lute *Lute) BlockDOM2Md(htmlStr string) (kramdown string) {
 }
 
 func (lute *Lute) BlockDOM2StdMd(htmlStr string) (markdown string) {
-	keepEscaped := lute.ParseOptions.KeepEscaped
-	lute.ParseOptions.KeepEscaped = false
-	defer func() {
-		lute.ParseOptions.KeepEscaped = keepEscaped
-	}()
+	lute = lute.withParseOptions(func(options *parse.Options) { options.KeepEscaped = false })
 
 	htmlStr = strings.ReplaceAll(htmlStr, editor.Zwsp, "")
 
@@ -787,10 +789,10 @@ func (lute *Lute) Blockquote2Callout(ivHTML string) (ovHTML string) {
 
 	firstChild := bq.FirstChild.Next
 	if nil == firstChild {
-		id := ast.NewNodeID()
+		newP := &ast.Node{Type: ast.NodeParagraph}
+		id := lute.ParseOptions.NewNodeID(newP)
 		ialTokens := []byte("{: id=\"" + id + "\"}")
-		newP := &ast.Node{Type: ast.NodeParagraph, ID: id}
-		newP.KramdownIAL = [][]string{{"id", id}, {"updated", id[:14]}}
+		newP.KramdownIAL = [][]string{{"id", id}, {"updated", ast.NodeIDUpdated(id)}}
 		newP.ID = id
 		bq.AppendChild(newP)
 		bq.AppendChild(&ast.Node{Type: ast.NodeKramdownBlockIAL, Tokens: ialTokens})
@@ -820,10 +822,10 @@ func (lute *Lute) Blockquote2Callout(ivHTML string) (ovHTML string) {
 	bq.CalloutIcon = ast.GetCalloutIcon(bq.CalloutType)
 	if firstIsType {
 		if nil == firstChild.Next.Next {
-			id := ast.NewNodeID()
+			newP := &ast.Node{Type: ast.NodeParagraph}
+			id := lute.ParseOptions.NewNodeID(newP)
 			ialTokens := []byte("{: id=\"" + id + "\"}")
-			newP := &ast.Node{Type: ast.NodeParagraph, ID: id}
-			newP.KramdownIAL = [][]string{{"id", id}, {"updated", id[:14]}}
+			newP.KramdownIAL = [][]string{{"id", id}, {"updated", ast.NodeIDUpdated(id)}}
 			newP.ID = id
 			bq.AppendChild(newP)
 			bq.AppendChild(&ast.Node{Type: ast.NodeKramdownBlockIAL, Tokens: ialTokens})
@@ -1106,6 +1108,17 @@ func (lute *Lute) genASTByBlockDOM(n *html.Node, tree *parse.Tree) {
 		tree.Context.Tip.AppendChild(node)
 		tree.Context.Tip = node
 		defer tree.Context.ParentTip()
+	case ast.NodeGitConflictOurs, ast.NodeGitConflictBase, ast.NodeGitConflictTheirs:
+		node.Type = dataType
+		marker := util.StrToBytes(util.DomAttrValue(n, "data-marker"))
+		if ast.NodeGitConflictOurs == dataType {
+			tree.Context.Tip.AppendChild(&ast.Node{Type: ast.NodeGitConflictOpenMarker, Tokens: marker})
+		} else if ast.NodeGitConflictBase == dataType {
+			node.Tokens = marker
+		}
+		tree.Context.Tip.AppendChild(node)
+		tree.Context.Tip = node
+		defer tree.Context.ParentTip()
 	case ast.NodeSuperBlock:
 		node.Type = ast.NodeSuperBlock
 		tree.Context.Tip.AppendChild(node)
@@ -1182,7 +1195,7 @@ func (lute *Lute) genASTByBlockDOM(n *html.Node, tree *parse.Tree) {
 		node.Type = ast.NodeAttributeView
 		node.AttributeViewID = util.DomAttrValue(n, "data-av-id")
 		if "" == node.AttributeViewID {
-			node.AttributeViewID = ast.NewNodeID()
+			node.AttributeViewID = lute.ParseOptions.NewNodeID(node)
 		}
 		node.AttributeViewType = util.DomAttrValue(n, "data-av-type")
 		tree.Context.Tip.AppendChild(node)
@@ -1264,6 +1277,8 @@ func (lute *Lute) genASTByBlockDOM(n *html.Node, tree *parse.Tree) {
 	switch dataType {
 	case ast.NodeSuperBlock:
 		node.AppendChild(&ast.Node{Type: ast.NodeSuperBlockCloseMarker})
+	case ast.NodeGitConflictTheirs:
+		node.InsertAfter(&ast.Node{Type: ast.NodeGitConflictCloseMarker, Tokens: util.StrToBytes(util.DomAttrValue(n, "data-marker"))})
 	case ast.NodeCodeBlock:
 		node.AppendChild(&ast.Node{Type: ast.NodeCodeBlockFenceCloseMarker, Tokens: util.StrToBytes("```")})
 	}
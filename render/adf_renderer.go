@@ -0,0 +1,711 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/editor"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/util"
+)
+
+// ADFNode 描述了 Atlassian Document Format（ADF）中的节点。
+type ADFNode struct {
+	Type    string                 `json:"type"`
+	Attrs   map[string]interface{} `json:"attrs,omitempty"`
+	Content []*ADFNode             `json:"content,omitempty"`
+	Text    string                 `json:"text,omitempty"`
+	Marks   []*ADFMark             `json:"marks,omitempty"`
+}
+
+// ADFMark 描述了 ADF 中文本节点的格式。
+type ADFMark struct {
+	Type  string                 `json:"type"`
+	Attrs map[string]interface{} `json:"attrs,omitempty"`
+}
+
+// adfDocument 描述了 ADF 文档，文档的 content 不能省略。
+type adfDocument struct {
+	Version int        `json:"version"`
+	Type    string     `json:"type"`
+	Content []*ADFNode `json:"content"`
+}
+
+// ADFRenderer 描述了 Atlassian Document Format（ADF）JSON 渲染器，用于通过 Jira 和 Confluence 的 REST API 写入富文本内容。
+//
+// 节点和 ADF 节点的对应关系如下：
+//   - 强调、加粗、删除线、下划线、行内代码、上下标和链接使用文本节点的 marks，行级公式使用 code
+//   - 任务列表使用 taskList 和 taskItem，任务列表项中的后续段落使用 hardBreak 连接
+//   - 提示块使用 panel，NOTE、TIP、IMPORTANT、WARNING 和 CAUTION 分别对应 info、success、note、warning 和 error，自定义标题输出为加粗的第一个段落
+//   - ADF 中的引述不能嵌套，嵌套的引述合并到外层引述中
+//   - 仅包含一张图片的段落使用 mediaSingle 嵌入外部图片，段落中的其他图片输出为链接
+//   - 表格单元格的对齐方式使用段落的 alignment，脚注引用使用上标，脚注内容使用有序列表输出在最后
+//   - 公式块和 HTML 块使用 codeBlock，目录没有对应的节点
+type ADFRenderer struct {
+	*BaseRenderer
+
+	document *adfDocument
+	stack    []*ADFNode // 正在输出的容器节点
+	marks    []*ADFMark // 当前生效的文本格式
+	localID  int        // 任务列表和任务列表项的 localId 序号
+}
+
+// NewADFRenderer 创建一个 Atlassian Document Format JSON 渲染器。
+func NewADFRenderer(tree *parse.Tree, options *Options, parseOptions *parse.Options) *ADFRenderer {
+	ret := &ADFRenderer{BaseRenderer: NewBaseRenderer(tree, options, parseOptions)}
+	ret.DefaultRendererFunc = ret.renderChildren
+	ret.RendererFuncs[ast.NodeParagraph] = ret.renderParagraph
+	ret.RendererFuncs[ast.NodeHeading] = ret.renderHeading
+	ret.RendererFuncs[ast.NodeBlockquote] = ret.renderBlockquote
+	ret.RendererFuncs[ast.NodeCallout] = ret.renderCallout
+	ret.RendererFuncs[ast.NodeList] = ret.renderList
+	ret.RendererFuncs[ast.NodeListItem] = ret.renderListItem
+	ret.RendererFuncs[ast.NodeThematicBreak] = ret.renderThematicBreak
+	ret.RendererFuncs[ast.NodeCodeBlock] = ret.renderCodeBlock
+	ret.RendererFuncs[ast.NodeMathBlock] = ret.renderMathBlock
+	ret.RendererFuncs[ast.NodeHTMLBlock] = ret.renderHTMLBlock
+	ret.RendererFuncs[ast.NodeTable] = ret.renderTable
+	ret.RendererFuncs[ast.NodeTableRow] = ret.renderTableRow
+	ret.RendererFuncs[ast.NodeTableCell] = ret.renderTableCell
+	ret.RendererFuncs[ast.NodeFootnotesRef] = ret.renderFootnotesRef
+	ret.RendererFuncs[ast.NodeFootnotesDefBlock] = ret.renderFootnotesDefBlock
+	ret.RendererFuncs[ast.NodeFootnotesDef] = ret.renderFootnotesDef
+	ret.RendererFuncs[ast.NodeText] = ret.renderText
+	ret.RendererFuncs[ast.NodeLinkText] = ret.renderText
+	ret.RendererFuncs[ast.NodeBackslashContent] = ret.renderText
+	ret.RendererFuncs[ast.NodeHTMLEntity] = ret.renderText
+	ret.RendererFuncs[ast.NodeEmojiUnicode] = ret.renderText
+	ret.RendererFuncs[ast.NodeBlockRefText] = ret.renderText
+	ret.RendererFuncs[ast.NodeBlockRefDynamicText] = ret.renderText
+	ret.RendererFuncs[ast.NodeFileAnnotationRefText] = ret.renderText
+	ret.RendererFuncs[ast.NodeEmojiImg] = ret.renderEmojiImg
+	ret.RendererFuncs[ast.NodeSoftBreak] = ret.renderSoftBreak
+	ret.RendererFuncs[ast.NodeHardBreak] = ret.renderHardBreak
+	ret.RendererFuncs[ast.NodeInlineHTML] = ret.renderInlineHTML
+	ret.RendererFuncs[ast.NodeCodeSpan] = ret.renderCodeSpan
+	ret.RendererFuncs[ast.NodeInlineMath] = ret.renderInlineMath
+	ret.RendererFuncs[ast.NodeStrong] = ret.renderMark("strong", nil)
+	ret.RendererFuncs[ast.NodeEmphasis] = ret.renderMark("em", nil)
+	ret.RendererFuncs[ast.NodeStrikethrough] = ret.renderMark("strike", nil)
+	ret.RendererFuncs[ast.NodeUnderline] = ret.renderMark("underline", nil)
+	ret.RendererFuncs[ast.NodeKbd] = ret.renderMark("code", nil)
+	ret.RendererFuncs[ast.NodeSup] = ret.renderMark("subsup", map[string]interface{}{"type": "sup"})
+	ret.RendererFuncs[ast.NodeSub] = ret.renderMark("subsup", map[string]interface{}{"type": "sub"})
+	ret.RendererFuncs[ast.NodeTextMark] = ret.renderTextMark
+	ret.RendererFuncs[ast.NodeLink] = ret.renderLink
+	ret.RendererFuncs[ast.NodeImage] = ret.renderImage
+	ret.RendererFuncs[ast.NodeTaskListItemMarker] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeToC] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeLinkRefDefBlock] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeYamlFrontMatter] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeKramdownBlockIAL] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeKramdownSpanIAL] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeHeadingID] = ret.renderSkip
+	return ret
+}
+
+func (r *ADFRenderer) Render() (output []byte) {
+	r.document = &adfDocument{Version: 1, Type: "doc", Content: []*ADFNode{}}
+	r.stack = []*ADFNode{{Type: "doc"}}
+	r.marks, r.localID = nil, 0
+	ast.Walk(r.Tree.Root, r.renderNode)
+	if nil != r.stack[0].Content {
+		r.document.Content = r.stack[0].Content
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(r.document); nil != err {
+		panic("marshal adf document failed: " + err.Error()) // 通过 RenderE 渲染时会被恢复为错误
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// renderChildren 用于渲染没有对应 ADF 节点的节点，仅渲染其子节点。
+func (r *ADFRenderer) renderChildren(node *ast.Node, entering bool) ast.WalkStatus {
+	return ast.WalkContinue
+}
+
+// renderSkip 用于渲染在 ADF 中不可见的节点。
+func (r *ADFRenderer) renderSkip(node *ast.Node, entering bool) ast.WalkStatus {
+	return ast.WalkSkipChildren
+}
+
+// top 返回正在输出的容器节点。
+func (r *ADFRenderer) top() *ADFNode {
+	return r.stack[len(r.stack)-1]
+}
+
+// open 将容器节点 node 添加到正在输出的容器节点中，并开始输出 node 的内容。
+func (r *ADFRenderer) open(node *ADFNode) {
+	r.add(node)
+	r.stack = append(r.stack, node)
+}
+
+// close 结束输出最近的容器节点。
+func (r *ADFRenderer) close() {
+	r.stack = r.stack[:len(r.stack)-1]
+}
+
+// add 将节点 node 添加到正在输出的容器节点中。
+func (r *ADFRenderer) add(node *ADFNode) {
+	top := r.top()
+	top.Content = append(top.Content, node)
+}
+
+// nextLocalID 返回下一个任务列表或者任务列表项的 localId。
+func (r *ADFRenderer) nextLocalID() string {
+	r.localID++
+	return strconv.Itoa(r.localID)
+}
+
+// inline 判断正在输出的容器节点是否只能包含行级节点。
+func (r *ADFRenderer) inline() bool {
+	switch r.top().Type {
+	case "paragraph", "heading", "taskItem":
+		return true
+	}
+	return false
+}
+
+// writeText 使用当前生效的文本格式输出文本 text，相邻的相同格式的文本节点会被合并。
+func (r *ADFRenderer) writeText(text string) {
+	if "" == text {
+		return
+	}
+	if !r.inline() {
+		// 行级内容需要包裹在段落中
+		r.open(&ADFNode{Type: "paragraph"})
+		defer r.close()
+	}
+
+	marks := r.marks
+	for _, mark := range r.marks {
+		if "code" == mark.Type {
+			// code 只能和 link 一起使用
+			marks = nil
+			for _, m := range r.marks {
+				if "code" == m.Type || "link" == m.Type {
+					marks = append(marks, m)
+				}
+			}
+			break
+		}
+	}
+	marks = adfUniqueMarks(marks)
+
+	top := r.top()
+	if last := len(top.Content) - 1; 0 <= last && "text" == top.Content[last].Type && adfSameMarks(top.Content[last].Marks, marks) {
+		top.Content[last].Text += text
+		return
+	}
+	r.add(&ADFNode{Type: "text", Text: text, Marks: marks})
+}
+
+// adfUniqueMarks 去掉 marks 中重复类型的格式，保留外层的格式。
+func adfUniqueMarks(marks []*ADFMark) (ret []*ADFMark) {
+	types := map[string]bool{}
+	for _, mark := range marks {
+		if types[mark.Type] {
+			continue
+		}
+		types[mark.Type] = true
+		ret = append(ret, mark)
+	}
+	return
+}
+
+// adfSameMarks 判断两组文本格式是否相同。
+func adfSameMarks(marks1, marks2 []*ADFMark) bool {
+	if len(marks1) != len(marks2) {
+		return false
+	}
+	for i := range marks1 {
+		if marks1[i] != marks2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *ADFRenderer) pushMark(mark *ADFMark) {
+	r.marks = append(r.marks, mark)
+}
+
+func (r *ADFRenderer) popMark() {
+	r.marks = r.marks[:len(r.marks)-1]
+}
+
+func (r *ADFRenderer) renderParagraph(node *ast.Node, entering bool) ast.WalkStatus {
+	if "taskItem" == r.top().Type {
+		// 任务列表项直接包含行级节点，后续段落使用 hardBreak 连接
+		if entering && 0 < len(r.top().Content) {
+			r.add(&ADFNode{Type: "hardBreak"})
+		}
+		return ast.WalkContinue
+	}
+
+	if !entering {
+		r.close()
+		return ast.WalkContinue
+	}
+
+	if image := imageParagraph(node); nil != image {
+		r.open(&ADFNode{Type: "mediaSingle", Attrs: map[string]interface{}{"layout": "center"}})
+		r.add(r.media(image))
+		return ast.WalkSkipChildren
+	}
+	r.open(&ADFNode{Type: "paragraph"})
+	return ast.WalkContinue
+}
+
+// media 返回外部图片 image 对应的 media 节点。
+func (r *ADFRenderer) media(image *ast.Node) *ADFNode {
+	attrs := map[string]interface{}{"type": "external", "url": r.imageDest(image)}
+	if alt := strings.TrimSpace(imageAlt(image)); "" != alt {
+		attrs["alt"] = alt
+	}
+	return &ADFNode{Type: "media", Attrs: attrs}
+}
+
+func (r *ADFRenderer) imageDest(image *ast.Node) string {
+	if dest := image.ChildByType(ast.NodeLinkDest); nil != dest {
+		return strings.TrimSpace(util.BytesToStr(r.LinkPath(dest.Tokens)))
+	}
+	return ""
+}
+
+func (r *ADFRenderer) renderHeading(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		level := node.HeadingLevel
+		if 1 > level {
+			level = 1
+		} else if 6 < level {
+			level = 6
+		}
+		r.open(&ADFNode{Type: "heading", Attrs: map[string]interface{}{"level": level}})
+	} else {
+		r.close()
+	}
+	return ast.WalkContinue
+}
+
+func (r *ADFRenderer) renderBlockquote(node *ast.Node, entering bool) ast.WalkStatus {
+	if node.ParentIs(ast.NodeBlockquote) {
+		return ast.WalkContinue
+	}
+
+	if entering {
+		r.open(&ADFNode{Type: "blockquote"})
+	} else {
+		r.close()
+	}
+	return ast.WalkContinue
+}
+
+// adfPanelTypes 为提示块类型对应的面板类型。
+var adfPanelTypes = map[string]string{
+	ast.CalloutTypeNote:      "info",
+	ast.CalloutTypeTip:       "success",
+	ast.CalloutTypeImportant: "note",
+	ast.CalloutTypeWarning:   "warning",
+	ast.CalloutTypeCaution:   "error",
+}
+
+func (r *ADFRenderer) renderCallout(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.close()
+		return ast.WalkContinue
+	}
+
+	panelType, ok := adfPanelTypes[strings.ToUpper(node.CalloutType)]
+	if !ok {
+		panelType = "info"
+	}
+	r.open(&ADFNode{Type: "panel", Attrs: map[string]interface{}{"panelType": panelType}})
+	if title := strings.TrimSpace(node.CalloutTitle); "" != title && ast.GetCalloutTitle(node.CalloutType) != title {
+		r.open(&ADFNode{Type: "paragraph"})
+		r.pushMark(&ADFMark{Type: "strong"})
+		r.writeText(title)
+		r.popMark()
+		r.close()
+	}
+	return ast.WalkContinue
+}
+
+// adfTaskList 判断列表 list 是否是任务列表。
+func adfTaskList(list *ast.Node) bool {
+	return 3 == list.ListData.Typ
+}
+
+func (r *ADFRenderer) renderList(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.close()
+		return ast.WalkContinue
+	}
+
+	switch {
+	case adfTaskList(node):
+		r.open(&ADFNode{Type: "taskList", Attrs: map[string]interface{}{"localId": r.nextLocalID()}})
+	case 1 == node.ListData.Typ:
+		list := &ADFNode{Type: "orderedList"}
+		if 1 != node.ListData.Start {
+			list.Attrs = map[string]interface{}{"order": node.ListData.Start}
+		}
+		r.open(list)
+	default:
+		r.open(&ADFNode{Type: "bulletList"})
+	}
+	return ast.WalkContinue
+}
+
+func (r *ADFRenderer) renderListItem(node *ast.Node, entering bool) ast.WalkStatus {
+	if !adfTaskList(node.Parent) {
+		if entering {
+			r.open(&ADFNode{Type: "listItem"})
+		} else {
+			r.close()
+		}
+		return ast.WalkContinue
+	}
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	state := "TODO"
+	if paragraph := node.FirstChild; nil != paragraph && nil != paragraph.FirstChild && ast.NodeTaskListItemMarker == paragraph.FirstChild.Type && paragraph.FirstChild.TaskListItemChecked {
+		state = "DONE"
+	}
+	var item *ADFNode
+	for n := node.FirstChild; nil != n; n = n.Next {
+		if ast.NodeList == n.Type && adfTaskList(n) {
+			// 嵌套的任务列表是任务列表项的兄弟节点
+			if nil != item {
+				r.close()
+				item = nil
+			}
+			ast.Walk(n, r.renderNode)
+			continue
+		}
+
+		if nil == item {
+			item = &ADFNode{Type: "taskItem", Attrs: map[string]interface{}{"localId": r.nextLocalID(), "state": state}}
+			r.open(item)
+		}
+		if ast.NodeParagraph == n.Type {
+			ast.Walk(n, r.renderNode)
+			continue
+		}
+		// 任务列表项只能包含行级节点，其他块使用纯文本输出
+		if text := strings.TrimSpace(n.Text()); "" != text {
+			if 0 < len(item.Content) {
+				r.add(&ADFNode{Type: "hardBreak"})
+			}
+			r.writeText(text)
+		}
+	}
+	if nil != item {
+		r.close()
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *ADFRenderer) renderThematicBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.add(&ADFNode{Type: "rule"})
+	}
+	return ast.WalkSkipChildren
+}
+
+// addCodeBlock 输出语言为 language 的代码块 code。
+func (r *ADFRenderer) addCodeBlock(language, code string) {
+	codeBlock := &ADFNode{Type: "codeBlock"}
+	if "" != language {
+		codeBlock.Attrs = map[string]interface{}{"language": language}
+	}
+	if "" != code {
+		codeBlock.Content = []*ADFNode{{Type: "text", Text: code}}
+	}
+	r.add(codeBlock)
+}
+
+func (r *ADFRenderer) renderCodeBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		var code, language string
+		if content := node.ChildByType(ast.NodeCodeBlockCode); nil != content {
+			code = util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil))
+		}
+		if info := node.ChildByType(ast.NodeCodeBlockFenceInfoMarker); nil != info {
+			if fields := strings.Fields(util.BytesToStr(info.CodeBlockInfo)); 0 < len(fields) {
+				language = strings.ToLower(fields[0])
+			}
+		}
+		r.addCodeBlock(language, strings.TrimSuffix(code, "\n"))
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *ADFRenderer) renderMathBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		var math string
+		if content := node.ChildByType(ast.NodeMathBlockContent); nil != content {
+			math = strings.TrimSpace(util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil)))
+		}
+		r.addCodeBlock("latex", math)
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *ADFRenderer) renderHTMLBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if html := strings.TrimSpace(util.BytesToStr(node.Tokens)); "" != html {
+			r.addCodeBlock("html", html)
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *ADFRenderer) renderTable(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.open(&ADFNode{Type: "table", Attrs: map[string]interface{}{"isNumberColumnEnabled": false, "layout": "default"}})
+	} else {
+		r.close()
+	}
+	return ast.WalkContinue
+}
+
+func (r *ADFRenderer) renderTableRow(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.open(&ADFNode{Type: "tableRow"})
+	} else {
+		r.close()
+	}
+	return ast.WalkContinue
+}
+
+func (r *ADFRenderer) renderTableCell(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.close()
+		r.close()
+		return ast.WalkContinue
+	}
+
+	typ := "tableCell"
+	if ast.NodeTableHead == node.Parent.Parent.Type {
+		typ = "tableHeader"
+	}
+	r.open(&ADFNode{Type: typ, Attrs: map[string]interface{}{}})
+	// 单元格的内容需要包裹在段落中
+	paragraph := &ADFNode{Type: "paragraph"}
+	switch node.TableCellAlign {
+	case 2:
+		paragraph.Marks = []*ADFMark{{Type: "alignment", Attrs: map[string]interface{}{"align": "center"}}}
+	case 3:
+		paragraph.Marks = []*ADFMark{{Type: "alignment", Attrs: map[string]interface{}{"align": "end"}}}
+	}
+	r.open(paragraph)
+	return ast.WalkContinue
+}
+
+func (r *ADFRenderer) renderFootnotesRef(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		idx, def := r.Tree.FindFootnotesDef(node.Tokens)
+		if nil == def {
+			r.writeText("[^" + util.BytesToStr(node.Tokens) + "]")
+		} else {
+			r.pushMark(&ADFMark{Type: "subsup", Attrs: map[string]interface{}{"type": "sup"}})
+			r.writeText(strconv.Itoa(idx))
+			r.popMark()
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *ADFRenderer) renderFootnotesDefBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.add(&ADFNode{Type: "rule"})
+		r.open(&ADFNode{Type: "orderedList"})
+	} else {
+		r.close()
+	}
+	return ast.WalkContinue
+}
+
+func (r *ADFRenderer) renderFootnotesDef(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.open(&ADFNode{Type: "listItem"})
+	} else {
+		r.close()
+	}
+	return ast.WalkContinue
+}
+
+func (r *ADFRenderer) renderText(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		tokens := bytes.ReplaceAll(node.Tokens, editor.CaretTokens, nil)
+		if nil != node.Previous && ast.NodeTaskListItemMarker == node.Previous.Type {
+			tokens = bytes.TrimLeft(tokens, " ")
+		}
+		if ast.NodeText == node.Type || ast.NodeLinkText == node.Type {
+			if r.Options.AutoSpace {
+				tokens = r.Space(tokens)
+			}
+			if r.Options.FixTermTypo {
+				tokens = r.FixTermTypo(tokens)
+			}
+		}
+		r.writeText(util.BytesToStr(tokens))
+	}
+	return ast.WalkContinue
+}
+
+func (r *ADFRenderer) renderEmojiImg(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if alias := node.ChildByType(ast.NodeEmojiAlias); nil != alias {
+			r.add(&ADFNode{Type: "emoji", Attrs: map[string]interface{}{"shortName": util.BytesToStr(alias.Tokens)}})
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *ADFRenderer) renderSoftBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if r.Options.SoftBreak2HardBreak {
+			r.renderHardBreak(node, entering)
+		} else {
+			r.writeText(" ")
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *ADFRenderer) renderHardBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering && r.inline() {
+		r.add(&ADFNode{Type: "hardBreak"})
+	}
+	return ast.WalkContinue
+}
+
+func (r *ADFRenderer) renderInlineHTML(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering && bytes.HasPrefix(bytes.ToLower(node.Tokens), []byte("<br")) {
+		r.renderHardBreak(node, entering)
+	}
+	return ast.WalkContinue
+}
+
+func (r *ADFRenderer) renderCodeSpan(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if content := node.ChildByType(ast.NodeCodeSpanContent); nil != content {
+			r.pushMark(&ADFMark{Type: "code"})
+			r.writeText(util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil)))
+			r.popMark()
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *ADFRenderer) renderInlineMath(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if content := node.ChildByType(ast.NodeInlineMathContent); nil != content {
+			r.pushMark(&ADFMark{Type: "code"})
+			r.writeText(strings.TrimSpace(util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil))))
+			r.popMark()
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+// renderMark 返回一个渲染函数，在渲染节点的子节点时使用类型为 typ 的文本格式。
+func (r *ADFRenderer) renderMark(typ string, attrs map[string]interface{}) RendererFunc {
+	return func(node *ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			r.pushMark(&ADFMark{Type: typ, Attrs: attrs})
+		} else {
+			r.popMark()
+		}
+		return ast.WalkContinue
+	}
+}
+
+// adfTextMarkMarks 为行级元素类型对应的文本格式。
+var adfTextMarkMarks = map[string]*ADFMark{
+	"strong":      {Type: "strong"},
+	"em":          {Type: "em"},
+	"s":           {Type: "strike"},
+	"u":           {Type: "underline"},
+	"code":        {Type: "code"},
+	"kbd":         {Type: "code"},
+	"inline-math": {Type: "code"},
+	"sup":         {Type: "subsup", Attrs: map[string]interface{}{"type": "sup"}},
+	"sub":         {Type: "subsup", Attrs: map[string]interface{}{"type": "sub"}},
+}
+
+func (r *ADFRenderer) renderTextMark(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	marks := len(r.marks)
+	for _, typ := range strings.Split(node.TextMarkType, " ") {
+		if mark, ok := adfTextMarkMarks[typ]; ok {
+			r.pushMark(mark)
+		}
+	}
+	if node.IsTextMarkType("a") && "" != node.TextMarkAHref {
+		r.pushMark(&ADFMark{Type: "link", Attrs: map[string]interface{}{"href": node.TextMarkAHref}})
+	}
+	if node.IsTextMarkType("inline-math") {
+		r.writeText(strings.TrimSpace(node.TextMarkInlineMathContent))
+	} else {
+		r.writeText(node.TextMarkTextContent)
+	}
+	r.marks = r.marks[:marks]
+	return ast.WalkSkipChildren
+}
+
+func (r *ADFRenderer) renderLink(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.popMark()
+		return ast.WalkContinue
+	}
+
+	var dest string
+	if d := node.ChildByType(ast.NodeLinkDest); nil != d {
+		dest = strings.TrimSpace(util.BytesToStr(r.LinkPath(d.Tokens)))
+	}
+	r.pushMark(&ADFMark{Type: "link", Attrs: map[string]interface{}{"href": dest}})
+	if 2 == node.LinkType || emptyLinkText(node) {
+		r.writeText(dest)
+		return ast.WalkSkipChildren
+	}
+	return ast.WalkContinue
+}
+
+func (r *ADFRenderer) renderImage(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		// 段落中的图片输出为链接
+		dest := r.imageDest(node)
+		text := strings.TrimSpace(imageAlt(node))
+		if "" == text {
+			text = dest
+		}
+		r.pushMark(&ADFMark{Type: "link", Attrs: map[string]interface{}{"href": dest}})
+		r.writeText(text)
+		r.popMark()
+	}
+	return ast.WalkSkipChildren
+}
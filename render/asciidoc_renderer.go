@@ -0,0 +1,830 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/editor"
+	"github.com/88250/lute/lex"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/util"
+)
+
+// AsciiDocRenderer 描述了 AsciiDoc 渲染器。
+//
+// 节点和 AsciiDoc 结构的对应关系如下：
+//   - 一级标题对应 == 开头的一级节，标题前输出 [[ID]] 作为锚点，容器块中的标题使用 [discrete] 样式
+//   - 引述使用 ____ 分隔的引述块，提示块使用 NOTE、TIP 等警示块，列表项中的后续块使用 + 连接
+//   - 代码块、公式块、HTML 块分别使用 source、latexmath 和透传块，表格使用 |=== 并且通过 cols 设置列对齐方式
+//   - 脚注在第一次引用的位置使用 footnote 宏输出，后续引用使用 ID 引用同一个脚注
+//   - 用到公式和目录时在文档头部设置 stem 和 toc 属性，YAML Front Matter 输出为文档头部前的注释块
+type AsciiDocRenderer struct {
+	*BaseRenderer
+
+	attributes  []string     // 文档头部的属性
+	frontMatter string       // 输出在文档头部前的 YAML Front Matter 注释块
+	footnotes   map[int]bool // 已经输出过的脚注
+	macro       int          // 宏的方括号的嵌套层数，方括号中的 ] 需要转义
+}
+
+// NewAsciiDocRenderer 创建一个 AsciiDoc 渲染器。
+func NewAsciiDocRenderer(tree *parse.Tree, options *Options, parseOptions *parse.Options) *AsciiDocRenderer {
+	ret := &AsciiDocRenderer{BaseRenderer: NewBaseRenderer(tree, options, parseOptions)}
+	ret.DefaultRendererFunc = ret.renderChildren
+	ret.RendererFuncs[ast.NodeParagraph] = ret.renderParagraph
+	ret.RendererFuncs[ast.NodeHeading] = ret.renderHeading
+	ret.RendererFuncs[ast.NodeBlockquote] = ret.renderBlockquote
+	ret.RendererFuncs[ast.NodeCallout] = ret.renderCallout
+	ret.RendererFuncs[ast.NodeList] = ret.renderList
+	ret.RendererFuncs[ast.NodeListItem] = ret.renderListItem
+	ret.RendererFuncs[ast.NodeTaskListItemMarker] = ret.renderTaskListItemMarker
+	ret.RendererFuncs[ast.NodeThematicBreak] = ret.renderThematicBreak
+	ret.RendererFuncs[ast.NodeCodeBlock] = ret.renderCodeBlock
+	ret.RendererFuncs[ast.NodeMathBlock] = ret.renderMathBlock
+	ret.RendererFuncs[ast.NodeHTMLBlock] = ret.renderHTMLBlock
+	ret.RendererFuncs[ast.NodeTable] = ret.renderTable
+	ret.RendererFuncs[ast.NodeTableRow] = ret.renderTableRow
+	ret.RendererFuncs[ast.NodeTableCell] = ret.renderTableCell
+	ret.RendererFuncs[ast.NodeToC] = ret.renderToC
+	ret.RendererFuncs[ast.NodeFootnotesRef] = ret.renderFootnotesRef
+	ret.RendererFuncs[ast.NodeText] = ret.renderText
+	ret.RendererFuncs[ast.NodeLinkText] = ret.renderText
+	ret.RendererFuncs[ast.NodeBackslashContent] = ret.renderText
+	ret.RendererFuncs[ast.NodeHTMLEntity] = ret.renderText
+	ret.RendererFuncs[ast.NodeEmojiUnicode] = ret.renderText
+	ret.RendererFuncs[ast.NodeBlockRefText] = ret.renderText
+	ret.RendererFuncs[ast.NodeBlockRefDynamicText] = ret.renderText
+	ret.RendererFuncs[ast.NodeFileAnnotationRefText] = ret.renderText
+	ret.RendererFuncs[ast.NodeEmojiImg] = ret.renderEmojiImg
+	ret.RendererFuncs[ast.NodeSoftBreak] = ret.renderSoftBreak
+	ret.RendererFuncs[ast.NodeHardBreak] = ret.renderHardBreak
+	ret.RendererFuncs[ast.NodeInlineHTML] = ret.renderInlineHTML
+	ret.RendererFuncs[ast.NodeCodeSpan] = ret.renderCodeSpan
+	ret.RendererFuncs[ast.NodeInlineMath] = ret.renderInlineMath
+	ret.RendererFuncs[ast.NodeStrong] = ret.renderQuote("*")
+	ret.RendererFuncs[ast.NodeEmphasis] = ret.renderQuote("_")
+	ret.RendererFuncs[ast.NodeMark] = ret.renderQuote("#")
+	ret.RendererFuncs[ast.NodeStrikethrough] = ret.renderRole("line-through")
+	ret.RendererFuncs[ast.NodeUnderline] = ret.renderRole("underline")
+	ret.RendererFuncs[ast.NodeSup] = ret.renderMarkup("^", "^")
+	ret.RendererFuncs[ast.NodeSub] = ret.renderMarkup("~", "~")
+	ret.RendererFuncs[ast.NodeKbd] = ret.renderMacro("kbd:[")
+	ret.RendererFuncs[ast.NodeTextMark] = ret.renderTextMark
+	ret.RendererFuncs[ast.NodeLink] = ret.renderLink
+	ret.RendererFuncs[ast.NodeImage] = ret.renderImage
+	ret.RendererFuncs[ast.NodeFootnotesDefBlock] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeLinkRefDefBlock] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeYamlFrontMatter] = ret.renderYamlFrontMatter
+	ret.RendererFuncs[ast.NodeKramdownBlockIAL] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeKramdownSpanIAL] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeHeadingID] = ret.renderSkip
+	return ret
+}
+
+func (r *AsciiDocRenderer) Render() (output []byte) {
+	r.LastOut = lex.ItemNewline
+	r.Writer = &bytes.Buffer{}
+	r.Writer.Grow(4096)
+	r.attributes, r.frontMatter, r.footnotes, r.macro = nil, "", map[int]bool{}, 0
+	ast.Walk(r.Tree.Root, r.renderNode)
+
+	// 文档头部前只能有注释，因此 Front Matter 注释块输出在属性之前
+	header := r.frontMatter
+	if 0 < len(r.attributes) {
+		header += strings.Join(r.attributes, "\n") + "\n"
+	}
+	if "" != header && 0 < r.Writer.Len() {
+		header += "\n"
+	}
+	return append([]byte(header), r.Writer.Bytes()...)
+}
+
+// attribute 在文档头部设置属性 attribute。
+func (r *AsciiDocRenderer) attribute(attribute string) {
+	for _, attr := range r.attributes {
+		if attr == attribute {
+			return
+		}
+	}
+	r.attributes = append(r.attributes, attribute)
+}
+
+// renderChildren 用于渲染没有对应 AsciiDoc 结构的节点，仅渲染其子节点。
+func (r *AsciiDocRenderer) renderChildren(node *ast.Node, entering bool) ast.WalkStatus {
+	return ast.WalkContinue
+}
+
+// renderSkip 用于渲染在 AsciiDoc 文档中不可见的节点。
+func (r *AsciiDocRenderer) renderSkip(node *ast.Node, entering bool) ast.WalkStatus {
+	return ast.WalkSkipChildren
+}
+
+// startBlock 在输出块 node 前进行分隔：容器块的第一个子块紧接着容器块的开头，紧凑列表的列表项之间换行，
+// 列表项中的后续块使用 + 连接，其他块之间空一行。
+func (r *AsciiDocRenderer) startBlock(node *ast.Node) {
+	prev := node.Previous
+	if nil == prev || ast.NodeBlockquoteMarker == prev.Type || 1 > r.Writer.Len() {
+		return
+	}
+
+	r.Newline()
+	switch {
+	case ast.NodeListItem == node.Type:
+		if !node.Parent.ListData.Tight {
+			r.WriteByte(lex.ItemNewline)
+		}
+	case ast.NodeListItem == node.Parent.Type:
+		if ast.NodeList == node.Type {
+			return
+		}
+		if ast.NodeList == prev.Type {
+			// 前面空一行的 + 将块连接到上一层的列表项
+			r.WriteByte(lex.ItemNewline)
+		}
+		r.WriteString("+\n")
+	case ast.NodeList == prev.Type && ast.NodeList == node.Type:
+		// 相邻的列表需要使用注释分隔，否则会被合并
+		r.WriteString("\n//\n\n")
+	default:
+		r.WriteByte(lex.ItemNewline)
+	}
+}
+
+func (r *AsciiDocRenderer) renderParagraph(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.Newline()
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	if image := imageParagraph(node); nil != image {
+		r.writeImage("image::", image)
+		return ast.WalkSkipChildren
+	}
+	return ast.WalkContinue
+}
+
+func (r *AsciiDocRenderer) renderHeading(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.Newline()
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	if ast.NodeDocument != node.Parent.Type {
+		// 节只能出现在文档的顶层
+		r.WriteString("[discrete]\n")
+	}
+	if id := r.HeadingID(node); asciidocID(id) {
+		r.WriteString("[[" + id + "]]\n")
+	}
+	level := node.HeadingLevel
+	if 1 > level {
+		level = 1
+	} else if 5 < level {
+		level = 5
+	}
+	r.WriteString(strings.Repeat("=", level+1) + " ")
+	return ast.WalkContinue
+}
+
+// delimiter 返回分隔块 node 的分隔符，嵌套的同类分隔块使用更长的分隔符。
+func (r *AsciiDocRenderer) delimiter(node *ast.Node, c string) string {
+	length := 4
+	for p := node.Parent; nil != p; p = p.Parent {
+		if p.Type == node.Type {
+			length++
+		}
+	}
+	return strings.Repeat(c, length)
+}
+
+func (r *AsciiDocRenderer) renderBlockquote(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+	} else {
+		r.Newline()
+	}
+	r.WriteString(r.delimiter(node, "_") + "\n")
+	return ast.WalkContinue
+}
+
+// asciidocAdmonitions 为 AsciiDoc 内置的警示类型。
+var asciidocAdmonitions = map[string]bool{
+	"NOTE": true, "TIP": true, "IMPORTANT": true, "WARNING": true, "CAUTION": true,
+}
+
+func (r *AsciiDocRenderer) renderCallout(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.Newline()
+		r.WriteString(r.delimiter(node, "=") + "\n")
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	typ := strings.ToUpper(node.CalloutType)
+	title := strings.TrimSpace(node.CalloutTitle)
+	if !asciidocAdmonitions[typ] {
+		if "" == title {
+			title = node.CalloutType
+		}
+		typ = "NOTE"
+	}
+	if "" != title && ast.GetCalloutTitle(node.CalloutType) != title {
+		r.WriteString("." + title + "\n")
+	}
+	r.WriteString("[" + typ + "]\n" + r.delimiter(node, "=") + "\n")
+	return ast.WalkContinue
+}
+
+func (r *AsciiDocRenderer) renderList(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		if 1 == node.ListData.Typ && 1 != node.ListData.Start {
+			r.WriteString("[start=" + strconv.Itoa(node.ListData.Start) + "]\n")
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *AsciiDocRenderer) renderListItem(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.Newline()
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	// 列表的嵌套层级使用标记的数量表示，有序列表和无序列表分别计算
+	ordered := 1 == node.Parent.ListData.Typ
+	depth := 0
+	for p := node.Parent; nil != p; p = p.Parent {
+		if ast.NodeList == p.Type && ordered == (1 == p.ListData.Typ) {
+			depth++
+		}
+	}
+	if 5 < depth {
+		depth = 5
+	}
+	marker := "*"
+	if ordered {
+		marker = "."
+	}
+	r.WriteString(strings.Repeat(marker, depth) + " ")
+	return ast.WalkContinue
+}
+
+func (r *AsciiDocRenderer) renderTaskListItemMarker(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if node.TaskListItemChecked {
+			r.WriteString("[x]")
+		} else {
+			r.WriteString("[ ]")
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *AsciiDocRenderer) renderThematicBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		r.WriteString("'''\n")
+	}
+	return ast.WalkSkipChildren
+}
+
+// writeDelimited 使用分隔符 c 输出分隔块的内容 content，内容中存在分隔符时使用更长的分隔符。
+func (r *AsciiDocRenderer) writeDelimited(c, content string) {
+	delimiter := strings.Repeat(c, 4)
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(line); len(line) >= len(delimiter) && "" == strings.Trim(line, c) {
+			delimiter = line + c
+		}
+	}
+	r.WriteString(delimiter + "\n")
+	if "" != content {
+		r.WriteString(content + "\n")
+	}
+	r.WriteString(delimiter + "\n")
+}
+
+func (r *AsciiDocRenderer) renderCodeBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	var code string
+	if content := node.ChildByType(ast.NodeCodeBlockCode); nil != content {
+		code = util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil))
+	}
+	if info := node.ChildByType(ast.NodeCodeBlockFenceInfoMarker); nil != info {
+		if fields := strings.Fields(util.BytesToStr(info.CodeBlockInfo)); 0 < len(fields) {
+			r.WriteString("[source," + fields[0] + "]\n")
+		}
+	}
+	r.writeDelimited("-", strings.TrimSuffix(code, "\n"))
+	return ast.WalkSkipChildren
+}
+
+func (r *AsciiDocRenderer) renderMathBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.attribute(":stem: latexmath")
+		r.startBlock(node)
+		var math string
+		if content := node.ChildByType(ast.NodeMathBlockContent); nil != content {
+			math = strings.TrimSpace(util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil)))
+		}
+		r.WriteString("[latexmath]\n")
+		r.writeDelimited("+", math)
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *AsciiDocRenderer) renderYamlFrontMatter(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if content := node.ChildByType(ast.NodeYamlFrontMatterContent); nil != content {
+			if yaml := strings.TrimSpace(util.BytesToStr(content.Tokens)); "" != yaml {
+				writer := r.Writer
+				r.Writer = &bytes.Buffer{}
+				r.writeDelimited("/", yaml)
+				r.frontMatter = r.Writer.String()
+				r.Writer = writer
+			}
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *AsciiDocRenderer) renderHTMLBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if html := strings.TrimSpace(util.BytesToStr(node.Tokens)); "" != html {
+			r.startBlock(node)
+			r.writeDelimited("+", html)
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *AsciiDocRenderer) renderTable(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.WriteString("|===\n")
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	var cols []string
+	for _, align := range node.TableAligns {
+		switch align {
+		case 2:
+			cols = append(cols, "^")
+		case 3:
+			cols = append(cols, ">")
+		default:
+			cols = append(cols, "<")
+		}
+	}
+	r.WriteString("[%header,cols=\"" + strings.Join(cols, ",") + "\"]\n|===\n")
+	return ast.WalkContinue
+}
+
+func (r *AsciiDocRenderer) renderTableRow(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.WriteByte(lex.ItemNewline)
+		if ast.NodeTableHead == node.Parent.Type {
+			r.WriteByte(lex.ItemNewline)
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *AsciiDocRenderer) renderTableCell(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if nil != node.Previous {
+			r.WriteByte(' ')
+		}
+		r.WriteString("| ")
+	}
+	return ast.WalkContinue
+}
+
+func (r *AsciiDocRenderer) renderToC(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.attribute(":toc: macro")
+		r.startBlock(node)
+		r.WriteString("toc::[]\n")
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *AsciiDocRenderer) renderFootnotesRef(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	idx, def := r.Tree.FindFootnotesDef(node.Tokens)
+	if nil == def {
+		r.WriteString(r.escape("[^" + util.BytesToStr(node.Tokens) + "]"))
+		return ast.WalkSkipChildren
+	}
+
+	id := "fn" + strconv.Itoa(idx)
+	if r.footnotes[idx] {
+		r.WriteString("footnote:" + id + "[]")
+		return ast.WalkSkipChildren
+	}
+	r.footnotes[idx] = true
+
+	// 脚注内容输出在引用的位置，多个段落使用空格连接
+	writer, lastOut := r.Writer, r.LastOut
+	r.Writer, r.LastOut = &bytes.Buffer{}, lex.ItemNewline
+	r.macro++
+	for n := def.FirstChild; nil != n; n = n.Next {
+		if 0 < r.Writer.Len() {
+			r.WriteByte(' ')
+		}
+		ast.Walk(n, r.renderNode)
+	}
+	r.macro--
+	content := strings.Join(strings.Fields(r.Writer.String()), " ")
+	r.Writer, r.LastOut = writer, lastOut
+	r.WriteString("footnote:" + id + "[" + content + "]")
+	return ast.WalkSkipChildren
+}
+
+func (r *AsciiDocRenderer) renderText(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		tokens := bytes.ReplaceAll(node.Tokens, editor.CaretTokens, nil)
+		if ast.NodeText == node.Type || ast.NodeLinkText == node.Type {
+			if r.Options.AutoSpace {
+				tokens = r.Space(tokens)
+			}
+			if r.Options.FixTermTypo {
+				tokens = r.FixTermTypo(tokens)
+			}
+		}
+		text := r.escape(util.BytesToStr(tokens))
+		if node.ParentIs(ast.NodeTableCell) {
+			text = strings.ReplaceAll(text, "|", "\\|")
+		}
+		r.WriteString(text)
+	}
+	return ast.WalkContinue
+}
+
+func (r *AsciiDocRenderer) renderEmojiImg(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if alias := node.ChildByType(ast.NodeEmojiAlias); nil != alias {
+			r.WriteString(r.escape(util.BytesToStr(alias.Tokens)))
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *AsciiDocRenderer) renderSoftBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if r.Options.SoftBreak2HardBreak {
+			r.renderHardBreak(node, entering)
+		} else if node.ParentIs(ast.NodeTableCell) || 0 < r.macro {
+			r.WriteByte(' ')
+		} else {
+			r.WriteByte(lex.ItemNewline)
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *AsciiDocRenderer) renderHardBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if node.ParentIs(ast.NodeTableCell) || 0 < r.macro {
+			r.WriteByte(' ')
+		} else {
+			r.WriteString(" +\n")
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *AsciiDocRenderer) renderInlineHTML(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if bytes.HasPrefix(bytes.ToLower(node.Tokens), []byte("<br")) {
+			r.renderHardBreak(node, entering)
+		} else {
+			r.WriteString("+++" + util.BytesToStr(node.Tokens) + "+++")
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *AsciiDocRenderer) renderCodeSpan(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		var code string
+		if content := node.ChildByType(ast.NodeCodeSpanContent); nil != content {
+			code = util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil))
+		}
+		r.writeCode(node, code)
+	}
+	return ast.WalkSkipChildren
+}
+
+// writeCode 输出行内代码 code，代码使用透传避免其中的字符被识别为行级标记。
+func (r *AsciiDocRenderer) writeCode(node *ast.Node, code string) {
+	if "" == code {
+		return
+	}
+	if r.constrained(node) && !strings.Contains(code, "+`") {
+		r.WriteString("`+" + code + "+`")
+		return
+	}
+	r.WriteString("``+" + code + "+``")
+}
+
+func (r *AsciiDocRenderer) renderInlineMath(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		var math string
+		if content := node.ChildByType(ast.NodeInlineMathContent); nil != content {
+			math = strings.TrimSpace(util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil)))
+		}
+		r.writeMath(math)
+	}
+	return ast.WalkSkipChildren
+}
+
+// writeMath 输出行级公式 math。
+func (r *AsciiDocRenderer) writeMath(math string) {
+	r.attribute(":stem: latexmath")
+	r.WriteString("latexmath:[" + strings.ReplaceAll(math, "]", "\\]") + "]")
+}
+
+// constrained 判断行级节点 node 是否可以使用受限的格式标记（单个标记字符），标记两侧需要不是单词字符。
+func (r *AsciiDocRenderer) constrained(node *ast.Node) bool {
+	return r.boundary(node.Previous, false) && r.boundary(node.Next, true)
+}
+
+// boundary 判断行级节点 node 与相邻的行级节点之间是否是单词边界，next 为 true 时判断 node 的开头，否则判断 node 的结尾。
+func (r *AsciiDocRenderer) boundary(node *ast.Node, next bool) bool {
+	if nil == node {
+		return true
+	}
+	switch node.Type {
+	case ast.NodeText:
+		if 1 > len(node.Tokens) {
+			return true
+		}
+		var c rune
+		if next {
+			c, _ = utf8.DecodeRune(node.Tokens)
+		} else {
+			c, _ = utf8.DecodeLastRune(node.Tokens)
+		}
+		return !asciidocWordRune(c)
+	case ast.NodeSoftBreak, ast.NodeHardBreak, ast.NodeKramdownSpanIAL:
+		return true
+	}
+	return false
+}
+
+// renderQuote 返回一个渲染函数，使用格式标记 mark 包裹节点的子节点，必要时使用非受限的格式标记（两个标记字符）。
+func (r *AsciiDocRenderer) renderQuote(mark string) RendererFunc {
+	return func(node *ast.Node, entering bool) ast.WalkStatus {
+		if r.constrained(node) {
+			r.WriteString(mark)
+		} else {
+			r.WriteString(mark + mark)
+		}
+		return ast.WalkContinue
+	}
+}
+
+// renderRole 返回一个渲染函数，使用角色为 role 的高亮格式包裹节点的子节点。
+func (r *AsciiDocRenderer) renderRole(role string) RendererFunc {
+	return func(node *ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			r.WriteString("[." + role + "]##")
+		} else {
+			r.WriteString("##")
+		}
+		return ast.WalkContinue
+	}
+}
+
+// renderMarkup 返回一个渲染函数，使用 open 和 close 包裹节点的子节点。
+func (r *AsciiDocRenderer) renderMarkup(open, close string) RendererFunc {
+	return func(node *ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			r.WriteString(open)
+		} else {
+			r.WriteString(close)
+		}
+		return ast.WalkContinue
+	}
+}
+
+// renderMacro 返回一个渲染函数，使用宏 macro 包裹节点的子节点。
+func (r *AsciiDocRenderer) renderMacro(macro string) RendererFunc {
+	return func(node *ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			r.macro++
+			r.WriteString(macro)
+		} else {
+			r.macro--
+			r.WriteByte(']')
+		}
+		return ast.WalkContinue
+	}
+}
+
+// asciidocTextMarkMarkups 为行级元素类型对应的开始和结束标记。
+var asciidocTextMarkMarkups = [][]string{
+	{"strong", "**", "**"},
+	{"em", "__", "__"},
+	{"mark", "##", "##"},
+	{"s", "[.line-through]##", "##"},
+	{"u", "[.underline]##", "##"},
+	{"sup", "^", "^"},
+	{"sub", "~", "~"},
+	{"kbd", "kbd:[", "]"},
+}
+
+func (r *AsciiDocRenderer) renderTextMark(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	if node.IsTextMarkType("inline-math") {
+		r.writeMath(strings.TrimSpace(node.TextMarkInlineMathContent))
+		return ast.WalkSkipChildren
+	}
+
+	var open, close []string
+	for _, markup := range asciidocTextMarkMarkups {
+		if node.IsTextMarkType(markup[0]) {
+			open = append(open, markup[1])
+			close = append([]string{markup[2]}, close...)
+		}
+	}
+	r.WriteString(strings.Join(open, ""))
+	if node.IsTextMarkType("code") {
+		r.writeCode(node, node.TextMarkTextContent)
+	} else if node.IsTextMarkType("a") && "" != node.TextMarkAHref {
+		r.writeLink(node, node.TextMarkAHref, r.escape(node.TextMarkTextContent))
+	} else {
+		r.WriteString(r.escape(node.TextMarkTextContent))
+	}
+	r.WriteString(strings.Join(close, ""))
+	return ast.WalkSkipChildren
+}
+
+func (r *AsciiDocRenderer) renderLink(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	var dest string
+	if d := node.ChildByType(ast.NodeLinkDest); nil != d {
+		dest = strings.TrimSpace(util.BytesToStr(r.LinkPath(d.Tokens)))
+	}
+	if 2 == node.LinkType || "" == node.Text() {
+		if asciidocURL(dest) && r.boundary(node.Previous, false) && r.boundary(node.Next, true) {
+			r.WriteString(dest)
+		} else {
+			r.writeLink(node, dest, "")
+		}
+		return ast.WalkSkipChildren
+	}
+
+	writer, lastOut := r.Writer, r.LastOut
+	r.Writer = &bytes.Buffer{}
+	r.macro++
+	for n := node.FirstChild; nil != n; n = n.Next {
+		ast.Walk(n, r.renderNode)
+	}
+	r.macro--
+	text := r.Writer.String()
+	r.Writer, r.LastOut = writer, lastOut
+	r.writeLink(node, dest, text)
+	return ast.WalkSkipChildren
+}
+
+// writeLink 输出链接到 dest 的超链接 node，链接到 #ID 时使用交叉引用。
+func (r *AsciiDocRenderer) writeLink(node *ast.Node, dest, text string) {
+	if strings.HasPrefix(dest, "#") && asciidocID(dest[1:]) {
+		r.WriteString("<<" + dest[1:] + "," + text + ">>")
+		return
+	}
+	if !asciidocURL(dest) || !r.boundary(node.Previous, false) {
+		// 前面是单词字符时 URL 不会被自动识别
+		dest = "link:" + dest
+	}
+	if strings.ContainsAny(dest, " []") {
+		dest = strings.Replace(dest, ":", ":++", 1) + "++"
+	}
+	r.WriteString(dest + "[" + text + "]")
+}
+
+func (r *AsciiDocRenderer) renderImage(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.writeImage("image:", node)
+	}
+	return ast.WalkSkipChildren
+}
+
+// writeImage 使用宏 macro 输出图片 image。
+func (r *AsciiDocRenderer) writeImage(macro string, image *ast.Node) {
+	var dest, alt string
+	if d := image.ChildByType(ast.NodeLinkDest); nil != d {
+		dest = strings.TrimSpace(util.BytesToStr(r.LinkPath(d.Tokens)))
+	}
+	alt = strings.TrimSpace(strings.ReplaceAll(imageAlt(image), "\n", " "))
+	if strings.ContainsAny(alt, ",\"=]") {
+		// 包含逗号等字符的替代文本需要使用引号包裹，否则会被识别为多个属性
+		alt = "\"" + strings.ReplaceAll(alt, "\"", "\\\"") + "\""
+	}
+	r.WriteString(macro + dest + "[" + alt + "]")
+}
+
+// escape 转义 AsciiDoc 文本中可能被识别为格式标记的字符，在宏的方括号中时还需要转义 ]。
+func (r *AsciiDocRenderer) escape(text string) string {
+	ret := asciidocEscape(text)
+	if 0 < r.macro {
+		ret = strings.ReplaceAll(ret, "]", "\\]")
+	}
+	return ret
+}
+
+// asciidocEscapes 为可能被识别为格式标记的字符的替换写法。
+var asciidocEscapes = map[rune]string{
+	'*': "{asterisk}",
+	'`': "{backtick}",
+	'^': "{caret}",
+	'~': "{tilde}",
+	'+': "{plus}",
+	'_': "&#95;",
+	'#': "&#35;",
+}
+
+// asciidocEscape 转义 AsciiDoc 文本中可能被识别为格式标记的字符，单词中间的标记字符（比如 a_b）不需要转义。
+func asciidocEscape(text string) string {
+	buf := &bytes.Buffer{}
+	var prev rune
+	for i, c := range text {
+		next, _ := utf8.DecodeRuneInString(text[i+utf8.RuneLen(c):])
+		if replacement, ok := asciidocEscapes[c]; ok {
+			if !asciidocLetter(prev) || !asciidocLetter(next) || '^' == c || '~' == c {
+				buf.WriteString(replacement)
+				prev = c
+				continue
+			}
+		}
+		buf.WriteRune(c)
+		prev = c
+	}
+	return buf.String()
+}
+
+// asciidocWordRune 判断字符 c 是否是单词字符。
+func asciidocWordRune(c rune) bool {
+	return asciidocLetter(c) || '_' == c
+}
+
+// asciidocLetter 判断字符 c 是否是字母或者数字。
+func asciidocLetter(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// asciidocID 判断 id 是否可以作为锚点 ID 使用。
+func asciidocID(id string) bool {
+	for i, c := range id {
+		if unicode.IsLetter(c) || '_' == c || ':' == c {
+			continue
+		}
+		if 0 < i && (unicode.IsDigit(c) || strings.ContainsRune("-.", c)) {
+			continue
+		}
+		return false
+	}
+	return "" != id
+}
+
+// asciidocURL 判断 dest 是否是 AsciiDoc 可以自动识别的 URL。
+func asciidocURL(dest string) bool {
+	for _, scheme := range []string{"http://", "https://", "ftp://", "irc://", "mailto:"} {
+		if strings.HasPrefix(dest, scheme) {
+			return true
+		}
+	}
+	return false
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/88250/lute/util"
 
 	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/formatters"
 	chromahtml "github.com/alecthomas/chroma/formatters/html"
 	chromalexers "github.com/alecthomas/chroma/lexers"
 	"github.com/alecthomas/chroma/styles"
@@ -203,6 +204,28 @@ func highlightChroma(codeNode *ast.Node, tokens []byte, language string, r *Html
 	return
 }
 
+// highlightANSI 使用终端（256 色）格式对代码 code 进行语法高亮，language 为空时自动检测语言。
+func highlightANSI(code, language, styleName string) (ret string, ok bool) {
+	var lexer chroma.Lexer
+	if "" != language {
+		lexer = chromalexers.Get(language)
+	} else {
+		lexer = chromalexers.Analyse(code)
+	}
+	if nil == lexer {
+		return
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if nil != err {
+		return
+	}
+	var b bytes.Buffer
+	if err = formatters.TTY256.Format(&b, styles.Get(styleName), iterator); nil != err {
+		return
+	}
+	return b.String(), true
+}
+
 func isGo(language string) bool {
 	return strings.EqualFold(language, "go") || strings.EqualFold(language, "golang")
 }
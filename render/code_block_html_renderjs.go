@@ -85,3 +85,8 @@ func (r *HtmlRenderer) renderCodeBlockCode(node *ast.Node, entering bool) ast.Wa
 	}
 	return ast.WalkContinue
 }
+
+// highlightANSI 不实现语法高亮。
+func highlightANSI(code, language, styleName string) (ret string, ok bool) {
+	return
+}
@@ -0,0 +1,169 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"bytes"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/diff"
+	"github.com/88250/lute/html"
+	"github.com/88250/lute/lex"
+	"github.com/88250/lute/parse"
+)
+
+// DiffHtmlRenderer 描述了差异 HTML 渲染器，用于渲染 diff.Diff 的比较结果。
+//
+// 继承 HtmlRenderer 按照新语法树的顺序渲染，并使用 <ins> 和 <del> 标记差异：
+//   - 新增的块使用 <ins class="diff-block"> 包裹，删除的块渲染旧语法树中的块并使用 <del class="diff-block"> 包裹
+//   - 移动的块在新位置使用 <ins class="diff-moved"> 包裹
+//   - 修改的块如果所有文本变化都在文本节点中，则在块内使用 <ins> 和 <del> 标记变化的文本，否则渲染为删除旧块后新增新块
+type DiffHtmlRenderer struct {
+	*HtmlRenderer
+
+	result *diff.Result
+	old    *HtmlRenderer                    // 用于渲染旧语法树中被删除的块
+	texts  map[*ast.Node][]*diff.TextChange // 当前渲染的修改块中需要标记变化的文本节点
+}
+
+// NewDiffHtmlRenderer 创建一个差异 HTML 渲染器。
+func NewDiffHtmlRenderer(result *diff.Result, options *Options, parseOptions *parse.Options) *DiffHtmlRenderer {
+	ret := &DiffHtmlRenderer{HtmlRenderer: NewHtmlRenderer(result.New, options, parseOptions), result: result}
+	ret.old = NewHtmlRenderer(result.Old, options, parseOptions)
+	ret.old.ExtRendererFuncs = ret.ExtRendererFuncs // 删除的块和其他块使用相同的自定义渲染函数
+	ret.RendererFuncs[ast.NodeText] = ret.renderText
+	return ret
+}
+
+func (r *DiffHtmlRenderer) Render() (output []byte) {
+	r.LastOut = lex.ItemNewline
+	r.Writer = &bytes.Buffer{}
+	r.Writer.Grow(4096)
+
+	for _, change := range r.result.Changes {
+		switch change.Op {
+		case diff.OpEqual:
+			r.RenderNode(change.New)
+		case diff.OpInsert:
+			r.renderBlock("ins", "diff-block", change.New, r.HtmlRenderer)
+		case diff.OpDelete:
+			r.renderBlock("del", "diff-block", change.Old, r.old)
+		case diff.OpModify, diff.OpMove:
+			if change.Modified {
+				if r.texts = diffTexts(change); nil == r.texts {
+					// 无法在块内标记变化时渲染为删除旧块后新增新块
+					r.renderBlock("del", "diff-block", change.Old, r.old)
+					if diff.OpModify == change.Op {
+						r.renderBlock("ins", "diff-block", change.New, r.HtmlRenderer)
+						continue
+					}
+				}
+			}
+			if diff.OpMove == change.Op {
+				r.renderBlock("ins", "diff-moved", change.New, r.HtmlRenderer)
+			} else {
+				r.RenderNode(change.New)
+			}
+			r.texts = nil
+		}
+	}
+	r.Write(r.RenderFootnotes())
+	output = r.Writer.Bytes()
+	return
+}
+
+// renderBlock 使用 renderer 渲染块 node，并使用 tag 标签包裹。
+func (r *DiffHtmlRenderer) renderBlock(tag, class string, node *ast.Node, renderer *HtmlRenderer) {
+	r.Newline()
+	r.Tag(tag, [][]string{{"class", class}}, false)
+	r.Newline()
+	renderer.Writer, renderer.LastOut = r.Writer, r.LastOut
+	renderer.RenderNode(node)
+	r.LastOut = renderer.LastOut
+	r.Newline()
+	r.Tag("/"+tag, nil, false)
+	r.Newline()
+}
+
+func (r *DiffHtmlRenderer) renderText(node *ast.Node, entering bool) ast.WalkStatus {
+	texts := r.texts[node]
+	if !entering || nil == texts {
+		return r.HtmlRenderer.renderText(node, entering)
+	}
+
+	for _, text := range texts {
+		tokens := []byte(text.Text)
+		if r.Options.AutoSpace {
+			tokens = r.Space(tokens)
+		}
+		if r.Options.FixTermTypo {
+			tokens = r.FixTermTypo(tokens)
+		}
+		switch text.Op {
+		case diff.OpInsert:
+			r.WriteString("<ins>")
+			r.Write(html.EscapeHTML(tokens))
+			r.WriteString("</ins>")
+		case diff.OpDelete:
+			r.WriteString("<del>")
+			r.Write(html.EscapeHTML(tokens))
+			r.WriteString("</del>")
+		default:
+			r.Write(html.EscapeHTML(tokens))
+		}
+	}
+	return ast.WalkContinue
+}
+
+// diffTexts 将修改块的文本变化分配到新语法树中的文本节点上，删除的文本分配到其后的第一个文本节点上。
+//
+// 文本变化不在文本节点中（比如代码块内容有修改）或者新块中没有文本节点时返回 nil。
+func diffTexts(change *diff.BlockChange) (ret map[*ast.Node][]*diff.TextChange) {
+	ret = map[*ast.Node][]*diff.TextChange{}
+	var deletes []*diff.TextChange
+	var last *ast.Node
+	changed := false
+	for _, text := range change.Texts {
+		if ast.NodeText != text.Node.Type {
+			if diff.OpEqual != text.Op {
+				return nil
+			}
+			if nil != last && 0 < len(deletes) {
+				ret[last] = append(ret[last], deletes...)
+				deletes = nil
+			}
+			continue
+		}
+
+		if diff.OpDelete == text.Op {
+			deletes = append(deletes, text)
+			changed = true
+			continue
+		}
+		if diff.OpInsert == text.Op {
+			changed = true
+		}
+		ret[text.Node] = append(ret[text.Node], deletes...)
+		ret[text.Node] = append(ret[text.Node], text)
+		deletes = nil
+		last = text.Node
+	}
+	if !changed {
+		return nil
+	}
+	if 0 < len(deletes) {
+		if nil == last {
+			return nil
+		}
+		ret[last] = append(ret[last], deletes...)
+	}
+	return
+}
@@ -0,0 +1,269 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"strconv"
+)
+
+const (
+	docxTextWidth         = 9026    // A4 纸张减去左右页边距后的正文宽度，单位为 1/20 磅
+	docxListIndent        = 720     // 每一级列表的缩进，单位为 1/20 磅
+	docxEMUPerPixel       = 9525    // 按照 96 DPI 计算的每像素 EMU 数
+	docxMaxImageWidth     = 5731510 // 图片的最大宽度（正文宽度），单位为 EMU
+	docxBulletNumID       = 1       // 无序列表使用的编号实例 ID
+	docxFirstOrderedNumID = 2       // 第一个有序列表使用的编号实例 ID
+
+	docxRelImage     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/image"
+	docxRelHyperlink = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink"
+)
+
+// docxRel 描述了 document.xml.rels 中的一个关系。
+type docxRel struct {
+	id       string
+	typ      string
+	target   string
+	external bool
+}
+
+// docxMedia 描述了嵌入到 word/media 下的一张图片。
+type docxMedia struct {
+	name          string // 文件名，比如 image1.png
+	relID         string
+	data          []byte
+	width, height int // 像素
+}
+
+// extent 返回图片在文档中的宽高，宽度超过正文宽度时等比例缩小，单位为 EMU。
+func (media *docxMedia) extent() (cx, cy int) {
+	cx, cy = media.width*docxEMUPerPixel, media.height*docxEMUPerPixel
+	if cx > docxMaxImageWidth {
+		cy = int(int64(cy) * docxMaxImageWidth / int64(cx))
+		cx = docxMaxImageWidth
+	}
+	return
+}
+
+// addRel 添加一个关系并返回关系 ID，rId1~rId4 被 styles.xml、numbering.xml、footnotes.xml 和 settings.xml 占用。
+func (r *DocxRenderer) addRel(typ, target string, external bool) string {
+	id := "rId" + strconv.Itoa(len(r.rels)+5)
+	r.rels = append(r.rels, &docxRel{id: id, typ: typ, target: target, external: external})
+	return id
+}
+
+// embedImage 嵌入链接地址为 dest 的本地图片，图片不存在或者格式不支持时返回 nil。
+func (r *DocxRenderer) embedImage(dest string) *docxMedia {
+	path := localAssetPath(r.assetsDir, dest)
+	if "" == path {
+		return nil
+	}
+	if media, ok := r.images[path]; ok {
+		return media
+	}
+
+	r.images[path] = nil
+	data, err := os.ReadFile(path)
+	if nil != err {
+		return nil
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if nil != err || 1 > config.Width || 1 > config.Height {
+		return nil
+	}
+
+	media := &docxMedia{name: "image" + strconv.Itoa(len(r.media)+1) + "." + format, data: data, width: config.Width, height: config.Height}
+	media.relID = r.addRel(docxRelImage, "media/"+media.name, false)
+	r.media = append(r.media, media)
+	r.images[path] = media
+	return media
+}
+
+// pack 将渲染结果打包为 .docx 文件。
+func (r *DocxRenderer) pack() []byte {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	write := func(name string, content []byte) {
+		f, err := w.Create(name)
+		if nil == err {
+			_, err = f.Write(content)
+		}
+		if nil != err {
+			panic("write docx part [" + name + "] failed: " + err.Error()) // 通过 RenderE 渲染时会被恢复为错误
+		}
+	}
+
+	write("[Content_Types].xml", r.contentTypes())
+	write("_rels/.rels", []byte(docxXMLHeader+`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`+
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>`+
+		`</Relationships>`))
+	write("word/_rels/document.xml.rels", r.documentRels())
+	write("word/document.xml", r.documentXML())
+	write("word/styles.xml", []byte(docxStyles))
+	write("word/numbering.xml", r.numberingXML())
+	write("word/footnotes.xml", r.footnotesXML())
+	write("word/settings.xml", r.settingsXML())
+	for _, media := range r.media {
+		write("word/media/"+media.name, media.data)
+	}
+	if err := w.Close(); nil != err {
+		panic("write docx failed: " + err.Error())
+	}
+	return buf.Bytes()
+}
+
+func (r *DocxRenderer) contentTypes() []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(docxXMLHeader + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	buf.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	buf.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	for _, format := range []string{"png", "jpeg", "gif"} {
+		buf.WriteString(`<Default Extension="` + format + `" ContentType="image/` + format + `"/>`)
+	}
+	const wordprocessingml = "application/vnd.openxmlformats-officedocument.wordprocessingml."
+	buf.WriteString(`<Override PartName="/word/document.xml" ContentType="` + wordprocessingml + `document.main+xml"/>`)
+	buf.WriteString(`<Override PartName="/word/styles.xml" ContentType="` + wordprocessingml + `styles+xml"/>`)
+	buf.WriteString(`<Override PartName="/word/numbering.xml" ContentType="` + wordprocessingml + `numbering+xml"/>`)
+	buf.WriteString(`<Override PartName="/word/footnotes.xml" ContentType="` + wordprocessingml + `footnotes+xml"/>`)
+	buf.WriteString(`<Override PartName="/word/settings.xml" ContentType="` + wordprocessingml + `settings+xml"/>`)
+	buf.WriteString(`</Types>`)
+	return buf.Bytes()
+}
+
+func (r *DocxRenderer) documentRels() []byte {
+	const officeDocument = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/"
+	buf := &bytes.Buffer{}
+	buf.WriteString(docxXMLHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	buf.WriteString(`<Relationship Id="rId1" Type="` + officeDocument + `styles" Target="styles.xml"/>`)
+	buf.WriteString(`<Relationship Id="rId2" Type="` + officeDocument + `numbering" Target="numbering.xml"/>`)
+	buf.WriteString(`<Relationship Id="rId3" Type="` + officeDocument + `footnotes" Target="footnotes.xml"/>`)
+	buf.WriteString(`<Relationship Id="rId4" Type="` + officeDocument + `settings" Target="settings.xml"/>`)
+	for _, rel := range r.rels {
+		buf.WriteString(`<Relationship Id="` + rel.id + `" Type="` + rel.typ + `" Target="` + docxEscape(rel.target) + `"`)
+		if rel.external {
+			buf.WriteString(` TargetMode="External"`)
+		}
+		buf.WriteString(`/>`)
+	}
+	buf.WriteString(`</Relationships>`)
+	return buf.Bytes()
+}
+
+func (r *DocxRenderer) documentXML() []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(docxXMLHeader + `<w:document ` + docxNamespaces + `><w:body>`)
+	buf.Write(r.document.Bytes())
+	buf.WriteString(`<w:sectPr><w:footnotePr><w:numFmt w:val="decimal"/></w:footnotePr>`)
+	buf.WriteString(`<w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="708" w:footer="708" w:gutter="0"/>`)
+	buf.WriteString(`</w:sectPr></w:body></w:document>`)
+	return buf.Bytes()
+}
+
+func (r *DocxRenderer) numberingXML() []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(docxXMLHeader + `<w:numbering ` + docxNamespaces + `>`)
+	bullets := []string{"•", "◦", "▪"}
+	for abstractNumID := 0; abstractNumID < 2; abstractNumID++ {
+		buf.WriteString(`<w:abstractNum w:abstractNumId="` + strconv.Itoa(abstractNumID) + `"><w:multiLevelType w:val="hybridMultilevel"/>`)
+		for lvl := 0; lvl < 9; lvl++ {
+			buf.WriteString(`<w:lvl w:ilvl="` + strconv.Itoa(lvl) + `"><w:start w:val="1"/>`)
+			if 0 == abstractNumID {
+				buf.WriteString(`<w:numFmt w:val="bullet"/><w:lvlText w:val="` + bullets[lvl%len(bullets)] + `"/>`)
+			} else {
+				buf.WriteString(`<w:numFmt w:val="decimal"/><w:lvlText w:val="%` + strconv.Itoa(lvl+1) + `."/>`)
+			}
+			buf.WriteString(`<w:lvlJc w:val="left"/><w:pPr><w:ind w:left="` + strconv.Itoa(docxListIndent*(lvl+1)) + `" w:hanging="360"/></w:pPr></w:lvl>`)
+		}
+		buf.WriteString(`</w:abstractNum>`)
+	}
+	buf.WriteString(`<w:num w:numId="` + strconv.Itoa(docxBulletNumID) + `"><w:abstractNumId w:val="0"/></w:num>`)
+	for i, start := range r.starts {
+		buf.WriteString(`<w:num w:numId="` + strconv.Itoa(docxFirstOrderedNumID+i) + `"><w:abstractNumId w:val="1"/>`)
+		for lvl := 0; lvl < 9; lvl++ {
+			// 每个有序列表都需要重新开始编号
+			buf.WriteString(`<w:lvlOverride w:ilvl="` + strconv.Itoa(lvl) + `"><w:startOverride w:val="` + strconv.Itoa(start) + `"/></w:lvlOverride>`)
+		}
+		buf.WriteString(`</w:num>`)
+	}
+	buf.WriteString(`</w:numbering>`)
+	return buf.Bytes()
+}
+
+func (r *DocxRenderer) footnotesXML() []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(docxXMLHeader + `<w:footnotes ` + docxNamespaces + `>`)
+	buf.WriteString(`<w:footnote w:type="separator" w:id="-1"><w:p><w:r><w:separator/></w:r></w:p></w:footnote>`)
+	buf.WriteString(`<w:footnote w:type="continuationSeparator" w:id="0"><w:p><w:r><w:continuationSeparator/></w:r></w:p></w:footnote>`)
+	buf.Write(r.footnotes.Bytes())
+	buf.WriteString(`</w:footnotes>`)
+	return buf.Bytes()
+}
+
+func (r *DocxRenderer) settingsXML() []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(docxXMLHeader + `<w:settings ` + docxNamespaces + `>`)
+	if r.toc {
+		// 打开文档时更新目录域
+		buf.WriteString(`<w:updateFields w:val="true"/>`)
+	}
+	buf.WriteString(`<w:defaultTabStop w:val="720"/>`)
+	buf.WriteString(`<w:footnotePr><w:footnote w:id="-1"/><w:footnote w:id="0"/></w:footnotePr>`)
+	buf.WriteString(`<w:compat><w:compatSetting w:name="compatibilityMode" w:uri="http://schemas.microsoft.com/office/word" w:val="15"/></w:compat>`)
+	buf.WriteString(`</w:settings>`)
+	return buf.Bytes()
+}
+
+const docxXMLHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+const docxNamespaces = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" ` +
+	`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+	`xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" ` +
+	`xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" ` +
+	`xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture"`
+
+var docxStyles = docxXMLHeader + `<w:styles ` + docxNamespaces + `>` +
+	`<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:asciiTheme="minorHAnsi" w:eastAsiaTheme="minorEastAsia" w:hAnsiTheme="minorHAnsi" w:cstheme="minorBidi"/><w:sz w:val="22"/><w:szCs w:val="22"/><w:lang w:val="en-US" w:eastAsia="zh-CN"/></w:rPr></w:rPrDefault>` +
+	`<w:pPrDefault><w:pPr><w:spacing w:after="160" w:line="276" w:lineRule="auto"/></w:pPr></w:pPrDefault></w:docDefaults>` +
+	`<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>` +
+	docxHeadingStyle(1, 32) + docxHeadingStyle(2, 28) + docxHeadingStyle(3, 26) + docxHeadingStyle(4, 24) + docxHeadingStyle(5, 22) + docxHeadingStyle(6, 22) +
+	`<w:style w:type="paragraph" w:styleId="Compact"><w:name w:val="Compact"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:spacing w:before="36" w:after="36"/></w:pPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:spacing w:after="0"/><w:contextualSpacing/></w:pPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:pBdr><w:left w:val="single" w:sz="18" w:space="8" w:color="D0D7DE"/></w:pBdr><w:ind w:left="360"/></w:pPr><w:rPr><w:color w:val="57606A"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Callout"><w:name w:val="Callout"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:pBdr><w:left w:val="single" w:sz="18" w:space="8" w:color="0969DA"/></w:pBdr><w:shd w:val="clear" w:color="auto" w:fill="F6F8FA"/><w:ind w:left="360"/></w:pPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="CalloutTitle"><w:name w:val="Callout Title"/><w:basedOn w:val="Callout"/><w:next w:val="Callout"/><w:qFormat/><w:pPr><w:keepNext/></w:pPr><w:rPr><w:b/><w:color w:val="0969DA"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="SourceCode"><w:name w:val="Source Code"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:shd w:val="clear" w:color="auto" w:fill="F6F8FA"/><w:spacing w:after="160" w:line="240" w:lineRule="auto"/></w:pPr><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:sz w:val="20"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="HorizontalRule"><w:name w:val="Horizontal Rule"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="D0D7DE"/></w:pBdr></w:pPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="FootnoteText"><w:name w:val="footnote text"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="0" w:line="240" w:lineRule="auto"/></w:pPr><w:rPr><w:sz w:val="20"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="TOCHeading"><w:name w:val="TOC Heading"/><w:basedOn w:val="Heading1"/><w:next w:val="Normal"/><w:qFormat/></w:style>` +
+	`<w:style w:type="character" w:default="1" w:styleId="DefaultParagraphFont"><w:name w:val="Default Paragraph Font"/><w:uiPriority w:val="1"/><w:semiHidden/></w:style>` +
+	`<w:style w:type="character" w:styleId="VerbatimChar"><w:name w:val="Verbatim Char"/><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:sz w:val="20"/><w:shd w:val="clear" w:color="auto" w:fill="EFF1F3"/></w:rPr></w:style>` +
+	`<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:rPr><w:color w:val="0969DA"/><w:u w:val="single"/></w:rPr></w:style>` +
+	`<w:style w:type="character" w:styleId="FootnoteReference"><w:name w:val="footnote reference"/><w:rPr><w:vertAlign w:val="superscript"/></w:rPr></w:style>` +
+	`<w:style w:type="table" w:default="1" w:styleId="TableNormal"><w:name w:val="Normal Table"/><w:semiHidden/><w:tblPr><w:tblInd w:w="0" w:type="dxa"/><w:tblCellMar><w:top w:w="0" w:type="dxa"/><w:left w:w="108" w:type="dxa"/><w:bottom w:w="0" w:type="dxa"/><w:right w:w="108" w:type="dxa"/></w:tblCellMar></w:tblPr></w:style>` +
+	`<w:style w:type="table" w:styleId="Table"><w:name w:val="Table"/><w:basedOn w:val="TableNormal"/><w:tblPr><w:tblBorders>` +
+	`<w:top w:val="single" w:sz="4" w:space="0" w:color="D0D7DE"/><w:left w:val="single" w:sz="4" w:space="0" w:color="D0D7DE"/><w:bottom w:val="single" w:sz="4" w:space="0" w:color="D0D7DE"/><w:right w:val="single" w:sz="4" w:space="0" w:color="D0D7DE"/>` +
+	`<w:insideH w:val="single" w:sz="4" w:space="0" w:color="D0D7DE"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="D0D7DE"/></w:tblBorders></w:tblPr>` +
+	`<w:tblStylePr w:type="firstRow"><w:tcPr><w:shd w:val="clear" w:color="auto" w:fill="F6F8FA"/></w:tcPr></w:tblStylePr></w:style>` +
+	`</w:styles>`
+
+// docxHeadingStyle 返回 level 级标题的样式，size 为字号，单位为半磅。
+func docxHeadingStyle(level, size int) string {
+	l := strconv.Itoa(level)
+	return `<w:style w:type="paragraph" w:styleId="Heading` + l + `"><w:name w:val="heading ` + l + `"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/>` +
+		`<w:pPr><w:keepNext/><w:keepLines/><w:spacing w:before="240" w:after="120"/><w:outlineLvl w:val="` + strconv.Itoa(level-1) + `"/></w:pPr>` +
+		`<w:rPr><w:b/><w:sz w:val="` + strconv.Itoa(size) + `"/><w:szCs w:val="` + strconv.Itoa(size) + `"/></w:rPr></w:style>`
+}
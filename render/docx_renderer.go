@@ -0,0 +1,789 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/editor"
+	"github.com/88250/lute/lex"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/util"
+)
+
+// 行级格式，用于在输出文本时生成 <w:rPr>。
+const (
+	docxBold = iota
+	docxItalic
+	docxStrike
+	docxUnderline
+	docxHighlight
+	docxCode
+	docxMath
+	docxSuperscript
+	docxSubscript
+	docxHyperlink
+	docxFormatCount
+)
+
+// DocxRenderer 描述了 Word 文档（Office Open XML）渲染器，Render 返回 .docx 文件的内容，不依赖 Pandoc 等外部转换工具。
+//
+// 节点和 Word 结构的对应关系如下：
+//   - 标题使用 Heading1~Heading6 样式，引述和提示块分别使用 Quote 和 Callout 样式，代码块和数学公式块使用 SourceCode 样式
+//   - 列表使用 numbering.xml 中的编号，每个有序列表单独编号，任务列表项使用 ☐ 和 ☒ 表示是否完成
+//   - 表格使用 <w:tbl>，合并单元格（参考 IsMergedCellTable）使用 gridSpan 和 vMerge，被合并的单元格需要使用 fn__none 占位
+//   - 脚注输出到 footnotes.xml，目录使用 TOC 域，打开文档时由 Word 更新
+//   - 本地路径的图片（PNG、JPEG 和 GIF）嵌入到 word/media 下，其他图片使用替代文本
+type DocxRenderer struct {
+	*BaseRenderer
+
+	assetsDir string                  // 图片相对路径的基础目录
+	document  *bytes.Buffer           // document.xml 中 <w:body> 的内容
+	footnotes *bytes.Buffer           // footnotes.xml 中脚注的内容
+	formats   [docxFormatCount]int    // 当前生效的行级格式的嵌套计数
+	rels      []*docxRel              // document.xml.rels 中的图片和超链接关系
+	media     []*docxMedia            // 嵌入的图片
+	images    map[string]*docxMedia   // 图片路径到嵌入图片的映射，同一张图片只嵌入一次
+	nums      map[*ast.Node]int       // 有序列表的编号实例 ID
+	starts    []int                   // 有序列表编号实例的起始序号，下标加 docxFirstOrderedNumID 为编号实例 ID
+	cells     map[*ast.Node]*docxCell // 当前表格单元格的合并信息
+	columns   int                     // 当前表格的列数
+	docPrID   int                     // 图片的 <wp:docPr> ID
+	links     []bool                  // 正在渲染的链接是否输出了 <w:hyperlink>
+	footnote  bool                    // 是否需要在下一个段落开头输出脚注标记
+	toc       bool                    // 是否包含目录
+}
+
+// docxCell 描述了表格单元格在 Word 中的合并信息。
+type docxCell struct {
+	skip     bool   // 是否被左侧的单元格横向合并
+	gridSpan int    // 横向合并的列数
+	vMerge   string // 纵向合并，restart 表示开始合并，continue 表示被上方的单元格合并
+}
+
+// NewDocxRenderer 创建一个 Word 文档渲染器，assetsDir 为图片相对路径的基础目录。
+func NewDocxRenderer(tree *parse.Tree, assetsDir string, options *Options, parseOptions *parse.Options) *DocxRenderer {
+	ret := &DocxRenderer{BaseRenderer: NewBaseRenderer(tree, options, parseOptions), assetsDir: assetsDir,
+		images: map[string]*docxMedia{}, nums: map[*ast.Node]int{}}
+	ret.DefaultRendererFunc = ret.renderChildren
+	ret.RendererFuncs[ast.NodeParagraph] = ret.renderParagraph
+	ret.RendererFuncs[ast.NodeHeading] = ret.renderHeading
+	ret.RendererFuncs[ast.NodeListItem] = ret.renderListItem
+	ret.RendererFuncs[ast.NodeTaskListItemMarker] = ret.renderTaskListItemMarker
+	ret.RendererFuncs[ast.NodeCallout] = ret.renderCallout
+	ret.RendererFuncs[ast.NodeThematicBreak] = ret.renderThematicBreak
+	ret.RendererFuncs[ast.NodeCodeBlock] = ret.renderCodeBlock
+	ret.RendererFuncs[ast.NodeMathBlock] = ret.renderMathBlock
+	ret.RendererFuncs[ast.NodeHTMLBlock] = ret.renderHTMLBlock
+	ret.RendererFuncs[ast.NodeTable] = ret.renderTable
+	ret.RendererFuncs[ast.NodeTableRow] = ret.renderTableRow
+	ret.RendererFuncs[ast.NodeTableCell] = ret.renderTableCell
+	ret.RendererFuncs[ast.NodeFootnotesDefBlock] = ret.renderFootnotesDefBlock
+	ret.RendererFuncs[ast.NodeFootnotesDef] = ret.renderFootnotesDef
+	ret.RendererFuncs[ast.NodeFootnotesRef] = ret.renderFootnotesRef
+	ret.RendererFuncs[ast.NodeToC] = ret.renderToC
+	ret.RendererFuncs[ast.NodeText] = ret.renderText
+	ret.RendererFuncs[ast.NodeLinkText] = ret.renderText
+	ret.RendererFuncs[ast.NodeCodeSpanContent] = ret.renderText
+	ret.RendererFuncs[ast.NodeInlineMathContent] = ret.renderText
+	ret.RendererFuncs[ast.NodeBackslashContent] = ret.renderText
+	ret.RendererFuncs[ast.NodeHTMLEntity] = ret.renderText
+	ret.RendererFuncs[ast.NodeEmojiUnicode] = ret.renderText
+	ret.RendererFuncs[ast.NodeBlockRefText] = ret.renderText
+	ret.RendererFuncs[ast.NodeBlockRefDynamicText] = ret.renderText
+	ret.RendererFuncs[ast.NodeFileAnnotationRefText] = ret.renderText
+	ret.RendererFuncs[ast.NodeEmojiImg] = ret.renderEmojiImg
+	ret.RendererFuncs[ast.NodeSoftBreak] = ret.renderSoftBreak
+	ret.RendererFuncs[ast.NodeHardBreak] = ret.renderHardBreak
+	ret.RendererFuncs[ast.NodeInlineHTML] = ret.renderInlineHTML
+	ret.RendererFuncs[ast.NodeStrong] = ret.renderFormat(docxBold)
+	ret.RendererFuncs[ast.NodeEmphasis] = ret.renderFormat(docxItalic)
+	ret.RendererFuncs[ast.NodeStrikethrough] = ret.renderFormat(docxStrike)
+	ret.RendererFuncs[ast.NodeUnderline] = ret.renderFormat(docxUnderline)
+	ret.RendererFuncs[ast.NodeMark] = ret.renderFormat(docxHighlight)
+	ret.RendererFuncs[ast.NodeCodeSpan] = ret.renderFormat(docxCode)
+	ret.RendererFuncs[ast.NodeKbd] = ret.renderFormat(docxCode)
+	ret.RendererFuncs[ast.NodeInlineMath] = ret.renderFormat(docxMath)
+	ret.RendererFuncs[ast.NodeSup] = ret.renderFormat(docxSuperscript)
+	ret.RendererFuncs[ast.NodeSub] = ret.renderFormat(docxSubscript)
+	ret.RendererFuncs[ast.NodeTextMark] = ret.renderTextMark
+	ret.RendererFuncs[ast.NodeLink] = ret.renderLink
+	ret.RendererFuncs[ast.NodeLinkRefDefBlock] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeYamlFrontMatter] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeKramdownBlockIAL] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeKramdownSpanIAL] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeImage] = ret.renderImage
+	return ret
+}
+
+func (r *DocxRenderer) Render() (output []byte) {
+	r.LastOut = lex.ItemNewline
+	r.document, r.footnotes = &bytes.Buffer{}, &bytes.Buffer{}
+	r.document.Grow(4096)
+	r.Writer = r.document
+	ast.Walk(r.Tree.Root, r.renderNode)
+	return r.pack()
+}
+
+// renderChildren 用于渲染没有对应 Word 结构的节点，仅渲染其子节点。
+func (r *DocxRenderer) renderChildren(node *ast.Node, entering bool) ast.WalkStatus {
+	return ast.WalkContinue
+}
+
+// renderSkip 用于渲染在 Word 文档中不可见的节点。
+func (r *DocxRenderer) renderSkip(node *ast.Node, entering bool) ast.WalkStatus {
+	return ast.WalkSkipChildren
+}
+
+func (r *DocxRenderer) renderParagraph(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startParagraph(node, "")
+	} else {
+		r.WriteString("</w:p>")
+	}
+	return ast.WalkContinue
+}
+
+func (r *DocxRenderer) renderHeading(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startParagraph(node, "Heading"+strconv.Itoa(node.HeadingLevel))
+	} else {
+		r.WriteString("</w:p>")
+	}
+	return ast.WalkContinue
+}
+
+func (r *DocxRenderer) renderListItem(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering && nil == node.FirstChild {
+		// 空的列表项也需要输出编号
+		r.startParagraph(node, "")
+		r.WriteString("</w:p>")
+	}
+	return ast.WalkContinue
+}
+
+func (r *DocxRenderer) renderTaskListItemMarker(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if node.TaskListItemChecked {
+			r.writeText("☒")
+		} else {
+			r.writeText("☐")
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *DocxRenderer) renderCallout(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		title := strings.TrimSpace(node.CalloutIcon + " " + node.CalloutTitle)
+		if "" == title {
+			title = node.CalloutType
+		}
+		r.startParagraph(node, "CalloutTitle")
+		r.writeText(title)
+		r.WriteString("</w:p>")
+	}
+	return ast.WalkContinue
+}
+
+func (r *DocxRenderer) renderThematicBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startParagraph(node, "HorizontalRule")
+		r.WriteString("</w:p>")
+	}
+	return ast.WalkContinue
+}
+
+func (r *DocxRenderer) renderCodeBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		code := node.ChildByType(ast.NodeCodeBlockCode)
+		r.startParagraph(node, "SourceCode")
+		if nil != code {
+			r.formats[docxCode]++
+			r.writeText(strings.TrimSuffix(util.BytesToStr(bytes.ReplaceAll(code.Tokens, editor.CaretTokens, nil)), "\n"))
+			r.formats[docxCode]--
+		}
+		r.WriteString("</w:p>")
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *DocxRenderer) renderMathBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		content := node.ChildByType(ast.NodeMathBlockContent)
+		r.startParagraph(node, "SourceCode")
+		if nil != content {
+			r.formats[docxMath]++
+			r.writeText(strings.TrimSpace(util.BytesToStr(content.Tokens)))
+			r.formats[docxMath]--
+		}
+		r.WriteString("</w:p>")
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *DocxRenderer) renderHTMLBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		tokens := bytes.TrimSpace(node.Tokens)
+		if 1 > len(tokens) || bytes.HasPrefix(tokens, []byte("<!--")) {
+			return ast.WalkSkipChildren
+		}
+		r.startParagraph(node, "SourceCode")
+		r.formats[docxCode]++
+		r.writeText(util.BytesToStr(tokens))
+		r.formats[docxCode]--
+		r.WriteString("</w:p>")
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *DocxRenderer) renderToC(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.toc = true
+		r.startParagraph(node, "")
+		r.WriteString("<w:r><w:fldChar w:fldCharType=\"begin\" w:dirty=\"true\"/></w:r>")
+		opts := r.tocOptions(node)
+		r.WriteString("<w:r><w:instrText xml:space=\"preserve\"> TOC \\o \"" + strconv.Itoa(opts.minLevel) + "-" + strconv.Itoa(opts.maxLevel) + "\" \\h \\z \\u </w:instrText></w:r>")
+		r.WriteString("<w:r><w:fldChar w:fldCharType=\"separate\"/></w:r>")
+		r.WriteString("<w:r><w:fldChar w:fldCharType=\"end\"/></w:r>")
+		r.WriteString("</w:p>")
+	}
+	return ast.WalkSkipChildren
+}
+
+// startParagraph 开始输出块 node 对应的段落，style 为空时根据 node 所在的容器块确定段落样式。
+func (r *DocxRenderer) startParagraph(node *ast.Node, style string) {
+	r.WriteString("<w:p>")
+	pPr := &bytes.Buffer{}
+	if "" == style {
+		style = r.paragraphStyle(node)
+	}
+	if "" != style {
+		pPr.WriteString("<w:pStyle w:val=\"" + style + "\"/>")
+	}
+
+	if item, depth := r.listItem(node); nil != item {
+		list := item.Parent
+		first := item == node || item.FirstChild == node
+		if first && 3 != list.ListData.Typ {
+			pPr.WriteString("<w:numPr><w:ilvl w:val=\"" + strconv.Itoa(depth) + "\"/><w:numId w:val=\"" + strconv.Itoa(r.numID(list)) + "\"/></w:numPr>")
+		} else {
+			// 列表项中的后续块和任务列表项没有编号，只缩进到列表项内容的位置
+			pPr.WriteString("<w:ind w:left=\"" + strconv.Itoa(docxListIndent*(depth+1)) + "\"/>")
+		}
+	}
+
+	if nil != node.Parent && ast.NodeTableCell == node.Type {
+		switch node.TableCellAlign {
+		case 2:
+			pPr.WriteString("<w:jc w:val=\"center\"/>")
+		case 3:
+			pPr.WriteString("<w:jc w:val=\"right\"/>")
+		}
+	}
+
+	if 0 < pPr.Len() {
+		r.WriteString("<w:pPr>")
+		r.Write(pPr.Bytes())
+		r.WriteString("</w:pPr>")
+	}
+
+	if r.footnote {
+		r.footnote = false
+		r.WriteString("<w:r><w:rPr><w:rStyle w:val=\"FootnoteReference\"/></w:rPr><w:footnoteRef/></w:r><w:r><w:t xml:space=\"preserve\"> </w:t></w:r>")
+	}
+}
+
+// paragraphStyle 根据块 node 所在的容器块返回段落样式。
+func (r *DocxRenderer) paragraphStyle(node *ast.Node) string {
+	for p := node.Parent; nil != p; p = p.Parent {
+		switch p.Type {
+		case ast.NodeBlockquote:
+			return "Quote"
+		case ast.NodeCallout:
+			return "Callout"
+		case ast.NodeFootnotesDef:
+			return "FootnoteText"
+		case ast.NodeTableCell:
+			return "Compact"
+		case ast.NodeListItem:
+			if p.Parent.ListData.Tight {
+				return "ListParagraph"
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// listItem 返回块 node 所在的列表项以及列表的嵌套层级（从 0 开始），不在列表中时返回 nil。
+func (r *DocxRenderer) listItem(node *ast.Node) (item *ast.Node, depth int) {
+	for p := node; nil != p; p = p.Parent {
+		switch p.Type {
+		case ast.NodeListItem:
+			if nil == item {
+				item = p
+			}
+		case ast.NodeList:
+			if nil != item {
+				depth++
+			}
+		case ast.NodeBlockquote, ast.NodeCallout, ast.NodeTableCell, ast.NodeFootnotesDef:
+			if nil == item {
+				return
+			}
+		}
+	}
+	if nil != item {
+		depth--
+		if 8 < depth {
+			depth = 8
+		}
+	}
+	return
+}
+
+// numID 返回列表 list 使用的编号实例 ID，无序列表共用一个编号实例，每个有序列表使用单独的编号实例以便重新开始编号。
+func (r *DocxRenderer) numID(list *ast.Node) int {
+	if 1 != list.ListData.Typ {
+		return docxBulletNumID
+	}
+	if id, ok := r.nums[list]; ok {
+		return id
+	}
+	start := list.ListData.Start
+	if 1 > start {
+		start = 1
+	}
+	id := docxFirstOrderedNumID + len(r.starts)
+	r.starts = append(r.starts, start)
+	r.nums[list] = id
+	return id
+}
+
+func (r *DocxRenderer) renderTable(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.WriteString("</w:tbl>")
+		// 相邻的表格在 Word 中会被合并，所以使用一个空段落分隔
+		r.WriteString("<w:p/>")
+		r.cells = nil
+		return ast.WalkContinue
+	}
+
+	r.cells, r.columns = map[*ast.Node]*docxCell{}, 0
+	var rows []*ast.Node
+	for n := node.FirstChild; nil != n; n = n.Next {
+		if ast.NodeTableHead == n.Type {
+			rows = append(rows, n.ChildrenByType(ast.NodeTableRow)...)
+		} else if ast.NodeTableRow == n.Type {
+			rows = append(rows, n)
+		}
+	}
+	merged := r.IsMergedCellTable(node)
+	pending, spans := map[int]int{}, map[int]int{}
+	for _, row := range rows {
+		col, skip := 0, 0
+		for cell := row.FirstChild; nil != cell; cell = cell.Next {
+			if ast.NodeTableCell != cell.Type {
+				continue
+			}
+			if 0 < skip {
+				r.cells[cell] = &docxCell{skip: true}
+				skip--
+				continue
+			}
+
+			c := &docxCell{gridSpan: 1}
+			r.cells[cell] = c
+			if 0 < pending[col] {
+				pending[col]--
+				c.gridSpan, c.vMerge = spans[col], "continue"
+			} else if merged {
+				if colspan, _ := strconv.Atoi(cell.IALAttr("colspan")); 1 < colspan {
+					c.gridSpan = colspan
+				}
+				if rowspan, _ := strconv.Atoi(cell.IALAttr("rowspan")); 1 < rowspan {
+					c.vMerge = "restart"
+					pending[col], spans[col] = rowspan-1, c.gridSpan
+				}
+			}
+			skip = c.gridSpan - 1
+			col += c.gridSpan
+		}
+		if col > r.columns {
+			r.columns = col
+		}
+	}
+	if 1 > r.columns {
+		r.columns = 1
+	}
+
+	r.WriteString("<w:tbl><w:tblPr><w:tblStyle w:val=\"Table\"/><w:tblW w:w=\"5000\" w:type=\"pct\"/><w:tblLook w:val=\"04A0\" w:firstRow=\"1\" w:lastRow=\"0\" w:firstColumn=\"0\" w:lastColumn=\"0\" w:noHBand=\"0\" w:noVBand=\"1\"/></w:tblPr>")
+	r.WriteString("<w:tblGrid>")
+	width := strconv.Itoa(docxTextWidth / r.columns)
+	for i := 0; i < r.columns; i++ {
+		r.WriteString("<w:gridCol w:w=\"" + width + "\"/>")
+	}
+	r.WriteString("</w:tblGrid>")
+	return ast.WalkContinue
+}
+
+func (r *DocxRenderer) renderTableRow(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.WriteString("<w:tr>")
+		if ast.NodeTableHead == node.Parent.Type {
+			r.WriteString("<w:trPr><w:tblHeader/></w:trPr>")
+			r.formats[docxBold]++
+		}
+	} else {
+		if ast.NodeTableHead == node.Parent.Type {
+			r.formats[docxBold]--
+		}
+		r.WriteString("</w:tr>")
+	}
+	return ast.WalkContinue
+}
+
+func (r *DocxRenderer) renderTableCell(node *ast.Node, entering bool) ast.WalkStatus {
+	c := r.cells[node]
+	if nil == c {
+		c = &docxCell{gridSpan: 1}
+	}
+	if c.skip {
+		return ast.WalkSkipChildren
+	}
+
+	if !entering {
+		if "continue" != c.vMerge {
+			r.WriteString("</w:p></w:tc>")
+		}
+		return ast.WalkContinue
+	}
+
+	r.WriteString("<w:tc><w:tcPr>")
+	r.WriteString("<w:tcW w:w=\"" + strconv.Itoa(docxTextWidth/r.columns*c.gridSpan) + "\" w:type=\"dxa\"/>")
+	if 1 < c.gridSpan {
+		r.WriteString("<w:gridSpan w:val=\"" + strconv.Itoa(c.gridSpan) + "\"/>")
+	}
+	switch c.vMerge {
+	case "restart":
+		r.WriteString("<w:vMerge w:val=\"restart\"/>")
+	case "continue":
+		r.WriteString("<w:vMerge/></w:tcPr><w:p/></w:tc>")
+		return ast.WalkSkipChildren
+	}
+	r.WriteString("</w:tcPr>")
+	r.startParagraph(node, "")
+	return ast.WalkContinue
+}
+
+func (r *DocxRenderer) renderFootnotesDefBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.Writer = r.footnotes
+	} else {
+		r.Writer = r.document
+	}
+	return ast.WalkContinue
+}
+
+func (r *DocxRenderer) renderFootnotesDef(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		idx, _ := r.Tree.FindFootnotesDef(node.Tokens)
+		r.WriteString("<w:footnote w:id=\"" + strconv.Itoa(idx) + "\">")
+		r.footnote = true
+		if nil == node.FirstChild {
+			r.startParagraph(node, "FootnoteText")
+			r.WriteString("</w:p>")
+		}
+	} else {
+		r.footnote = false
+		r.WriteString("</w:footnote>")
+	}
+	return ast.WalkContinue
+}
+
+func (r *DocxRenderer) renderFootnotesRef(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		idx, def := r.Tree.FindFootnotesDef(node.Tokens)
+		if nil == def {
+			r.writeText("[^" + util.BytesToStr(node.Tokens) + "]")
+			return ast.WalkSkipChildren
+		}
+		r.WriteString("<w:r><w:rPr><w:rStyle w:val=\"FootnoteReference\"/></w:rPr><w:footnoteReference w:id=\"" + strconv.Itoa(idx) + "\"/></w:r>")
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *DocxRenderer) renderText(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		tokens := bytes.ReplaceAll(node.Tokens, editor.CaretTokens, nil)
+		if ast.NodeText == node.Type || ast.NodeLinkText == node.Type {
+			if r.Options.AutoSpace {
+				tokens = r.Space(tokens)
+			}
+			if r.Options.FixTermTypo {
+				tokens = r.FixTermTypo(tokens)
+			}
+		}
+		r.writeText(util.BytesToStr(tokens))
+	}
+	return ast.WalkContinue
+}
+
+func (r *DocxRenderer) renderEmojiImg(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if alias := node.ChildByType(ast.NodeEmojiAlias); nil != alias {
+			r.writeText(util.BytesToStr(alias.Tokens))
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *DocxRenderer) renderSoftBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if r.Options.SoftBreak2HardBreak {
+			r.WriteString("<w:r><w:br/></w:r>")
+		} else {
+			r.writeText(" ")
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *DocxRenderer) renderHardBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.WriteString("<w:r><w:br/></w:r>")
+	}
+	return ast.WalkContinue
+}
+
+func (r *DocxRenderer) renderInlineHTML(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering && bytes.HasPrefix(bytes.ToLower(node.Tokens), []byte("<br")) {
+		r.WriteString("<w:r><w:br/></w:r>")
+	}
+	return ast.WalkContinue
+}
+
+// renderFormat 返回一个渲染函数，在渲染节点的子节点时使用行级格式 format。
+func (r *DocxRenderer) renderFormat(format int) RendererFunc {
+	return func(node *ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			r.formats[format]++
+		} else {
+			r.formats[format]--
+		}
+		return ast.WalkContinue
+	}
+}
+
+func (r *DocxRenderer) renderTextMark(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	var formats []int
+	for _, typ := range strings.Split(node.TextMarkType, " ") {
+		switch typ {
+		case "strong":
+			formats = append(formats, docxBold)
+		case "em":
+			formats = append(formats, docxItalic)
+		case "s":
+			formats = append(formats, docxStrike)
+		case "u":
+			formats = append(formats, docxUnderline)
+		case "mark":
+			formats = append(formats, docxHighlight)
+		case "code", "kbd":
+			formats = append(formats, docxCode)
+		case "inline-math":
+			formats = append(formats, docxMath)
+		case "sup":
+			formats = append(formats, docxSuperscript)
+		case "sub":
+			formats = append(formats, docxSubscript)
+		}
+	}
+	for _, format := range formats {
+		r.formats[format]++
+	}
+	text := node.TextMarkTextContent
+	if node.IsTextMarkType("inline-math") {
+		text = node.TextMarkInlineMathContent
+	}
+	if node.IsTextMarkType("a") && r.startHyperlink(node.TextMarkAHref) {
+		r.writeText(text)
+		r.endHyperlink()
+	} else {
+		r.writeText(text)
+	}
+	for _, format := range formats {
+		r.formats[format]--
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *DocxRenderer) renderLink(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		last := len(r.links) - 1
+		if r.links[last] {
+			r.endHyperlink()
+		}
+		r.links = r.links[:last]
+		return ast.WalkContinue
+	}
+
+	dest := node.ChildByType(ast.NodeLinkDest)
+	started := nil != dest && r.startHyperlink(util.BytesToStr(r.LinkPath(dest.Tokens)))
+	r.links = append(r.links, started)
+	if started && "" == node.Text() {
+		r.writeText(util.BytesToStr(dest.Tokens))
+	}
+	return ast.WalkContinue
+}
+
+// startHyperlink 开始输出链接到 dest 的超链接，dest 为空时不输出并返回 false。
+func (r *DocxRenderer) startHyperlink(dest string) bool {
+	dest = strings.TrimSpace(dest)
+	if "" == dest {
+		return false
+	}
+
+	if strings.HasPrefix(dest, "#") {
+		r.WriteString("<w:hyperlink w:anchor=\"" + docxEscape(dest[1:]) + "\">")
+	} else {
+		r.WriteString("<w:hyperlink r:id=\"" + r.addRel(docxRelHyperlink, dest, true) + "\">")
+	}
+	r.formats[docxHyperlink]++
+	return true
+}
+
+func (r *DocxRenderer) endHyperlink() {
+	r.formats[docxHyperlink]--
+	r.WriteString("</w:hyperlink>")
+}
+
+func (r *DocxRenderer) renderImage(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	alt := imageAlt(node)
+	var destStr string
+	if dest := node.ChildByType(ast.NodeLinkDest); nil != dest {
+		destStr = strings.TrimSpace(util.BytesToStr(dest.Tokens))
+	}
+	media := r.embedImage(destStr)
+	if nil == media {
+		// 远程图片和 assetsDir 以外的图片不嵌入，输出为链接到原地址的超链接
+		if "" != destStr && !strings.HasPrefix(destStr, "data:") && "" == localAssetPath(r.assetsDir, destStr) &&
+			1 > r.formats[docxHyperlink] && r.startHyperlink(destStr) {
+			if "" == alt {
+				alt = destStr
+			}
+			r.writeText(alt)
+			r.endHyperlink()
+			return ast.WalkSkipChildren
+		}
+		r.writeText(alt)
+		return ast.WalkSkipChildren
+	}
+
+	r.docPrID++
+	id := strconv.Itoa(r.docPrID)
+	cx, cy := media.extent()
+	extent := "cx=\"" + strconv.Itoa(cx) + "\" cy=\"" + strconv.Itoa(cy) + "\""
+	r.WriteString("<w:r><w:drawing><wp:inline distT=\"0\" distB=\"0\" distL=\"0\" distR=\"0\">")
+	r.WriteString("<wp:extent " + extent + "/>")
+	r.WriteString("<wp:docPr id=\"" + id + "\" name=\"Picture " + id + "\" descr=\"" + docxEscape(alt) + "\"/>")
+	r.WriteString("<wp:cNvGraphicFramePr><a:graphicFrameLocks noChangeAspect=\"1\"/></wp:cNvGraphicFramePr>")
+	r.WriteString("<a:graphic><a:graphicData uri=\"http://schemas.openxmlformats.org/drawingml/2006/picture\"><pic:pic>")
+	r.WriteString("<pic:nvPicPr><pic:cNvPr id=\"" + id + "\" name=\"" + media.name + "\"/><pic:cNvPicPr/></pic:nvPicPr>")
+	r.WriteString("<pic:blipFill><a:blip r:embed=\"" + media.relID + "\"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>")
+	r.WriteString("<pic:spPr><a:xfrm><a:off x=\"0\" y=\"0\"/><a:ext " + extent + "/></a:xfrm><a:prstGeom prst=\"rect\"><a:avLst/></a:prstGeom></pic:spPr>")
+	r.WriteString("</pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r>")
+	return ast.WalkSkipChildren
+}
+
+// writeText 使用当前生效的行级格式输出文本 text。
+func (r *DocxRenderer) writeText(text string) {
+	if "" == text {
+		return
+	}
+
+	r.WriteString("<w:r>")
+	r.writeRunProperties()
+	for i, line := range strings.Split(text, "\n") {
+		if 0 < i {
+			r.WriteString("<w:br/>")
+		}
+		for j, segment := range strings.Split(line, "\t") {
+			if 0 < j {
+				r.WriteString("<w:tab/>")
+			}
+			if "" != segment {
+				r.WriteString("<w:t xml:space=\"preserve\">")
+				r.WriteString(docxEscape(segment))
+				r.WriteString("</w:t>")
+			}
+		}
+	}
+	r.WriteString("</w:r>")
+}
+
+func (r *DocxRenderer) writeRunProperties() {
+	f := &r.formats
+	rPr := &bytes.Buffer{}
+	if 0 < f[docxHyperlink] {
+		rPr.WriteString("<w:rStyle w:val=\"Hyperlink\"/>")
+	} else if 0 < f[docxCode] {
+		rPr.WriteString("<w:rStyle w:val=\"VerbatimChar\"/>")
+	}
+	if 0 < f[docxMath] {
+		rPr.WriteString("<w:rFonts w:ascii=\"Cambria Math\" w:hAnsi=\"Cambria Math\"/>")
+	}
+	if 0 < f[docxBold] {
+		rPr.WriteString("<w:b/>")
+	}
+	if 0 < f[docxItalic] || 0 < f[docxMath] {
+		rPr.WriteString("<w:i/>")
+	}
+	if 0 < f[docxStrike] {
+		rPr.WriteString("<w:strike/>")
+	}
+	if 0 < f[docxHighlight] {
+		rPr.WriteString("<w:highlight w:val=\"yellow\"/>")
+	}
+	if 0 < f[docxUnderline] {
+		rPr.WriteString("<w:u w:val=\"single\"/>")
+	}
+	if 0 < f[docxSuperscript] {
+		rPr.WriteString("<w:vertAlign w:val=\"superscript\"/>")
+	} else if 0 < f[docxSubscript] {
+		rPr.WriteString("<w:vertAlign w:val=\"subscript\"/>")
+	}
+	if 0 < rPr.Len() {
+		r.WriteString("<w:rPr>")
+		r.Write(rPr.Bytes())
+		r.WriteString("</w:rPr>")
+	}
+}
+
+// docxEscape 转义 XML 文本，XML 中不允许出现的字符会被替换为 U+FFFD。
+func docxEscape(text string) string {
+	buf := &bytes.Buffer{}
+	xml.EscapeText(buf, []byte(text))
+	return buf.String()
+}
@@ -0,0 +1,323 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/html"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/util"
+)
+
+// EpubMetadata 描述了 EPUB 电子书的元数据。
+type EpubMetadata struct {
+	Identifier string    // 唯一标识，为空时生成一个 urn:uuid
+	Title      string    // 书名，为空时使用第一章的标题
+	Language   string    // 语言，为空时使用 en
+	Author     string    // 作者，为空时不输出
+	Modified   time.Time // 修改时间，为零值时使用当前时间
+}
+
+// epubMediaTypes 为 EPUB 核心媒体类型中的图片格式。
+var epubMediaTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".svg":  "image/svg+xml",
+	".webp": "image/webp",
+}
+
+// EpubRenderer 描述了 EPUB 3 渲染器，Render 返回 .epub 文件的内容。
+//
+// 每棵语法树作为一章，使用 HtmlRenderer 渲染为 XHTML，目录 nav.xhtml 使用和 [toc] 相同的标题数据生成，没有标题的章节使用章节标题。
+// 本地路径的图片会被打包到 images 下，其他图片保持原样。原始 HTML 会重新序列化为 XHTML，无法配对的行级标签转义输出为文本。
+type EpubRenderer struct {
+	metadata     *EpubMetadata
+	trees        []*parse.Tree
+	assetsDir    string // 图片相对路径的基础目录
+	options      *Options
+	parseOptions *parse.Options
+
+	chapters []*epubChapter
+	media    []*epubMedia
+	images   map[string]*epubMedia // 图片路径到打包图片的映射，同一张图片只打包一次
+}
+
+// epubChapter 描述了一个章节。
+type epubChapter struct {
+	name     string     // 文件名
+	title    string     // 章节标题
+	content  []byte     // XHTML 正文
+	headings []*Heading // 章节中的标题
+}
+
+// epubMedia 描述了打包的图片。
+type epubMedia struct {
+	name      string // 文件名，相对于 images 目录
+	mediaType string
+	data      []byte
+}
+
+// NewEpubRenderer 创建一个 EPUB 渲染器，trees 中的语法树依次作为各章，assetsDir 为图片相对路径的基础目录。
+func NewEpubRenderer(trees []*parse.Tree, metadata *EpubMetadata, assetsDir string, options *Options, parseOptions *parse.Options) *EpubRenderer {
+	if nil == metadata {
+		metadata = &EpubMetadata{}
+	}
+	return &EpubRenderer{metadata: metadata, trees: trees, assetsDir: assetsDir, options: options, parseOptions: parseOptions,
+		images: map[string]*epubMedia{}}
+}
+
+func (r *EpubRenderer) Render() (output []byte) {
+	r.chapters, r.media, r.images = nil, nil, map[string]*epubMedia{}
+	for i, tree := range r.trees {
+		r.chapters = append(r.chapters, r.renderChapter(i+1, tree))
+	}
+	return r.pack()
+}
+
+// renderChapter 渲染第 num 章。
+func (r *EpubRenderer) renderChapter(num int, tree *parse.Tree) (ret *epubChapter) {
+	options := *r.options
+	options.HeadingID = true // 目录需要链接到标题
+	options.HeadingAnchor = false
+	options.LinkBase, options.LinkPrefix = "", ""
+	options.ImageLazyLoading = ""
+	renderer := NewHtmlRenderer(tree, &options, r.parseOptions)
+	renderer.RendererFuncs[ast.NodeImage] = func(node *ast.Node, entering bool) ast.WalkStatus {
+		dest := node.ChildByType(ast.NodeLinkDest)
+		if !entering || nil == dest {
+			return renderer.renderImage(node, entering)
+		}
+
+		media := r.addImage(util.BytesToStr(dest.Tokens))
+		if nil == media {
+			return renderer.renderImage(node, entering)
+		}
+		tokens := dest.Tokens
+		dest.Tokens = []byte("images/" + media.name)
+		defer func() { dest.Tokens = tokens }()
+		return renderer.renderImage(node, entering)
+	}
+
+	renderer.RendererFuncs[ast.NodeHTMLBlock] = func(node *ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			renderer.Newline()
+			tokens := node.Tokens
+			if renderer.Options.Sanitize {
+				tokens = sanitize(tokens)
+			}
+			renderer.Write(xhtmlBlock(renderer.tagSrcPath(tokens)))
+			renderer.Newline()
+		}
+		return ast.WalkContinue
+	}
+	renderer.RendererFuncs[ast.NodeInlineHTML] = func(node *ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			if renderer.Options.Sanitize {
+				tokens := node.Tokens
+				node.Tokens = sanitize(tokens)
+				defer func() { node.Tokens = tokens }()
+			}
+			renderer.Write(xhtmlInline(node))
+		}
+		return ast.WalkContinue
+	}
+
+	ret = &epubChapter{name: "chapter" + strconv.Itoa(num) + ".xhtml", content: renderer.Render(), headings: renderer.headings()}
+	ret.title = tree.Name
+	if "" == ret.title {
+		if heading := tree.Root.ChildByType(ast.NodeHeading); nil != heading {
+			ret.title = strings.TrimSpace(heading.Text())
+		}
+	}
+	if "" == ret.title {
+		ret.title = "Chapter " + strconv.Itoa(num)
+	}
+	return
+}
+
+// addImage 打包链接地址为 dest 的本地图片，dest 不是本地路径、文件无法读取或者不是 EPUB 支持的图片格式时返回 nil。
+func (r *EpubRenderer) addImage(dest string) *epubMedia {
+	path := localAssetPath(r.assetsDir, dest)
+	if "" == path {
+		return nil
+	}
+	if media, ok := r.images[path]; ok {
+		return media
+	}
+
+	r.images[path] = nil
+	ext := strings.ToLower(filepath.Ext(path))
+	mediaType := epubMediaTypes[ext]
+	if "" == mediaType {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if nil != err {
+		return nil
+	}
+
+	media := &epubMedia{name: "image" + strconv.Itoa(len(r.media)+1) + ext, mediaType: mediaType, data: data}
+	r.media = append(r.media, media)
+	r.images[path] = media
+	return media
+}
+
+// pack 将渲染结果打包为 .epub 文件。
+func (r *EpubRenderer) pack() []byte {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	write := func(name string, content []byte, method uint16) {
+		f, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		if nil == err {
+			_, err = f.Write(content)
+		}
+		if nil != err {
+			panic("write epub file [" + name + "] failed: " + err.Error()) // 通过 RenderE 渲染时会被恢复为错误
+		}
+	}
+
+	// mimetype 必须是第一个文件并且不能压缩
+	write("mimetype", []byte("application/epub+zip"), zip.Store)
+	write("META-INF/container.xml", []byte(epubXMLHeader+`<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">`+
+		`<rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`), zip.Deflate)
+	write("OEBPS/content.opf", r.opf(), zip.Deflate)
+	write("OEBPS/nav.xhtml", r.nav(), zip.Deflate)
+	for _, chapter := range r.chapters {
+		write("OEBPS/"+chapter.name, r.xhtml(chapter.title, chapter.content), zip.Deflate)
+	}
+	for _, media := range r.media {
+		write("OEBPS/images/"+media.name, media.data, zip.Deflate)
+	}
+	if err := w.Close(); nil != err {
+		panic("write epub failed: " + err.Error())
+	}
+	return buf.Bytes()
+}
+
+const epubXMLHeader = "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n"
+
+func (r *EpubRenderer) language() string {
+	if "" == r.metadata.Language {
+		return "en"
+	}
+	return r.metadata.Language
+}
+
+func (r *EpubRenderer) title() string {
+	if "" == r.metadata.Title && 0 < len(r.chapters) {
+		return r.chapters[0].title
+	}
+	return r.metadata.Title
+}
+
+func (r *EpubRenderer) opf() []byte {
+	identifier := r.metadata.Identifier
+	if "" == identifier {
+		identifier = epubUUID()
+	}
+	modified := r.metadata.Modified
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(epubXMLHeader)
+	buf.WriteString(`<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="uid" xml:lang="` + html.EscapeHTMLStr(r.language()) + `">`)
+	buf.WriteString(`<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">`)
+	buf.WriteString(`<dc:identifier id="uid">` + html.EscapeHTMLStr(identifier) + `</dc:identifier>`)
+	buf.WriteString(`<dc:title>` + html.EscapeHTMLStr(r.title()) + `</dc:title>`)
+	buf.WriteString(`<dc:language>` + html.EscapeHTMLStr(r.language()) + `</dc:language>`)
+	if "" != r.metadata.Author {
+		buf.WriteString(`<dc:creator>` + html.EscapeHTMLStr(r.metadata.Author) + `</dc:creator>`)
+	}
+	buf.WriteString(`<meta property="dcterms:modified">` + modified.UTC().Format("2006-01-02T15:04:05Z") + `</meta>`)
+	buf.WriteString(`</metadata>`)
+
+	buf.WriteString(`<manifest>`)
+	buf.WriteString(`<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>`)
+	for i, chapter := range r.chapters {
+		buf.WriteString(`<item id="chapter` + strconv.Itoa(i+1) + `" href="` + chapter.name + `" media-type="application/xhtml+xml"/>`)
+	}
+	for i, media := range r.media {
+		buf.WriteString(`<item id="image` + strconv.Itoa(i+1) + `" href="images/` + media.name + `" media-type="` + media.mediaType + `"/>`)
+	}
+	buf.WriteString(`</manifest>`)
+
+	buf.WriteString(`<spine>`)
+	for i := range r.chapters {
+		buf.WriteString(`<itemref idref="chapter` + strconv.Itoa(i+1) + `"/>`)
+	}
+	buf.WriteString(`</spine></package>`)
+	return buf.Bytes()
+}
+
+// nav 生成目录，每章的标题按照层级嵌套，没有标题的章节使用章节标题。
+func (r *EpubRenderer) nav() []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(`<nav epub:type="toc" id="toc"><h1>` + html.EscapeHTMLStr(r.title()) + `</h1><ol>`)
+	for _, chapter := range r.chapters {
+		if 1 > len(chapter.headings) {
+			buf.WriteString(`<li><a href="` + chapter.name + `">` + html.EscapeHTMLStr(chapter.title) + `</a></li>`)
+			continue
+		}
+		for _, heading := range chapter.headings {
+			r.navItem(buf, chapter.name, heading)
+		}
+	}
+	buf.WriteString(`</ol></nav>`)
+	return r.xhtml(r.title(), buf.Bytes())
+}
+
+func (r *EpubRenderer) navItem(buf *bytes.Buffer, href string, heading *Heading) {
+	buf.WriteString(`<li><a href="` + href + `#` + html.EscapeHTMLStr(heading.ID) + `">` + heading.Content + `</a>`)
+	if 0 < len(heading.Children) {
+		buf.WriteString(`<ol>`)
+		for _, child := range heading.Children {
+			r.navItem(buf, href, child)
+		}
+		buf.WriteString(`</ol>`)
+	}
+	buf.WriteString(`</li>`)
+}
+
+// xhtml 返回标题为 title、正文为 body 的 XHTML 文档。
+func (r *EpubRenderer) xhtml(title string, body []byte) []byte {
+	lang := html.EscapeHTMLStr(r.language())
+	buf := &bytes.Buffer{}
+	buf.WriteString(epubXMLHeader)
+	buf.WriteString("<!DOCTYPE html>\n")
+	buf.WriteString(`<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="` + lang + `" lang="` + lang + `">` + "\n")
+	buf.WriteString("<head>\n<meta charset=\"UTF-8\" />\n<title>" + html.EscapeHTMLStr(title) + "</title>\n</head>\n<body>\n")
+	buf.Write(body)
+	buf.WriteString("\n</body>\n</html>\n")
+	return buf.Bytes()
+}
+
+// epubUUID 生成一个随机的 urn:uuid。
+func epubUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,119 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/html"
+	"github.com/88250/lute/html/atom"
+)
+
+// xhtmlVoidElements 为 HTML 中的空元素，输出为 XHTML 时需要自闭合。
+var xhtmlVoidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true, "input": true,
+	"keygen": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// xhtmlBlock 将 HTML 块 tokens 解析后重新序列化为 XHTML：空元素自闭合，属性值使用引号包裹，未闭合的元素补全结束标签。
+func xhtmlBlock(tokens []byte) []byte {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(bytes.NewReader(tokens), body)
+	if nil != err {
+		return html.EscapeHTML(tokens)
+	}
+
+	buf := &bytes.Buffer{}
+	for _, n := range nodes {
+		if html.DoctypeNode == n.Type {
+			continue
+		}
+		if err = html.Render(buf, n); nil != err {
+			return html.EscapeHTML(tokens)
+		}
+	}
+	return buf.Bytes()
+}
+
+// xhtmlInline 将行级 HTML 节点 node 输出为 XHTML。
+//
+// 行级 HTML 节点仅包含一个标签，开始标签和结束标签只有在同一个父节点下配对时才输出为标签，否则会导致 XHTML 元素交叉或者未闭合，
+// 这时和无法识别的内容一样转义输出为文本。
+func xhtmlInline(node *ast.Node) []byte {
+	z := html.NewTokenizer(bytes.NewReader(node.Tokens))
+	tt := z.Next()
+	token := z.Token()
+	switch tt {
+	case html.CommentToken:
+		return []byte("<!--" + strings.ReplaceAll(token.Data, "--", "- -") + "-->")
+	case html.SelfClosingTagToken:
+		return []byte(xhtmlStartTag(token, true))
+	case html.StartTagToken:
+		if xhtmlVoidElements[token.Data] {
+			return []byte(xhtmlStartTag(token, true))
+		}
+		if nil != xhtmlInlinePair(node) {
+			return []byte(xhtmlStartTag(token, false))
+		}
+	case html.EndTagToken:
+		if nil != xhtmlInlinePair(node) {
+			return []byte("</" + token.Data + ">")
+		}
+	}
+	return html.EscapeHTML(node.Tokens)
+}
+
+// xhtmlStartTag 返回开始标签 token 的 XHTML，selfClosing 为 true 时输出为自闭合标签。
+func xhtmlStartTag(token html.Token, selfClosing bool) string {
+	buf := &bytes.Buffer{}
+	buf.WriteString("<" + token.Data)
+	for _, attr := range token.Attr {
+		buf.WriteString(" " + attr.Key + "=\"" + html.EscapeString(attr.Val) + "\"")
+	}
+	if selfClosing {
+		buf.WriteString(" />")
+	} else {
+		buf.WriteString(">")
+	}
+	return buf.String()
+}
+
+// xhtmlInlinePair 返回和行级 HTML 节点 node 配对的兄弟节点，没有配对的节点时返回 nil。
+func xhtmlInlinePair(node *ast.Node) *ast.Node {
+	type openTag struct {
+		name string
+		node *ast.Node
+	}
+
+	var stack []openTag
+	pairs := map[*ast.Node]*ast.Node{}
+	for n := node.Parent.FirstChild; nil != n; n = n.Next {
+		if ast.NodeInlineHTML != n.Type {
+			continue
+		}
+
+		z := html.NewTokenizer(bytes.NewReader(n.Tokens))
+		tt := z.Next()
+		name := z.Token().Data
+		switch {
+		case html.StartTagToken == tt && !xhtmlVoidElements[name]:
+			stack = append(stack, openTag{name, n})
+		case html.EndTagToken == tt:
+			if last := len(stack) - 1; 0 <= last && stack[last].name == name {
+				pairs[n], pairs[stack[last].node] = stack[last].node, n
+				stack = stack[:last]
+			}
+		}
+	}
+	return pairs[node]
+}
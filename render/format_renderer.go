@@ -153,6 +153,9 @@ func NewFormatRenderer(tree *parse.Tree, options *Options, parseOptions *parse.O
 	ret.RendererFuncs[ast.NodeGitConflictOpenMarker] = ret.renderGitConflictOpenMarker
 	ret.RendererFuncs[ast.NodeGitConflictContent] = ret.renderGitConflictContent
 	ret.RendererFuncs[ast.NodeGitConflictCloseMarker] = ret.renderGitConflictCloseMarker
+	ret.RendererFuncs[ast.NodeGitConflictOurs] = ret.renderGitConflictSection
+	ret.RendererFuncs[ast.NodeGitConflictBase] = ret.renderGitConflictSection
+	ret.RendererFuncs[ast.NodeGitConflictTheirs] = ret.renderGitConflictSection
 	ret.RendererFuncs[ast.NodeIFrame] = ret.renderIFrame
 	ret.RendererFuncs[ast.NodeWidget] = ret.renderWidget
 	ret.RendererFuncs[ast.NodeVideo] = ret.renderVideo
@@ -480,6 +483,23 @@ func (r *FormatRenderer) renderGitConflictCloseMarker(node *ast.Node, entering b
 	return ast.WalkContinue
 }
 
+func (r *FormatRenderer) renderGitConflictSection(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if ast.NodeGitConflictBase == node.Type {
+			r.Write(node.Tokens)
+			r.WriteByte(lex.ItemNewline)
+		} else if ast.NodeGitConflictTheirs == node.Type {
+			r.WriteString("=======")
+			r.WriteByte(lex.ItemNewline)
+		}
+	} else {
+		// 分隔行前后不保留空行
+		r.Writer.Truncate(len(bytes.TrimRight(r.Writer.Bytes(), "\n")))
+		r.WriteByte(lex.ItemNewline)
+	}
+	return ast.WalkContinue
+}
+
 func (r *FormatRenderer) renderGitConflictContent(node *ast.Node, entering bool) ast.WalkStatus {
 	if !entering {
 		r.Write(node.Tokens)
@@ -499,6 +519,8 @@ func (r *FormatRenderer) renderGitConflictOpenMarker(node *ast.Node, entering bo
 func (r *FormatRenderer) renderGitConflict(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		r.Newline()
+	} else if r.withoutKramdownBlockIAL(node) && !r.isLastNode(r.Tree.Root, node) {
+		r.WriteByte(lex.ItemNewline)
 	}
 	return ast.WalkContinue
 }
@@ -847,7 +869,7 @@ func (r *FormatRenderer) renderBackslash(node *ast.Node, entering bool) ast.Walk
 
 func (r *FormatRenderer) renderToC(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("[toc]\n\n")
+		r.WriteString(tocMarker(node) + "\n\n")
 	}
 	return ast.WalkContinue
 }
@@ -0,0 +1,185 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// HeadingIDSlugger 描述了标题 ID 生成策略。
+type HeadingIDSlugger interface {
+	// Slug 将标题文本 text 转换为标题 ID。
+	Slug(text string) string
+
+	// Unique 在 id 已被文档中其他标题使用时返回去重后的 ID，used 为已经使用的 ID 集合。
+	Unique(id string, used map[string]bool) string
+}
+
+// HeadingIDSluggers 按名称记录了内置的标题 ID 生成策略。
+var HeadingIDSluggers = map[string]HeadingIDSlugger{
+	"lute":   LuteHeadingIDSlugger{},
+	"github": GitHubHeadingIDSlugger{},
+	"gitlab": GitLabHeadingIDSlugger{},
+	"hugo":   HugoHeadingIDSlugger{},
+	"pandoc": PandocHeadingIDSlugger{},
+}
+
+// LuteHeadingIDSlugger 为 Lute 默认的标题 ID 生成策略：保留字母和数字，其他字符替换为 -，重复时在末尾追加 -。
+type LuteHeadingIDSlugger struct{}
+
+func (LuteHeadingIDSlugger) Slug(text string) string {
+	text = strings.TrimLeft(text, "#")
+	buf := &strings.Builder{}
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			buf.WriteRune(r)
+		} else {
+			buf.WriteByte('-')
+		}
+	}
+	return buf.String()
+}
+
+func (LuteHeadingIDSlugger) Unique(id string, used map[string]bool) string {
+	for ; used[id]; id += "-" {
+	}
+	return id
+}
+
+// GitHubHeadingIDSlugger 为兼容 GitHub 的标题 ID 生成策略（同 github-slugger）：转为小写，去掉标点符号，空格替换为 -，重复时追加 -1、-2 后缀。
+type GitHubHeadingIDSlugger struct{}
+
+func (GitHubHeadingIDSlugger) Slug(text string) string {
+	text = strings.ToLower(strings.TrimSpace(text))
+	buf := &strings.Builder{}
+	for _, r := range text {
+		switch {
+		case ' ' == r || '-' == r:
+			buf.WriteByte('-')
+		case '_' == r || unicode.In(r, unicode.Letter, unicode.Mark, unicode.Number):
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
+
+func (GitHubHeadingIDSlugger) Unique(id string, used map[string]bool) string {
+	return numberedHeadingID(id, used)
+}
+
+// GitLabHeadingIDSlugger 为兼容 GitLab 的标题 ID 生成策略：转为小写，去掉标点符号，空格替换为 -，合并连续的 -，
+// 纯数字 ID 加上 anchor- 前缀，重复时追加 -1、-2 后缀。
+type GitLabHeadingIDSlugger struct{}
+
+func (GitLabHeadingIDSlugger) Slug(text string) string {
+	text = strings.ToLower(strings.TrimSpace(text))
+	buf := &strings.Builder{}
+	digits := true
+	var last rune
+	for _, r := range text {
+		switch {
+		case ' ' == r || '-' == r:
+			if '-' == last {
+				continue
+			}
+			r = '-'
+		case unicode.In(r, unicode.Letter, unicode.Mark, unicode.Nd, unicode.Pc):
+		default:
+			continue
+		}
+		if !unicode.IsDigit(r) {
+			digits = false
+		}
+		buf.WriteRune(r)
+		last = r
+	}
+	ret := buf.String()
+	if "" != ret && digits {
+		ret = "anchor-" + ret
+	}
+	return ret
+}
+
+func (GitLabHeadingIDSlugger) Unique(id string, used map[string]bool) string {
+	return numberedHeadingID(id, used)
+}
+
+// HugoHeadingIDSlugger 为兼容 Hugo/Goldmark（autoHeadingIDType = "github"）的标题 ID 生成策略：
+// 转为小写，仅保留字母、数字和 _，空格和 - 替换为 -，空 ID 使用 heading，重复时追加 -1、-2 后缀。
+type HugoHeadingIDSlugger struct{}
+
+func (HugoHeadingIDSlugger) Slug(text string) string {
+	text = strings.TrimSpace(text)
+	buf := &strings.Builder{}
+	for _, r := range text {
+		switch {
+		case ' ' == r || '-' == r:
+			buf.WriteByte('-')
+		case '_' == r || unicode.IsLetter(r) || unicode.IsDigit(r):
+			buf.WriteRune(unicode.ToLower(r))
+		}
+	}
+	if 1 > buf.Len() {
+		return "heading"
+	}
+	return buf.String()
+}
+
+func (HugoHeadingIDSlugger) Unique(id string, used map[string]bool) string {
+	return numberedHeadingID(id, used)
+}
+
+// PandocHeadingIDSlugger 为兼容 Pandoc（auto_identifiers）的标题 ID 生成策略：转为小写，仅保留字母、数字、_、- 和 .，
+// 连续空白替换为 -，去掉第一个字母之前的所有字符，空 ID 使用 section，重复时追加 -1、-2 后缀。
+type PandocHeadingIDSlugger struct{}
+
+func (PandocHeadingIDSlugger) Slug(text string) string {
+	text = strings.ToLower(strings.TrimSpace(text))
+	buf := &strings.Builder{}
+	var space bool
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("_-.", r) {
+			continue
+		}
+		if 1 > buf.Len() && !unicode.IsLetter(r) {
+			space = false
+			continue
+		}
+		if space && 0 < buf.Len() {
+			buf.WriteByte('-')
+		}
+		space = false
+		buf.WriteRune(r)
+	}
+	if 1 > buf.Len() {
+		return "section"
+	}
+	return buf.String()
+}
+
+func (PandocHeadingIDSlugger) Unique(id string, used map[string]bool) string {
+	return numberedHeadingID(id, used)
+}
+
+// numberedHeadingID 依次尝试在 id 后追加 -1、-2 等后缀，返回第一个未被使用的 ID。
+func numberedHeadingID(id string, used map[string]bool) string {
+	for i := 1; ; i++ {
+		if ret := id + "-" + strconv.Itoa(i); !used[ret] {
+			return ret
+		}
+	}
+}
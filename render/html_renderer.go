@@ -152,6 +152,9 @@ func NewHtmlRenderer(tree *parse.Tree, options *Options, parseOptions *parse.Opt
 	ret.RendererFuncs[ast.NodeGitConflictOpenMarker] = ret.renderGitConflictOpenMarker
 	ret.RendererFuncs[ast.NodeGitConflictContent] = ret.renderGitConflictContent
 	ret.RendererFuncs[ast.NodeGitConflictCloseMarker] = ret.renderGitConflictCloseMarker
+	ret.RendererFuncs[ast.NodeGitConflictOurs] = ret.renderGitConflictSection
+	ret.RendererFuncs[ast.NodeGitConflictBase] = ret.renderGitConflictSection
+	ret.RendererFuncs[ast.NodeGitConflictTheirs] = ret.renderGitConflictSection
 	ret.RendererFuncs[ast.NodeIFrame] = ret.renderIFrame
 	ret.RendererFuncs[ast.NodeWidget] = ret.renderWidget
 	ret.RendererFuncs[ast.NodeVideo] = ret.renderVideo
@@ -393,13 +396,30 @@ func (r *HtmlRenderer) renderWidget(node *ast.Node, entering bool) ast.WalkStatu
 }
 
 func (r *HtmlRenderer) renderGitConflictCloseMarker(node *ast.Node, entering bool) ast.WalkStatus {
-	if entering {
+	if entering && isRawGitConflict(node.Parent) {
 		r.Write(node.Tokens)
 		r.Newline()
 	}
 	return ast.WalkContinue
 }
 
+func (r *HtmlRenderer) renderGitConflictSection(node *ast.Node, entering bool) ast.WalkStatus {
+	r.Newline()
+	if entering {
+		class := "git-conflict-ours"
+		if ast.NodeGitConflictBase == node.Type {
+			class = "git-conflict-base"
+		} else if ast.NodeGitConflictTheirs == node.Type {
+			class = "git-conflict-theirs"
+		}
+		r.Tag("div", [][]string{{"class", class}, {"data-marker", html.EscapeString(gitConflictMarker(node))}}, false)
+	} else {
+		r.Tag("/div", nil, false)
+	}
+	r.Newline()
+	return ast.WalkContinue
+}
+
 func (r *HtmlRenderer) renderGitConflictContent(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		r.Write(html.EscapeHTML(node.Tokens))
@@ -409,7 +429,7 @@ func (r *HtmlRenderer) renderGitConflictContent(node *ast.Node, entering bool) a
 }
 
 func (r *HtmlRenderer) renderGitConflictOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
-	if entering {
+	if entering && isRawGitConflict(node.Parent) {
 		r.Write(node.Tokens)
 		r.Newline()
 	}
@@ -1301,7 +1321,7 @@ func (r *HtmlRenderer) renderHeading(node *ast.Node, entering bool) ast.WalkStat
 		r.Newline()
 		level := headingLevel[node.HeadingLevel : node.HeadingLevel+1]
 		r.WriteString("<h" + level)
-		id := HeadingID(node)
+		id := r.HeadingID(node)
 		if r.Options.ToC || r.Options.HeadingID || r.Options.KramdownBlockIAL {
 			r.WriteString(" id=\"" + id + "\"")
 			if r.Options.KramdownBlockIAL {
@@ -1319,9 +1339,12 @@ func (r *HtmlRenderer) renderHeading(node *ast.Node, entering bool) ast.WalkStat
 			}
 		}
 		r.WriteString(">")
+		if number := r.HeadingNumber(node); "" != number {
+			r.WriteString(number + " ")
+		}
 	} else {
 		if r.Options.HeadingAnchor {
-			id := HeadingID(node)
+			id := r.HeadingID(node)
 			r.Tag("a", [][]string{{"id", "vditorAnchor-" + id}, {"class", "vditor-anchor"}, {"href", "#" + id}}, false)
 			r.WriteString(`<svg viewBox="0 0 16 16" version="1.1" width="16" height="16"><path fill-rule="evenodd" d="M4 9h1v1H4c-1.5 0-3-1.69-3-3.5S2.55 3 4 3h4c1.45 0 3 1.69 3 3.5 0 1.41-.91 2.72-2 3.25V8.59c.58-.45 1-1.27 1-2.09C10 5.22 8.98 4 8 4H4c-.98 0-2 1.22-2 2.5S3 9 4 9zm9-3h-1v1h1c1 0 2 1.22 2 2.5S13.98 12 13 12H9c-.98 0-2-1.22-2-2.5 0-.83.42-1.64 1-2.09V6.25c-1.09.53-2 1.84-2 3.25C6 11.31 7.55 13 9 13h4c1.45 0 3-1.69 3-3.5S14.5 6 13 6z"></path></svg>`)
 			r.Tag("/a", nil, false)
@@ -0,0 +1,632 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/editor"
+	"github.com/88250/lute/lex"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/util"
+)
+
+// JiraRenderer 描述了 Jira/Confluence 维基标记渲染器。
+//
+// 节点和维基标记的对应关系如下：
+//   - 标题使用 h1. ~ h6.，自定义了 ID 的标题使用 {anchor} 输出锚点
+//   - 列表的标记由各层级列表的 * 和 # 组成，任务列表项使用 (/) 和 (x) 表示是否完成，列表项中的多个段落使用 \\ 换行连接
+//   - 引述使用 {quote}，代码块使用 {code}，没有语言的代码块和公式块使用 {noformat}，提示块使用 {info}、{tip}、{note} 和 {warning}
+//   - 代码块中出现结束宏时改用另一种宏输出，YAML Front Matter 使用 {code:yaml} 输出
+//   - 表格的表头单元格使用 || 分隔，脚注引用使用上标，脚注内容输出在最后
+//   - 维基标记中的换行即为硬换行，因此软换行输出为空格
+type JiraRenderer struct {
+	*BaseRenderer
+}
+
+// NewJiraRenderer 创建一个 Jira/Confluence 维基标记渲染器。
+func NewJiraRenderer(tree *parse.Tree, options *Options, parseOptions *parse.Options) *JiraRenderer {
+	ret := &JiraRenderer{BaseRenderer: NewBaseRenderer(tree, options, parseOptions)}
+	ret.DefaultRendererFunc = ret.renderChildren
+	ret.RendererFuncs[ast.NodeParagraph] = ret.renderParagraph
+	ret.RendererFuncs[ast.NodeHeading] = ret.renderHeading
+	ret.RendererFuncs[ast.NodeBlockquote] = ret.renderBlockquote
+	ret.RendererFuncs[ast.NodeCallout] = ret.renderCallout
+	ret.RendererFuncs[ast.NodeList] = ret.renderList
+	ret.RendererFuncs[ast.NodeListItem] = ret.renderListItem
+	ret.RendererFuncs[ast.NodeTaskListItemMarker] = ret.renderTaskListItemMarker
+	ret.RendererFuncs[ast.NodeThematicBreak] = ret.renderThematicBreak
+	ret.RendererFuncs[ast.NodeCodeBlock] = ret.renderCodeBlock
+	ret.RendererFuncs[ast.NodeMathBlock] = ret.renderMathBlock
+	ret.RendererFuncs[ast.NodeHTMLBlock] = ret.renderHTMLBlock
+	ret.RendererFuncs[ast.NodeTable] = ret.renderTable
+	ret.RendererFuncs[ast.NodeTableRow] = ret.renderTableRow
+	ret.RendererFuncs[ast.NodeTableCell] = ret.renderTableCell
+	ret.RendererFuncs[ast.NodeToC] = ret.renderToC
+	ret.RendererFuncs[ast.NodeFootnotesRef] = ret.renderFootnotesRef
+	ret.RendererFuncs[ast.NodeFootnotesDefBlock] = ret.renderFootnotesDefBlock
+	ret.RendererFuncs[ast.NodeFootnotesDef] = ret.renderFootnotesDef
+	ret.RendererFuncs[ast.NodeText] = ret.renderText
+	ret.RendererFuncs[ast.NodeLinkText] = ret.renderText
+	ret.RendererFuncs[ast.NodeBackslashContent] = ret.renderText
+	ret.RendererFuncs[ast.NodeHTMLEntity] = ret.renderText
+	ret.RendererFuncs[ast.NodeEmojiUnicode] = ret.renderText
+	ret.RendererFuncs[ast.NodeBlockRefText] = ret.renderText
+	ret.RendererFuncs[ast.NodeBlockRefDynamicText] = ret.renderText
+	ret.RendererFuncs[ast.NodeFileAnnotationRefText] = ret.renderText
+	ret.RendererFuncs[ast.NodeEmojiImg] = ret.renderEmojiImg
+	ret.RendererFuncs[ast.NodeSoftBreak] = ret.renderSoftBreak
+	ret.RendererFuncs[ast.NodeHardBreak] = ret.renderHardBreak
+	ret.RendererFuncs[ast.NodeInlineHTML] = ret.renderInlineHTML
+	ret.RendererFuncs[ast.NodeCodeSpan] = ret.renderCodeSpan
+	ret.RendererFuncs[ast.NodeInlineMath] = ret.renderInlineMath
+	ret.RendererFuncs[ast.NodeStrong] = ret.renderEffect("*")
+	ret.RendererFuncs[ast.NodeEmphasis] = ret.renderEffect("_")
+	ret.RendererFuncs[ast.NodeStrikethrough] = ret.renderEffect("-")
+	ret.RendererFuncs[ast.NodeUnderline] = ret.renderEffect("+")
+	ret.RendererFuncs[ast.NodeSup] = ret.renderEffect("^")
+	ret.RendererFuncs[ast.NodeSub] = ret.renderEffect("~")
+	ret.RendererFuncs[ast.NodeKbd] = ret.renderMonospace
+	ret.RendererFuncs[ast.NodeTextMark] = ret.renderTextMark
+	ret.RendererFuncs[ast.NodeLink] = ret.renderLink
+	ret.RendererFuncs[ast.NodeImage] = ret.renderImage
+	ret.RendererFuncs[ast.NodeLinkRefDefBlock] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeYamlFrontMatter] = ret.renderYamlFrontMatter
+	ret.RendererFuncs[ast.NodeKramdownBlockIAL] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeKramdownSpanIAL] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeHeadingID] = ret.renderSkip
+	return ret
+}
+
+// renderChildren 用于渲染没有对应维基标记的节点，仅渲染其子节点。
+func (r *JiraRenderer) renderChildren(node *ast.Node, entering bool) ast.WalkStatus {
+	return ast.WalkContinue
+}
+
+// renderSkip 用于渲染在维基标记中不可见的节点。
+func (r *JiraRenderer) renderSkip(node *ast.Node, entering bool) ast.WalkStatus {
+	return ast.WalkSkipChildren
+}
+
+// startBlock 在输出块 node 前进行分隔：容器块的第一个子块紧接着容器块的开头，列表项中的后续段落使用 \\ 换行连接，
+// 列表项之间和列表项中的子列表换行，其他块之间空一行。
+func (r *JiraRenderer) startBlock(node *ast.Node) {
+	prev := node.Previous
+	if nil == prev || ast.NodeBlockquoteMarker == prev.Type || 1 > r.Writer.Len() {
+		return
+	}
+
+	if ast.NodeListItem == node.Parent.Type && ast.NodeParagraph == node.Type && ast.NodeParagraph == prev.Type {
+		r.WriteString(" \\\\ ")
+		return
+	}
+	r.Newline()
+	if ast.NodeListItem == node.Type || ast.NodeListItem == node.Parent.Type || ast.NodeFootnotesDef == node.Type {
+		return
+	}
+	r.WriteByte(lex.ItemNewline)
+}
+
+func (r *JiraRenderer) renderParagraph(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+	} else if ast.NodeListItem != node.Parent.Type || nil == node.Next || ast.NodeParagraph != node.Next.Type {
+		r.Newline()
+	}
+	return ast.WalkContinue
+}
+
+func (r *JiraRenderer) renderHeading(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.Newline()
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	level := node.HeadingLevel
+	if 1 > level {
+		level = 1
+	} else if 6 < level {
+		level = 6
+	}
+	r.WriteString("h" + strconv.Itoa(level) + ". ")
+	if nil != node.ChildByType(ast.NodeHeadingID) {
+		r.WriteString("{anchor:" + r.HeadingID(node) + "}")
+	}
+	return ast.WalkContinue
+}
+
+func (r *JiraRenderer) renderBlockquote(node *ast.Node, entering bool) ast.WalkStatus {
+	if node.ParentIs(ast.NodeBlockquote) {
+		// 维基标记不支持嵌套的引述
+		return ast.WalkContinue
+	}
+
+	if entering {
+		r.startBlock(node)
+	} else {
+		r.Newline()
+	}
+	r.WriteString("{quote}\n")
+	return ast.WalkContinue
+}
+
+// jiraPanels 为提示块类型对应的宏。
+var jiraPanels = map[string]string{
+	ast.CalloutTypeNote:      "info",
+	ast.CalloutTypeTip:       "tip",
+	ast.CalloutTypeImportant: "info",
+	ast.CalloutTypeWarning:   "note",
+	ast.CalloutTypeCaution:   "warning",
+}
+
+func (r *JiraRenderer) renderCallout(node *ast.Node, entering bool) ast.WalkStatus {
+	macro, ok := jiraPanels[strings.ToUpper(node.CalloutType)]
+	if !ok {
+		macro = "info"
+	}
+	if !entering {
+		r.Newline()
+		r.WriteString("{" + macro + "}\n")
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	title := strings.TrimSpace(node.CalloutTitle)
+	if "" == title {
+		if title = ast.GetCalloutTitle(node.CalloutType); "" == title {
+			title = node.CalloutType
+		}
+	}
+	r.WriteString("{" + macro)
+	if "" != title {
+		r.WriteString(":title=" + strings.NewReplacer("|", "", "}", "").Replace(title))
+	}
+	r.WriteString("}\n")
+	return ast.WalkContinue
+}
+
+func (r *JiraRenderer) renderList(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+	}
+	return ast.WalkContinue
+}
+
+func (r *JiraRenderer) renderListItem(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.Newline()
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	// 列表项的标记由外层各级列表的标记组成，比如有序列表中的无序列表为 #*
+	var marker []byte
+	for p := node.Parent; nil != p; p = p.Parent {
+		if ast.NodeList != p.Type {
+			continue
+		}
+		if 1 == p.ListData.Typ || (3 == p.ListData.Typ && 0 == p.ListData.BulletChar) {
+			marker = append([]byte{'#'}, marker...)
+		} else {
+			marker = append([]byte{'*'}, marker...)
+		}
+	}
+	r.Write(marker)
+	r.WriteByte(' ')
+	return ast.WalkContinue
+}
+
+func (r *JiraRenderer) renderTaskListItemMarker(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if node.TaskListItemChecked {
+			r.WriteString("(/)")
+		} else {
+			r.WriteString("(x)")
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *JiraRenderer) renderThematicBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		r.WriteString("----\n")
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *JiraRenderer) renderCodeBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	var code string
+	if content := node.ChildByType(ast.NodeCodeBlockCode); nil != content {
+		code = util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil))
+	}
+	code = strings.TrimSuffix(code, "\n")
+	var lang string
+	if info := node.ChildByType(ast.NodeCodeBlockFenceInfoMarker); nil != info {
+		if fields := strings.Fields(util.BytesToStr(info.CodeBlockInfo)); 0 < len(fields) {
+			lang = strings.ToLower(fields[0])
+		}
+	}
+	r.writeCode(lang, code)
+	return ast.WalkSkipChildren
+}
+
+// writeCode 输出语言为 lang 的代码 code，lang 为空时使用 {noformat}。
+//
+// 维基标记不支持在 {code} 和 {noformat} 中转义，因此代码中包含结束宏时改用另一种宏，两种宏都包含时在代码中的宏名前插入零宽空格。
+func (r *JiraRenderer) writeCode(lang, code string) {
+	hasCode, hasNoformat := strings.Contains(code, "{code"), strings.Contains(code, "{noformat")
+	if "" != lang && hasCode && !hasNoformat {
+		lang = ""
+	} else if "" == lang && hasNoformat && !hasCode {
+		lang = "none"
+	}
+
+	macro, open := "noformat", "{noformat}"
+	if "" != lang {
+		macro, open = "code", "{code:"+lang+"}"
+	}
+	code = strings.ReplaceAll(code, "{"+macro, "{\u200b"+macro)
+	r.WriteString(open + "\n" + code + "\n{" + macro + "}\n")
+}
+
+func (r *JiraRenderer) renderMathBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		var math string
+		if content := node.ChildByType(ast.NodeMathBlockContent); nil != content {
+			math = strings.TrimSpace(util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil)))
+		}
+		r.writeCode("", math)
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *JiraRenderer) renderHTMLBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if html := strings.TrimSpace(util.BytesToStr(node.Tokens)); "" != html {
+			r.startBlock(node)
+			r.writeCode("html", html)
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *JiraRenderer) renderYamlFrontMatter(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if content := node.ChildByType(ast.NodeYamlFrontMatterContent); nil != content {
+			if yaml := strings.TrimSpace(util.BytesToStr(content.Tokens)); "" != yaml {
+				r.startBlock(node)
+				r.writeCode("yaml", yaml)
+			}
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *JiraRenderer) renderTable(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+	}
+	return ast.WalkContinue
+}
+
+func (r *JiraRenderer) renderTableRow(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		if ast.NodeTableHead == node.Parent.Type {
+			r.WriteString("||\n")
+		} else {
+			r.WriteString("|\n")
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *JiraRenderer) renderTableCell(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if ast.NodeTableHead == node.Parent.Parent.Type {
+			r.WriteString("||")
+		} else {
+			r.WriteByte('|')
+		}
+		if nil == node.FirstChild {
+			// 空的单元格需要使用空格占位
+			r.WriteByte(' ')
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *JiraRenderer) renderToC(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		r.WriteString("{toc}\n")
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *JiraRenderer) renderFootnotesRef(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		idx, def := r.Tree.FindFootnotesDef(node.Tokens)
+		if nil == def {
+			r.WriteString(jiraEscape("[^" + util.BytesToStr(node.Tokens) + "]"))
+		} else {
+			r.WriteString("{^}" + strconv.Itoa(idx) + "{^}")
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *JiraRenderer) renderFootnotesDefBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		r.WriteString("----\n")
+	}
+	return ast.WalkContinue
+}
+
+func (r *JiraRenderer) renderFootnotesDef(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		idx, _ := r.Tree.FindFootnotesDef(node.Tokens)
+		r.WriteString("{^}" + strconv.Itoa(idx) + "{^} ")
+	}
+	return ast.WalkContinue
+}
+
+func (r *JiraRenderer) renderText(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		tokens := bytes.ReplaceAll(node.Tokens, editor.CaretTokens, nil)
+		if ast.NodeText == node.Type || ast.NodeLinkText == node.Type {
+			if r.Options.AutoSpace {
+				tokens = r.Space(tokens)
+			}
+			if r.Options.FixTermTypo {
+				tokens = r.FixTermTypo(tokens)
+			}
+		}
+		r.WriteString(jiraEscape(util.BytesToStr(tokens)))
+	}
+	return ast.WalkContinue
+}
+
+func (r *JiraRenderer) renderEmojiImg(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if alias := node.ChildByType(ast.NodeEmojiAlias); nil != alias {
+			r.WriteString(jiraEscape(util.BytesToStr(alias.Tokens)))
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *JiraRenderer) renderSoftBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if r.Options.SoftBreak2HardBreak {
+			r.renderHardBreak(node, entering)
+		} else {
+			r.WriteByte(' ')
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *JiraRenderer) renderHardBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if node.ParentIs(ast.NodeTableCell) || node.ParentIs(ast.NodeListItem) {
+			r.WriteString(" \\\\ ")
+		} else {
+			r.WriteByte(lex.ItemNewline)
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *JiraRenderer) renderInlineHTML(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering && bytes.HasPrefix(bytes.ToLower(node.Tokens), []byte("<br")) {
+		r.renderHardBreak(node, entering)
+	}
+	return ast.WalkContinue
+}
+
+func (r *JiraRenderer) renderCodeSpan(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		var code string
+		if content := node.ChildByType(ast.NodeCodeSpanContent); nil != content {
+			code = util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil))
+		}
+		r.writeMonospace(code)
+	}
+	return ast.WalkSkipChildren
+}
+
+// writeMonospace 输出等宽文本 text。
+func (r *JiraRenderer) writeMonospace(text string) {
+	if "" != text {
+		r.WriteString("{{" + jiraEscape(text) + "}}")
+	}
+}
+
+func (r *JiraRenderer) renderMonospace(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.WriteString("{{")
+	} else {
+		r.WriteString("}}")
+	}
+	return ast.WalkContinue
+}
+
+func (r *JiraRenderer) renderInlineMath(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if content := node.ChildByType(ast.NodeInlineMathContent); nil != content {
+			r.writeMonospace(strings.TrimSpace(util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil))))
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+// renderEffect 返回一个渲染函数，使用文本效果标记 mark 包裹节点的子节点，两侧不是单词边界时使用 {mark} 的写法。
+func (r *JiraRenderer) renderEffect(mark string) RendererFunc {
+	return func(node *ast.Node, entering bool) ast.WalkStatus {
+		if jiraBoundary(node.Previous, false) && jiraBoundary(node.Next, true) {
+			r.WriteString(mark)
+		} else {
+			r.WriteString("{" + mark + "}")
+		}
+		return ast.WalkContinue
+	}
+}
+
+// jiraBoundary 判断行级节点 node 与相邻的行级节点之间是否是单词边界，next 为 true 时判断 node 的开头，否则判断 node 的结尾。
+func jiraBoundary(node *ast.Node, next bool) bool {
+	if nil == node {
+		return true
+	}
+	switch node.Type {
+	case ast.NodeText:
+		if 1 > len(node.Tokens) {
+			return true
+		}
+		var c rune
+		if next {
+			c, _ = utf8.DecodeRune(node.Tokens)
+		} else {
+			c, _ = utf8.DecodeLastRune(node.Tokens)
+		}
+		return !jiraWordRune(c)
+	case ast.NodeSoftBreak, ast.NodeHardBreak, ast.NodeKramdownSpanIAL:
+		return true
+	}
+	return false
+}
+
+// jiraTextMarkEffects 为行级元素类型对应的文本效果标记。
+var jiraTextMarkEffects = [][]string{
+	{"strong", "*"},
+	{"em", "_"},
+	{"s", "-"},
+	{"u", "+"},
+	{"sup", "^"},
+	{"sub", "~"},
+}
+
+func (r *JiraRenderer) renderTextMark(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	var open, close string
+	for _, effect := range jiraTextMarkEffects {
+		if node.IsTextMarkType(effect[0]) {
+			mark := "{" + effect[1] + "}"
+			open, close = open+mark, mark+close
+		}
+	}
+	r.WriteString(open)
+	switch {
+	case node.IsTextMarkType("inline-math"):
+		r.writeMonospace(strings.TrimSpace(node.TextMarkInlineMathContent))
+	case node.IsTextMarkType("code") || node.IsTextMarkType("kbd"):
+		r.writeMonospace(node.TextMarkTextContent)
+	case node.IsTextMarkType("a") && "" != node.TextMarkAHref:
+		r.WriteString("[" + jiraEscape(node.TextMarkTextContent) + "|" + node.TextMarkAHref + "]")
+	default:
+		r.WriteString(jiraEscape(node.TextMarkTextContent))
+	}
+	r.WriteString(close)
+	return ast.WalkSkipChildren
+}
+
+func (r *JiraRenderer) renderLink(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.WriteByte(']')
+		return ast.WalkContinue
+	}
+
+	var dest string
+	if d := node.ChildByType(ast.NodeLinkDest); nil != d {
+		dest = strings.TrimSpace(util.BytesToStr(r.LinkPath(d.Tokens)))
+	}
+	r.WriteByte('[')
+	if 2 == node.LinkType || emptyLinkText(node) {
+		r.WriteString(dest)
+		return ast.WalkSkipChildren
+	}
+
+	// 链接文本输出后再输出链接地址
+	for n := node.FirstChild; nil != n; n = n.Next {
+		ast.Walk(n, r.renderNode)
+	}
+	r.WriteString("|" + dest)
+	return ast.WalkSkipChildren
+}
+
+func (r *JiraRenderer) renderImage(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		var dest, alt string
+		if d := node.ChildByType(ast.NodeLinkDest); nil != d {
+			dest = strings.TrimSpace(util.BytesToStr(r.LinkPath(d.Tokens)))
+		}
+		alt = strings.NewReplacer(",", " ", "!", "", "|", "", "\n", " ").Replace(strings.TrimSpace(imageAlt(node)))
+		r.WriteString("!" + dest)
+		if "" != alt {
+			r.WriteString("|alt=" + alt)
+		}
+		r.WriteByte('!')
+	}
+	return ast.WalkSkipChildren
+}
+
+// jiraEffects 为维基标记中的文本效果字符。
+const jiraEffects = "*_-+^~"
+
+// jiraEscape 转义维基标记文本中的特殊字符，文本效果字符仅在可能作为开始或者结束标记时转义（比如 a_b 中的 _ 不需要转义）。
+func jiraEscape(text string) string {
+	buf := &bytes.Buffer{}
+	prev := ' '
+	for i, c := range text {
+		next, size := utf8.DecodeRuneInString(text[i+utf8.RuneLen(c):])
+		if 1 > size {
+			next = ' '
+		}
+		switch {
+		case strings.ContainsRune("{}[]|", c):
+			buf.WriteByte('\\')
+		case strings.ContainsRune(jiraEffects, c):
+			opening := !jiraWordRune(prev) && !unicode.IsSpace(next)
+			closing := !unicode.IsSpace(prev) && !jiraWordRune(next)
+			if opening || closing {
+				buf.WriteByte('\\')
+			}
+		case '!' == c && !jiraWordRune(prev) && !unicode.IsSpace(next), '?' == c && '?' == next:
+			// !url! 为图片，??text?? 为引用
+			buf.WriteByte('\\')
+		}
+		buf.WriteRune(c)
+		prev = c
+	}
+	return buf.String()
+}
+
+// jiraWordRune 判断字符 c 是否是字母或者数字。
+func jiraWordRune(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c)
+}
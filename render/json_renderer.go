@@ -56,8 +56,7 @@ func (r *JSONRenderer) renderNode(node *ast.Node, entering bool) ast.WalkStatus
 		node.Data, node.TypeStr = "", ""
 		node.Properties = nil
 		if nil != err {
-			panic("marshal node to json failed: " + err.Error())
-			return ast.WalkStop
+			panic("marshal node to json failed: " + err.Error()) // 通过 RenderE 渲染时会被恢复为错误
 		}
 		n := util.BytesToStr(data)
 		n = n[:len(n)-1] // 去掉结尾的 }
@@ -0,0 +1,781 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"bytes"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/editor"
+	"github.com/88250/lute/lex"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/util"
+)
+
+// LaTeXDefaultTemplate 为默认的 LaTeX 文档模板。
+const LaTeXDefaultTemplate = `\documentclass{article}
+\usepackage[utf8]{inputenc}
+\usepackage[T1]{fontenc}
+$packages$
+\begin{document}
+
+$body$
+\end{document}
+`
+
+// LaTeXOptions 描述了 LaTeX 渲染选项。
+type LaTeXOptions struct {
+	// Template 为文档模板，其中的 $packages$ 会被替换为正文用到的宏包，$body$ 会被替换为正文。为空时仅输出正文
+	Template string
+	// Minted 设置是否使用 minted 宏包渲染代码块，默认使用 listings 宏包
+	Minted bool
+	// LongTable 设置是否使用 longtable 渲染表格（可以跨页），默认使用 tabular
+	LongTable bool
+}
+
+// NewLaTeXOptions 创建一个默认的 LaTeX 渲染选项。
+func NewLaTeXOptions() *LaTeXOptions {
+	return &LaTeXOptions{Template: LaTeXDefaultTemplate}
+}
+
+// latexPackages 为可能用到的宏包及其选项，按照在导言区中的顺序排列。
+var latexPackages = [][]string{
+	{"xcolor", ""},
+	{"amsmath", ""},
+	{"amssymb", ""},
+	{"graphicx", ""},
+	{"ulem", "normalem"},
+	{"soul", ""},
+	{"longtable", ""},
+	{"listings", ""},
+	{"minted", ""},
+	{"tcolorbox", ""},
+	{"hyperref", ""},
+}
+
+// LaTeXRenderer 描述了 LaTeX 渲染器。
+//
+// 节点和 LaTeX 结构的对应关系如下：
+//   - 标题使用 \section~\subparagraph，标题 ID 只包含 ASCII 字母、数字和 -_:. 时输出 \label，链接到 #ID 时使用 \hyperref
+//   - 数学公式使用 \( \) 和 \[ \]，公式块内容以 \begin 开头时（比如 align 环境）原样输出
+//   - 表格使用 tabular 或者 longtable，代码块使用 listings 或者 minted，提示块使用 tcolorbox
+//   - 脚注在第一次引用的位置使用 \footnote 输出，后续引用使用 \footref
+//   - 仅包含一张本地图片的段落使用 figure 环境，替代文本作为图注，网络图片使用链接
+type LaTeXRenderer struct {
+	*BaseRenderer
+
+	latexOptions *LaTeXOptions
+	packages     map[string]bool // 正文中用到的宏包
+	footnotes    map[int]bool    // 已经输出过的脚注
+}
+
+// NewLaTeXRenderer 创建一个 LaTeX 渲染器，latexOptions 为 nil 时使用 NewLaTeXOptions 返回的默认选项。
+func NewLaTeXRenderer(tree *parse.Tree, latexOptions *LaTeXOptions, options *Options, parseOptions *parse.Options) *LaTeXRenderer {
+	if nil == latexOptions {
+		latexOptions = NewLaTeXOptions()
+	}
+	ret := &LaTeXRenderer{BaseRenderer: NewBaseRenderer(tree, options, parseOptions), latexOptions: latexOptions}
+	ret.DefaultRendererFunc = ret.renderChildren
+	ret.RendererFuncs[ast.NodeParagraph] = ret.renderParagraph
+	ret.RendererFuncs[ast.NodeHeading] = ret.renderHeading
+	ret.RendererFuncs[ast.NodeBlockquote] = ret.renderEnvironment("quote")
+	ret.RendererFuncs[ast.NodeList] = ret.renderList
+	ret.RendererFuncs[ast.NodeListItem] = ret.renderListItem
+	ret.RendererFuncs[ast.NodeCallout] = ret.renderCallout
+	ret.RendererFuncs[ast.NodeThematicBreak] = ret.renderThematicBreak
+	ret.RendererFuncs[ast.NodeCodeBlock] = ret.renderCodeBlock
+	ret.RendererFuncs[ast.NodeMathBlock] = ret.renderMathBlock
+	ret.RendererFuncs[ast.NodeTable] = ret.renderTable
+	ret.RendererFuncs[ast.NodeTableRow] = ret.renderTableRow
+	ret.RendererFuncs[ast.NodeTableCell] = ret.renderTableCell
+	ret.RendererFuncs[ast.NodeFootnotesRef] = ret.renderFootnotesRef
+	ret.RendererFuncs[ast.NodeToC] = ret.renderToC
+	ret.RendererFuncs[ast.NodeText] = ret.renderText
+	ret.RendererFuncs[ast.NodeLinkText] = ret.renderText
+	ret.RendererFuncs[ast.NodeBackslashContent] = ret.renderText
+	ret.RendererFuncs[ast.NodeHTMLEntity] = ret.renderText
+	ret.RendererFuncs[ast.NodeEmojiUnicode] = ret.renderText
+	ret.RendererFuncs[ast.NodeBlockRefText] = ret.renderText
+	ret.RendererFuncs[ast.NodeBlockRefDynamicText] = ret.renderText
+	ret.RendererFuncs[ast.NodeFileAnnotationRefText] = ret.renderText
+	ret.RendererFuncs[ast.NodeEmojiImg] = ret.renderEmojiImg
+	ret.RendererFuncs[ast.NodeSoftBreak] = ret.renderSoftBreak
+	ret.RendererFuncs[ast.NodeHardBreak] = ret.renderHardBreak
+	ret.RendererFuncs[ast.NodeInlineHTML] = ret.renderInlineHTML
+	ret.RendererFuncs[ast.NodeCodeSpan] = ret.renderCodeSpan
+	ret.RendererFuncs[ast.NodeKbd] = ret.renderCommand("texttt")
+	ret.RendererFuncs[ast.NodeInlineMath] = ret.renderInlineMath
+	ret.RendererFuncs[ast.NodeStrong] = ret.renderCommand("textbf")
+	ret.RendererFuncs[ast.NodeEmphasis] = ret.renderCommand("emph")
+	ret.RendererFuncs[ast.NodeStrikethrough] = ret.renderCommand("sout")
+	ret.RendererFuncs[ast.NodeUnderline] = ret.renderCommand("uline")
+	ret.RendererFuncs[ast.NodeMark] = ret.renderCommand("hl")
+	ret.RendererFuncs[ast.NodeSup] = ret.renderCommand("textsuperscript")
+	ret.RendererFuncs[ast.NodeSub] = ret.renderCommand("textsubscript")
+	ret.RendererFuncs[ast.NodeTextMark] = ret.renderTextMark
+	ret.RendererFuncs[ast.NodeLink] = ret.renderLink
+	ret.RendererFuncs[ast.NodeImage] = ret.renderImage
+	ret.RendererFuncs[ast.NodeTaskListItemMarker] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeHTMLBlock] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeFootnotesDefBlock] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeLinkRefDefBlock] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeYamlFrontMatter] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeKramdownBlockIAL] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeKramdownSpanIAL] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeHeadingID] = ret.renderSkip
+	return ret
+}
+
+func (r *LaTeXRenderer) Render() (output []byte) {
+	r.LastOut = lex.ItemNewline
+	r.Writer = &bytes.Buffer{}
+	r.Writer.Grow(4096)
+	r.packages, r.footnotes = map[string]bool{}, map[int]bool{}
+	ast.Walk(r.Tree.Root, r.renderNode)
+
+	body := r.Writer.String()
+	if "" == r.latexOptions.Template {
+		return []byte(body)
+	}
+
+	packages := &bytes.Buffer{}
+	for _, pkg := range latexPackages {
+		if !r.packages[pkg[0]] {
+			continue
+		}
+		packages.WriteString("\\usepackage")
+		if "" != pkg[1] {
+			packages.WriteString("[" + pkg[1] + "]")
+		}
+		packages.WriteString("{" + pkg[0] + "}\n")
+	}
+	output = []byte(strings.Replace(r.latexOptions.Template, "$packages$", strings.TrimSuffix(packages.String(), "\n"), 1))
+	output = bytes.Replace(output, []byte("$body$"), []byte(body), 1)
+	return
+}
+
+// use 记录正文中用到的宏包。
+func (r *LaTeXRenderer) use(packages ...string) {
+	for _, pkg := range packages {
+		r.packages[pkg] = true
+	}
+}
+
+// renderChildren 用于渲染没有对应 LaTeX 结构的节点，仅渲染其子节点。
+func (r *LaTeXRenderer) renderChildren(node *ast.Node, entering bool) ast.WalkStatus {
+	return ast.WalkContinue
+}
+
+// renderSkip 用于渲染在 LaTeX 文档中不可见的节点。
+func (r *LaTeXRenderer) renderSkip(node *ast.Node, entering bool) ast.WalkStatus {
+	return ast.WalkSkipChildren
+}
+
+// startBlock 在输出块 node 前进行分隔：容器块的第一个子块紧接着容器块的开头，紧凑列表项中的块之间换行，其他块之间空一行。
+func (r *LaTeXRenderer) startBlock(node *ast.Node) {
+	if nil == node.Previous || ast.NodeBlockquoteMarker == node.Previous.Type {
+		return
+	}
+
+	if ast.NodeListItem == node.Parent.Type && node.Parent.Parent.ListData.Tight {
+		r.Newline()
+		return
+	}
+
+	buf := r.Writer.Bytes()
+	if 1 > len(buf) || bytes.HasSuffix(buf, []byte("\n\n")) {
+		return
+	}
+	r.Newline()
+	r.WriteByte(lex.ItemNewline)
+}
+
+func (r *LaTeXRenderer) renderParagraph(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		if image := r.figure(node); nil != image {
+			r.renderFigure(image)
+			return ast.WalkSkipChildren
+		}
+	} else {
+		r.Newline()
+	}
+	return ast.WalkContinue
+}
+
+// figure 返回仅包含一张本地图片的顶层段落中的图片，其他情况返回 nil。
+func (r *LaTeXRenderer) figure(paragraph *ast.Node) (ret *ast.Node) {
+	if ast.NodeDocument != paragraph.Parent.Type {
+		return
+	}
+	ret = imageParagraph(paragraph)
+	if nil != ret && r.remote(r.imagePath(ret)) {
+		return nil
+	}
+	return
+}
+
+func (r *LaTeXRenderer) renderFigure(image *ast.Node) {
+	r.use("graphicx")
+	r.WriteString("\\begin{figure}[htbp]\n\\centering\n")
+	r.WriteString("\\includegraphics[width=\\linewidth,keepaspectratio]{" + r.imagePath(image) + "}\n")
+	if alt := imageAlt(image); "" != alt {
+		r.WriteString("\\caption{" + latexEscape(alt) + "}\n")
+	}
+	r.WriteString("\\end{figure}\n")
+}
+
+// latexSections 为标题级别对应的分节命令。
+var latexSections = []string{"section", "subsection", "subsubsection", "paragraph", "subparagraph", "subparagraph"}
+
+func (r *LaTeXRenderer) renderHeading(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		level := node.HeadingLevel
+		if 1 > level {
+			level = 1
+		} else if 6 < level {
+			level = 6
+		}
+		r.WriteString("\\" + latexSections[level-1] + "{")
+	} else {
+		r.WriteByte('}')
+		if id := r.HeadingID(node); latexLabel(id) {
+			r.WriteString("\\label{" + id + "}")
+		}
+		r.Newline()
+	}
+	return ast.WalkContinue
+}
+
+// renderEnvironment 返回一个渲染函数，使用环境 name 包裹块节点。
+func (r *LaTeXRenderer) renderEnvironment(name string) RendererFunc {
+	return func(node *ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			r.startBlock(node)
+			r.WriteString("\\begin{" + name + "}\n")
+		} else {
+			r.Newline()
+			r.WriteString("\\end{" + name + "}\n")
+		}
+		return ast.WalkContinue
+	}
+}
+
+func (r *LaTeXRenderer) renderList(node *ast.Node, entering bool) ast.WalkStatus {
+	name := "itemize"
+	if 1 == node.ListData.Typ {
+		name = "enumerate"
+	}
+	if !entering {
+		r.Newline()
+		r.WriteString("\\end{" + name + "}\n")
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	r.WriteString("\\begin{" + name + "}\n")
+	if 1 == node.ListData.Typ && 1 < node.ListData.Start {
+		// 有序列表的计数器按照嵌套层级依次为 enumi、enumii、enumiii 和 enumiv
+		depth := 1
+		for p := node.Parent; nil != p; p = p.Parent {
+			if ast.NodeList == p.Type && 1 == p.ListData.Typ {
+				depth++
+			}
+		}
+		if 4 >= depth {
+			counter := "enum" + []string{"i", "ii", "iii", "iv"}[depth-1]
+			r.WriteString("\\setcounter{" + counter + "}{" + strconv.Itoa(node.ListData.Start-1) + "}\n")
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *LaTeXRenderer) renderListItem(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.Newline()
+		return ast.WalkContinue
+	}
+
+	r.Newline()
+	r.WriteString("\\item")
+	if nil != node.FirstChild && nil != node.FirstChild.FirstChild && ast.NodeTaskListItemMarker == node.FirstChild.FirstChild.Type {
+		r.use("amssymb")
+		if node.FirstChild.FirstChild.TaskListItemChecked {
+			r.WriteString("[$\\boxtimes$]")
+		} else {
+			r.WriteString("[$\\square$]")
+		}
+		return ast.WalkContinue
+	}
+	r.WriteByte(' ')
+	return ast.WalkContinue
+}
+
+// latexCalloutColors 为内置提示块类型对应的颜色。
+var latexCalloutColors = map[string]string{
+	ast.CalloutTypeNote:      "blue",
+	ast.CalloutTypeTip:       "green",
+	ast.CalloutTypeImportant: "violet",
+	ast.CalloutTypeWarning:   "orange",
+	ast.CalloutTypeCaution:   "red",
+}
+
+func (r *LaTeXRenderer) renderCallout(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.Newline()
+		r.WriteString("\\end{tcolorbox}\n")
+		return ast.WalkContinue
+	}
+
+	r.use("tcolorbox")
+	r.startBlock(node)
+	color, ok := latexCalloutColors[strings.ToUpper(node.CalloutType)]
+	if !ok {
+		color = "gray"
+	}
+	title := node.CalloutTitle
+	if "" == title {
+		title = node.CalloutType
+	}
+	r.WriteString("\\begin{tcolorbox}[colback=" + color + "!5!white,colframe=" + color + "!75!black")
+	if "" != title {
+		r.WriteString(",title={" + latexEscape(title) + "}")
+	}
+	r.WriteString("]\n")
+	return ast.WalkContinue
+}
+
+func (r *LaTeXRenderer) renderThematicBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		r.WriteString("\\begin{center}\n\\rule{0.5\\linewidth}{0.4pt}\n\\end{center}\n")
+	}
+	return ast.WalkSkipChildren
+}
+
+// latexListingsLanguages 为代码块语言对应的 listings 语言，listings 不支持的语言不设置 language。
+var latexListingsLanguages = map[string]string{
+	"c": "C", "cpp": "C++", "c++": "C++", "java": "Java", "python": "Python", "py": "Python", "ruby": "Ruby",
+	"php": "PHP", "perl": "Perl", "sql": "SQL", "bash": "bash", "sh": "sh", "shell": "sh", "html": "HTML",
+	"xml": "XML", "matlab": "Matlab", "r": "R", "haskell": "Haskell", "lisp": "Lisp", "fortran": "Fortran",
+	"pascal": "Pascal", "tex": "TeX", "latex": "[LaTeX]TeX", "make": "make", "makefile": "make", "lua": "Lua",
+	"scala": "Scala", "erlang": "erlang", "ocaml": "[Objective]Caml", "csharp": "[Sharp]C", "c#": "[Sharp]C",
+}
+
+func (r *LaTeXRenderer) renderCodeBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	var code string
+	if content := node.ChildByType(ast.NodeCodeBlockCode); nil != content {
+		code = util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil))
+	}
+	code = strings.TrimSuffix(code, "\n")
+	var lang string
+	if info := node.ChildByType(ast.NodeCodeBlockFenceInfoMarker); nil != info {
+		if fields := strings.Fields(util.BytesToStr(info.CodeBlockInfo)); 0 < len(fields) {
+			lang = strings.ToLower(fields[0])
+		}
+	}
+
+	if r.latexOptions.Minted {
+		r.use("minted")
+		if "" == lang {
+			lang = "text"
+		}
+		r.WriteString("\\begin{minted}{" + lang + "}\n" + code + "\n\\end{minted}\n")
+		return ast.WalkSkipChildren
+	}
+
+	r.use("listings")
+	r.WriteString("\\begin{lstlisting}")
+	if language, ok := latexListingsLanguages[lang]; ok {
+		r.WriteString("[language=" + language + "]")
+	}
+	r.WriteString("\n" + code + "\n\\end{lstlisting}\n")
+	return ast.WalkSkipChildren
+}
+
+func (r *LaTeXRenderer) renderMathBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	r.use("amsmath")
+	r.startBlock(node)
+	var math string
+	if content := node.ChildByType(ast.NodeMathBlockContent); nil != content {
+		math = strings.TrimSpace(util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil)))
+	}
+	if strings.HasPrefix(math, "\\begin{") {
+		r.WriteString(math + "\n")
+	} else {
+		r.WriteString("\\[\n" + math + "\n\\]\n")
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *LaTeXRenderer) renderInlineMath(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.use("amsmath")
+		var math []byte
+		if content := node.ChildByType(ast.NodeInlineMathContent); nil != content {
+			math = bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil)
+		}
+		r.WriteString("\\(" + strings.TrimSpace(util.BytesToStr(math)) + "\\)")
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *LaTeXRenderer) renderTable(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		if r.latexOptions.LongTable {
+			r.WriteString("\\hline\n\\end{longtable}\n")
+		} else {
+			r.WriteString("\\hline\n\\end{tabular}\n\\end{center}\n")
+		}
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	spec := &bytes.Buffer{}
+	spec.WriteByte('|')
+	for _, align := range node.TableAligns {
+		switch align {
+		case 2:
+			spec.WriteByte('c')
+		case 3:
+			spec.WriteByte('r')
+		default:
+			spec.WriteByte('l')
+		}
+		spec.WriteByte('|')
+	}
+	if r.latexOptions.LongTable {
+		r.use("longtable")
+		r.WriteString("\\begin{longtable}{" + spec.String() + "}\n")
+	} else {
+		r.WriteString("\\begin{center}\n\\begin{tabular}{" + spec.String() + "}\n")
+	}
+	r.WriteString("\\hline\n")
+	return ast.WalkContinue
+}
+
+func (r *LaTeXRenderer) renderTableRow(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.WriteString(" \\\\\n")
+		if ast.NodeTableHead == node.Parent.Type {
+			r.WriteString("\\hline\n")
+			if r.latexOptions.LongTable {
+				r.WriteString("\\endhead\n")
+			}
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *LaTeXRenderer) renderTableCell(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if nil != node.Previous {
+			r.WriteString(" & ")
+		}
+		if ast.NodeTableHead == node.Parent.Parent.Type {
+			r.WriteString("\\textbf{")
+		}
+	} else if ast.NodeTableHead == node.Parent.Parent.Type {
+		r.WriteByte('}')
+	}
+	return ast.WalkContinue
+}
+
+func (r *LaTeXRenderer) renderFootnotesRef(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	idx, def := r.Tree.FindFootnotesDef(node.Tokens)
+	if nil == def {
+		r.WriteString(latexEscape("[^" + util.BytesToStr(node.Tokens) + "]"))
+		return ast.WalkSkipChildren
+	}
+
+	label := "fn:" + strconv.Itoa(idx)
+	if r.footnotes[idx] {
+		r.WriteString("\\footref{" + label + "}")
+		return ast.WalkSkipChildren
+	}
+	r.footnotes[idx] = true
+
+	// 脚注内容输出在引用的位置
+	writer, lastOut := r.Writer, r.LastOut
+	r.Writer, r.LastOut = &bytes.Buffer{}, lex.ItemNewline
+	for n := def.FirstChild; nil != n; n = n.Next {
+		ast.Walk(n, r.renderNode)
+	}
+	content := strings.TrimSpace(r.Writer.String())
+	r.Writer, r.LastOut = writer, lastOut
+	r.WriteString("\\footnote{\\label{" + label + "}" + content + "}")
+	return ast.WalkSkipChildren
+}
+
+func (r *LaTeXRenderer) renderToC(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		r.WriteString("\\tableofcontents\n")
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *LaTeXRenderer) renderText(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		tokens := bytes.ReplaceAll(node.Tokens, editor.CaretTokens, nil)
+		if ast.NodeText == node.Type || ast.NodeLinkText == node.Type {
+			if r.Options.AutoSpace {
+				tokens = r.Space(tokens)
+			}
+			if r.Options.FixTermTypo {
+				tokens = r.FixTermTypo(tokens)
+			}
+		}
+		r.WriteString(latexEscape(util.BytesToStr(tokens)))
+	}
+	return ast.WalkContinue
+}
+
+func (r *LaTeXRenderer) renderEmojiImg(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if alias := node.ChildByType(ast.NodeEmojiAlias); nil != alias {
+			r.WriteString(latexEscape(util.BytesToStr(alias.Tokens)))
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *LaTeXRenderer) renderSoftBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if r.Options.SoftBreak2HardBreak {
+			r.renderHardBreak(node, entering)
+		} else {
+			r.WriteByte(lex.ItemNewline)
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *LaTeXRenderer) renderHardBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if node.ParentIs(ast.NodeTableCell) {
+			// 表格单元格中的 \\ 会结束表格行
+			r.WriteByte(' ')
+		} else {
+			r.WriteString("\\\\\n")
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *LaTeXRenderer) renderInlineHTML(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering && bytes.HasPrefix(bytes.ToLower(node.Tokens), []byte("<br")) {
+		r.renderHardBreak(node, entering)
+	}
+	return ast.WalkContinue
+}
+
+func (r *LaTeXRenderer) renderCodeSpan(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		var code []byte
+		if content := node.ChildByType(ast.NodeCodeSpanContent); nil != content {
+			code = bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil)
+		}
+		r.WriteString("\\texttt{" + latexEscape(util.BytesToStr(code)) + "}")
+	}
+	return ast.WalkSkipChildren
+}
+
+// latexCommandPackages 为行级格式命令依赖的宏包。
+var latexCommandPackages = map[string][]string{
+	"sout":  {"ulem"},
+	"uline": {"ulem"},
+	"hl":    {"xcolor", "soul"},
+}
+
+// renderCommand 返回一个渲染函数，使用命令 name 包裹节点的子节点。
+func (r *LaTeXRenderer) renderCommand(name string) RendererFunc {
+	return func(node *ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			r.use(latexCommandPackages[name]...)
+			r.WriteString("\\" + name + "{")
+		} else {
+			r.WriteByte('}')
+		}
+		return ast.WalkContinue
+	}
+}
+
+// latexTextMarkCommands 为行级元素类型对应的命令。
+var latexTextMarkCommands = map[string]string{
+	"strong": "textbf",
+	"em":     "emph",
+	"s":      "sout",
+	"u":      "uline",
+	"mark":   "hl",
+	"code":   "texttt",
+	"kbd":    "texttt",
+	"sup":    "textsuperscript",
+	"sub":    "textsubscript",
+}
+
+func (r *LaTeXRenderer) renderTextMark(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	closing := 0
+	for _, typ := range strings.Split(node.TextMarkType, " ") {
+		if name, ok := latexTextMarkCommands[typ]; ok {
+			r.use(latexCommandPackages[name]...)
+			r.WriteString("\\" + name + "{")
+			closing++
+		}
+	}
+	if node.IsTextMarkType("a") && "" != node.TextMarkAHref {
+		r.startLink(node.TextMarkAHref)
+		closing++
+	}
+	if node.IsTextMarkType("inline-math") {
+		r.use("amsmath")
+		r.WriteString("\\(" + strings.TrimSpace(node.TextMarkInlineMathContent) + "\\)")
+	} else {
+		r.WriteString(latexEscape(node.TextMarkTextContent))
+	}
+	r.WriteString(strings.Repeat("}", closing))
+	return ast.WalkSkipChildren
+}
+
+func (r *LaTeXRenderer) renderLink(node *ast.Node, entering bool) ast.WalkStatus {
+	autolink := 2 == node.LinkType || "" == node.Text()
+	if !entering {
+		if !autolink {
+			r.WriteByte('}')
+		}
+		return ast.WalkContinue
+	}
+
+	var dest string
+	if d := node.ChildByType(ast.NodeLinkDest); nil != d {
+		dest = strings.TrimSpace(util.BytesToStr(r.LinkPath(d.Tokens)))
+	}
+	if autolink {
+		r.use("hyperref")
+		r.WriteString("\\url{" + latexURLEscape(dest) + "}")
+		return ast.WalkSkipChildren
+	}
+	r.startLink(dest)
+	return ast.WalkContinue
+}
+
+// startLink 开始输出链接到 dest 的超链接，链接文本输出后需要使用 } 结束。
+func (r *LaTeXRenderer) startLink(dest string) {
+	r.use("hyperref")
+	if strings.HasPrefix(dest, "#") && latexLabel(dest[1:]) {
+		r.WriteString("\\hyperref[" + dest[1:] + "]{")
+		return
+	}
+	r.WriteString("\\href{" + latexURLEscape(dest) + "}{")
+}
+
+func (r *LaTeXRenderer) renderImage(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	path := r.imagePath(node)
+	if r.remote(path) {
+		// 无法在 LaTeX 中直接引用网络图片
+		alt := imageAlt(node)
+		if "" == alt {
+			alt = path
+		}
+		r.use("hyperref")
+		r.WriteString("\\href{" + latexURLEscape(path) + "}{" + latexEscape(alt) + "}")
+		return ast.WalkSkipChildren
+	}
+
+	r.use("graphicx")
+	r.WriteString("\\includegraphics[width=\\linewidth,keepaspectratio]{" + path + "}")
+	return ast.WalkSkipChildren
+}
+
+// imagePath 返回图片 image 的地址，本地路径会进行 URL 解码。
+func (r *LaTeXRenderer) imagePath(image *ast.Node) (ret string) {
+	dest := image.ChildByType(ast.NodeLinkDest)
+	if nil == dest {
+		return
+	}
+	ret = strings.TrimSpace(util.BytesToStr(r.LinkPath(dest.Tokens)))
+	if !r.remote(ret) {
+		if unescaped, err := url.PathUnescape(ret); nil == err {
+			ret = unescaped
+		}
+	}
+	return
+}
+
+func (r *LaTeXRenderer) remote(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "//")
+}
+
+var latexEscaper = strings.NewReplacer(
+	"\\", "\\textbackslash{}",
+	"{", "\\{",
+	"}", "\\}",
+	"$", "\\$",
+	"&", "\\&",
+	"#", "\\#",
+	"^", "\\textasciicircum{}",
+	"_", "\\_",
+	"%", "\\%",
+	"~", "\\textasciitilde{}",
+	"<", "\\textless{}",
+	">", "\\textgreater{}",
+	"|", "\\textbar{}",
+)
+
+// latexEscape 转义 LaTeX 文本中的特殊字符。
+func latexEscape(text string) string {
+	return latexEscaper.Replace(text)
+}
+
+var latexURLEscaper = strings.NewReplacer("%", "\\%", "#", "\\#")
+
+// latexURLEscape 转义 \url 和 \href 中的链接地址。
+func latexURLEscape(url string) string {
+	return latexURLEscaper.Replace(url)
+}
+
+// latexLabel 判断 id 是否可以作为 \label 使用。
+func latexLabel(id string) bool {
+	if "" == id {
+		return false
+	}
+	for _, c := range id {
+		if !('a' <= c && 'z' >= c) && !('A' <= c && 'Z' >= c) && !('0' <= c && '9' >= c) && !strings.ContainsRune("-_:.", c) {
+			return false
+		}
+	}
+	return true
+}
@@ -12,8 +12,12 @@ package render
 
 import (
 	"bytes"
+	"net/url"
+	"path/filepath"
 	"strings"
 
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/lex"
 	"github.com/88250/lute/util"
 )
 
@@ -86,3 +90,79 @@ func (r *BaseRenderer) isRelativePath(dest []byte) bool {
 	}
 	return !bytes.Contains(dest, []byte(":/")) && !bytes.Contains(dest, []byte(":\\")) && !bytes.Contains(dest, []byte(":%5C"))
 }
+
+// localAssetPath 返回链接地址 dest 对应的本地文件路径，dest 必须是 assetsDir 下的相对路径，否则返回 ""。
+//
+// 绝对路径和 file:// 地址不会被打包，以免文档把 assetsDir 以外的本地文件带入导出结果，这些地址保留为链接。
+func localAssetPath(assetsDir, dest string) string {
+	dest = strings.TrimSpace(dest)
+	if strings.Contains(dest, "://") || strings.HasPrefix(dest, "data:") || "" == dest {
+		return ""
+	}
+	if i := strings.IndexAny(dest, "?#"); 0 <= i {
+		dest = dest[:i]
+	}
+	if unescaped, err := url.PathUnescape(dest); nil == err {
+		dest = unescaped
+	}
+	if strings.HasPrefix(dest, "/") || strings.HasPrefix(dest, "\\") {
+		return ""
+	}
+	dest = filepath.FromSlash(dest)
+	if filepath.IsAbs(dest) || "" != filepath.VolumeName(dest) {
+		return ""
+	}
+
+	base := filepath.Clean(assetsDir)
+	ret := filepath.Join(base, dest)
+	rel, err := filepath.Rel(base, ret)
+	if nil != err || "." == rel || ".." == rel || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return ret
+}
+
+// imageAlt 返回图片 image 的替代文本，和 HTML 渲染一样使用图片描述中所有子节点的纯文本。
+func imageAlt(image *ast.Node) string {
+	buf := &bytes.Buffer{}
+	ast.Walk(image, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.WalkContinue
+		}
+		switch n.Type {
+		case ast.NodeText, ast.NodeLinkText, ast.NodeCodeSpanContent, ast.NodeInlineMathContent:
+			buf.Write(n.Tokens)
+		case ast.NodeSoftBreak, ast.NodeHardBreak:
+			buf.WriteByte(lex.ItemNewline)
+		}
+		return ast.WalkContinue
+	})
+	return buf.String()
+}
+
+// emptyLinkText 判断链接 link 的链接文本是否为空。
+func emptyLinkText(link *ast.Node) bool {
+	openBracket := link.ChildByType(ast.NodeOpenBracket)
+	return nil == openBracket || nil == openBracket.Next || ast.NodeCloseBracket == openBracket.Next.Type
+}
+
+// imageParagraph 返回仅包含一张图片的段落中的图片，其他情况返回 nil。
+func imageParagraph(paragraph *ast.Node) (ret *ast.Node) {
+	for n := paragraph.FirstChild; nil != n; n = n.Next {
+		switch n.Type {
+		case ast.NodeImage:
+			if nil != ret {
+				return nil
+			}
+			ret = n
+		case ast.NodeText:
+			if !util.IsEmptyStr(string(n.Tokens)) {
+				return nil
+			}
+		case ast.NodeKramdownSpanIAL:
+		default:
+			return nil
+		}
+	}
+	return
+}
@@ -0,0 +1,560 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/util"
+)
+
+// MdastRenderer 描述了 mdast（https://github.com/syntax-tree/mdast）JSON 渲染器，输出的语法树可以直接交给 unified/remark 插件处理。
+//
+// 除了 CommonMark 节点，还会输出 GFM（delete、table、footnoteDefinition 等）和 remark-math（math、inlineMath）扩展节点，
+// 标题自定义 ID 和 IAL 输出到 data.hProperties 上。其他 Lute 特有的节点（块引用、超级块等）输出为 "lute:" 命名空间下的自定义节点，
+// 节点属性和标记符输出到 data 上，这样可以通过 parse.ParseMdast 还原为一致的语法树。
+type MdastRenderer struct {
+	*BaseRenderer
+
+	stack []map[string]interface{} // 正在输出的父节点
+}
+
+// NewMdastRenderer 创建一个 mdast JSON 渲染器。
+func NewMdastRenderer(tree *parse.Tree, options *Options, parseOptions *parse.Options) *MdastRenderer {
+	ret := &MdastRenderer{BaseRenderer: NewBaseRenderer(tree, options, parseOptions)}
+	ret.DefaultRendererFunc = ret.renderCustom
+	ret.RendererFuncs[ast.NodeDocument] = ret.renderTransparent
+	ret.RendererFuncs[ast.NodeParagraph] = ret.renderParent("paragraph")
+	ret.RendererFuncs[ast.NodeHeading] = ret.renderHeading
+	ret.RendererFuncs[ast.NodeThematicBreak] = ret.renderLeaf("thematicBreak")
+	ret.RendererFuncs[ast.NodeBlockquote] = ret.renderParent("blockquote")
+	ret.RendererFuncs[ast.NodeList] = ret.renderList
+	ret.RendererFuncs[ast.NodeListItem] = ret.renderListItem
+	ret.RendererFuncs[ast.NodeCodeBlock] = ret.renderCodeBlock
+	ret.RendererFuncs[ast.NodeMathBlock] = ret.renderMathBlock
+	ret.RendererFuncs[ast.NodeHTMLBlock] = ret.renderHTML
+	ret.RendererFuncs[ast.NodeInlineHTML] = ret.renderHTML
+	ret.RendererFuncs[ast.NodeYamlFrontMatter] = ret.renderYamlFrontMatter
+	ret.RendererFuncs[ast.NodeTable] = ret.renderTable
+	ret.RendererFuncs[ast.NodeTableHead] = ret.renderTransparent
+	ret.RendererFuncs[ast.NodeTableRow] = ret.renderParent("tableRow")
+	ret.RendererFuncs[ast.NodeTableCell] = ret.renderParent("tableCell")
+	ret.RendererFuncs[ast.NodeLinkRefDefBlock] = ret.renderTransparent
+	ret.RendererFuncs[ast.NodeLinkRefDef] = ret.renderLinkRefDef
+	ret.RendererFuncs[ast.NodeFootnotesDefBlock] = ret.renderTransparent
+	ret.RendererFuncs[ast.NodeFootnotesDef] = ret.renderFootnotesDef
+	ret.RendererFuncs[ast.NodeFootnotesRef] = ret.renderFootnotesRef
+	ret.RendererFuncs[ast.NodeText] = ret.renderText
+	ret.RendererFuncs[ast.NodeLinkText] = ret.renderText
+	ret.RendererFuncs[ast.NodeHTMLEntity] = ret.renderText
+	ret.RendererFuncs[ast.NodeBackslash] = ret.renderTransparent
+	ret.RendererFuncs[ast.NodeBackslashContent] = ret.renderText
+	ret.RendererFuncs[ast.NodeEmoji] = ret.renderTransparent
+	ret.RendererFuncs[ast.NodeEmojiUnicode] = ret.renderText
+	ret.RendererFuncs[ast.NodeEmojiImg] = ret.renderEmojiImg
+	ret.RendererFuncs[ast.NodeSoftBreak] = ret.renderSoftBreak
+	ret.RendererFuncs[ast.NodeHardBreak] = ret.renderLeaf("break")
+	ret.RendererFuncs[ast.NodeBr] = ret.renderLeaf("break")
+	ret.RendererFuncs[ast.NodeEmphasis] = ret.renderParent("emphasis")
+	ret.RendererFuncs[ast.NodeStrong] = ret.renderParent("strong")
+	ret.RendererFuncs[ast.NodeStrikethrough] = ret.renderParent("delete")
+	ret.RendererFuncs[ast.NodeCodeSpan] = ret.renderCodeSpan
+	ret.RendererFuncs[ast.NodeInlineMath] = ret.renderInlineMath
+	ret.RendererFuncs[ast.NodeLink] = ret.renderLink
+	ret.RendererFuncs[ast.NodeImage] = ret.renderImage
+	return ret
+}
+
+func (r *MdastRenderer) Render() (output []byte) {
+	root := r.newNode("root", r.Tree.Root)
+	if last := r.Tree.Root.LastChild; 1 > len(r.Tree.Root.KramdownIAL) && nil != last && r.docIAL(last) {
+		mdastSetIAL(root, parse.Tokens2IAL(last.Tokens))
+	}
+	root["children"] = []interface{}{}
+	r.stack = []map[string]interface{}{root}
+	ast.Walk(r.Tree.Root, r.renderNode)
+
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(root); nil != err {
+		panic("marshal mdast failed: " + err.Error()) // 通过 RenderE 渲染时会被恢复为错误
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// newNode 创建类型为 typ 的 mdast 节点，节点位置使用 node 的源码位置。
+//
+// node 的 IAL 输出到 data 上：data.hProperties 为属性键值，data.kramdownIAL 为按原顺序排列的属性对，用于还原 IAL。
+func (r *MdastRenderer) newNode(typ string, node *ast.Node) map[string]interface{} {
+	ret := map[string]interface{}{"type": typ}
+	if p := node.Position; nil != p {
+		ret["position"] = map[string]interface{}{
+			"start": map[string]interface{}{"line": p.StartLine, "column": p.StartColumn, "offset": p.StartOffset},
+			"end":   map[string]interface{}{"line": p.EndLine, "column": p.EndColumn, "offset": p.EndOffset},
+		}
+	}
+	mdastSetIAL(ret, node.KramdownIAL)
+	return ret
+}
+
+// mdastSetIAL 将 IAL 设置到 mdast 节点 node 的 data 上。
+func mdastSetIAL(node map[string]interface{}, ial [][]string) {
+	if 1 > len(ial) {
+		return
+	}
+
+	properties := map[string]interface{}{}
+	for k, v := range parse.IAL2Map(ial) {
+		properties[k] = v
+	}
+	mdastSetData(node, "hProperties", properties)
+	mdastSetData(node, "kramdownIAL", ial)
+}
+
+// mdastSetData 设置 mdast 节点 node 的 data 上键为 key 的值。
+func mdastSetData(node map[string]interface{}, key string, value interface{}) {
+	data, ok := node["data"].(map[string]interface{})
+	if !ok {
+		data = map[string]interface{}{}
+		node["data"] = data
+	}
+	data[key] = value
+}
+
+// open 将父节点 parent 添加到正在输出的父节点中，并开始输出 parent 的子节点。
+func (r *MdastRenderer) open(parent map[string]interface{}) {
+	parent["children"] = []interface{}{}
+	r.add(parent)
+	r.stack = append(r.stack, parent)
+}
+
+// close 结束输出最近的父节点。
+func (r *MdastRenderer) close() {
+	r.stack = r.stack[:len(r.stack)-1]
+}
+
+// add 将节点 node 添加到正在输出的父节点中，相邻的文本节点会被合并。
+func (r *MdastRenderer) add(node map[string]interface{}) {
+	top := r.stack[len(r.stack)-1]
+	children := top["children"].([]interface{})
+	if "text" == node["type"] && 0 < len(children) {
+		if last := children[len(children)-1].(map[string]interface{}); "text" == last["type"] {
+			last["value"] = last["value"].(string) + node["value"].(string)
+			if end, ok := node["position"].(map[string]interface{}); ok {
+				if position, ok := last["position"].(map[string]interface{}); ok {
+					position["end"] = end["end"]
+				}
+			}
+			return
+		}
+	}
+	top["children"] = append(children, node)
+}
+
+// renderParent 返回一个渲染函数，将节点渲染为类型为 typ 的 mdast 父节点。
+func (r *MdastRenderer) renderParent(typ string) RendererFunc {
+	return func(node *ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			r.open(r.newNode(typ, node))
+		} else {
+			r.close()
+		}
+		return ast.WalkContinue
+	}
+}
+
+// renderLeaf 返回一个渲染函数，将节点渲染为类型为 typ 的 mdast 叶子节点。
+func (r *MdastRenderer) renderLeaf(typ string) RendererFunc {
+	return func(node *ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			r.add(r.newNode(typ, node))
+		}
+		return ast.WalkSkipChildren
+	}
+}
+
+// renderTransparent 用于渲染没有对应 mdast 节点的节点，仅渲染其子节点。
+func (r *MdastRenderer) renderTransparent(node *ast.Node, entering bool) ast.WalkStatus {
+	return ast.WalkContinue
+}
+
+// mdastSyntax 判断节点 node 是否是 mdast 节点中不需要输出的语法标记。
+func mdastSyntax(node *ast.Node) bool {
+	if node.IsMarker() {
+		return true
+	}
+	switch node.Type {
+	case ast.NodeHeadingID, ast.NodeBang, ast.NodeOpenBracket, ast.NodeCloseBracket, ast.NodeOpenParen, ast.NodeCloseParen,
+		ast.NodeLinkDest, ast.NodeLinkSpace, ast.NodeLinkTitle:
+		return true
+	}
+	return false
+}
+
+// renderCustom 将 Lute 特有的节点渲染为 "lute:" 命名空间下的自定义节点，节点的 Tokens 输出到 value 上，其他属性输出到 data 上。
+func (r *MdastRenderer) renderCustom(node *ast.Node, entering bool) ast.WalkStatus {
+	if parent := node.Parent; nil != parent && nil != r.RendererFuncs[parent.Type] && mdastSyntax(node) {
+		// 已经映射为 mdast 节点的节点的语法标记
+		return ast.WalkSkipChildren
+	}
+	if node.IsMarker() {
+		// 自定义节点的标记符不输出为节点，记录到自定义节点的 data.markers 上
+		if entering {
+			top := r.stack[len(r.stack)-1]
+			data, _ := top["data"].(map[string]interface{})
+			markers, _ := data["markers"].([]interface{})
+			mdastSetData(top, "markers", append(markers, map[string]interface{}{"type": parse.MdastCustomType(node.Type), "value": util.BytesToStr(node.Tokens)}))
+		}
+		return ast.WalkSkipChildren
+	}
+	if r.ownedIAL(node) {
+		// IAL 已经输出到所属节点的 data 上
+		return ast.WalkSkipChildren
+	}
+
+	if !entering {
+		if nil != node.FirstChild {
+			r.close()
+		}
+		return ast.WalkContinue
+	}
+
+	custom := r.newNode(parse.MdastCustomType(node.Type), node)
+	if 0 < len(node.Tokens) {
+		custom["value"] = util.BytesToStr(node.Tokens)
+	}
+	for k, v := range mdastData(node) {
+		mdastSetData(custom, k, v)
+	}
+	if nil == node.FirstChild {
+		r.add(custom)
+		return ast.WalkSkipChildren
+	}
+	r.open(custom)
+	return ast.WalkContinue
+}
+
+// ownedIAL 判断节点 node 是否是其前一个节点或者文档的 IAL 节点，这些 IAL 已经输出到所属节点或者 root 的 data 上。
+func (r *MdastRenderer) ownedIAL(node *ast.Node) bool {
+	if ast.NodeKramdownBlockIAL != node.Type && ast.NodeKramdownSpanIAL != node.Type {
+		return false
+	}
+	if r.docIAL(node) {
+		return 0 < len(node.Parent.KramdownIAL) || node == node.Parent.LastChild
+	}
+	return nil != node.Previous && 0 < len(node.Previous.KramdownIAL)
+}
+
+// docIAL 判断节点 node 是否是文档的 IAL 节点。
+func (r *MdastRenderer) docIAL(node *ast.Node) bool {
+	return ast.NodeKramdownBlockIAL == node.Type && ast.NodeDocument == node.Parent.Type && util.IsDocIAL(node.Tokens)
+}
+
+// mdastData 返回节点 node 除类型、Tokens、子节点和位置以外的属性，IAL 通过 newNode 输出。
+func mdastData(node *ast.Node) (ret map[string]interface{}) {
+	n := *node
+	n.Children, n.FootnotesRefs, n.Position, n.Properties = nil, nil, nil, nil
+	data, err := json.Marshal(&n)
+	if nil != err {
+		panic("marshal node data failed: " + err.Error())
+	}
+	if err = json.Unmarshal(data, &ret); nil != err {
+		panic("unmarshal node data failed: " + err.Error())
+	}
+	delete(ret, "Type")
+	return
+}
+
+func (r *MdastRenderer) renderHeading(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.close()
+		return ast.WalkContinue
+	}
+
+	heading := r.newNode("heading", node)
+	heading["depth"] = node.HeadingLevel
+	if id := node.ChildByType(ast.NodeHeadingID); nil != id {
+		data, _ := heading["data"].(map[string]interface{})
+		properties, _ := data["hProperties"].(map[string]interface{})
+		if nil == properties {
+			properties = map[string]interface{}{}
+		}
+		properties["id"] = strings.TrimPrefix(util.BytesToStr(id.Tokens), "#")
+		mdastSetData(heading, "hProperties", properties)
+	}
+	r.open(heading)
+	return ast.WalkContinue
+}
+
+func (r *MdastRenderer) renderList(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.close()
+		return ast.WalkContinue
+	}
+
+	list := r.newNode("list", node)
+	ordered := 1 == node.ListData.Typ || (3 == node.ListData.Typ && 0 == node.ListData.BulletChar)
+	list["ordered"] = ordered
+	if ordered {
+		list["start"] = node.ListData.Start
+	} else {
+		list["start"] = nil
+	}
+	list["spread"] = !node.ListData.Tight
+	r.open(list)
+	return ast.WalkContinue
+}
+
+func (r *MdastRenderer) renderListItem(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.close()
+		return ast.WalkContinue
+	}
+
+	item := r.newNode("listItem", node)
+	item["spread"] = !node.ListData.Tight && nil != node.FirstChild && nil != node.FirstChild.Next
+	item["checked"] = nil
+	if 3 == node.ListData.Typ {
+		if paragraph := node.FirstChild; nil != paragraph && nil != paragraph.FirstChild && ast.NodeTaskListItemMarker == paragraph.FirstChild.Type {
+			item["checked"] = paragraph.FirstChild.TaskListItemChecked
+		}
+	}
+	r.open(item)
+	return ast.WalkContinue
+}
+
+func (r *MdastRenderer) renderCodeBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		code := r.newNode("code", node)
+		code["lang"], code["meta"] = nil, nil
+		if info := node.ChildByType(ast.NodeCodeBlockFenceInfoMarker); nil != info {
+			if fields := strings.SplitN(strings.TrimSpace(util.BytesToStr(info.CodeBlockInfo)), " ", 2); "" != fields[0] {
+				code["lang"] = fields[0]
+				if 1 < len(fields) && "" != strings.TrimSpace(fields[1]) {
+					code["meta"] = strings.TrimSpace(fields[1])
+				}
+			}
+		}
+		code["value"] = ""
+		if content := node.ChildByType(ast.NodeCodeBlockCode); nil != content {
+			code["value"] = strings.TrimSuffix(util.BytesToStr(content.Tokens), "\n")
+		}
+		r.add(code)
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *MdastRenderer) renderMathBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		math := r.newNode("math", node)
+		math["meta"], math["value"] = nil, ""
+		if content := node.ChildByType(ast.NodeMathBlockContent); nil != content {
+			math["value"] = util.BytesToStr(content.Tokens)
+		}
+		r.add(math)
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *MdastRenderer) renderInlineMath(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		math := r.newNode("inlineMath", node)
+		math["value"] = ""
+		if content := node.ChildByType(ast.NodeInlineMathContent); nil != content {
+			math["value"] = util.BytesToStr(content.Tokens)
+		}
+		r.add(math)
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *MdastRenderer) renderHTML(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		html := r.newNode("html", node)
+		html["value"] = util.BytesToStr(node.Tokens)
+		r.add(html)
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *MdastRenderer) renderYamlFrontMatter(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		yaml := r.newNode("yaml", node)
+		yaml["value"] = ""
+		if content := node.ChildByType(ast.NodeYamlFrontMatterContent); nil != content {
+			yaml["value"] = util.BytesToStr(content.Tokens)
+		}
+		r.add(yaml)
+	}
+	return ast.WalkSkipChildren
+}
+
+// mdastAligns 为表格对齐方式对应的 mdast 对齐方式。
+var mdastAligns = []interface{}{nil, "left", "center", "right"}
+
+func (r *MdastRenderer) renderTable(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.close()
+		return ast.WalkContinue
+	}
+
+	table := r.newNode("table", node)
+	aligns := []interface{}{}
+	for _, align := range node.TableAligns {
+		if 0 > align || len(mdastAligns) <= align {
+			align = 0
+		}
+		aligns = append(aligns, mdastAligns[align])
+	}
+	table["align"] = aligns
+	r.open(table)
+	return ast.WalkContinue
+}
+
+// mdastLabel 返回链接引用和脚注的 label 规范化后的 identifier。
+func mdastLabel(label string) (identifier string) {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+// mdastLinkDest 返回链接 link 的地址和标题，没有标题时标题为 nil。
+func (r *MdastRenderer) mdastLinkDest(link *ast.Node) (url string, title interface{}) {
+	if dest := link.ChildByType(ast.NodeLinkDest); nil != dest {
+		url = util.BytesToStr(r.LinkPath(dest.Tokens))
+	}
+	if t := link.ChildByType(ast.NodeLinkTitle); nil != t {
+		title = util.BytesToStr(t.Tokens)
+	}
+	return
+}
+
+func (r *MdastRenderer) renderLinkRefDef(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		definition := r.newNode("definition", node)
+		label := util.BytesToStr(node.Tokens)
+		definition["identifier"], definition["label"] = mdastLabel(label), label
+		definition["url"], definition["title"] = "", nil
+		if link := node.ChildByType(ast.NodeLink); nil != link {
+			definition["url"], definition["title"] = r.mdastLinkDest(link)
+		}
+		r.add(definition)
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *MdastRenderer) renderFootnotesDef(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.close()
+		return ast.WalkContinue
+	}
+
+	definition := r.newNode("footnoteDefinition", node)
+	label := strings.TrimPrefix(util.BytesToStr(node.Tokens), "^")
+	definition["identifier"], definition["label"] = mdastLabel(label), label
+	r.open(definition)
+	return ast.WalkContinue
+}
+
+func (r *MdastRenderer) renderFootnotesRef(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		reference := r.newNode("footnoteReference", node)
+		label := strings.TrimPrefix(util.BytesToStr(node.Tokens), "^")
+		reference["identifier"], reference["label"] = mdastLabel(label), label
+		r.add(reference)
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *MdastRenderer) renderText(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		value := util.BytesToStr(node.Tokens)
+		if nil != node.Previous && ast.NodeTaskListItemMarker == node.Previous.Type {
+			value = strings.TrimPrefix(value, " ")
+		}
+		if "" == value {
+			return ast.WalkSkipChildren
+		}
+		text := r.newNode("text", node)
+		text["value"] = value
+		r.add(text)
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *MdastRenderer) renderEmojiImg(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if alias := node.ChildByType(ast.NodeEmojiAlias); nil != alias {
+			text := r.newNode("text", node)
+			text["value"] = util.BytesToStr(alias.Tokens)
+			r.add(text)
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *MdastRenderer) renderSoftBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		text := r.newNode("text", node)
+		text["value"] = "\n"
+		r.add(text)
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *MdastRenderer) renderCodeSpan(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		code := r.newNode("inlineCode", node)
+		code["value"] = ""
+		if content := node.ChildByType(ast.NodeCodeSpanContent); nil != content {
+			code["value"] = util.BytesToStr(content.Tokens)
+		}
+		r.add(code)
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *MdastRenderer) renderLink(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.close()
+		return ast.WalkContinue
+	}
+
+	if 3 == node.LinkType {
+		reference := r.newNode("linkReference", node)
+		label := util.BytesToStr(node.LinkRefLabel)
+		reference["identifier"], reference["label"] = mdastLabel(label), label
+		reference["referenceType"] = "full"
+		if text := node.ChildByType(ast.NodeLinkText); nil != text && bytes.Equal(text.Tokens, node.LinkRefLabel) {
+			reference["referenceType"] = "shortcut"
+		}
+		r.open(reference)
+		return ast.WalkContinue
+	}
+
+	link := r.newNode("link", node)
+	link["url"], link["title"] = r.mdastLinkDest(node)
+	r.open(link)
+	return ast.WalkContinue
+}
+
+func (r *MdastRenderer) renderImage(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		image := r.newNode("image", node)
+		image["url"], image["title"] = r.mdastLinkDest(node)
+		image["alt"] = imageAlt(node)
+		r.add(image)
+	}
+	return ast.WalkSkipChildren
+}
@@ -151,6 +151,9 @@ func NewProtyleExportMdRenderer(tree *parse.Tree, options *Options, parseOptions
 	ret.RendererFuncs[ast.NodeGitConflictOpenMarker] = ret.renderGitConflictOpenMarker
 	ret.RendererFuncs[ast.NodeGitConflictContent] = ret.renderGitConflictContent
 	ret.RendererFuncs[ast.NodeGitConflictCloseMarker] = ret.renderGitConflictCloseMarker
+	ret.RendererFuncs[ast.NodeGitConflictOurs] = ret.renderGitConflictSection
+	ret.RendererFuncs[ast.NodeGitConflictBase] = ret.renderGitConflictSection
+	ret.RendererFuncs[ast.NodeGitConflictTheirs] = ret.renderGitConflictSection
 	ret.RendererFuncs[ast.NodeIFrame] = ret.renderIFrame
 	ret.RendererFuncs[ast.NodeWidget] = ret.renderWidget
 	ret.RendererFuncs[ast.NodeVideo] = ret.renderVideo
@@ -704,6 +707,23 @@ func (r *ProtyleExportMdRenderer) renderGitConflictCloseMarker(node *ast.Node, e
 	return ast.WalkContinue
 }
 
+func (r *ProtyleExportMdRenderer) renderGitConflictSection(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if ast.NodeGitConflictBase == node.Type {
+			r.Write(node.Tokens)
+			r.WriteByte(lex.ItemNewline)
+		} else if ast.NodeGitConflictTheirs == node.Type {
+			r.WriteString("=======")
+			r.WriteByte(lex.ItemNewline)
+		}
+	} else {
+		// 分隔行前后不保留空行
+		r.Writer.Truncate(len(bytes.TrimRight(r.Writer.Bytes(), "\n")))
+		r.WriteByte(lex.ItemNewline)
+	}
+	return ast.WalkContinue
+}
+
 func (r *ProtyleExportMdRenderer) renderGitConflictContent(node *ast.Node, entering bool) ast.WalkStatus {
 	if !entering {
 		r.Write(node.Tokens)
@@ -723,6 +743,8 @@ func (r *ProtyleExportMdRenderer) renderGitConflictOpenMarker(node *ast.Node, en
 func (r *ProtyleExportMdRenderer) renderGitConflict(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		r.Newline()
+	} else if r.withoutKramdownBlockIAL(node) && !r.isLastNode(r.Tree.Root, node) {
+		r.WriteByte(lex.ItemNewline)
 	}
 	return ast.WalkContinue
 }
@@ -1058,7 +1080,7 @@ func (r *ProtyleExportMdRenderer) renderBackslash(node *ast.Node, entering bool)
 
 func (r *ProtyleExportMdRenderer) renderToC(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		r.WriteString("[toc]\n\n")
+		r.WriteString(tocMarker(node) + "\n\n")
 	}
 	return ast.WalkContinue
 }
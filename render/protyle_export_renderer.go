@@ -150,6 +150,9 @@ func NewProtyleExportRenderer(tree *parse.Tree, options *Options, parseOptions *
 	ret.RendererFuncs[ast.NodeGitConflictOpenMarker] = ret.renderGitConflictOpenMarker
 	ret.RendererFuncs[ast.NodeGitConflictContent] = ret.renderGitConflictContent
 	ret.RendererFuncs[ast.NodeGitConflictCloseMarker] = ret.renderGitConflictCloseMarker
+	ret.RendererFuncs[ast.NodeGitConflictOurs] = ret.renderGitConflictSection
+	ret.RendererFuncs[ast.NodeGitConflictBase] = ret.renderGitConflictSection
+	ret.RendererFuncs[ast.NodeGitConflictTheirs] = ret.renderGitConflictSection
 	ret.RendererFuncs[ast.NodeIFrame] = ret.renderIFrame
 	ret.RendererFuncs[ast.NodeWidget] = ret.renderWidget
 	ret.RendererFuncs[ast.NodeVideo] = ret.renderVideo
@@ -598,6 +601,36 @@ func (r *ProtyleExportRenderer) renderGitConflictOpenMarker(node *ast.Node, ente
 }
 
 func (r *ProtyleExportRenderer) renderGitConflict(node *ast.Node, entering bool) ast.WalkStatus {
+	if isRawGitConflict(node) {
+		// 旧的未细化的结构由 renderGitConflictContent 渲染
+		return ast.WalkContinue
+	}
+
+	if entering {
+		var attrs [][]string
+		r.blockNodeAttrs(node, &attrs, "git-conflict")
+		r.Tag("div", attrs, false)
+	} else {
+		r.renderIAL(node)
+		r.Tag("/div", nil, false)
+	}
+	return ast.WalkContinue
+}
+
+// renderGitConflictSection 渲染 Git 冲突中的一方内容，各方内容并排显示，标记符所在行保存在 data-marker 属性上。
+func (r *ProtyleExportRenderer) renderGitConflictSection(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		class := "git-conflict__ours"
+		if ast.NodeGitConflictBase == node.Type {
+			class = "git-conflict__base"
+		} else if ast.NodeGitConflictTheirs == node.Type {
+			class = "git-conflict__theirs"
+		}
+		attrs := [][]string{{"data-type", node.Type.String()}, {"class", class}, {"data-marker", html.EscapeString(gitConflictMarker(node))}}
+		r.Tag("div", attrs, false)
+	} else {
+		r.Tag("/div", nil, false)
+	}
 	return ast.WalkContinue
 }
 
@@ -1739,7 +1772,7 @@ func (r *ProtyleExportRenderer) renderTaskListItemMarker(node *ast.Node, enterin
 			r.WriteString("<div class=\"protyle-action protyle-action--task\"><svg><use xlink:href=\"#iconUncheck\"></use></svg></div>")
 		}
 		if nil == node.Next {
-			node.InsertAfter(&ast.Node{ID: ast.NewNodeID(), Type: ast.NodeParagraph})
+			node.InsertAfter(&ast.Node{ID: r.ParseOptions.NewNodeID(nil), Type: ast.NodeParagraph})
 		}
 	}
 	return ast.WalkContinue
@@ -12,9 +12,9 @@ package render
 
 import (
 	"bytes"
+	"context"
 	"strconv"
 	"strings"
-	"unicode"
 	"unicode/utf8"
 
 	"github.com/88250/lute/ast"
@@ -86,6 +86,16 @@ type Options struct {
 	Terms map[string]string
 	// ToC 设置是否打开“目录”支持。
 	ToC bool
+	// ToCMinLevel 设置目录中包含的最小标题级别，默认为 1。
+	ToCMinLevel int
+	// ToCMaxLevel 设置目录中包含的最大标题级别，默认为 6。
+	ToCMaxLevel int
+	// ToCNumbering 设置是否对目录条目自动编号（1、1.1、1.2 等）。
+	ToCNumbering bool
+	// ToCNumberHeadings 设置是否将目录编号同时加在标题前，仅在 HTML 渲染器 HtmlRenderer 中支持。
+	ToCNumberHeadings bool
+	// ToCExcludeClass 设置目录排除类名，标题内联属性列表中 class 包含该类名或者 toc 属性为 false 时不会出现在目录中，默认为 "no-toc"。
+	ToCExcludeClass string
 	// HeadingID 设置是否打开“自定义标题 ID”支持。
 	HeadingID bool
 	// KramdownIALIDRenderName 设置 kramdown 内联属性列表中出现 id 属性时渲染 id 属性用的 name(key) 名称，默认为 "id"。
@@ -93,6 +103,8 @@ type Options struct {
 	KramdownIALIDRenderName string
 	// HeadingAnchor 设置是否对标题生成链接锚点。
 	HeadingAnchor bool
+	// HeadingIDSlugger 设置标题 ID 生成策略，标题锚点和目录都使用该策略生成的 ID，为 nil 时使用 Lute 默认策略。
+	HeadingIDSlugger HeadingIDSlugger
 	// GFMTaskListItemClass 作为 GFM 任务列表项类名，默认为 "vditor-task"。
 	GFMTaskListItemClass string
 	// VditorCodeBlockPreview 设置 Vditor 代码块是否需要渲染预览部分
@@ -143,6 +155,9 @@ func NewOptions() *Options {
 		ChineseParagraphBeginningSpace: false,
 		FixTermTypo:                    false,
 		ToC:                            false,
+		ToCMinLevel:                    1,
+		ToCMaxLevel:                    6,
+		ToCExcludeClass:                "no-toc",
 		HeadingID:                      false,
 		KramdownIALIDRenderName:        "id",
 		GFMTaskListItemClass:           "vditor-task",
@@ -172,6 +187,10 @@ type BaseRenderer struct {
 	DisableTags         int                              // 标签嵌套计数器，用于判断不可能出现标签嵌套的情况，比如语法树允许图片节点包含链接节点，但是 HTML <img> 不能包含 <a>
 	FootnotesDefs       []*ast.Node                      // 脚注定义集
 	RenderingFootnotes  bool                             // 是否正在渲染脚注定义
+
+	cancellation   context.Context      // 用于取消渲染，仅通过 RenderContext 渲染时设置
+	current        *ast.Node            // 正在渲染的节点，用于错误定位
+	headingNumbers map[*ast.Node]string // 标题编号，仅在开启 ToCNumberHeadings 时使用
 }
 
 // NewBaseRenderer 构造一个 BaseRenderer。
@@ -188,28 +207,99 @@ func (r *BaseRenderer) Render() (output []byte) {
 	r.Writer = &bytes.Buffer{}
 	r.Writer.Grow(4096)
 
-	ast.Walk(r.Tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
-		extRender := r.ExtRendererFuncs[n.Type]
-		if nil != extRender {
-			output, status := extRender(n, entering)
-			r.WriteString(output)
-			return status
+	ast.Walk(r.Tree.Root, r.renderNode)
+
+	output = r.Writer.Bytes()
+	return
+}
+
+// RenderNode 遍历并渲染节点 node（包括其子节点），输出追加到 Writer 上。
+//
+// 和 Render 不同，该函数不会重置 Writer 和 LastOut，所以可以多次调用以便逐个渲染块级节点。
+func (r *BaseRenderer) RenderNode(node *ast.Node) {
+	ast.Walk(node, r.renderNode)
+}
+
+// renderNode 使用节点 n 对应的渲染函数进行渲染。
+func (r *BaseRenderer) renderNode(n *ast.Node, entering bool) ast.WalkStatus {
+	r.current = n
+	if entering && nil != r.cancellation && r.Tree.Root == n.Parent {
+		if err := r.cancellation.Err(); nil != err {
+			panic(err) // 由 RenderContext 负责恢复
+		}
+	}
+
+	extRender := r.ExtRendererFuncs[n.Type]
+	if nil != extRender {
+		output, status := extRender(n, entering)
+		r.WriteString(output)
+		return status
+	}
+
+	render := r.RendererFuncs[n.Type]
+	if nil == render {
+		if nil != r.DefaultRendererFunc {
+			return r.DefaultRendererFunc(n, entering)
 		}
+		if ast.IsExtBlockType(n.Type) {
+			return r.renderExtBlock(n, entering)
+		}
+		if ast.IsExtInlineType(n.Type) {
+			return ast.WalkContinue // 没有提供 ExtRendererFuncs 时行级语法扩展节点仅渲染其子节点
+		}
+		return r.renderDefault(n, entering)
+	}
+	return render(n, entering)
+}
 
-		render := r.RendererFuncs[n.Type]
-		if nil == render {
-			if nil != r.DefaultRendererFunc {
-				return r.DefaultRendererFunc(n, entering)
+// RenderE 使用渲染器 renderer 进行渲染，渲染过程中发生 panic 时返回 *parse.Error 而不会导致程序崩溃。
+func RenderE(renderer Renderer) (output []byte, err error) {
+	return RenderContext(context.Background(), renderer)
+}
+
+// RenderContext 和 RenderE 一样进行渲染，并且在 ctx 取消后尽快中止渲染，此时返回的 *parse.Error 包装了 ctx.Err()。
+//
+// 仅基于 BaseRenderer 的渲染器支持取消以及出错位置定位。
+func RenderContext(ctx context.Context, renderer Renderer) (output []byte, err error) {
+	var name string
+	var position func() *ast.Position
+	if b, ok := renderer.(interface{ baseRenderer() *BaseRenderer }); ok {
+		base := b.baseRenderer()
+		if nil != base.Tree {
+			name = base.Tree.Name
+		}
+		position = func() *ast.Position {
+			for n := base.current; nil != n; n = n.Parent {
+				if nil != n.Position {
+					return n.Position
+				}
 			}
-			return r.renderDefault(n, entering)
+			return nil
 		}
-		return render(n, entering)
-	})
+		base.cancellation, base.current = ctx, nil
+		defer func() { base.cancellation, base.current = nil, nil }()
+	}
+	defer parse.RecoverError(&err, name, position)
 
-	output = r.Writer.Bytes()
+	if err = ctx.Err(); nil != err {
+		return nil, &parse.Error{Name: name, Err: err}
+	}
+	output = renderer.Render()
 	return
 }
 
+func (r *BaseRenderer) baseRenderer() *BaseRenderer {
+	return r
+}
+
+// renderExtBlock 在没有提供 ExtRendererFuncs 时渲染块级语法扩展节点：容器块仅渲染其子节点，叶子块不输出任何内容。
+func (r *BaseRenderer) renderExtBlock(n *ast.Node, entering bool) ast.WalkStatus {
+	if ast.IsExtLeafBlockType(n.Type) {
+		return ast.WalkSkipChildren
+	}
+	return ast.WalkContinue
+}
+
 func (r *BaseRenderer) renderDefault(n *ast.Node, entering bool) ast.WalkStatus {
 	r.WriteString("not found render function for node [type=" + n.Type.String() + ", Tokens=" + util.BytesToStr(n.Tokens) + "]")
 	return ast.WalkContinue
@@ -355,51 +445,63 @@ func SubStr(str string, length int) (ret string) {
 	return
 }
 
+// HeadingID 使用 Lute 默认策略返回标题 heading 的 ID。
 func HeadingID(heading *ast.Node) (ret string) {
-	if 0 == len(util.StrToBytes(heading.HeadingNormalizedID)) {
-		headingID0(heading)
+	return HeadingIDWithSlugger(heading, nil)
+}
+
+// HeadingID 使用渲染选项中配置的标题 ID 生成策略返回标题 heading 的 ID。
+func (r *BaseRenderer) HeadingID(heading *ast.Node) (ret string) {
+	return HeadingIDWithSlugger(heading, r.Options.HeadingIDSlugger)
+}
+
+// HeadingIDWithSlugger 使用标题 ID 生成策略 slugger 返回标题 heading 的 ID，slugger 为 nil 时使用 Lute 默认策略。
+func HeadingIDWithSlugger(heading *ast.Node, slugger HeadingIDSlugger) (ret string) {
+	if 0 == len(heading.HeadingNormalizedID) {
+		headingID0(heading, slugger)
 	}
 	return heading.HeadingNormalizedID
 }
 
-func headingID0(heading *ast.Node) {
+func headingID0(heading *ast.Node, slugger HeadingIDSlugger) {
+	if nil == slugger {
+		slugger = LuteHeadingIDSlugger{}
+	}
+	_, legacy := slugger.(LuteHeadingIDSlugger)
+
 	var root *ast.Node
 	for root = heading.Parent; ast.NodeDocument != root.Type; root = root.Parent {
 	}
 
-	idOccurs := map[string]int{}
+	used := map[string]bool{}
 	ast.Walk(root, func(n *ast.Node, entering bool) ast.WalkStatus {
 		if entering {
 			if ast.NodeHeading == n.Type {
-				id := normalizeHeadingID(n)
-				for ; 0 < idOccurs[id]; id += "-" {
+				// 自定义标题 ID 原样使用，仅 Lute 默认策略为了兼容会对其进行规范化
+				id, custom := headingIDText(n)
+				if !custom || legacy {
+					id = slugger.Slug(id)
+				}
+				if used[id] {
+					id = slugger.Unique(id, used)
 				}
 				n.HeadingNormalizedID = id
-				idOccurs[id] = 1
+				used[id] = true
 			}
 		}
 		return ast.WalkContinue
 	})
 }
 
-func normalizeHeadingID(heading *ast.Node) (ret string) {
-	headingID := heading.ChildByType(ast.NodeHeadingID)
-	var id string
-	if nil != headingID {
-		id = util.BytesToStr(headingID.Tokens)
+// headingIDText 返回标题 heading 用于生成 ID 的文本，custom 表示该文本是否来自自定义标题 ID。
+func headingIDText(heading *ast.Node) (ret string, custom bool) {
+	if headingID := heading.ChildByType(ast.NodeHeadingID); nil != headingID {
+		ret = util.BytesToStr(headingID.Tokens)
 	}
-	if "" == id {
-		id = heading.Text()
-	}
-
-	id = strings.TrimLeft(id, "#")
-	id = strings.ReplaceAll(id, editor.Caret, "")
-	for _, r := range id {
-		if unicode.IsLetter(r) || unicode.IsDigit(r) {
-			ret += string(r)
-		} else {
-			ret += "-"
-		}
+	ret = strings.TrimLeft(strings.ReplaceAll(ret, editor.Caret, ""), "#")
+	custom = "" != ret
+	if !custom {
+		ret = strings.ReplaceAll(heading.Text(), editor.Caret, "")
 	}
 	return
 }
@@ -411,13 +513,15 @@ type Heading struct {
 	HPath    string     `json:"hPath"`
 	Content  string     `json:"content"`
 	Level    int        `json:"level"`
+	Number   string     `json:"number,omitempty"`
 	Children []*Heading `json:"children"`
 	parent   *Heading
+	node     *ast.Node
 }
 
 func (r *BaseRenderer) renderToC(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
-		headings := r.headings()
+		headings := r.tocHeadings(r.tocOptions(node))
 		length := len(headings)
 		r.WriteString("<div class=\"vditor-toc\" data-block=\"0\" data-type=\"toc-block\" contenteditable=\"false\">")
 		if 0 < length {
@@ -437,6 +541,9 @@ func (r *BaseRenderer) renderToC(node *ast.Node, entering bool) ast.WalkStatus {
 func (r *BaseRenderer) renderToC0(heading *Heading) {
 	r.WriteString("<li>")
 	r.Tag("span", [][]string{{"data-target-id", heading.ID}}, false)
+	if "" != heading.Number {
+		r.WriteString(heading.Number + " ")
+	}
 	r.WriteString(heading.Content)
 	r.Tag("/span", nil, false)
 	if 0 < len(heading.Children) {
@@ -475,14 +582,22 @@ func (r *BaseRenderer) Tag(name string, attrs [][]string, selfclosing bool) {
 }
 
 func (r *BaseRenderer) headings() (ret []*Heading) {
+	return r.tocHeadings(r.tocOptions(nil))
+}
+
+// tocHeadings 返回按照目录选项 opts 筛选后的标题树。
+func (r *BaseRenderer) tocHeadings(opts *tocOptions) (ret []*Heading) {
 	headings := r.Tree.Root.ChildrenByType(ast.NodeHeading)
 	var tip *Heading
 	for _, heading := range headings {
 		if r.Tree.Root != heading.Parent {
 			continue
 		}
+		if heading.HeadingLevel < opts.minLevel || heading.HeadingLevel > opts.maxLevel || r.tocExcluded(heading) {
+			continue
+		}
 
-		id := HeadingID(heading)
+		id := r.HeadingID(heading)
 		if r.Options.VditorWYSIWYG {
 			id = "wysiwyg-" + id
 		} else if r.Options.VditorIR {
@@ -505,6 +620,7 @@ func (r *BaseRenderer) headings() (ret []*Heading) {
 			HPath:   r.Tree.HPath,
 			Content: headingText(heading),
 			Level:   heading.HeadingLevel,
+			node:    heading,
 		}
 
 		if nil == tip {
@@ -524,6 +640,9 @@ func (r *BaseRenderer) headings() (ret []*Heading) {
 		}
 		tip = h
 	}
+	if opts.numbering {
+		numberHeadings(ret, "")
+	}
 	return
 }
 
@@ -689,13 +808,36 @@ func (r *BaseRenderer) isLastNode(treeRoot, node *ast.Node) bool {
 	return treeRoot.LastChild == n
 }
 
+// gitConflictMarker 返回 Git 冲突内容节点 section 对应的标记符所在行：本地的内容对应 <<<<<<< 所在行，
+// 共同祖先的内容对应 ||||||| 所在行，拉取下来的内容对应 >>>>>>> 所在行。
+func gitConflictMarker(section *ast.Node) string {
+	switch section.Type {
+	case ast.NodeGitConflictOurs:
+		if marker := section.Parent.ChildByType(ast.NodeGitConflictOpenMarker); nil != marker {
+			return string(marker.Tokens)
+		}
+	case ast.NodeGitConflictBase:
+		return string(section.Tokens)
+	case ast.NodeGitConflictTheirs:
+		if marker := section.Parent.ChildByType(ast.NodeGitConflictCloseMarker); nil != marker {
+			return string(marker.Tokens)
+		}
+	}
+	return ""
+}
+
+// isRawGitConflict 判断 Git 冲突节点 gitConflict 是否为旧的未细化的结构，即内容保存在 NodeGitConflictContent 中。
+func isRawGitConflict(gitConflict *ast.Node) bool {
+	return nil != gitConflict.ChildByType(ast.NodeGitConflictContent)
+}
+
 func (r *BaseRenderer) NodeID(node *ast.Node) (ret string) {
 	for _, kv := range node.KramdownIAL {
 		if "id" == kv[0] {
 			return kv[1]
 		}
 	}
-	return ast.NewNodeID()
+	return r.ParseOptions.NewNodeID(node)
 }
 
 func (r *BaseRenderer) NodeAttrs(node *ast.Node) (ret [][]string) {
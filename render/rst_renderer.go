@@ -0,0 +1,807 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/editor"
+	"github.com/88250/lute/lex"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/util"
+)
+
+// RSTRenderer 描述了 reStructuredText 渲染器。
+//
+// 节点和 reStructuredText 结构的对应关系如下：
+//   - 标题使用 = - ~ ^ " ' 划线，标题前输出 .. _ID: 作为链接目标，容器块中的标题使用 rubric 指令
+//   - 包含硬换行的段落使用行块（| 开头），引述缩进 4 个空格，提示块使用 note、tip 等警示指令，自定义标题时使用 admonition 指令
+//   - 代码块、公式块、HTML 块和表格分别使用 code、math、raw 和 list-table 指令，表格的列对齐方式无法表示
+//   - 脚注和行级图片的替换定义集中输出在最后，仅包含一张图片的段落使用 image 指令
+//   - YAML Front Matter 输出为注释
+//   - reStructuredText 不支持嵌套的行级标记，嵌套时仅输出最外层的标记，删除线、下划线和高亮输出为普通文本
+type RSTRenderer struct {
+	*BaseRenderer
+
+	writers       []*bytes.Buffer // 容器块外层的输出，容器块的内容输出完毕后缩进输出到外层
+	lineBlock     bool            // 是否正在输出行块
+	markup        int             // 行级标记的嵌套层数
+	footnotes     []int           // 按照引用顺序排列的脚注
+	footnoteDefs  map[int]*ast.Node
+	substitutions []string // 行级图片的替换定义
+}
+
+// NewRSTRenderer 创建一个 reStructuredText 渲染器。
+func NewRSTRenderer(tree *parse.Tree, options *Options, parseOptions *parse.Options) *RSTRenderer {
+	ret := &RSTRenderer{BaseRenderer: NewBaseRenderer(tree, options, parseOptions)}
+	ret.DefaultRendererFunc = ret.renderChildren
+	ret.RendererFuncs[ast.NodeParagraph] = ret.renderParagraph
+	ret.RendererFuncs[ast.NodeHeading] = ret.renderHeading
+	ret.RendererFuncs[ast.NodeBlockquote] = ret.renderBlockquote
+	ret.RendererFuncs[ast.NodeCallout] = ret.renderCallout
+	ret.RendererFuncs[ast.NodeList] = ret.renderList
+	ret.RendererFuncs[ast.NodeListItem] = ret.renderListItem
+	ret.RendererFuncs[ast.NodeTaskListItemMarker] = ret.renderTaskListItemMarker
+	ret.RendererFuncs[ast.NodeThematicBreak] = ret.renderThematicBreak
+	ret.RendererFuncs[ast.NodeCodeBlock] = ret.renderCodeBlock
+	ret.RendererFuncs[ast.NodeMathBlock] = ret.renderMathBlock
+	ret.RendererFuncs[ast.NodeHTMLBlock] = ret.renderHTMLBlock
+	ret.RendererFuncs[ast.NodeTable] = ret.renderTable
+	ret.RendererFuncs[ast.NodeToC] = ret.renderToC
+	ret.RendererFuncs[ast.NodeFootnotesRef] = ret.renderFootnotesRef
+	ret.RendererFuncs[ast.NodeText] = ret.renderText
+	ret.RendererFuncs[ast.NodeLinkText] = ret.renderText
+	ret.RendererFuncs[ast.NodeBackslashContent] = ret.renderText
+	ret.RendererFuncs[ast.NodeHTMLEntity] = ret.renderText
+	ret.RendererFuncs[ast.NodeEmojiUnicode] = ret.renderText
+	ret.RendererFuncs[ast.NodeBlockRefText] = ret.renderText
+	ret.RendererFuncs[ast.NodeBlockRefDynamicText] = ret.renderText
+	ret.RendererFuncs[ast.NodeFileAnnotationRefText] = ret.renderText
+	ret.RendererFuncs[ast.NodeEmojiImg] = ret.renderEmojiImg
+	ret.RendererFuncs[ast.NodeSoftBreak] = ret.renderSoftBreak
+	ret.RendererFuncs[ast.NodeHardBreak] = ret.renderHardBreak
+	ret.RendererFuncs[ast.NodeCodeSpan] = ret.renderCodeSpan
+	ret.RendererFuncs[ast.NodeInlineMath] = ret.renderInlineMath
+	ret.RendererFuncs[ast.NodeStrong] = ret.renderMarkup("**", "**")
+	ret.RendererFuncs[ast.NodeEmphasis] = ret.renderMarkup("*", "*")
+	ret.RendererFuncs[ast.NodeSup] = ret.renderMarkup(":sup:`", "`")
+	ret.RendererFuncs[ast.NodeSub] = ret.renderMarkup(":sub:`", "`")
+	ret.RendererFuncs[ast.NodeKbd] = ret.renderMarkup(":kbd:`", "`")
+	ret.RendererFuncs[ast.NodeTextMark] = ret.renderTextMark
+	ret.RendererFuncs[ast.NodeLink] = ret.renderLink
+	ret.RendererFuncs[ast.NodeImage] = ret.renderImage
+	ret.RendererFuncs[ast.NodeInlineHTML] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeFootnotesDefBlock] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeLinkRefDefBlock] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeYamlFrontMatter] = ret.renderYamlFrontMatter
+	ret.RendererFuncs[ast.NodeKramdownBlockIAL] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeKramdownSpanIAL] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeHeadingID] = ret.renderSkip
+	return ret
+}
+
+func (r *RSTRenderer) Render() (output []byte) {
+	r.LastOut = lex.ItemNewline
+	r.Writer = &bytes.Buffer{}
+	r.Writer.Grow(4096)
+	r.writers, r.lineBlock, r.markup = nil, false, 0
+	r.footnotes, r.footnoteDefs, r.substitutions = nil, map[int]*ast.Node{}, nil
+	ast.Walk(r.Tree.Root, r.renderNode)
+
+	for _, idx := range r.footnotes {
+		r.separate()
+		r.push()
+		for n := r.footnoteDefs[idx].FirstChild; nil != n; n = n.Next {
+			ast.Walk(n, r.renderNode)
+		}
+		marker := ".. [" + strconv.Itoa(idx) + "] "
+		r.WriteString(rstIndent(r.pop(), marker, "   "))
+	}
+	for _, substitution := range r.substitutions {
+		r.separate()
+		r.WriteString(substitution)
+	}
+	return r.Writer.Bytes()
+}
+
+// renderChildren 用于渲染没有对应 reStructuredText 结构的节点，仅渲染其子节点。
+func (r *RSTRenderer) renderChildren(node *ast.Node, entering bool) ast.WalkStatus {
+	return ast.WalkContinue
+}
+
+// renderSkip 用于渲染在 reStructuredText 文档中不可见的节点。
+func (r *RSTRenderer) renderSkip(node *ast.Node, entering bool) ast.WalkStatus {
+	return ast.WalkSkipChildren
+}
+
+// push 开始将容器块的内容输出到新的缓冲区中。
+func (r *RSTRenderer) push() {
+	r.writers = append(r.writers, r.Writer)
+	r.Writer = &bytes.Buffer{}
+	r.LastOut = lex.ItemNewline
+}
+
+// pop 结束输出容器块的内容并恢复外层的输出，返回容器块的内容。
+func (r *RSTRenderer) pop() (ret string) {
+	ret = r.Writer.String()
+	r.Writer = r.writers[len(r.writers)-1]
+	r.writers = r.writers[:len(r.writers)-1]
+	r.LastOut = lex.ItemNewline
+	return
+}
+
+// separate 在当前输出不为空时输出一个空行，用于分隔块。
+func (r *RSTRenderer) separate() {
+	buf := r.Writer.Bytes()
+	if 1 > len(buf) || bytes.HasSuffix(buf, []byte("\n\n")) {
+		return
+	}
+	if !bytes.HasSuffix(buf, []byte("\n")) {
+		r.WriteByte(lex.ItemNewline)
+	}
+	r.WriteByte(lex.ItemNewline)
+}
+
+// startBlock 在输出块 node 前进行分隔：紧凑列表中仅包含一个段落的列表项之间换行，其他块之间空一行。
+//
+// 相邻的列表或者列表后的引述需要使用空注释分隔，否则会被合并到列表中。
+func (r *RSTRenderer) startBlock(node *ast.Node) {
+	if 1 > r.Writer.Len() {
+		return
+	}
+	if ast.NodeListItem == node.Type && node.Parent.ListData.Tight && nil != node.Previous {
+		if prev := node.Previous; nil == prev.FirstChild || (ast.NodeParagraph == prev.FirstChild.Type && nil == prev.FirstChild.Next) {
+			return
+		}
+	}
+	r.separate()
+	if prev := node.Previous; nil != prev && ast.NodeList == prev.Type && (ast.NodeList == node.Type || ast.NodeBlockquote == node.Type) {
+		r.WriteString("..\n\n")
+	}
+}
+
+// lineBreaks 判断段落 paragraph 是否需要使用行块输出。
+func (r *RSTRenderer) lineBreaks(paragraph *ast.Node) bool {
+	for n := paragraph.FirstChild; nil != n; n = n.Next {
+		if ast.NodeHardBreak == n.Type || ast.NodeBr == n.Type || (ast.NodeSoftBreak == n.Type && r.Options.SoftBreak2HardBreak) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RSTRenderer) renderParagraph(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		if r.lineBlock {
+			r.lineBlock = false
+			lines := strings.Split(r.pop(), "\n")
+			for i, line := range lines {
+				lines[i] = strings.TrimRight("| "+line, " ")
+			}
+			r.WriteString(strings.Join(lines, "\n"))
+		}
+		r.WriteByte(lex.ItemNewline)
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	if image := imageParagraph(node); nil != image {
+		r.WriteString(strings.TrimSuffix(r.imageDirective("image", image), "\n"))
+		return ast.WalkSkipChildren
+	}
+	if r.lineBreaks(node) {
+		r.lineBlock = true
+		r.push()
+	}
+	return ast.WalkContinue
+}
+
+// imageDirective 返回图片 image 的指令 directive，比如 image:: 或者替换定义中的 |image1| image::。
+func (r *RSTRenderer) imageDirective(directive string, image *ast.Node) string {
+	var dest, alt string
+	if d := image.ChildByType(ast.NodeLinkDest); nil != d {
+		dest = strings.TrimSpace(util.BytesToStr(r.LinkPath(d.Tokens)))
+	}
+	alt = strings.TrimSpace(strings.ReplaceAll(imageAlt(image), "\n", " "))
+	ret := ".. " + directive + ":: " + dest + "\n"
+	if "" != alt {
+		ret += "   :alt: " + alt + "\n"
+	}
+	return ret
+}
+
+// rstAdornments 为各级标题的划线字符。
+var rstAdornments = []string{"=", "-", "~", "^", "\"", "'"}
+
+func (r *RSTRenderer) renderHeading(node *ast.Node, entering bool) ast.WalkStatus {
+	if ast.NodeDocument != node.Parent.Type {
+		// 节只能出现在文档的顶层
+		if entering {
+			r.startBlock(node)
+			r.WriteString(".. rubric:: ")
+		} else {
+			r.WriteByte(lex.ItemNewline)
+		}
+		return ast.WalkContinue
+	}
+
+	if entering {
+		r.startBlock(node)
+		if id := r.HeadingID(node); "" != id {
+			r.WriteString(".. _" + rstTargetName(id) + ":\n\n")
+		}
+		r.push()
+		return ast.WalkContinue
+	}
+
+	title := strings.ReplaceAll(strings.TrimSpace(r.pop()), "\n", " ")
+	level := node.HeadingLevel
+	if 1 > level {
+		level = 1
+	} else if 6 < level {
+		level = 6
+	}
+	r.WriteString(title + "\n" + strings.Repeat(rstAdornments[level-1], textWidth(title)) + "\n")
+	return ast.WalkContinue
+}
+
+func (r *RSTRenderer) renderBlockquote(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		r.push()
+	} else {
+		r.WriteString(rstIndent(r.pop(), "    ", "    "))
+	}
+	return ast.WalkContinue
+}
+
+// rstAdmonitions 为 reStructuredText 内置的警示指令。
+var rstAdmonitions = map[string]bool{
+	"attention": true, "caution": true, "danger": true, "error": true, "hint": true,
+	"important": true, "note": true, "tip": true, "warning": true,
+}
+
+func (r *RSTRenderer) renderCallout(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		r.push()
+		return ast.WalkContinue
+	}
+
+	content := r.pop()
+	typ := strings.ToLower(node.CalloutType)
+	title := strings.TrimSpace(node.CalloutTitle)
+	if rstAdmonitions[typ] && ("" == title || ast.GetCalloutTitle(node.CalloutType) == title) {
+		r.WriteString(".. " + typ + "::\n")
+	} else {
+		if "" == title {
+			if title = ast.GetCalloutTitle(node.CalloutType); "" == title {
+				title = node.CalloutType
+			}
+		}
+		r.WriteString(".. admonition:: " + title + "\n")
+		if "" != typ {
+			r.WriteString("   :class: " + typ + "\n")
+		}
+	}
+	if "" != strings.TrimSpace(content) {
+		r.WriteString("\n" + rstIndent(content, "   ", "   "))
+	}
+	return ast.WalkContinue
+}
+
+func (r *RSTRenderer) renderList(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+	}
+	return ast.WalkContinue
+}
+
+func (r *RSTRenderer) renderListItem(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		r.push()
+		return ast.WalkContinue
+	}
+
+	marker := "-"
+	if 1 == node.Parent.ListData.Typ {
+		num := node.ListData.Num
+		if 1 > num {
+			num = node.Parent.ListData.Start
+			for prev := node.Previous; nil != prev; prev = prev.Previous {
+				num++
+			}
+		}
+		delimiter := node.ListData.Delimiter
+		if 0 == delimiter {
+			delimiter = '.'
+		}
+		marker = strconv.Itoa(num) + string(delimiter)
+	}
+	content := r.pop()
+	if "" == strings.TrimSpace(content) {
+		r.WriteString(marker + "\n")
+		return ast.WalkContinue
+	}
+	r.WriteString(rstIndent(content, marker+" ", strings.Repeat(" ", len(marker)+1)))
+	return ast.WalkContinue
+}
+
+func (r *RSTRenderer) renderTaskListItemMarker(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if node.TaskListItemChecked {
+			r.WriteString("[x]")
+		} else {
+			r.WriteString("[ ]")
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *RSTRenderer) renderThematicBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	// 分隔线只能出现在文档的顶层，并且不能位于文档或者节的开头和结尾
+	if ast.NodeDocument != node.Parent.Type || nil == node.Previous || nil == node.Next {
+		return ast.WalkSkipChildren
+	}
+	for _, n := range []*ast.Node{node.Previous, node.Next} {
+		if ast.NodeHeading == n.Type || ast.NodeThematicBreak == n.Type {
+			return ast.WalkSkipChildren
+		}
+	}
+	r.startBlock(node)
+	r.WriteString("----\n")
+	return ast.WalkSkipChildren
+}
+
+func (r *RSTRenderer) renderCodeBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	var code string
+	if content := node.ChildByType(ast.NodeCodeBlockCode); nil != content {
+		code = util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil))
+	}
+	var lang string
+	if info := node.ChildByType(ast.NodeCodeBlockFenceInfoMarker); nil != info {
+		if fields := strings.Fields(util.BytesToStr(info.CodeBlockInfo)); 0 < len(fields) {
+			lang = fields[0]
+		}
+	}
+	r.startBlock(node)
+	r.WriteString(".. code::")
+	if "" != lang {
+		r.WriteString(" " + lang)
+	}
+	r.WriteByte(lex.ItemNewline)
+	if "" != strings.TrimSpace(code) {
+		r.WriteString("\n" + rstIndent(code, "   ", "   "))
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *RSTRenderer) renderMathBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		r.WriteString(".. math::\n")
+		if content := node.ChildByType(ast.NodeMathBlockContent); nil != content {
+			if math := strings.TrimSpace(util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil))); "" != math {
+				r.WriteString("\n" + rstIndent(math, "   ", "   "))
+			}
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *RSTRenderer) renderYamlFrontMatter(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if content := node.ChildByType(ast.NodeYamlFrontMatterContent); nil != content {
+			if yaml := strings.TrimSpace(util.BytesToStr(content.Tokens)); "" != yaml {
+				r.startBlock(node)
+				r.WriteString("..\n" + rstIndent(yaml, "   ", "   "))
+			}
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *RSTRenderer) renderHTMLBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if html := strings.TrimSpace(util.BytesToStr(node.Tokens)); "" != html {
+			r.startBlock(node)
+			r.WriteString(".. raw:: html\n\n" + rstIndent(html, "   ", "   "))
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *RSTRenderer) renderTable(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	r.WriteString(".. list-table::\n")
+	var rows []*ast.Node
+	if head := node.ChildByType(ast.NodeTableHead); nil != head {
+		rows = head.ChildrenByType(ast.NodeTableRow)
+		r.WriteString("   :header-rows: " + strconv.Itoa(len(rows)) + "\n")
+	}
+	r.WriteByte(lex.ItemNewline)
+	for n := node.FirstChild; nil != n; n = n.Next {
+		if ast.NodeTableRow == n.Type {
+			rows = append(rows, n)
+		}
+	}
+	for _, row := range rows {
+		first := true
+		for cell := row.FirstChild; nil != cell; cell = cell.Next {
+			if ast.NodeTableCell != cell.Type {
+				continue
+			}
+			r.push()
+			for c := cell.FirstChild; nil != c; c = c.Next {
+				ast.Walk(c, r.renderNode)
+			}
+			text := strings.TrimSpace(strings.ReplaceAll(r.pop(), "\n", " "))
+			if first {
+				r.WriteString("   * -")
+				first = false
+			} else {
+				r.WriteString("     -")
+			}
+			if "" != text {
+				r.WriteString(" " + text)
+			}
+			r.WriteByte(lex.ItemNewline)
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *RSTRenderer) renderToC(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		r.WriteString(".. contents::\n")
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *RSTRenderer) renderFootnotesRef(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	idx, def := r.Tree.FindFootnotesDef(node.Tokens)
+	if nil == def {
+		r.WriteString(rstEscape("[^" + util.BytesToStr(node.Tokens) + "]"))
+		return ast.WalkSkipChildren
+	}
+	if _, ok := r.footnoteDefs[idx]; !ok {
+		r.footnoteDefs[idx] = def
+		r.footnotes = append(r.footnotes, idx)
+	}
+	r.startMarkup()
+	r.WriteString("[" + strconv.Itoa(idx) + "]_")
+	r.endMarkup(node)
+	return ast.WalkSkipChildren
+}
+
+func (r *RSTRenderer) renderText(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		tokens := bytes.ReplaceAll(node.Tokens, editor.CaretTokens, nil)
+		if ast.NodeText == node.Type || ast.NodeLinkText == node.Type {
+			if r.Options.AutoSpace {
+				tokens = r.Space(tokens)
+			}
+			if r.Options.FixTermTypo {
+				tokens = r.FixTermTypo(tokens)
+			}
+		}
+		r.WriteString(rstEscape(util.BytesToStr(tokens)))
+	}
+	return ast.WalkContinue
+}
+
+func (r *RSTRenderer) renderEmojiImg(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if alias := node.ChildByType(ast.NodeEmojiAlias); nil != alias {
+			r.WriteString(rstEscape(util.BytesToStr(alias.Tokens)))
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *RSTRenderer) renderSoftBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if r.lineBlock && !r.Options.SoftBreak2HardBreak {
+			r.WriteByte(' ')
+		} else {
+			r.renderHardBreak(node, entering)
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *RSTRenderer) renderHardBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if node.ParentIs(ast.NodeTableCell) {
+			r.WriteByte(' ')
+		} else {
+			r.WriteByte(lex.ItemNewline)
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *RSTRenderer) renderCodeSpan(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		var code string
+		if content := node.ChildByType(ast.NodeCodeSpanContent); nil != content {
+			code = util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil))
+		}
+		r.writeLiteral(node, code)
+	}
+	return ast.WalkSkipChildren
+}
+
+// writeLiteral 输出行内代码 code，嵌套在其他行级标记中时输出为普通文本。
+func (r *RSTRenderer) writeLiteral(node *ast.Node, code string) {
+	if 0 < r.markup {
+		r.WriteString(rstEscape(code))
+		return
+	}
+	if "" == strings.TrimSpace(code) {
+		return
+	}
+	r.startMarkup()
+	r.WriteString("``" + strings.TrimSpace(code) + "``")
+	r.endMarkup(node)
+}
+
+func (r *RSTRenderer) renderInlineMath(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		var math string
+		if content := node.ChildByType(ast.NodeInlineMathContent); nil != content {
+			math = strings.TrimSpace(util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil)))
+		}
+		r.writeMath(node, math)
+	}
+	return ast.WalkSkipChildren
+}
+
+// writeMath 输出行级公式 math。
+func (r *RSTRenderer) writeMath(node *ast.Node, math string) {
+	if 0 < r.markup || "" == math {
+		r.WriteString(rstEscape(math))
+		return
+	}
+	r.startMarkup()
+	r.WriteString(":math:`" + strings.ReplaceAll(math, "`", "\\`") + "`")
+	r.endMarkup(node)
+}
+
+// startMarkup 在行级标记开始前进行分隔，行级标记前面需要是空白或者标点，否则使用转义的空格分隔。
+func (r *RSTRenderer) startMarkup() {
+	buf := r.Writer.Bytes()
+	if 1 > len(buf) {
+		return
+	}
+	last, _ := utf8.DecodeLastRune(buf)
+	if !unicode.IsSpace(last) && !strings.ContainsRune("-:/'\"<([{", last) {
+		r.WriteString("\\ ")
+	}
+}
+
+// isolated 判断行级节点 node 前后是否是空白。
+func (r *RSTRenderer) isolated(node *ast.Node) bool {
+	if buf := r.Writer.Bytes(); 0 < len(buf) {
+		if last, _ := utf8.DecodeLastRune(buf); !unicode.IsSpace(last) {
+			return false
+		}
+	}
+	if next := node.Next; nil != next && ast.NodeText == next.Type && 0 < len(next.Tokens) {
+		if first, _ := utf8.DecodeRune(next.Tokens); !unicode.IsSpace(first) {
+			return false
+		}
+	}
+	return true
+}
+
+// endMarkup 在行级标记 node 结束后进行分隔，行级标记后面需要是空白或者标点，否则使用转义的空格分隔。
+func (r *RSTRenderer) endMarkup(node *ast.Node) {
+	next := node.Next
+	if nil == next || ast.NodeText != next.Type || 1 > len(next.Tokens) {
+		return
+	}
+	first, _ := utf8.DecodeRune(next.Tokens)
+	if !unicode.IsSpace(first) && !strings.ContainsRune("-.,:;!?\\/'\")]}>", first) {
+		r.WriteString("\\ ")
+	}
+}
+
+// renderMarkup 返回一个渲染函数，使用 open 和 close 包裹节点的子节点。
+func (r *RSTRenderer) renderMarkup(open, close string) RendererFunc {
+	return func(node *ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			r.markup++
+			if 1 == r.markup {
+				r.startMarkup()
+				r.WriteString(open)
+			}
+		} else {
+			r.markup--
+			if 0 == r.markup {
+				r.WriteString(close)
+				r.endMarkup(node)
+			}
+		}
+		return ast.WalkContinue
+	}
+}
+
+// rstTextMarkMarkups 为行级元素类型对应的标记，按照优先级排列。
+var rstTextMarkMarkups = [][]string{
+	{"strong", "**", "**"},
+	{"em", "*", "*"},
+	{"sup", ":sup:`", "`"},
+	{"sub", ":sub:`", "`"},
+	{"kbd", ":kbd:`", "`"},
+}
+
+func (r *RSTRenderer) renderTextMark(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	switch {
+	case node.IsTextMarkType("inline-math"):
+		r.writeMath(node, strings.TrimSpace(node.TextMarkInlineMathContent))
+		return ast.WalkSkipChildren
+	case node.IsTextMarkType("code"):
+		r.writeLiteral(node, node.TextMarkTextContent)
+		return ast.WalkSkipChildren
+	case node.IsTextMarkType("a") && "" != node.TextMarkAHref:
+		r.writeLink(node, rstEscape(node.TextMarkTextContent), node.TextMarkAHref)
+		return ast.WalkSkipChildren
+	}
+
+	text := rstEscape(node.TextMarkTextContent)
+	if 0 == r.markup && "" != strings.TrimSpace(text) {
+		for _, markup := range rstTextMarkMarkups {
+			if node.IsTextMarkType(markup[0]) {
+				r.startMarkup()
+				r.WriteString(markup[1] + text + markup[2])
+				r.endMarkup(node)
+				return ast.WalkSkipChildren
+			}
+		}
+	}
+	r.WriteString(text)
+	return ast.WalkSkipChildren
+}
+
+func (r *RSTRenderer) renderLink(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	var dest string
+	if d := node.ChildByType(ast.NodeLinkDest); nil != d {
+		dest = strings.TrimSpace(util.BytesToStr(r.LinkPath(d.Tokens)))
+	}
+	if 2 == node.LinkType || "" == node.Text() {
+		if r.isolated(node) {
+			r.WriteString(dest)
+		} else {
+			// 独立的 URL 需要使用空白或者标点与其他文本分隔
+			r.writeLink(node, rstEscape(dest), dest)
+		}
+		return ast.WalkSkipChildren
+	}
+
+	r.push()
+	r.markup++
+	for n := node.FirstChild; nil != n; n = n.Next {
+		ast.Walk(n, r.renderNode)
+	}
+	r.markup--
+	text := r.pop()
+	r.writeLink(node, text, dest)
+	return ast.WalkSkipChildren
+}
+
+// writeLink 输出链接到 dest 的匿名超链接引用，链接到 #ID 时使用内部链接目标。
+func (r *RSTRenderer) writeLink(node *ast.Node, text, dest string) {
+	if 0 < r.markup {
+		r.WriteString(text)
+		return
+	}
+
+	if strings.HasPrefix(dest, "#") && 1 < len(dest) {
+		dest = rstTargetName(dest[1:]) + "_"
+	}
+	r.startMarkup()
+	r.WriteString("`" + strings.ReplaceAll(strings.TrimSpace(text), "<", "\\<") + " <" + dest + ">`__")
+	r.endMarkup(node)
+}
+
+func (r *RSTRenderer) renderImage(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		// 行级图片使用替换引用
+		name := "image" + strconv.Itoa(len(r.substitutions)+1)
+		r.substitutions = append(r.substitutions, r.imageDirective("|"+name+"| image", node))
+		r.startMarkup()
+		r.WriteString("|" + name + "|")
+		r.endMarkup(node)
+	}
+	return ast.WalkSkipChildren
+}
+
+// rstIndent 缩进 content 的每一个非空行，第一行使用 first 缩进，其他行使用 rest 缩进。
+func rstIndent(content, first, rest string) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	buf := &bytes.Buffer{}
+	for i, line := range lines {
+		if 0 == i {
+			buf.WriteString(first)
+		} else if "" != strings.TrimSpace(line) {
+			buf.WriteString(rest)
+		}
+		buf.WriteString(strings.TrimRight(line, " "))
+		buf.WriteByte(lex.ItemNewline)
+	}
+	return buf.String()
+}
+
+// rstEscape 转义 reStructuredText 文本中可能被识别为行级标记的字符。
+func rstEscape(text string) string {
+	buf := &bytes.Buffer{}
+	for i, c := range text {
+		switch c {
+		case '\\', '*', '`', '|':
+			buf.WriteByte('\\')
+		case '_':
+			// 单词末尾的 _ 会被识别为超链接引用
+			next, _ := utf8.DecodeRuneInString(text[i+1:])
+			if i+1 >= len(text) || (!unicode.IsLetter(next) && !unicode.IsDigit(next)) {
+				buf.WriteByte('\\')
+			}
+		}
+		buf.WriteRune(c)
+	}
+	return buf.String()
+}
+
+// rstTargetName 返回 id 作为链接目标名时的写法，不是简单引用名时使用反引号包裹。
+func rstTargetName(id string) string {
+	for i, c := range id {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) {
+			continue
+		}
+		// 简单引用名中的 - _ . + 不能连续出现，也不能位于开头和结尾
+		if strings.ContainsRune("-_.+", c) && 0 < i && i < len(id)-1 && !strings.ContainsRune("-_.+:", rune(id[i+1])) {
+			continue
+		}
+		return "`" + strings.ReplaceAll(id, "`", "\\`") + "`"
+	}
+	return id
+}
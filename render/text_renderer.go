@@ -0,0 +1,881 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/editor"
+	"github.com/88250/lute/lex"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/util"
+)
+
+// ANSI 样式（SGR 参数）。
+const (
+	textANSIHeading = "1;36"
+	textANSIStrong  = "1"
+	textANSIEm      = "3"
+	textANSIStrike  = "9"
+	textANSIUnder   = "4"
+	textANSIMark    = "7"
+	textANSICode    = "33"
+	textANSILink    = "4;34"
+	textANSIDim     = "2"
+	textANSIReset   = "\x1b[0m"
+)
+
+// TextOptions 描述了纯文本渲染选项。
+type TextOptions struct {
+	// Width 为折行宽度，按照显示宽度计算（中日韩字符的宽度为 2），小于 1 时不折行
+	Width int
+	// ANSI 设置是否使用 ANSI 转义序列输出标题、强调和代码等的样式，代码块使用 Options.CodeSyntaxHighlightStyleName 进行语法高亮
+	ANSI bool
+}
+
+// NewTextOptions 创建一个默认的纯文本渲染选项，折行宽度为 80。
+func NewTextOptions() *TextOptions {
+	return &TextOptions{Width: 80}
+}
+
+// TextRenderer 描述了纯文本渲染器，用于在终端或者邮件的 text/plain 部分中显示。
+//
+// 段落和标题按照折行宽度折行，一级和二级标题下面使用 = 和 - 划线，列表使用项目符号或者序号并且悬挂缩进，引述和提示块使用 │ 缩进，
+// 代码块和公式块缩进 4 个空格并且不折行，表格使用制表符绘制边框，脚注集中输出在最后。
+type TextRenderer struct {
+	*BaseRenderer
+
+	textOptions *TextOptions
+	output      *bytes.Buffer // 块级内容的输出，行级内容输出到 Writer 中，在块结束时折行后输出到这里
+	prefixes    []*textPrefix // 容器块的行首前缀
+	styles      []string      // 当前生效的 ANSI 样式
+}
+
+// textPrefix 描述了容器块的行首前缀。
+type textPrefix struct {
+	first string // 容器块第一行的前缀，比如列表项的项目符号
+	rest  string // 后续行的前缀
+	used  bool   // 是否已经输出过第一行
+}
+
+// NewTextRenderer 创建一个纯文本渲染器，textOptions 为 nil 时使用 NewTextOptions 返回的默认选项。
+func NewTextRenderer(tree *parse.Tree, textOptions *TextOptions, options *Options, parseOptions *parse.Options) *TextRenderer {
+	if nil == textOptions {
+		textOptions = NewTextOptions()
+	}
+	ret := &TextRenderer{BaseRenderer: NewBaseRenderer(tree, options, parseOptions), textOptions: textOptions}
+	ret.DefaultRendererFunc = ret.renderChildren
+	ret.RendererFuncs[ast.NodeParagraph] = ret.renderParagraph
+	ret.RendererFuncs[ast.NodeHeading] = ret.renderHeading
+	ret.RendererFuncs[ast.NodeBlockquote] = ret.renderBlockquote
+	ret.RendererFuncs[ast.NodeCallout] = ret.renderCallout
+	ret.RendererFuncs[ast.NodeList] = ret.renderList
+	ret.RendererFuncs[ast.NodeListItem] = ret.renderListItem
+	ret.RendererFuncs[ast.NodeThematicBreak] = ret.renderThematicBreak
+	ret.RendererFuncs[ast.NodeCodeBlock] = ret.renderCodeBlock
+	ret.RendererFuncs[ast.NodeMathBlock] = ret.renderMathBlock
+	ret.RendererFuncs[ast.NodeTable] = ret.renderTable
+	ret.RendererFuncs[ast.NodeToC] = ret.renderToC
+	ret.RendererFuncs[ast.NodeFootnotesRef] = ret.renderFootnotesRef
+	ret.RendererFuncs[ast.NodeText] = ret.renderText
+	ret.RendererFuncs[ast.NodeLinkText] = ret.renderText
+	ret.RendererFuncs[ast.NodeBackslashContent] = ret.renderText
+	ret.RendererFuncs[ast.NodeHTMLEntity] = ret.renderText
+	ret.RendererFuncs[ast.NodeEmojiUnicode] = ret.renderText
+	ret.RendererFuncs[ast.NodeBlockRefText] = ret.renderText
+	ret.RendererFuncs[ast.NodeBlockRefDynamicText] = ret.renderText
+	ret.RendererFuncs[ast.NodeFileAnnotationRefText] = ret.renderText
+	ret.RendererFuncs[ast.NodeInlineMathContent] = ret.renderText
+	ret.RendererFuncs[ast.NodeEmojiImg] = ret.renderEmojiImg
+	ret.RendererFuncs[ast.NodeSoftBreak] = ret.renderSoftBreak
+	ret.RendererFuncs[ast.NodeHardBreak] = ret.renderHardBreak
+	ret.RendererFuncs[ast.NodeInlineHTML] = ret.renderInlineHTML
+	ret.RendererFuncs[ast.NodeCodeSpan] = ret.renderCodeSpan
+	ret.RendererFuncs[ast.NodeKbd] = ret.renderStyle(textANSICode)
+	ret.RendererFuncs[ast.NodeStrong] = ret.renderStyle(textANSIStrong)
+	ret.RendererFuncs[ast.NodeEmphasis] = ret.renderStyle(textANSIEm)
+	ret.RendererFuncs[ast.NodeStrikethrough] = ret.renderStyle(textANSIStrike)
+	ret.RendererFuncs[ast.NodeUnderline] = ret.renderStyle(textANSIUnder)
+	ret.RendererFuncs[ast.NodeMark] = ret.renderStyle(textANSIMark)
+	ret.RendererFuncs[ast.NodeTextMark] = ret.renderTextMark
+	ret.RendererFuncs[ast.NodeLink] = ret.renderLink
+	ret.RendererFuncs[ast.NodeImage] = ret.renderImage
+	ret.RendererFuncs[ast.NodeTaskListItemMarker] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeHTMLBlock] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeFootnotesDefBlock] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeLinkRefDefBlock] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeYamlFrontMatter] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeKramdownBlockIAL] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeKramdownSpanIAL] = ret.renderSkip
+	ret.RendererFuncs[ast.NodeHeadingID] = ret.renderSkip
+	return ret
+}
+
+func (r *TextRenderer) Render() (output []byte) {
+	r.LastOut = lex.ItemNewline
+	r.Writer, r.output = &bytes.Buffer{}, &bytes.Buffer{}
+	r.prefixes, r.styles = nil, nil
+	ast.Walk(r.Tree.Root, r.renderNode)
+	r.renderFootnotes()
+	return r.output.Bytes()
+}
+
+// renderChildren 用于渲染没有对应纯文本结构的节点，仅渲染其子节点。
+func (r *TextRenderer) renderChildren(node *ast.Node, entering bool) ast.WalkStatus {
+	return ast.WalkContinue
+}
+
+// renderSkip 用于渲染在纯文本中不可见的节点。
+func (r *TextRenderer) renderSkip(node *ast.Node, entering bool) ast.WalkStatus {
+	return ast.WalkSkipChildren
+}
+
+// startBlock 在输出块 node 前进行分隔：容器块的第一个子块和紧凑列表中的块之间不分隔，其他块之间空一行。
+func (r *TextRenderer) startBlock(node *ast.Node) {
+	if nil == node.Previous || ast.NodeBlockquoteMarker == node.Previous.Type || 1 > r.output.Len() {
+		return
+	}
+	if ast.NodeListItem == node.Type && node.Parent.ListData.Tight {
+		return
+	}
+	if ast.NodeListItem == node.Parent.Type && node.Parent.Parent.ListData.Tight {
+		return
+	}
+	r.writeLines([]string{""})
+}
+
+// startInline 开始收集块 node 的行级内容。
+func (r *TextRenderer) startInline() {
+	r.Writer = &bytes.Buffer{}
+}
+
+// endInline 结束收集行级内容，返回收集到的内容。
+func (r *TextRenderer) endInline() (ret string) {
+	ret = r.Writer.String()
+	r.Writer = &bytes.Buffer{}
+	return
+}
+
+func (r *TextRenderer) pushPrefix(first, rest string) {
+	r.prefixes = append(r.prefixes, &textPrefix{first: first, rest: rest})
+}
+
+func (r *TextRenderer) popPrefix() {
+	r.prefixes = r.prefixes[:len(r.prefixes)-1]
+}
+
+// prefixWidth 返回当前行首前缀的显示宽度。
+func (r *TextRenderer) prefixWidth() (ret int) {
+	for _, prefix := range r.prefixes {
+		ret += textWidth(prefix.rest)
+	}
+	return
+}
+
+// availableWidth 返回当前可用的折行宽度，不折行时返回 0。
+func (r *TextRenderer) availableWidth() int {
+	if 1 > r.textOptions.Width {
+		return 0
+	}
+	if ret := r.textOptions.Width - r.prefixWidth(); 8 < ret {
+		return ret
+	}
+	return 8
+}
+
+// writeLines 在每行前面加上行首前缀后输出。
+func (r *TextRenderer) writeLines(lines []string) {
+	for _, line := range lines {
+		for _, prefix := range r.prefixes {
+			if prefix.used {
+				r.output.WriteString(prefix.rest)
+			} else {
+				r.output.WriteString(prefix.first)
+				prefix.used = true
+			}
+		}
+		r.output.WriteString(line)
+		trimmed := bytes.TrimRight(r.output.Bytes(), " ")
+		r.output.Truncate(len(trimmed))
+		r.output.WriteByte(lex.ItemNewline)
+	}
+}
+
+// writeText 将行级内容 text 折行后输出。
+func (r *TextRenderer) writeText(text string) {
+	r.writeLines(textWrap(text, r.availableWidth()))
+}
+
+// style 返回使用 ANSI 样式 code 的 text，没有开启 ANSI 时直接返回 text。
+func (r *TextRenderer) style(code, text string) string {
+	if !r.textOptions.ANSI || "" == text {
+		return text
+	}
+	return "\x1b[" + code + "m" + text + textANSIReset
+}
+
+// pushStyle 开始使用 ANSI 样式 code 输出行级内容。
+func (r *TextRenderer) pushStyle(code string) {
+	if r.textOptions.ANSI {
+		r.styles = append(r.styles, code)
+		r.WriteString("\x1b[" + code + "m")
+	}
+}
+
+// popStyle 结束使用最近的 ANSI 样式，并恢复外层的样式。
+func (r *TextRenderer) popStyle() {
+	if r.textOptions.ANSI {
+		r.styles = r.styles[:len(r.styles)-1]
+		r.WriteString(textANSIReset)
+		for _, code := range r.styles {
+			r.WriteString("\x1b[" + code + "m")
+		}
+	}
+}
+
+func (r *TextRenderer) renderParagraph(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		r.startInline()
+	} else {
+		r.writeText(r.endInline())
+	}
+	return ast.WalkContinue
+}
+
+func (r *TextRenderer) renderHeading(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		r.startInline()
+		if 2 < node.HeadingLevel {
+			r.WriteString(strings.Repeat("#", node.HeadingLevel) + " ")
+		}
+		r.pushStyle(textANSIHeading)
+		return ast.WalkContinue
+	}
+
+	r.popStyle()
+	lines := textWrap(r.endInline(), r.availableWidth())
+	if 3 > node.HeadingLevel {
+		width := 0
+		for _, line := range lines {
+			if w := textWidth(line); w > width {
+				width = w
+			}
+		}
+		marker := "="
+		if 2 == node.HeadingLevel {
+			marker = "-"
+		}
+		lines = append(lines, strings.Repeat(marker, width))
+	}
+	r.writeLines(lines)
+	return ast.WalkContinue
+}
+
+// quotePrefix 返回引述和提示块的行首前缀。
+func (r *TextRenderer) quotePrefix() string {
+	return r.style(textANSIDim, "│") + " "
+}
+
+func (r *TextRenderer) renderBlockquote(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		prefix := r.quotePrefix()
+		r.pushPrefix(prefix, prefix)
+	} else {
+		r.popPrefix()
+	}
+	return ast.WalkContinue
+}
+
+func (r *TextRenderer) renderCallout(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		r.popPrefix()
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	prefix := r.quotePrefix()
+	r.pushPrefix(prefix, prefix)
+	title := node.CalloutTitle
+	if "" == title {
+		if title = ast.GetCalloutTitle(node.CalloutType); "" == title {
+			title = node.CalloutType
+		}
+	}
+	r.writeLines([]string{strings.TrimSpace(node.CalloutIcon + " " + r.style(textANSIStrong, title))})
+	return ast.WalkContinue
+}
+
+func (r *TextRenderer) renderList(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+	}
+	return ast.WalkContinue
+}
+
+// textBullets 为各嵌套层级的无序列表项目符号。
+var textBullets = []string{"•", "◦", "▪"}
+
+func (r *TextRenderer) renderListItem(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		if !r.prefixes[len(r.prefixes)-1].used {
+			// 空的列表项也需要输出项目符号
+			r.writeLines([]string{""})
+		}
+		r.popPrefix()
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	var bullet string
+	if marker := node.FirstChild; nil != marker && nil != marker.FirstChild && ast.NodeTaskListItemMarker == marker.FirstChild.Type {
+		if marker.FirstChild.TaskListItemChecked {
+			bullet = "[x]"
+		} else {
+			bullet = "[ ]"
+		}
+	} else if 1 == node.Parent.ListData.Typ {
+		num := node.ListData.Num
+		if 1 > num {
+			num = node.Parent.ListData.Start
+			for prev := node.Previous; nil != prev; prev = prev.Previous {
+				num++
+			}
+		}
+		delimiter := node.ListData.Delimiter
+		if 0 == delimiter {
+			delimiter = '.'
+		}
+		bullet = strconv.Itoa(num) + string(delimiter)
+	} else {
+		depth := 0
+		for p := node.Parent.Parent; nil != p; p = p.Parent {
+			if ast.NodeList == p.Type {
+				depth++
+			}
+		}
+		if depth >= len(textBullets) {
+			depth = len(textBullets) - 1
+		}
+		bullet = textBullets[depth]
+	}
+	bullet += " "
+	r.pushPrefix(bullet, strings.Repeat(" ", textWidth(bullet)))
+	return ast.WalkContinue
+}
+
+func (r *TextRenderer) renderThematicBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		width := r.availableWidth()
+		if 1 > width {
+			width = 40
+		}
+		r.writeLines([]string{r.style(textANSIDim, strings.Repeat("─", width))})
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *TextRenderer) renderCodeBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	var code, language string
+	if content := node.ChildByType(ast.NodeCodeBlockCode); nil != content {
+		code = util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil))
+	}
+	code = strings.TrimSuffix(code, "\n")
+	if info := node.ChildByType(ast.NodeCodeBlockFenceInfoMarker); nil != info {
+		if fields := strings.Fields(util.BytesToStr(info.CodeBlockInfo)); 0 < len(fields) {
+			language = fields[0]
+		}
+	}
+	if r.textOptions.ANSI {
+		if highlighted, ok := highlightANSI(code, language, r.Options.CodeSyntaxHighlightStyleName); ok {
+			code = strings.TrimSuffix(highlighted, "\n")
+		} else {
+			code = r.style(textANSICode, code)
+		}
+	}
+	r.writeIndented(code)
+	return ast.WalkSkipChildren
+}
+
+func (r *TextRenderer) renderMathBlock(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.startBlock(node)
+		if content := node.ChildByType(ast.NodeMathBlockContent); nil != content {
+			math := strings.TrimSpace(util.BytesToStr(bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil)))
+			r.writeIndented(r.style(textANSICode, math))
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+// writeIndented 缩进 4 个空格输出 text，不进行折行。
+func (r *TextRenderer) writeIndented(text string) {
+	r.pushPrefix("    ", "    ")
+	r.writeLines(textANSILines(strings.Split(text, "\n")))
+	r.popPrefix()
+}
+
+func (r *TextRenderer) renderTable(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	var rows [][]string
+	head := 0
+	for n := node.FirstChild; nil != n; n = n.Next {
+		var tableRows []*ast.Node
+		if ast.NodeTableHead == n.Type {
+			tableRows = n.ChildrenByType(ast.NodeTableRow)
+			head += len(tableRows)
+		} else if ast.NodeTableRow == n.Type {
+			tableRows = append(tableRows, n)
+		}
+		for _, row := range tableRows {
+			var cells []string
+			for cell := row.FirstChild; nil != cell; cell = cell.Next {
+				if ast.NodeTableCell != cell.Type {
+					continue
+				}
+				r.startInline()
+				for c := cell.FirstChild; nil != c; c = c.Next {
+					ast.Walk(c, r.renderNode)
+				}
+				text := r.endInline()
+				if ast.NodeTableHead == n.Type {
+					text = r.style(textANSIStrong, text)
+				}
+				cells = append(cells, strings.ReplaceAll(text, "\n", " "))
+			}
+			rows = append(rows, cells)
+		}
+	}
+
+	var widths []int
+	for _, cells := range rows {
+		for i, cell := range cells {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if w := textWidth(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	border := func(left, middle, right string) string {
+		buf := &bytes.Buffer{}
+		buf.WriteString(left)
+		for i, width := range widths {
+			if 0 < i {
+				buf.WriteString(middle)
+			}
+			buf.WriteString(strings.Repeat("─", width+2))
+		}
+		buf.WriteString(right)
+		return buf.String()
+	}
+
+	lines := []string{border("┌", "┬", "┐")}
+	for i, cells := range rows {
+		if 0 < i && head == i {
+			lines = append(lines, border("├", "┼", "┤"))
+		}
+		buf := &bytes.Buffer{}
+		buf.WriteString("│")
+		for j, width := range widths {
+			var cell string
+			if j < len(cells) {
+				cell = cells[j]
+			}
+			align := 0
+			if j < len(node.TableAligns) {
+				align = node.TableAligns[j]
+			}
+			padding := width - textWidth(cell)
+			left := 0
+			switch align {
+			case 2:
+				left = padding / 2
+			case 3:
+				left = padding
+			}
+			buf.WriteString(" " + strings.Repeat(" ", left) + cell + strings.Repeat(" ", padding-left) + " │")
+		}
+		lines = append(lines, buf.String())
+	}
+	lines = append(lines, border("└", "┴", "┘"))
+	r.writeLines(lines)
+	return ast.WalkSkipChildren
+}
+
+func (r *TextRenderer) renderToC(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	r.startBlock(node)
+	var lines []string
+	for _, heading := range r.Tree.Root.ChildrenByType(ast.NodeHeading) {
+		if r.Tree.Root != heading.Parent {
+			continue
+		}
+		lines = append(lines, strings.Repeat("  ", heading.HeadingLevel-1)+strings.TrimSpace(heading.Text()))
+	}
+	r.writeLines(lines)
+	return ast.WalkSkipChildren
+}
+
+func (r *TextRenderer) renderFootnotesRef(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		idx, def := r.Tree.FindFootnotesDef(node.Tokens)
+		if nil == def {
+			r.WriteString("[^" + util.BytesToStr(node.Tokens) + "]")
+		} else {
+			r.WriteString("[" + strconv.Itoa(idx) + "]")
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+// renderFootnotes 在最后输出脚注。
+func (r *TextRenderer) renderFootnotes() {
+	defBlock := r.Tree.Root.ChildByType(ast.NodeFootnotesDefBlock)
+	if nil == defBlock || nil == defBlock.FirstChild {
+		return
+	}
+
+	if 0 < r.output.Len() {
+		r.writeLines([]string{"", r.style(textANSIDim, strings.Repeat("─", 10))})
+	}
+	for def := defBlock.FirstChild; nil != def; def = def.Next {
+		idx, _ := r.Tree.FindFootnotesDef(def.Tokens)
+		marker := "[" + strconv.Itoa(idx) + "] "
+		r.pushPrefix(marker, strings.Repeat(" ", textWidth(marker)))
+		for n := def.FirstChild; nil != n; n = n.Next {
+			ast.Walk(n, r.renderNode)
+		}
+		if !r.prefixes[len(r.prefixes)-1].used {
+			r.writeLines([]string{""})
+		}
+		r.popPrefix()
+	}
+}
+
+func (r *TextRenderer) renderText(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		tokens := bytes.ReplaceAll(node.Tokens, editor.CaretTokens, nil)
+		if ast.NodeText == node.Type || ast.NodeLinkText == node.Type {
+			if r.Options.AutoSpace {
+				tokens = r.Space(tokens)
+			}
+			if r.Options.FixTermTypo {
+				tokens = r.FixTermTypo(tokens)
+			}
+		}
+		r.Write(tokens)
+	}
+	return ast.WalkContinue
+}
+
+func (r *TextRenderer) renderEmojiImg(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if alias := node.ChildByType(ast.NodeEmojiAlias); nil != alias {
+			r.Write(alias.Tokens)
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *TextRenderer) renderSoftBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if r.Options.SoftBreak2HardBreak || 1 > r.textOptions.Width {
+			r.WriteByte(lex.ItemNewline)
+		} else {
+			r.WriteByte(lex.ItemSpace)
+		}
+	}
+	return ast.WalkContinue
+}
+
+func (r *TextRenderer) renderHardBreak(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.WriteByte(lex.ItemNewline)
+	}
+	return ast.WalkContinue
+}
+
+func (r *TextRenderer) renderInlineHTML(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering && bytes.HasPrefix(bytes.ToLower(node.Tokens), []byte("<br")) {
+		r.WriteByte(lex.ItemNewline)
+	}
+	return ast.WalkContinue
+}
+
+func (r *TextRenderer) renderCodeSpan(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		var code []byte
+		if content := node.ChildByType(ast.NodeCodeSpanContent); nil != content {
+			code = bytes.ReplaceAll(content.Tokens, editor.CaretTokens, nil)
+		}
+		r.writeCode(util.BytesToStr(code))
+	}
+	return ast.WalkSkipChildren
+}
+
+// writeCode 输出行内代码，没有开启 ANSI 时使用 ` 包裹。
+func (r *TextRenderer) writeCode(code string) {
+	if r.textOptions.ANSI {
+		r.pushStyle(textANSICode)
+		r.WriteString(code)
+		r.popStyle()
+	} else {
+		r.WriteString("`" + code + "`")
+	}
+}
+
+// renderStyle 返回一个渲染函数，使用 ANSI 样式 code 渲染节点的子节点，没有开启 ANSI 时仅渲染子节点。
+func (r *TextRenderer) renderStyle(code string) RendererFunc {
+	return func(node *ast.Node, entering bool) ast.WalkStatus {
+		if entering {
+			r.pushStyle(code)
+		} else {
+			r.popStyle()
+		}
+		return ast.WalkContinue
+	}
+}
+
+// textMarkStyles 为行级元素类型对应的 ANSI 样式。
+var textMarkStyles = map[string]string{
+	"strong": textANSIStrong,
+	"em":     textANSIEm,
+	"s":      textANSIStrike,
+	"u":      textANSIUnder,
+	"mark":   textANSIMark,
+	"kbd":    textANSICode,
+}
+
+func (r *TextRenderer) renderTextMark(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	pushed := 0
+	for _, typ := range strings.Split(node.TextMarkType, " ") {
+		if code, ok := textMarkStyles[typ]; ok {
+			r.pushStyle(code)
+			pushed++
+		}
+	}
+	switch {
+	case node.IsTextMarkType("inline-math"):
+		r.WriteString(node.TextMarkInlineMathContent)
+	case node.IsTextMarkType("code"):
+		r.writeCode(node.TextMarkTextContent)
+	case node.IsTextMarkType("a"):
+		r.writeLink(node.TextMarkTextContent, node.TextMarkAHref)
+	default:
+		r.WriteString(node.TextMarkTextContent)
+	}
+	for i := 0; i < pushed; i++ {
+		r.popStyle()
+	}
+	return ast.WalkSkipChildren
+}
+
+func (r *TextRenderer) renderLink(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	var dest string
+	if d := node.ChildByType(ast.NodeLinkDest); nil != d {
+		dest = util.BytesToStr(r.LinkPath(d.Tokens))
+	}
+	text := node.Text()
+	if 2 == node.LinkType {
+		text = dest
+	}
+	r.writeLink(text, dest)
+	return ast.WalkSkipChildren
+}
+
+// writeLink 输出链接文本 text，链接地址 dest 和文本不同时在文本后面使用括号输出地址，页内锚点不输出地址。
+func (r *TextRenderer) writeLink(text, dest string) {
+	dest = strings.TrimSpace(dest)
+	if "" == text {
+		text = dest
+	}
+	r.pushStyle(textANSILink)
+	r.WriteString(text)
+	r.popStyle()
+	if "" != dest && text != dest && !strings.HasPrefix(dest, "#") && "mailto:"+text != dest {
+		r.WriteString(" (" + dest + ")")
+	}
+}
+
+func (r *TextRenderer) renderImage(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		if alt := node.Text(); "" != alt {
+			r.WriteString("[image: " + alt + "]")
+		} else {
+			r.WriteString("[image]")
+		}
+	}
+	return ast.WalkSkipChildren
+}
+
+// textWidth 返回 text 的显示宽度，中日韩字符等全角字符的宽度为 2，ANSI 转义序列和组合字符的宽度为 0。
+func textWidth(text string) (ret int) {
+	for i := 0; i < len(text); {
+		if n := textANSILen(text[i:]); 0 < n {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(text[i:])
+		ret += textRuneWidth(r)
+		i += size
+	}
+	return
+}
+
+func textRuneWidth(r rune) int {
+	switch {
+	case unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || 0x200B == r || 0xFE0F == r:
+		return 0
+	case 0x1100 <= r && 0x115F >= r, 0x2E80 <= r && 0xA4CF >= r, 0xAC00 <= r && 0xD7A3 >= r, 0xF900 <= r && 0xFAFF >= r,
+		0xFE30 <= r && 0xFE4F >= r, 0xFF00 <= r && 0xFF60 >= r, 0xFFE0 <= r && 0xFFE6 >= r, 0x1F300 <= r && 0x1F64F >= r,
+		0x1F900 <= r && 0x1F9FF >= r, 0x20000 <= r && 0x3FFFD >= r:
+		return 2
+	}
+	return 1
+}
+
+// textANSILen 返回 text 开头的 ANSI SGR 转义序列的长度，text 不以转义序列开头时返回 0。
+func textANSILen(text string) int {
+	if !strings.HasPrefix(text, "\x1b[") {
+		return 0
+	}
+	if end := strings.IndexByte(text, 'm'); 0 < end {
+		return end + 1
+	}
+	return 0
+}
+
+// textWrap 将 text 按照显示宽度 width 折行，width 小于 1 时仅按照换行符分行。
+//
+// 在空白处以及中日韩字符之间折行，长度超过 width 的单词单独成行，行首行尾的空白会被去掉。
+func textWrap(text string, width int) (ret []string) {
+	for _, paragraph := range strings.Split(text, "\n") {
+		if 1 > width {
+			ret = append(ret, strings.TrimSpace(paragraph))
+			continue
+		}
+
+		line, lineWidth, space := &strings.Builder{}, 0, false
+		flush := func() {
+			ret = append(ret, line.String())
+			line.Reset()
+			lineWidth, space = 0, false
+		}
+		add := func(word string) {
+			w := textWidth(word)
+			sep := 0
+			if space && 0 < lineWidth {
+				sep = 1
+			}
+			if 0 < lineWidth && lineWidth+sep+w > width {
+				flush()
+				sep = 0
+			}
+			if 0 < sep {
+				line.WriteByte(lex.ItemSpace)
+			}
+			line.WriteString(word)
+			lineWidth += sep + w
+			space = false
+		}
+
+		word := &strings.Builder{}
+		addWord := func() {
+			if 0 == textWidth(word.String()) {
+				// 只包含转义序列的单词直接附加到行尾，不影响折行
+				line.WriteString(word.String())
+			} else {
+				add(word.String())
+			}
+			word.Reset()
+		}
+		for i := 0; i < len(paragraph); {
+			if n := textANSILen(paragraph[i:]); 0 < n {
+				word.WriteString(paragraph[i : i+n])
+				i += n
+				continue
+			}
+			r, size := utf8.DecodeRuneInString(paragraph[i:])
+			i += size
+			switch {
+			case unicode.IsSpace(r):
+				addWord()
+				space = true
+			case isCJK(r) || 2 == textRuneWidth(r):
+				if 0 < textWidth(word.String()) {
+					addWord()
+				}
+				word.WriteRune(r)
+				addWord()
+			default:
+				word.WriteRune(r)
+			}
+		}
+		addWord()
+		flush()
+	}
+	return textANSILines(ret)
+}
+
+// textANSILines 使每行的 ANSI 样式独立：在行尾重置样式，在下一行开头恢复上一行结束时生效的样式。
+func textANSILines(lines []string) []string {
+	var active []string
+	for i, line := range lines {
+		prefix := strings.Join(active, "")
+		for j := 0; j < len(line); j++ {
+			if n := textANSILen(line[j:]); 0 < n {
+				if code := line[j : j+n]; textANSIReset == code || "\x1b[m" == code {
+					active = nil
+				} else {
+					active = append(active, code)
+				}
+				j += n - 1
+			}
+		}
+		if "" != prefix || 0 < len(active) {
+			lines[i] = prefix + line
+			if 0 < len(active) {
+				lines[i] += textANSIReset
+			}
+		}
+	}
+	return lines
+}
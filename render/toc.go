@@ -0,0 +1,130 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package render
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/util"
+)
+
+// tocOptions 描述了渲染一个目录时使用的选项。
+type tocOptions struct {
+	minLevel  int  // 最小标题级别
+	maxLevel  int  // 最大标题级别
+	numbering bool // 是否自动编号
+}
+
+// tocOptions 返回目录节点 toc 的目录选项，先使用渲染选项，再使用 [toc levels=2-3 numbered] 中的参数覆盖。toc 为 nil 时仅使用渲染选项。
+func (r *BaseRenderer) tocOptions(toc *ast.Node) (ret *tocOptions) {
+	ret = &tocOptions{minLevel: r.Options.ToCMinLevel, maxLevel: r.Options.ToCMaxLevel, numbering: r.Options.ToCNumbering}
+	if nil != toc {
+		for key, value := range ToCParams(toc.Tokens) {
+			switch key {
+			case "levels":
+				from, to, found := strings.Cut(value, "-")
+				if !found {
+					to = from
+				}
+				if n, err := strconv.Atoi(strings.TrimSpace(from)); nil == err {
+					ret.minLevel = n
+				}
+				if n, err := strconv.Atoi(strings.TrimSpace(to)); nil == err {
+					ret.maxLevel = n
+				}
+			case "numbered":
+				ret.numbering = "" == value || "true" == value
+			}
+		}
+	}
+	if 1 > ret.minLevel {
+		ret.minLevel = 1
+	}
+	if 1 > ret.maxLevel || 6 < ret.maxLevel {
+		ret.maxLevel = 6
+	}
+	return
+}
+
+// ToCParams 解析目录标记 tokens（比如 [toc levels=2-3 numbered]）中的参数，没有值的参数对应的值为空字符串。
+func ToCParams(tokens []byte) (ret map[string]string) {
+	ret = map[string]string{}
+	content := strings.TrimSpace(string(tokens))
+	if !strings.HasPrefix(strings.ToLower(content), "[toc") || !strings.HasSuffix(content, "]") {
+		return
+	}
+
+	content = content[len("[toc") : len(content)-1]
+	for _, field := range strings.Fields(content) {
+		key, value, _ := strings.Cut(field, "=")
+		ret[strings.ToLower(key)] = value
+	}
+	return
+}
+
+// tocExcluded 判断标题 heading 是否需要从目录中排除。
+func (r *BaseRenderer) tocExcluded(heading *ast.Node) bool {
+	for _, kv := range heading.KramdownIAL {
+		switch kv[0] {
+		case "class":
+			if "" != r.Options.ToCExcludeClass && util.ContainsStr(r.Options.ToCExcludeClass, strings.Fields(kv[1])) {
+				return true
+			}
+		case "toc":
+			if "false" == kv[1] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// numberHeadings 为标题树 headings 自动编号，prefix 为上级标题的编号。
+func numberHeadings(headings []*Heading, prefix string) {
+	for i, heading := range headings {
+		heading.Number = prefix + strconv.Itoa(i+1)
+		numberHeadings(heading.Children, heading.Number+".")
+	}
+}
+
+// HeadingNumber 返回标题 heading 的编号，仅在开启 ToCNumberHeadings 时返回编号，不在目录中的标题返回 ""。
+//
+// 标题编号和文档中第一个目录使用相同的目录选项（包括 [toc levels=2-3] 中的参数），这样标题编号和目录中的编号保持一致。
+func (r *BaseRenderer) HeadingNumber(heading *ast.Node) string {
+	if !r.Options.ToCNumberHeadings {
+		return ""
+	}
+
+	if nil == r.headingNumbers {
+		r.headingNumbers = map[*ast.Node]string{}
+		opts := r.tocOptions(r.Tree.Root.ChildByType(ast.NodeToC))
+		opts.numbering = true
+		var walk func(headings []*Heading)
+		walk = func(headings []*Heading) {
+			for _, h := range headings {
+				r.headingNumbers[h.node] = h.Number
+				walk(h.Children)
+			}
+		}
+		walk(r.tocHeadings(opts))
+	}
+	return r.headingNumbers[heading]
+}
+
+// tocMarker 返回目录节点 toc 的 Markdown 标记，带参数时保留参数。
+func tocMarker(toc *ast.Node) string {
+	if 0 < len(ToCParams(toc.Tokens)) {
+		return string(toc.Tokens)
+	}
+	return "[toc]"
+}
@@ -143,6 +143,13 @@ func NewVditorSVRenderer(tree *parse.Tree, options *Options, parseOptions *parse
 	ret.RendererFuncs[ast.NodeFootnotesDef] = ret.renderFootnotesDef
 	ret.RendererFuncs[ast.NodeFootnotesRef] = ret.renderFootnotesRef
 	ret.RendererFuncs[ast.NodeToC] = ret.renderToC
+	ret.RendererFuncs[ast.NodeGitConflict] = ret.renderGitConflict
+	ret.RendererFuncs[ast.NodeGitConflictOpenMarker] = ret.renderGitConflictOpenMarker
+	ret.RendererFuncs[ast.NodeGitConflictContent] = ret.renderGitConflictContent
+	ret.RendererFuncs[ast.NodeGitConflictCloseMarker] = ret.renderGitConflictCloseMarker
+	ret.RendererFuncs[ast.NodeGitConflictOurs] = ret.renderGitConflictSection
+	ret.RendererFuncs[ast.NodeGitConflictBase] = ret.renderGitConflictSection
+	ret.RendererFuncs[ast.NodeGitConflictTheirs] = ret.renderGitConflictSection
 	ret.RendererFuncs[ast.NodeBackslash] = ret.renderBackslash
 	ret.RendererFuncs[ast.NodeBackslashContent] = ret.renderBackslashContent
 	ret.RendererFuncs[ast.NodeHTMLEntity] = ret.renderHtmlEntity
@@ -375,10 +382,62 @@ func (r *VditorSVRenderer) renderBackslash(node *ast.Node, entering bool) ast.Wa
 	return ast.WalkContinue
 }
 
+func (r *VditorSVRenderer) renderGitConflictCloseMarker(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.Tag("span", [][]string{{"data-type", "git-conflict-close-marker"}, {"class", "vditor-sv__marker"}}, false)
+		r.Write(html.EscapeHTML(node.Tokens))
+		r.Tag("/span", nil, false)
+		r.Newline()
+		r.Write(NewlineSV)
+	}
+	return ast.WalkContinue
+}
+
+func (r *VditorSVRenderer) renderGitConflictContent(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.Write(html.EscapeHTML(node.Tokens))
+		r.Newline()
+	}
+	return ast.WalkContinue
+}
+
+func (r *VditorSVRenderer) renderGitConflictOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.Tag("span", [][]string{{"data-type", "git-conflict-open-marker"}, {"class", "vditor-sv__marker"}}, false)
+		r.Write(html.EscapeHTML(node.Tokens))
+		r.Tag("/span", nil, false)
+		r.Newline()
+	}
+	return ast.WalkContinue
+}
+
+func (r *VditorSVRenderer) renderGitConflictSection(node *ast.Node, entering bool) ast.WalkStatus {
+	if !entering {
+		return ast.WalkContinue
+	}
+
+	if ast.NodeGitConflictBase == node.Type {
+		r.Tag("span", [][]string{{"data-type", "git-conflict-base-marker"}, {"class", "vditor-sv__marker"}}, false)
+		r.Write(html.EscapeHTML(node.Tokens))
+		r.Tag("/span", nil, false)
+		r.Newline()
+	} else if ast.NodeGitConflictTheirs == node.Type {
+		r.Tag("span", [][]string{{"data-type", "git-conflict-separator"}, {"class", "vditor-sv__marker"}}, false)
+		r.WriteString("=======")
+		r.Tag("/span", nil, false)
+		r.Newline()
+	}
+	return ast.WalkContinue
+}
+
+func (r *VditorSVRenderer) renderGitConflict(node *ast.Node, entering bool) ast.WalkStatus {
+	return ast.WalkContinue
+}
+
 func (r *VditorSVRenderer) renderToC(node *ast.Node, entering bool) ast.WalkStatus {
 	if entering {
 		r.WriteString("<span class=\"vditor-toc\" data-type=\"toc-block\" contenteditable=\"false\">")
-		r.WriteString("[toc]")
+		r.WriteString(tocMarker(node))
 		r.WriteString("</span>")
 		r.Newline()
 		r.Write(NewlineSV)
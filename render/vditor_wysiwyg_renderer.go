@@ -108,6 +108,13 @@ func NewVditorRenderer(tree *parse.Tree, options *Options, parseOptions *parse.O
 	ret.RendererFuncs[ast.NodeFootnotesDef] = ret.renderFootnotesDef
 	ret.RendererFuncs[ast.NodeFootnotesRef] = ret.renderFootnotesRef
 	ret.RendererFuncs[ast.NodeToC] = ret.renderToC
+	ret.RendererFuncs[ast.NodeGitConflict] = ret.renderGitConflict
+	ret.RendererFuncs[ast.NodeGitConflictOpenMarker] = ret.renderGitConflictOpenMarker
+	ret.RendererFuncs[ast.NodeGitConflictContent] = ret.renderGitConflictContent
+	ret.RendererFuncs[ast.NodeGitConflictCloseMarker] = ret.renderGitConflictCloseMarker
+	ret.RendererFuncs[ast.NodeGitConflictOurs] = ret.renderGitConflictSection
+	ret.RendererFuncs[ast.NodeGitConflictBase] = ret.renderGitConflictSection
+	ret.RendererFuncs[ast.NodeGitConflictTheirs] = ret.renderGitConflictSection
 	ret.RendererFuncs[ast.NodeBackslash] = ret.renderBackslash
 	ret.RendererFuncs[ast.NodeBackslashContent] = ret.renderBackslashContent
 	ret.RendererFuncs[ast.NodeHTMLEntity] = ret.renderHtmlEntity
@@ -315,6 +322,46 @@ func (r *VditorRenderer) renderBackslash(node *ast.Node, entering bool) ast.Walk
 	return ast.WalkContinue
 }
 
+func (r *VditorRenderer) renderGitConflictCloseMarker(node *ast.Node, entering bool) ast.WalkStatus {
+	return ast.WalkContinue
+}
+
+func (r *VditorRenderer) renderGitConflictContent(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.Write(html.EscapeHTML(node.Tokens))
+	}
+	return ast.WalkContinue
+}
+
+func (r *VditorRenderer) renderGitConflictOpenMarker(node *ast.Node, entering bool) ast.WalkStatus {
+	return ast.WalkContinue
+}
+
+// renderGitConflictSection 渲染 Git 冲突中的一方内容，各方内容并排显示，标记符所在行保存在 data-marker 属性上。
+func (r *VditorRenderer) renderGitConflictSection(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		typ, class := "git-conflict-ours", "vditor-git-conflict__ours"
+		if ast.NodeGitConflictBase == node.Type {
+			typ, class = "git-conflict-base", "vditor-git-conflict__base"
+		} else if ast.NodeGitConflictTheirs == node.Type {
+			typ, class = "git-conflict-theirs", "vditor-git-conflict__theirs"
+		}
+		r.Tag("div", [][]string{{"data-type", typ}, {"class", class}, {"data-marker", html.EscapeString(gitConflictMarker(node))}}, false)
+	} else {
+		r.Tag("/div", nil, false)
+	}
+	return ast.WalkContinue
+}
+
+func (r *VditorRenderer) renderGitConflict(node *ast.Node, entering bool) ast.WalkStatus {
+	if entering {
+		r.WriteString(`<div class="vditor-git-conflict" data-block="0" data-type="git-conflict">`)
+	} else {
+		r.WriteString("</div>")
+	}
+	return ast.WalkContinue
+}
+
 func (r *VditorRenderer) renderToC(node *ast.Node, entering bool) ast.WalkStatus {
 	return r.BaseRenderer.renderToC(node, entering)
 }
@@ -1013,7 +1060,7 @@ func (r *VditorRenderer) renderHeading(node *ast.Node, entering bool) ast.WalkSt
 			r.WriteString(" data-id=\"" + id + "\"")
 		}
 		if "" == id {
-			id = HeadingID(node)
+			id = r.HeadingID(node)
 		}
 		r.WriteString(" id=\"wysiwyg-" + id + "\"")
 		if !node.HeadingSetext {
@@ -1026,7 +1073,7 @@ func (r *VditorRenderer) renderHeading(node *ast.Node, entering bool) ast.WalkSt
 			}
 		}
 		if r.Options.HeadingAnchor {
-			id := HeadingID(node)
+			id := r.HeadingID(node)
 			r.Tag("a", [][]string{{"id", "vditorAnchor-" + id}, {"class", "vditor-anchor"}, {"href", "#" + id}}, false)
 			r.WriteString(`<svg viewBox="0 0 16 16" version="1.1" width="16" height="16"><path fill-rule="evenodd" d="M4 9h1v1H4c-1.5 0-3-1.69-3-3.5S2.55 3 4 3h4c1.45 0 3 1.69 3 3.5 0 1.41-.91 2.72-2 3.25V8.59c.58-.45 1-1.27 1-2.09C10 5.22 8.98 4 8 4H4c-.98 0-2 1.22-2 2.5S3 9 4 9zm9-3h-1v1h1c1 0 2 1.22 2 2.5S13.98 12 13 12H9c-.98 0-2-1.22-2-2.5 0-.83.42-1.64 1-2.09V6.25c-1.09.53-2 1.84-2 3.25C6 11.31 7.55 13 9 13h4c1.45 0 3-1.69 3-3.5S14.5 6 13 6z"></path></svg>`)
 			r.Tag("/a", nil, false)
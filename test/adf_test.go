@@ -0,0 +1,43 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"testing"
+
+	"github.com/88250/lute"
+)
+
+var adfTests = []parseTest{
+
+	{"6", "![a *b* `c`](x.png)\n\nfoo ![a *b* c](https://b3log.org/x.png) bar\n", `{"version":1,"type":"doc","content":[{"type":"mediaSingle","attrs":{"layout":"center"},"content":[{"type":"media","attrs":{"alt":"a b c","type":"external","url":"x.png"}}]},{"type":"paragraph","content":[{"type":"text","text":"foo "},{"type":"text","text":"a b c","marks":[{"type":"link","attrs":{"href":"https://b3log.org/x.png"}}]},{"type":"text","text":" bar"}]}]}`},
+	{"5", "## Intro\n\n**foo** *bar* [`b3log`](https://b3log.org)\n", `{"version":1,"type":"doc","content":[{"type":"heading","attrs":{"level":2},"content":[{"type":"text","text":"Intro"}]},{"type":"paragraph","content":[{"type":"text","text":"foo","marks":[{"type":"strong"}]},{"type":"text","text":" "},{"type":"text","text":"bar","marks":[{"type":"em"}]},{"type":"text","text":" "},{"type":"text","text":"b3log","marks":[{"type":"link","attrs":{"href":"https://b3log.org"}},{"type":"code"}]}]}]}`},
+	{"4", "> [!WARNING]\n> be careful\n\n> [!NOTE] Custom\n> hi\n", `{"version":1,"type":"doc","content":[{"type":"panel","attrs":{"panelType":"warning"},"content":[{"type":"paragraph","content":[{"type":"text","text":"be careful"}]}]},{"type":"panel","attrs":{"panelType":"info"},"content":[{"type":"paragraph","content":[{"type":"text","text":"Custom","marks":[{"type":"strong"}]}]},{"type":"paragraph","content":[{"type":"text","text":"hi"}]}]}]}`},
+	{"3", "- [x] done\n- [ ] todo\n\n  para\n  - [ ] sub\n", `{"version":1,"type":"doc","content":[{"type":"taskList","attrs":{"localId":"1"},"content":[{"type":"taskItem","attrs":{"localId":"2","state":"DONE"},"content":[{"type":"text","text":"done"}]},{"type":"taskItem","attrs":{"localId":"3","state":"TODO"},"content":[{"type":"text","text":"todo"},{"type":"hardBreak"},{"type":"text","text":"para"}]},{"type":"taskList","attrs":{"localId":"4"},"content":[{"type":"taskItem","attrs":{"localId":"5","state":"TODO"},"content":[{"type":"text","text":"sub"}]}]}]}]}`},
+	{"2", "| a | b |\n|:-:|--:|\n| 1 | **z** |\n", `{"version":1,"type":"doc","content":[{"type":"table","attrs":{"isNumberColumnEnabled":false,"layout":"default"},"content":[{"type":"tableRow","content":[{"type":"tableHeader","content":[{"type":"paragraph","content":[{"type":"text","text":"a"}],"marks":[{"type":"alignment","attrs":{"align":"center"}}]}]},{"type":"tableHeader","content":[{"type":"paragraph","content":[{"type":"text","text":"b"}],"marks":[{"type":"alignment","attrs":{"align":"end"}}]}]}]},{"type":"tableRow","content":[{"type":"tableCell","content":[{"type":"paragraph","content":[{"type":"text","text":"1"}],"marks":[{"type":"alignment","attrs":{"align":"center"}}]}]},{"type":"tableCell","content":[{"type":"paragraph","content":[{"type":"text","text":"z","marks":[{"type":"strong"}]}],"marks":[{"type":"alignment","attrs":{"align":"end"}}]}]}]}]}]}`},
+	{"1", "```go\nfoo\n```\n\nfoo[^1]\n\n[^1]: two\n", `{"version":1,"type":"doc","content":[{"type":"codeBlock","attrs":{"language":"go"},"content":[{"type":"text","text":"foo"}]},{"type":"paragraph","content":[{"type":"text","text":"foo"},{"type":"text","text":"1","marks":[{"type":"subsup","attrs":{"type":"sup"}}]}]},{"type":"rule"},{"type":"orderedList","content":[{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"two"}]}]}]}]}`},
+	{"0", "", `{"version":1,"type":"doc","content":[]}`},
+}
+
+func TestADF(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetCallout(true)
+
+	for _, test := range adfTests {
+		adf, err := luteEngine.ADF(test.name, []byte(test.from))
+		if nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		if test.to != string(adf) {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%s\ngot\n\t%s\noriginal markdown text\n\t%q", test.name, test.to, string(adf), test.from)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"testing"
+
+	"github.com/88250/lute"
+)
+
+var asciiDocTests = []parseTest{
+
+	{"13", "![a *b* `c`](x.png)\n\nfoo ![a *b* c](https://b3log.org/x.png) bar\n", "image::x.png[a b c]\n\nfoo image:https://b3log.org/x.png[a b c] bar\n"},
+	{"12", "---\ntitle: foo\nnote: |\n  ////\n---\n\n$$\nx\n$$\n", "/////\ntitle: foo\nnote: |\n  ////\n/////\n:stem: latexmath\n\n[latexmath]\n++++\nx\n++++\n"},
+	{"11", "---\ntitle: foo\n---\n\n# Title\n", "////\ntitle: foo\n////\n\n[[Title]]\n== Title\n"},
+	{"10", "# Doc\n\ntext\n\n## Sub\n\nlate $x$\n", ":stem: latexmath\n\n[[Doc]]\n== Doc\n\ntext\n\n[[Sub]]\n=== Sub\n\nlate latexmath:[x]\n"},
+	{"9", "# Intro {#intro}\n\n## 中文\n\nsee [intro](#intro) and [b3log](https://b3log.org) <https://ld246.com>\n", "[[intro]]\n== Intro\n\n[[中文]]\n=== 中文\n\nsee <<intro,intro>> and https://b3log.org[b3log] https://ld246.com\n"},
+	{"8", "**foo**bar *baz* `a_b` $E=mc^2$ 2 * 3 a_b foo_ `x`\n", ":stem: latexmath\n\n**foo**bar _baz_ `+a_b+` latexmath:[E=mc^2] 2 {asterisk} 3 a_b foo&#95; `+x+`\n"},
+	{"7", "line one  \nline two\n", "line one +\nline two\n"},
+	{"6", "> quote\n\n- a\n- b\n\n> after list\n", "____\nquote\n____\n\n* a\n* b\n\n____\nafter list\n____\n"},
+	{"5", "> [!WARNING]\n> be careful\n\n> [!NOTE] Custom\n> hi\n", "[WARNING]\n====\nbe careful\n====\n\n.Custom\n[NOTE]\n====\nhi\n====\n"},
+	{"4", "3. foo\n4. bar\n   - [x] baz\n\n     para\n", "[start=3]\n. foo\n. bar\n* [x] baz\n+\npara\n"},
+	{"3", "| a | b |\n|:-:|--:|\n| 1 | x\\|y |\n", "[%header,cols=\"^,>\"]\n|===\n| a | b\n\n| 1 | x\\|y\n|===\n"},
+	{"2", "```go\nfoo\n```\n\n$$\nx^2\n$$\n\n<div>html</div>\n", ":stem: latexmath\n\n[source,go]\n----\nfoo\n----\n\n[latexmath]\n++++\nx^2\n++++\n\n++++\n<div>html</div>\n++++\n"},
+	{"1", "foo[^1] bar[^1]\n\n[^1]: *note*\n\n    two\n", "foofootnote:fn1[_note_ two] barfootnote:fn1[]\n"},
+	{"0", "![Figure](img/foo.png)\n\nfoo ![a](https://b3log.org/a.png) bar\n\n---\n\nend\n", "image::img/foo.png[Figure]\n\nfoo image:https://b3log.org/a.png[a] bar\n\n'''\n\nend\n"},
+}
+
+func TestAsciiDoc(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetCallout(true)
+
+	for _, test := range asciiDocTests {
+		asciiDoc, err := luteEngine.AsciiDoc(test.name, []byte(test.from))
+		if nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		if test.to != string(asciiDoc) {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, asciiDoc, test.from)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/html"
+	"github.com/88250/lute/parse"
+)
+
+const (
+	nodeAdmonition = ast.NodeExtContainerBlock
+	nodePlantUML   = ast.NodeExtLeafBlock
+)
+
+var blockExtensionTests = []parseTest{
+
+	{"4", ":::\nfoo\n", "<p>:::<br />\nfoo</p>\n"},
+	{"3", "- ::: tip\n  foo\n  :::\n- bar\n", "<ul>\n<li><div class=\"admonition tip\">\n<p>foo</p>\n</div>\n</li>\n<li>bar</li>\n</ul>\n"},
+	{"2", "@startuml\nA -> B: <hi>\n@enduml\n\nfoo\n", "<pre class=\"plantuml\">A -&gt; B: &lt;hi&gt;\n</pre>\n<p>foo</p>\n"},
+	{"1", "::: warning\n> quote\n\n::: note\n*bar*\n:::\n:::\n", "<div class=\"admonition warning\">\n<blockquote>\n<p>quote</p>\n</blockquote>\n<div class=\"admonition note\">\n<p><em>bar</em></p>\n</div>\n</div>\n"},
+	{"0", "::: tip\nfoo **bar**\n:::\n", "<div class=\"admonition tip\">\n<p>foo <strong>bar</strong></p>\n</div>\n"},
+}
+
+func TestBlockExtension(t *testing.T) {
+	luteEngine := newBlockExtensionLute(t)
+	for _, test := range blockExtensionTests {
+		html := luteEngine.MarkdownStr(test.name, test.from)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+}
+
+func TestBlockExtensionFormat(t *testing.T) {
+	luteEngine := newBlockExtensionLute(t)
+	luteEngine.FormatRendererFuncs[nodePlantUML] = func(n *ast.Node, entering bool) (string, ast.WalkStatus) {
+		if !entering {
+			return "", ast.WalkContinue
+		}
+		return "@startuml\n" + string(n.Tokens) + "@enduml\n", ast.WalkSkipChildren
+	}
+
+	formatted := luteEngine.FormatStr("", "@startuml\nA -> B\n@enduml\n")
+	if "@startuml\nA -> B\n@enduml\n" != formatted {
+		t.Fatalf("unexpected format result %q", formatted)
+	}
+}
+
+func TestRegisterBlockExtension(t *testing.T) {
+	luteEngine := lute.New()
+	if err := luteEngine.RegisterBlockExtension(&parse.BlockExtension{Type: ast.NodeParagraph, Start: admonitionStart, Continue: admonitionContinue}); nil == err {
+		t.Fatalf("built-in node type should not be registered")
+	}
+	if err := luteEngine.RegisterBlockExtension(&parse.BlockExtension{Type: nodeAdmonition}); nil == err {
+		t.Fatalf("extension without start function should not be registered")
+	}
+	if err := luteEngine.RegisterBlockExtension(&parse.BlockExtension{Type: nodeAdmonition, Start: admonitionStart, Continue: admonitionContinue}); nil != err {
+		t.Fatalf("register extension failed: %s", err)
+	}
+	if err := luteEngine.RegisterBlockExtension(&parse.BlockExtension{Type: nodeAdmonition, Start: admonitionStart, Continue: admonitionContinue}); nil == err {
+		t.Fatalf("duplicated extension should not be registered")
+	}
+}
+
+func newBlockExtensionLute(t *testing.T) *lute.Lute {
+	ret := lute.New()
+	if err := ret.RegisterBlockExtension(&parse.BlockExtension{Type: nodeAdmonition, Start: admonitionStart, Continue: admonitionContinue}); nil != err {
+		t.Fatal(err)
+	}
+	if err := ret.RegisterBlockExtension(&parse.BlockExtension{Type: nodePlantUML, Start: plantUMLStart, Continue: plantUMLContinue, Finalize: plantUMLFinalize}); nil != err {
+		t.Fatal(err)
+	}
+
+	ret.Md2HTMLRendererFuncs[nodeAdmonition] = func(n *ast.Node, entering bool) (string, ast.WalkStatus) {
+		if entering {
+			return "<div class=\"admonition " + n.CustomBlockInfo + "\">\n", ast.WalkContinue
+		}
+		return "</div>\n", ast.WalkContinue
+	}
+	ret.Md2HTMLRendererFuncs[nodePlantUML] = func(n *ast.Node, entering bool) (string, ast.WalkStatus) {
+		if !entering {
+			return "", ast.WalkContinue
+		}
+		return "<pre class=\"plantuml\">" + string(html.EscapeHTML(n.Tokens)) + "</pre>\n", ast.WalkSkipChildren
+	}
+	return ret
+}
+
+func admonitionStart(t *parse.Tree, container *ast.Node) int {
+	ctx := t.Context
+	if ctx.Indented() {
+		return 0
+	}
+
+	line := ctx.CurrentLine()[ctx.NextNonspace():]
+	if !bytes.HasPrefix(line, []byte("::: ")) {
+		return 0
+	}
+	info := string(bytes.TrimSpace(line[3:]))
+	if "" == info {
+		return 0
+	}
+
+	admonition := ctx.OpenBlock(nodeAdmonition)
+	admonition.CustomBlockInfo = info
+	ctx.AdvanceOffset(len(ctx.CurrentLine())-ctx.Offset(), false)
+	return 1
+}
+
+func admonitionContinue(admonition *ast.Node, ctx *parse.Context) int {
+	if last := admonition.LastChild; nil != last && nodeAdmonition == last.Type && !last.Close {
+		return 0 // 交由嵌套的容器判断是否闭合
+	}
+	if !ctx.Indented() && bytes.Equal(bytes.TrimSpace(ctx.CurrentLine()[ctx.NextNonspace():]), []byte(":::")) {
+		ctx.Finalize(admonition)
+		return 2
+	}
+	return 0
+}
+
+func plantUMLStart(t *parse.Tree, container *ast.Node) int {
+	ctx := t.Context
+	if ctx.Indented() || !bytes.HasPrefix(ctx.CurrentLine()[ctx.NextNonspace():], []byte("@startuml")) {
+		return 0
+	}
+
+	ctx.OpenBlock(nodePlantUML)
+	ctx.AdvanceOffset(len(ctx.CurrentLine())-ctx.Offset(), false)
+	return 2
+}
+
+func plantUMLContinue(plantUML *ast.Node, ctx *parse.Context) int {
+	if bytes.Equal(bytes.TrimSpace(ctx.CurrentLine()), []byte("@enduml")) {
+		ctx.Finalize(plantUML)
+		return 2
+	}
+	return 0
+}
+
+func plantUMLFinalize(plantUML *ast.Node, ctx *parse.Context) {
+	// 去掉起始行 @startuml 后余下的内容
+	if i := bytes.IndexByte(plantUML.Tokens, '\n'); 0 <= i {
+		plantUML.Tokens = plantUML.Tokens[i+1:]
+	}
+}
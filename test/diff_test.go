@@ -0,0 +1,91 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/diff"
+	"github.com/88250/lute/parse"
+)
+
+type diffTest struct {
+	name     string
+	old      string
+	new      string
+	expected string
+}
+
+var diffTests = []diffTest{
+
+	{"7", "foo <b> bar\n", "foo <b> bar &\n", "<p>foo <b> bar<ins> &amp;</ins></p>\n"},
+	{"6", "```\ncode\n```\n", "```\ncode2\n```\n", "<del class=\"diff-block\">\n<pre><code>code\n</code></pre>\n</del>\n<ins class=\"diff-block\">\n<pre><code>code2\n</code></pre>\n</ins>\n"},
+	{"5", "foo **bar** baz\n", "foo **bar** qux\n", "<p>foo <strong>bar</strong> <del>baz</del><ins>qux</ins></p>\n"},
+	{"4", "# foo\n\nbar\n\nbaz\n", "bar\n\nbaz\n\n# foo\n", "<p>bar</p>\n<p>baz</p>\n<ins class=\"diff-moved\">\n<h1>foo</h1>\n</ins>\n"},
+	{"3", "foo\n\nbar\n\nbaz\n", "foo\n\nbaz\n", "<p>foo</p>\n<del class=\"diff-block\">\n<p>bar</p>\n</del>\n<p>baz</p>\n"},
+	{"2", "foo\n\nbar\n", "foo\n\nnew\n\nbar\n", "<p>foo</p>\n<ins class=\"diff-block\">\n<p>new</p>\n</ins>\n<p>bar</p>\n"},
+	{"1", "foo\n\nbar baz\n\nqux\n", "foo\n\nbar quux baz\n\nqux\n", "<p>foo</p>\n<p>bar <ins>quux </ins>baz</p>\n<p>qux</p>\n"},
+	{"0", "foo\n", "foo\n", "<p>foo</p>\n"},
+}
+
+func TestDiffHTML(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetCodeSyntaxHighlight(false)
+
+	for _, test := range diffTests {
+		html := string(luteEngine.DiffHTML([]byte(test.old), []byte(test.new)))
+		if test.expected != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\nold\n\t%q\nnew\n\t%q", test.name, test.expected, html, test.old, test.new)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetKramdownIAL(true)
+	luteEngine.SetKramdownBlockIAL(true)
+
+	// 按照 ID 匹配时内容完全不同的块也视为修改
+	oldTree := parse.Parse("", []byte("foo\n{: id=\"1\"}\n\nbar baz\n{: id=\"2\"}\n\nqux\n{: id=\"3\"}\n"), luteEngine.ParseOptions)
+	newTree := parse.Parse("", []byte("qux\n{: id=\"3\"}\n\nbar\n{: id=\"2\"}\n\nquux\n{: id=\"1\"}\n"), luteEngine.ParseOptions)
+	result := diff.Diff(oldTree, newTree)
+	if ops := diffOps(result); "move move modify" != ops {
+		t.Fatalf("unexpected ops [%s]", ops)
+	}
+
+	changes := map[string]*diff.BlockChange{}
+	for _, change := range result.Changes {
+		changes[change.New.ID] = change
+	}
+	if change := changes["1"]; !change.Modified || "foo" != string(change.Old.FirstChild.Tokens) {
+		t.Fatalf("block [1] should be matched by id")
+	}
+	change := changes["2"]
+	if !change.Modified || 2 != len(change.Texts) {
+		t.Fatalf("unexpected text changes of block [2]")
+	}
+	if text := change.Texts[1]; diff.OpDelete != text.Op || " baz" != text.Text || "bar baz" != string(text.Node.Tokens) {
+		t.Fatalf("unexpected text change %+v", text)
+	}
+	if change := changes["3"]; change.Modified || nil != change.Texts {
+		t.Fatalf("block [3] should not be modified")
+	}
+}
+
+func diffOps(result *diff.Result) string {
+	var ops []string
+	for _, change := range result.Changes {
+		ops = append(ops, change.Op.String())
+	}
+	return strings.Join(ops, " ")
+}
@@ -0,0 +1,55 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/parse"
+)
+
+var docStatsTests = []parseTest{
+
+	{"2", "", `{"runeCount":0,"wordCount":0,"linkCount":0,"imageCount":0,"refCount":0,"cjkCharCount":0,"latinWordCount":0,"readingMinutes":0,"headingCounts":{},"codeBlockCounts":{},"tableCount":0,"mathBlockCount":0,"taskDoneCount":0,"taskUndoneCount":0,"footnoteCount":0,"paragraphCount":0,"sentenceCount":0,"avgSentenceWords":0,"avgParagraphWords":0,"maxHeadingDepth":0}`},
+	{"1", "# 标题\n\n## Intro\n\n#### Deep\n\n## Next\n\n这是第一句。这是第二句！Hello world. It's v1.2 ok?\n\n- [x] done\n- [ ] todo\n- [ ] todo2\n\n```go\nfmt.Println()\n```\n\n```\nplain\n```\n\n| a | b |\n|---|---|\n| 1 | 2 |\n\n$$\nx\n$$\n\nfoo[^1] [link](https://b3log.org) ![img](a.png)\n\n[^1]: note\n", `{"runeCount":101,"wordCount":33,"linkCount":1,"imageCount":1,"refCount":0,"cjkCharCount":12,"latinWordCount":25,"readingMinutes":1,"headingCounts":{"1":1,"2":2,"4":1},"codeBlockCounts":{"":1,"go":1},"tableCount":1,"mathBlockCount":1,"taskDoneCount":1,"taskUndoneCount":2,"footnoteCount":1,"paragraphCount":6,"sentenceCount":9,"avgSentenceWords":2.6666666666666665,"avgParagraphWords":4,"maxHeadingDepth":3}`},
+	{"0", "中文内容。\n\nEnglish words here.\n", `{"runeCount":22,"wordCount":8,"linkCount":0,"imageCount":0,"refCount":0,"cjkCharCount":4,"latinWordCount":3,"readingMinutes":1,"headingCounts":{},"codeBlockCounts":{},"tableCount":0,"mathBlockCount":0,"taskDoneCount":0,"taskUndoneCount":0,"footnoteCount":0,"paragraphCount":2,"sentenceCount":2,"avgSentenceWords":3.5,"avgParagraphWords":3.5,"maxHeadingDepth":0}`},
+}
+
+func TestDocStats(t *testing.T) {
+	luteEngine := lute.New()
+	for _, test := range docStatsTests {
+		tree := parse.Parse(test.name, []byte(test.from), luteEngine.ParseOptions)
+		data, _ := json.Marshal(tree.Root.DocStats())
+		if stats := string(data); test.to != stats {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%s\ngot\n\t%s\noriginal markdown text\n\t%q", test.name, test.to, stats, test.from)
+		}
+	}
+}
+
+var docStatsProtyleTests = []parseTest{
+
+	{"0", "## Intro\n\n这是**第一句**。Hello [world](https://b3log.org).\n\n- [x] done\n- [ ] todo\n\n```js\nfoo()\n```\n", `{"runeCount":35,"wordCount":12,"linkCount":1,"imageCount":0,"refCount":0,"cjkCharCount":5,"latinWordCount":6,"readingMinutes":1,"headingCounts":{"2":1},"codeBlockCounts":{"js":1},"tableCount":0,"mathBlockCount":0,"taskDoneCount":1,"taskUndoneCount":1,"footnoteCount":0,"paragraphCount":3,"sentenceCount":4,"avgSentenceWords":2.25,"avgParagraphWords":3,"maxHeadingDepth":1}`},
+}
+
+func TestDocStatsProtyle(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetProtyleWYSIWYG(true)
+	luteEngine.SetKramdownIAL(true)
+	for _, test := range docStatsProtyleTests {
+		tree := luteEngine.BlockDOM2Tree(luteEngine.Md2BlockDOM(test.from, false))
+		data, _ := json.Marshal(tree.Root.DocStats())
+		if stats := string(data); test.to != stats {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%s\ngot\n\t%s\noriginal markdown text\n\t%q", test.name, test.to, stats, test.from)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+)
+
+// readZip 解压 .docx、.epub 等 zip 文件并检查所有 XML 部件都是格式良好的，返回部件名到内容的映射。
+func readZip(t *testing.T, data []byte) (parts map[string]string) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if nil != err {
+		t.Fatalf("open zip failed: %s", err)
+	}
+
+	parts = map[string]string{}
+	for _, f := range reader.File {
+		rc, err := f.Open()
+		if nil != err {
+			t.Fatalf("open part [%s] failed: %s", f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if nil != err {
+			t.Fatalf("read part [%s] failed: %s", f.Name, err)
+		}
+		parts[f.Name] = string(content)
+
+		switch filepath.Ext(f.Name) {
+		case ".xml", ".rels", ".opf", ".xhtml":
+			decoder := xml.NewDecoder(bytes.NewReader(content))
+			for {
+				if _, err = decoder.Token(); nil != err {
+					break
+				}
+			}
+			if io.EOF != err {
+				t.Fatalf("part [%s] is not well-formed: %s", f.Name, err)
+			}
+		}
+	}
+	return
+}
+
+func TestDocx(t *testing.T) {
+	assetsDir := t.TempDir()
+	img, err := os.Create(filepath.Join(assetsDir, "foo bar.png"))
+	if nil != err {
+		t.Fatalf("create image failed: %s", err)
+	}
+	if err = png.Encode(img, image.NewRGBA(image.Rect(0, 0, 200, 100))); nil != err {
+		t.Fatalf("encode image failed: %s", err)
+	}
+	img.Close()
+
+	luteEngine := lute.New()
+	luteEngine.SetToC(true)
+	markdown := "[toc]\n\n# Title\n\nfoo **bold** *em* `c<d` [link](https://b3log.org) [^1]\n\n" +
+		"![img](foo%20bar.png) ![a *b* `c`](foo%20bar.png) ![missing *em*](missing.png)\n\n" +
+		"3. one\n   - nested\n4. two\n\n1) restart\n\n- [x] done\n- [ ] todo\n\n" +
+		"> quote\n\n```go\nif a < b {\n\treturn\n}\n```\n\n| a | b |\n|:-:|--:|\n| 1 | 2 |\n\n---\n\n[^1]: footnote *text*\n"
+	docx, err := luteEngine.Docx("", []byte(markdown), assetsDir)
+	if nil != err {
+		t.Fatalf("render docx failed: %s", err)
+	}
+
+	parts := readZip(t, docx)
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "word/document.xml", "word/_rels/document.xml.rels", "word/styles.xml", "word/numbering.xml", "word/footnotes.xml", "word/settings.xml", "word/media/image1.png"} {
+		if _, ok := parts[name]; !ok {
+			t.Fatalf("part [%s] not found", name)
+		}
+	}
+	if _, ok := parts["word/media/image2.png"]; ok {
+		t.Fatalf("the same image should be embedded only once")
+	}
+
+	document := parts["word/document.xml"]
+	for _, expected := range []string{
+		`<w:instrText xml:space="preserve"> TOC \o "1-6" \h \z \u </w:instrText>`,
+		`<w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t xml:space="preserve">Title</w:t></w:r>`,
+		`<w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">bold</w:t></w:r>`,
+		`<w:r><w:rPr><w:i/></w:rPr><w:t xml:space="preserve">em</w:t></w:r>`,
+		`<w:r><w:rPr><w:rStyle w:val="VerbatimChar"/></w:rPr><w:t xml:space="preserve">c&lt;d</w:t></w:r>`,
+		`<w:hyperlink r:id="rId5"><w:r><w:rPr><w:rStyle w:val="Hyperlink"/></w:rPr><w:t xml:space="preserve">link</w:t></w:r></w:hyperlink>`,
+		`<w:footnoteReference w:id="1"/>`,
+		`<wp:extent cx="1905000" cy="952500"/>`,
+		`<a:blip r:embed="rId6"/>`,
+		`<wp:docPr id="2" name="Picture 2" descr="a b c"/>`,
+		`<w:t xml:space="preserve">missing em</w:t>`,
+		`<w:numPr><w:ilvl w:val="0"/><w:numId w:val="2"/></w:numPr></w:pPr><w:r><w:t xml:space="preserve">one</w:t>`,
+		`<w:numPr><w:ilvl w:val="1"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t xml:space="preserve">nested</w:t>`,
+		`<w:numPr><w:ilvl w:val="0"/><w:numId w:val="3"/></w:numPr></w:pPr><w:r><w:t xml:space="preserve">restart</w:t>`,
+		`<w:t xml:space="preserve">☒</w:t></w:r><w:r><w:t xml:space="preserve"> done</w:t>`,
+		`<w:pStyle w:val="Quote"/></w:pPr><w:r><w:t xml:space="preserve">quote</w:t>`,
+		`<w:t xml:space="preserve">if a &lt; b {</w:t><w:br/><w:tab/><w:t xml:space="preserve">return</w:t>`,
+		`<w:trPr><w:tblHeader/></w:trPr>`,
+		`<w:jc w:val="center"/></w:pPr><w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">a</w:t>`,
+		`<w:jc w:val="right"/></w:pPr><w:r><w:t xml:space="preserve">2</w:t>`,
+		`<w:pStyle w:val="HorizontalRule"/>`,
+	} {
+		if !strings.Contains(document, expected) {
+			t.Fatalf("document.xml should contain %s\n%s", expected, document)
+		}
+	}
+	if strings.Contains(document, "footnote text") || strings.Contains(document, "</w:p></w:p>") {
+		t.Fatalf("unexpected document.xml\n%s", document)
+	}
+
+	if !strings.Contains(parts["word/footnotes.xml"], `<w:footnote w:id="1"><w:p><w:pPr><w:pStyle w:val="FootnoteText"/></w:pPr><w:r><w:rPr><w:rStyle w:val="FootnoteReference"/></w:rPr><w:footnoteRef/></w:r>`) {
+		t.Fatalf("unexpected footnotes.xml\n%s", parts["word/footnotes.xml"])
+	}
+	if !strings.Contains(parts["word/numbering.xml"], `<w:num w:numId="2"><w:abstractNumId w:val="1"/><w:lvlOverride w:ilvl="0"><w:startOverride w:val="3"/>`) {
+		t.Fatalf("unexpected numbering.xml\n%s", parts["word/numbering.xml"])
+	}
+	rels := parts["word/_rels/document.xml.rels"]
+	if !strings.Contains(rels, `<Relationship Id="rId6" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="media/image1.png"/>`) ||
+		!strings.Contains(rels, `Target="https://b3log.org" TargetMode="External"`) {
+		t.Fatalf("unexpected document.xml.rels\n%s", rels)
+	}
+	if !strings.Contains(parts["word/settings.xml"], `<w:updateFields w:val="true"/>`) {
+		t.Fatalf("fields should be updated when there is a toc")
+	}
+}
+
+func TestDocxMergedCellTable(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetProtyleWYSIWYG(true)
+	luteEngine.SetKramdownIAL(true)
+
+	markdown := "|{: colspan=\"2\" rowspan=\"2\"}a|{: class=\"fn__none\"}|c|\n| ---| ---| ---|\n|{: class=\"fn__none\"}|{: class=\"fn__none\"}|f|\n|g|h|i|\n"
+	docx, err := luteEngine.Docx("", []byte(markdown), "")
+	if nil != err {
+		t.Fatalf("render docx failed: %s", err)
+	}
+
+	document := readZip(t, docx)["word/document.xml"]
+	rows := strings.Split(document, "<w:tr>")[1:]
+	if 3 != len(rows) {
+		t.Fatalf("expected 3 rows, got %d\n%s", len(rows), document)
+	}
+	if 2 != strings.Count(rows[0], "<w:tc>") || !strings.Contains(rows[0], `<w:gridSpan w:val="2"/><w:vMerge w:val="restart"/>`) {
+		t.Fatalf("unexpected first row\n%s", rows[0])
+	}
+	if 2 != strings.Count(rows[1], "<w:tc>") || !strings.Contains(rows[1], `<w:gridSpan w:val="2"/><w:vMerge/>`) {
+		t.Fatalf("unexpected second row\n%s", rows[1])
+	}
+	if 3 != strings.Count(rows[2], "<w:tc>") || strings.Contains(rows[2], "vMerge") {
+		t.Fatalf("unexpected third row\n%s", rows[2])
+	}
+	if 3 != strings.Count(document, "<w:gridCol ") {
+		t.Fatalf("expected 3 grid columns\n%s", document)
+	}
+}
+
+func TestDocxAssetsOutsideDir(t *testing.T) {
+	root := t.TempDir()
+	assetsDir := filepath.Join(root, "assets")
+	if err := os.Mkdir(assetsDir, 0755); nil != err {
+		t.Fatalf("create assets dir failed: %s", err)
+	}
+	secret := filepath.Join(root, "secret.png")
+	img, err := os.Create(secret)
+	if nil != err {
+		t.Fatalf("create image failed: %s", err)
+	}
+	if err = png.Encode(img, image.NewRGBA(image.Rect(0, 0, 2, 1))); nil != err {
+		t.Fatalf("encode image failed: %s", err)
+	}
+	img.Close()
+
+	luteEngine := lute.New()
+	markdown := "![up](../secret.png) ![dot](./../secret.png) ![abs](" + filepath.ToSlash(secret) + ") ![file](file://" + filepath.ToSlash(secret) + ")\n"
+	docx, err := luteEngine.Docx("", []byte(markdown), assetsDir)
+	if nil != err {
+		t.Fatalf("render docx failed: %s", err)
+	}
+
+	parts := readZip(t, docx)
+	for name := range parts {
+		if strings.HasPrefix(name, "word/media/") {
+			t.Fatalf("image outside the assets dir should not be embedded: %s", name)
+		}
+	}
+	document, rels := parts["word/document.xml"], parts["word/_rels/document.xml.rels"]
+	if strings.Contains(document, "<w:drawing>") || !strings.Contains(document, `<w:hyperlink r:id="rId7"><w:r><w:rPr><w:rStyle w:val="Hyperlink"/></w:rPr><w:t xml:space="preserve">abs</w:t>`) {
+		t.Fatalf("unexpected document.xml\n%s", document)
+	}
+	for _, target := range []string{"../secret.png", filepath.ToSlash(secret), "file://" + filepath.ToSlash(secret)} {
+		if !strings.Contains(rels, `Target="`+target+`" TargetMode="External"`) {
+			t.Fatalf("image %s should be kept as a link\n%s", target, rels)
+		}
+	}
+}
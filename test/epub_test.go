@@ -0,0 +1,151 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/render"
+)
+
+func TestEpub(t *testing.T) {
+	assetsDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(assetsDir, "foo bar.png"), []byte("png"), 0644); nil != err {
+		t.Fatalf("write image failed: %s", err)
+	}
+
+	luteEngine := lute.New()
+	chapters := [][]byte{
+		[]byte("# One\n\nfoo ![img](foo%20bar.png) ![again](foo%20bar.png) ![remote](https://b3log.org/a.png)\n\n## One **A**\n\n### One A 1\n\n## One B\n\n---\n\n- [x] done\n\nfoo[^1]\n\n[^1]: note\n"),
+		[]byte("# Two\n\nbar  \nbaz\n"),
+		[]byte("no heading\n"),
+	}
+	metadata := &render.EpubMetadata{Identifier: "urn:isbn:123", Title: "Foo & Bar", Language: "zh-CN", Author: "Lute",
+		Modified: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	epub, err := luteEngine.Epub(chapters, metadata, assetsDir)
+	if nil != err {
+		t.Fatalf("render epub failed: %s", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(epub), int64(len(epub)))
+	if nil != err {
+		t.Fatalf("open epub failed: %s", err)
+	}
+	if "mimetype" != reader.File[0].Name || zip.Store != reader.File[0].Method {
+		t.Fatalf("mimetype should be the first uncompressed file")
+	}
+	files := readZip(t, epub)
+	if "application/epub+zip" != files["mimetype"] || "png" != files["OEBPS/images/image1.png"] {
+		t.Fatalf("unexpected files %v", files)
+	}
+	if _, ok := files["OEBPS/images/image2.png"]; ok {
+		t.Fatalf("the same image should be packaged only once")
+	}
+
+	opf := files["OEBPS/content.opf"]
+	for _, expected := range []string{
+		`<dc:identifier id="uid">urn:isbn:123</dc:identifier><dc:title>Foo &amp; Bar</dc:title><dc:language>zh-CN</dc:language><dc:creator>Lute</dc:creator><meta property="dcterms:modified">2024-01-02T03:04:05Z</meta>`,
+		`<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>`,
+		`<item id="image1" href="images/image1.png" media-type="image/png"/>`,
+		`<spine><itemref idref="chapter1"/><itemref idref="chapter2"/><itemref idref="chapter3"/></spine>`,
+	} {
+		if !strings.Contains(opf, expected) {
+			t.Fatalf("content.opf should contain %s\n%s", expected, opf)
+		}
+	}
+
+	nav := files["OEBPS/nav.xhtml"]
+	expected := `<nav epub:type="toc" id="toc"><h1>Foo &amp; Bar</h1><ol>` +
+		`<li><a href="chapter1.xhtml#One">One</a><ol><li><a href="chapter1.xhtml#One-A">One <strong>A</strong></a><ol><li><a href="chapter1.xhtml#One-A-1">One A 1</a></li></ol></li><li><a href="chapter1.xhtml#One-B">One B</a></li></ol></li>` +
+		`<li><a href="chapter2.xhtml#Two">Two</a></li><li><a href="chapter3.xhtml">Chapter 3</a></li></ol></nav>`
+	if !strings.Contains(nav, expected) {
+		t.Fatalf("nav.xhtml should contain %s\n%s", expected, nav)
+	}
+
+	chapter1 := files["OEBPS/chapter1.xhtml"]
+	for _, expected := range []string{`<title>One</title>`, `<h2 id="One-A">`, `<img src="images/image1.png" alt="img" />`, `<img src="images/image1.png" alt="again" />`, `<img src="https://b3log.org/a.png" alt="remote" />`} {
+		if !strings.Contains(chapter1, expected) {
+			t.Fatalf("chapter1.xhtml should contain %s\n%s", expected, chapter1)
+		}
+	}
+}
+
+func TestEpubAssetsOutsideDir(t *testing.T) {
+	root := t.TempDir()
+	assetsDir := filepath.Join(root, "assets")
+	if err := os.Mkdir(assetsDir, 0755); nil != err {
+		t.Fatalf("create assets dir failed: %s", err)
+	}
+	secret := filepath.Join(root, "secret.png")
+	if err := os.WriteFile(secret, []byte("png"), 0644); nil != err {
+		t.Fatalf("write image failed: %s", err)
+	}
+
+	luteEngine := lute.New()
+	chapters := [][]byte{[]byte("![up](../secret.png) ![abs](" + filepath.ToSlash(secret) + ")\n")}
+	epub, err := luteEngine.Epub(chapters, &render.EpubMetadata{Title: "Foo"}, assetsDir)
+	if nil != err {
+		t.Fatalf("render epub failed: %s", err)
+	}
+
+	files := readZip(t, epub)
+	for name := range files {
+		if strings.HasPrefix(name, "OEBPS/images/") {
+			t.Fatalf("image outside the assets dir should not be packaged: %s", name)
+		}
+	}
+	chapter := files["OEBPS/chapter1.xhtml"]
+	for _, expected := range []string{`<img src="../secret.png" alt="up" />`, `<img src="` + filepath.ToSlash(secret) + `" alt="abs" />`} {
+		if !strings.Contains(chapter, expected) {
+			t.Fatalf("chapter1.xhtml should contain %s\n%s", expected, chapter)
+		}
+	}
+}
+
+func TestEpubXHTML(t *testing.T) {
+	luteEngine := lute.New()
+	chapters := [][]byte{[]byte("<div class=\"a\">\n<br>\n<img src=\"a.png\" alt='a&b'>\n<p>open\n</div>\n\n" +
+		"foo <br> <img src=\"b.png\"> <span class=\"x\">s</span> <b>bold *em</b>* </i> &nbsp;\n\n<hr>\n")}
+	epub, err := luteEngine.Epub(chapters, &render.EpubMetadata{Title: "Foo"}, "")
+	if nil != err {
+		t.Fatalf("render epub failed: %s", err)
+	}
+
+	chapter := readZip(t, epub)["OEBPS/chapter1.xhtml"]
+	for _, expected := range []string{
+		"<div class=\"a\">\n<br/>\n<img src=\"a.png\" alt=\"a&amp;b\"/>\n<p>open\n</p></div>",
+		`<p>foo <br /> <img src="b.png" /> <span class="x">s</span> &lt;b&gt;bold <em>em&lt;/b&gt;</em> &lt;/i&gt;`,
+		"<hr/>",
+	} {
+		if !strings.Contains(chapter, expected) {
+			t.Fatalf("chapter1.xhtml should contain %s\n%s", expected, chapter)
+		}
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(chapter))
+	for {
+		if _, err = decoder.Token(); io.EOF == err {
+			break
+		}
+		if nil != err {
+			t.Fatalf("chapter1.xhtml is not well-formed: %s\n%s", err, chapter)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+)
+
+func TestParseE(t *testing.T) {
+	luteEngine := lute.New()
+	tree, err := parse.ParseE("foo.md", []byte("# foo\n"), luteEngine.ParseOptions)
+	if nil != err || ast.NodeHeading != tree.Root.FirstChild.Type {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	// 块级解析 panic 时返回当前行
+	err = luteEngine.RegisterBlockExtension(&parse.BlockExtension{Type: ast.NodeExtLeafBlock,
+		Start: func(t *parse.Tree, container *ast.Node) int {
+			if bytes.HasPrefix(t.Context.CurrentLine(), []byte("!boom")) {
+				panic("block boom")
+			}
+			return 0
+		},
+		Continue: func(n *ast.Node, context *parse.Context) int { return 1 },
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	tree, err = parse.ParseE("foo.md", []byte("foo\n\n!boom\n"), luteEngine.ParseOptions)
+	assertParseError(t, tree, err, "foo.md", "3:1-3:6", "block boom")
+
+	// 行级解析 panic 时返回当前块的位置
+	err = luteEngine.RegisterInlineExtension(&parse.InlineExtension{Trigger: '@',
+		Parse: func(t *parse.Tree, block *ast.Node, ctx *parse.InlineContext) *ast.Node {
+			panic(errors.New("inline boom"))
+		},
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	luteEngine.SetSourcePos(true)
+	tree, err = parse.ParseE("bar.md", []byte("foo\n\n> bar\n> @baz\n"), luteEngine.ParseOptions)
+	assertParseError(t, tree, err, "bar.md", "3:3-4:7", "inline boom")
+}
+
+func TestParseContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tree, err := parse.ParseContext(ctx, "foo.md", []byte("foo\n"), lute.New().ParseOptions)
+	if nil != tree || !errors.Is(err, context.Canceled) {
+		t.Fatalf("parse should be canceled: %v", err)
+	}
+	if parseErr := (*parse.Error)(nil); !errors.As(err, &parseErr) || "foo.md" != parseErr.Name {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestMarkdownE(t *testing.T) {
+	luteEngine := lute.New()
+	html, err := luteEngine.MarkdownE("foo.md", []byte("**foo**\n"))
+	if nil != err || "<p><strong>foo</strong></p>\n" != string(html) {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	luteEngine.SetSourcePos(true)
+	luteEngine.Md2HTMLRendererFuncs[ast.NodeStrong] = func(n *ast.Node, entering bool) (string, ast.WalkStatus) {
+		panic("render boom")
+	}
+	html, err = luteEngine.MarkdownE("foo.md", []byte("foo\n\nbar **baz**\n"))
+	if nil != html {
+		t.Fatalf("unexpected html %q", html)
+	}
+	assertParseError(t, nil, err, "foo.md", "3:5-3:12", "render boom")
+
+	if _, err = luteEngine.FormatE("foo.md", []byte("**foo**\n")); nil != err {
+		t.Fatalf("format failed: %v", err)
+	}
+	if json, err := luteEngine.RenderJSONE("foo"); nil != err || "" == json {
+		t.Fatalf("render json failed: %v", err)
+	}
+}
+
+func TestMarkdownContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	luteEngine := lute.New()
+	luteEngine.Md2HTMLRendererFuncs[ast.NodeParagraph] = func(n *ast.Node, entering bool) (string, ast.WalkStatus) {
+		cancel() // 渲染第一个段落后取消
+		return "", ast.WalkContinue
+	}
+	_, err := luteEngine.MarkdownContext(ctx, "foo.md", []byte("foo\n\nbar\n"))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("render should be canceled: %v", err)
+	}
+}
+
+func TestHTML2MarkdownError(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.HTML2MdRendererFuncs[ast.NodeStrong] = func(n *ast.Node, entering bool) (string, ast.WalkStatus) {
+		panic("h2m boom")
+	}
+	_, err := luteEngine.HTML2MarkdownE("<p><strong>foo</strong></p>")
+	if parseErr := (*parse.Error)(nil); !errors.As(err, &parseErr) || "h2m boom" != parseErr.Err.Error() {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestEditorE(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetProtyleWYSIWYG(true)
+	if vHTML, err := luteEngine.Md2BlockDOME("foo", false); nil != err || !strings.Contains(vHTML, "foo") {
+		t.Fatalf("md to block dom failed: %v", err)
+	}
+
+	luteEngine.Md2BlockDOMRendererFuncs[ast.NodeParagraph] = func(n *ast.Node, entering bool) (string, ast.WalkStatus) {
+		panic("block dom boom")
+	}
+	if _, err := luteEngine.Md2BlockDOME("foo", false); nil == err || "block dom boom" != errors.Unwrap(err).Error() {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	luteEngine = lute.New()
+	luteEngine.Md2VditorDOMRendererFuncs[ast.NodeParagraph] = func(n *ast.Node, entering bool) (string, ast.WalkStatus) {
+		panic("vditor dom boom")
+	}
+	if _, err := luteEngine.Md2VditorDOME("foo"); nil == err || "vditor dom boom" != errors.Unwrap(err).Error() {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func assertParseError(t *testing.T, tree *parse.Tree, err error, name, pos, cause string) {
+	t.Helper()
+	if nil != tree {
+		t.Fatalf("tree should be nil when error occurred")
+	}
+	parseErr := (*parse.Error)(nil)
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if name != parseErr.Name || pos != parseErr.Position.String() || cause != parseErr.Err.Error() || nil == parseErr.Stack {
+		t.Fatalf("unexpected error [name=%s, pos=%s, cause=%s]", parseErr.Name, parseErr.Position, parseErr.Err)
+	}
+}
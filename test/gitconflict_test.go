@@ -11,14 +11,21 @@
 package test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/render"
 )
 
 var gitConflictTests = []parseTest{
 
-	{"0", "<<<<<<< HEAD\n这里是本地原来的内容\n=======\n这里是拉取下来的内容\n>>>>>>> feebfeb6bef44cf1384d51cdd7aef7e4197b8180", "<div class=\"language-git-conflict\"><<<<<<< HEAD\n这里是本地原来的内容\n=======\n这里是拉取下来的内容\n>>>>>>> feebfeb6bef44cf1384d51cdd7aef7e4197b8180\n</div>"},
+	{"3", "<<<<<<< HEAD\n**foo** <b>\n>>>>>>> theirs\n", "<div class=\"language-git-conflict\">\n<div class=\"git-conflict-ours\" data-marker=\"&lt;&lt;&lt;&lt;&lt;&lt;&lt; HEAD\">\n<p><strong>foo</strong> <b></p>\n</div>\n<div class=\"git-conflict-theirs\" data-marker=\"&gt;&gt;&gt;&gt;&gt;&gt;&gt; theirs\">\n</div>\n</div>"},
+	{"2", "foo\n<<<<<<< HEAD\n- a\n- b\n=======\n> c\n>>>>>>> theirs\nbar", "<p>foo</p>\n<div class=\"language-git-conflict\">\n<div class=\"git-conflict-ours\" data-marker=\"&lt;&lt;&lt;&lt;&lt;&lt;&lt; HEAD\">\n<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n</div>\n<div class=\"git-conflict-theirs\" data-marker=\"&gt;&gt;&gt;&gt;&gt;&gt;&gt; theirs\">\n<blockquote>\n<p>c</p>\n</blockquote>\n</div>\n</div>\n<p>bar</p>\n"},
+	{"1", "<<<<<<< HEAD\n这里是本地原来的内容\n||||||| merged common ancestors\n这里是共同祖先的内容\n=======\n这里是拉取下来的内容\n>>>>>>> feebfeb6bef44cf1384d51cdd7aef7e4197b8180", "<div class=\"language-git-conflict\">\n<div class=\"git-conflict-ours\" data-marker=\"&lt;&lt;&lt;&lt;&lt;&lt;&lt; HEAD\">\n<p>这里是本地原来的内容</p>\n</div>\n<div class=\"git-conflict-base\" data-marker=\"||||||| merged common ancestors\">\n<p>这里是共同祖先的内容</p>\n</div>\n<div class=\"git-conflict-theirs\" data-marker=\"&gt;&gt;&gt;&gt;&gt;&gt;&gt; feebfeb6bef44cf1384d51cdd7aef7e4197b8180\">\n<p>这里是拉取下来的内容</p>\n</div>\n</div>"},
+	{"0", "<<<<<<< HEAD\n这里是本地原来的内容\n=======\n这里是拉取下来的内容\n>>>>>>> feebfeb6bef44cf1384d51cdd7aef7e4197b8180", "<div class=\"language-git-conflict\">\n<div class=\"git-conflict-ours\" data-marker=\"&lt;&lt;&lt;&lt;&lt;&lt;&lt; HEAD\">\n<p>这里是本地原来的内容</p>\n</div>\n<div class=\"git-conflict-theirs\" data-marker=\"&gt;&gt;&gt;&gt;&gt;&gt;&gt; feebfeb6bef44cf1384d51cdd7aef7e4197b8180\">\n<p>这里是拉取下来的内容</p>\n</div>\n</div>"},
 }
 
 func TestGitConflict(t *testing.T) {
@@ -32,3 +39,110 @@ func TestGitConflict(t *testing.T) {
 		}
 	}
 }
+
+var gitConflictFormatTests = []parseTest{
+
+	{"2", "foo\n<<<<<<< HEAD\n# ours *em*\n\n- a\n- b\n||||||| base\nbase [link]\n=======\ntheirs\n>>>>>>> abc\nbar\n\n[link]: /url\n", "foo\n\n<<<<<<< HEAD\n# ours *em*\n\n- a\n- b\n||||||| base\nbase [link]\n=======\ntheirs\n>>>>>>> abc\n\nbar\n\n[link]: /url\n"},
+	{"1", "<<<<<<< HEAD\n**foo** <b>\n>>>>>>> theirs\n", "<<<<<<< HEAD\n**foo** <b>\n=======\n>>>>>>> theirs\n"},
+	{"0", "<<<<<<< HEAD\n这里是本地原来的内容\n=======\n这里是拉取下来的内容\n>>>>>>> feebfeb6bef44cf1384d51cdd7aef7e4197b8180", "<<<<<<< HEAD\n这里是本地原来的内容\n=======\n这里是拉取下来的内容\n>>>>>>> feebfeb6bef44cf1384d51cdd7aef7e4197b8180\n"},
+}
+
+func TestGitConflictFormat(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetGitConflict(true)
+
+	for _, test := range gitConflictFormatTests {
+		formatted := luteEngine.FormatStr(test.name, test.from)
+		if test.to != formatted {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, formatted, test.from)
+		}
+	}
+}
+
+func TestGitConflictAccept(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetGitConflict(true)
+
+	markdown := "foo\n\n<<<<<<< HEAD\nours 1\n\nours 2\n||||||| base\nbase\n=======\ntheirs\n>>>>>>> abc\n\nbar\n"
+	cases := []struct {
+		accept   func(*ast.Node) bool
+		expected string
+	}{
+		{parse.AcceptOurs, "foo\n\nours 1\n\nours 2\n\nbar\n"},
+		{parse.AcceptTheirs, "foo\n\ntheirs\n\nbar\n"},
+		{parse.AcceptBoth, "foo\n\nours 1\n\nours 2\n\ntheirs\n\nbar\n"},
+	}
+	for i, c := range cases {
+		tree := parse.Parse("", []byte(markdown), luteEngine.ParseOptions)
+		conflicts := tree.GitConflicts()
+		if 1 != len(conflicts) {
+			t.Fatalf("case [%d]: expected 1 conflict, got %d", i, len(conflicts))
+		}
+		if !c.accept(conflicts[0]) {
+			t.Fatalf("case [%d]: accept failed", i)
+		}
+		if md := string(render.NewFormatRenderer(tree, luteEngine.RenderOptions, luteEngine.ParseOptions).Render()); c.expected != md {
+			t.Fatalf("case [%d]: expected %q, got %q", i, c.expected, md)
+		}
+		if 0 != len(tree.GitConflicts()) {
+			t.Fatalf("case [%d]: conflict should be removed", i)
+		}
+	}
+
+	if parse.AcceptOurs(&ast.Node{Type: ast.NodeParagraph}) {
+		t.Fatalf("accept should fail on a non-conflict node")
+	}
+}
+
+func TestGitConflictBlockDOM(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetGitConflict(true)
+	luteEngine.SetKramdownIAL(true)
+	luteEngine.SetKramdownBlockIAL(true)
+
+	markdown := "foo\n{: id=\"20200101000000-aaaaaaa\"}\n\n<<<<<<< HEAD\n# ours\n{: id=\"20200101000000-bbbbbbb\"}\n||||||| base\nbase\n{: id=\"20200101000000-ccccccc\"}\n=======\ntheirs\n{: id=\"20200101000000-ddddddd\"}\n>>>>>>> abc\n{: id=\"20200101000000-eeeeeee\"}\n"
+	blockDOM := luteEngine.Md2BlockDOM(markdown, false)
+	if !strings.Contains(blockDOM, "data-type=\"NodeGitConflictBase\"") {
+		t.Fatalf("unexpected block DOM %s", blockDOM)
+	}
+	if md := luteEngine.BlockDOM2Md(blockDOM); !strings.HasPrefix(md, markdown) {
+		t.Fatalf("unexpected markdown %q", md)
+	}
+}
+
+func TestGitConflictSourcePos(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetGitConflict(true)
+	luteEngine.SetSourcePos(true)
+
+	markdown := "foo\n\n<<<<<<< HEAD\nours\n||||||| base\nbase\n=======\n*theirs*\n>>>>>>> abc\n"
+	tree := parse.Parse("", []byte(markdown), luteEngine.ParseOptions)
+	for _, text := range []string{"ours", "base", "theirs"} {
+		var p *ast.Node
+		ast.Walk(tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+			if entering && ast.NodeParagraph == n.Type && text == n.Text() {
+				p = n
+			}
+			return ast.WalkContinue
+		})
+		if nil == p || nil == p.Position {
+			t.Fatalf("paragraph [%s] should have a source position", text)
+		}
+		if got := strings.TrimSpace(markdown[p.Position.StartOffset:p.Position.EndOffset]); !strings.Contains(got, text) {
+			t.Fatalf("unexpected source of paragraph [%s]: %q", text, got)
+		}
+	}
+}
+
+func TestGitConflictVditorDOM(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetGitConflict(true)
+
+	markdown := "foo\n\n<<<<<<< HEAD\n# ours *em*\n\n- a\n- b\n||||||| base\nbase\n=======\ntheirs\n>>>>>>> abc\n\nbar\n"
+	if md := luteEngine.VditorDOM2Md(luteEngine.Md2VditorDOM(markdown)); markdown != md {
+		t.Fatalf("unexpected wysiwyg markdown %q", md)
+	}
+	if md := luteEngine.VditorIRDOM2Md(luteEngine.Md2VditorIRDOM(markdown)); markdown != md {
+		t.Fatalf("unexpected ir markdown %q", md)
+	}
+}
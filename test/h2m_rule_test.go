@@ -0,0 +1,133 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/html"
+	"github.com/88250/lute/parse"
+)
+
+const html2MdFlavorHTML = `<p><mark>m</mark> <sup>1</sup> <kbd>K</kbd> <u>u</u> <s>d</s> <span data-type="inline-math" data-content="x^2"></span> <span data-type="tag">tag</span></p><div class="language-math">\sum</div><table><thead><tr><th align="left">a</th><th>b</th></tr></thead><tbody><tr><td>1</td><td><sup>2</sup></td></tr></tbody></table><p><img src="a.png" width="100"></p>`
+
+var html2MdFlavorTests = []parseTest{
+
+	{"2", html2MdFlavorHTML, "<mark>m</mark> <sup>1</sup> <kbd>K</kbd> <u>u</u> <del>d</del> `x^2` #tag\n\n```math\n\\sum\n```\n\n<table>\n<thead>\n<tr>\n<th align=\"left\">a</th>\n<th>b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>1</td>\n<td><sup>2</sup></td>\n</tr>\n</tbody>\n</table>\n\n![](a.png)\n"},
+	{"1", html2MdFlavorHTML, "<mark>m</mark> <sup>1</sup> <kbd>K</kbd> <u>u</u> ~~d~~ $x^2$ #tag\n\n$$\n\\sum\n$$\n\n| a | b               |\n| :-- | ----------------- |\n| 1 | <sup>2</sup> |\n\n![](a.png)\n"},
+	{"0", html2MdFlavorHTML, "==m== ^1^ <kbd>K</kbd> <u>u</u> ~~d~~ $x^2$ #tag#\n\n$$\n\\sum\n$$\n\n| a | b    |\n| :-- | ------ |\n| 1 | ^2^ |\n\n![](a.png){: style=\"width: 100px;\"}\n"},
+}
+
+func TestHTML2MdFlavor(t *testing.T) {
+	for i, test := range html2MdFlavorTests {
+		luteEngine := lute.New()
+		luteEngine.SetSup(true)
+		luteEngine.SetSub(true)
+		luteEngine.SetMark(true)
+		luteEngine.SetKramdownIAL(true)
+		luteEngine.SetHTML2MarkdownFlavor(len(html2MdFlavorTests) - 1 - i)
+		md := luteEngine.HTML2Md(test.from)
+		if test.to != md {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal html\n\t%q", test.name, test.to, md, test.from)
+		}
+	}
+}
+
+const html2MdFlavorTaskHTML = `<ul><li><input type="checkbox" checked> done</li><li><input type="checkbox"> todo</li></ul><ol><li><input type="checkbox"> a</li></ol>`
+
+var html2MdFlavorTaskTests = []parseTest{
+
+	{"2", html2MdFlavorTaskHTML, "* \\[x\\] done\n* \\[ \\] todo\n\n1. \\[ \\] a\n"},
+	{"1", html2MdFlavorTaskHTML, "* [X] done\n* [ ] todo\n\n1. [ ] a\n"},
+	{"0", html2MdFlavorTaskHTML, "* [X] done\n* [ ] todo\n\n1. [ ] a\n"},
+}
+
+func TestHTML2MdFlavorTask(t *testing.T) {
+	for i, test := range html2MdFlavorTaskTests {
+		luteEngine := lute.New()
+		luteEngine.SetHTML2MarkdownFlavor(len(html2MdFlavorTaskTests) - 1 - i)
+		md := luteEngine.HTML2Md(test.from)
+		if test.to != md {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal html\n\t%q", test.name, test.to, md, test.from)
+		}
+		if 0 == i && strings.Contains(luteEngine.MarkdownStr("", md), "checkbox") {
+			t.Fatalf("test case [%s] failed: CommonMark output is still a task list", test.name)
+		}
+	}
+}
+
+const html2MdUnknownHTML = "<p>a <my-tag class=\"x\">b</my-tag> c <button>Buy</button></p><my-card><p>card</p>\n\n<p>x</p></my-card><p>end</p>"
+
+var html2MdUnknownTests = []parseTest{
+
+	{"2", html2MdUnknownHTML, "a <my-tag class=\"x\">b</my-tag> c <button>Buy</button>\n\n<my-card><p>card</p>\n<p>x</p></my-card>\n\nend\n"},
+	{"1", html2MdUnknownHTML, "a  c \n\nend\n"},
+	{"0", html2MdUnknownHTML, "a b c Buy\n\ncard\n\nx\n\nend\n"},
+}
+
+func TestHTML2MdUnknown(t *testing.T) {
+	for i, test := range html2MdUnknownTests {
+		luteEngine := lute.New()
+		luteEngine.SetHTML2MarkdownUnknown(len(html2MdUnknownTests) - 1 - i)
+		md := luteEngine.HTML2Md(test.from)
+		if test.to != md {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal html\n\t%q", test.name, test.to, md, test.from)
+		}
+	}
+}
+
+func TestHTML2MdRule(t *testing.T) {
+	luteEngine := lute.New()
+	if err := luteEngine.RegisterHTML2MdRule(&lute.HTML2MdRule{Tag: "div"}); nil == err {
+		t.Fatalf("register rule without convert function should fail")
+	}
+
+	rules := []*lute.HTML2MdRule{
+		{Class: "ad", Convert: func(n *html.Node, tree *parse.Tree) (*ast.Node, lute.HTML2MdAction) {
+			return nil, lute.HTML2MdDrop
+		}},
+		{Tag: "div", Class: "note", Convert: func(n *html.Node, tree *parse.Tree) (*ast.Node, lute.HTML2MdAction) {
+			blockquote := &ast.Node{Type: ast.NodeBlockquote}
+			blockquote.AppendChild(&ast.Node{Type: ast.NodeBlockquoteMarker, Tokens: []byte(">")})
+			return blockquote, lute.HTML2MdNode
+		}},
+		{Tag: "span", Class: "highlight", Convert: func(n *html.Node, tree *parse.Tree) (*ast.Node, lute.HTML2MdAction) {
+			strong := &ast.Node{Type: ast.NodeStrong}
+			strong.AppendChild(&ast.Node{Type: ast.NodeStrongA6kOpenMarker, Tokens: []byte("**")})
+			strong.AppendChild(&ast.Node{Type: ast.NodeStrongA6kCloseMarker, Tokens: []byte("**")})
+			return strong, lute.HTML2MdNode
+		}},
+		{Tag: "abbr", Convert: func(n *html.Node, tree *parse.Tree) (*ast.Node, lute.HTML2MdAction) {
+			return nil, lute.HTML2MdHTML
+		}},
+		{Tag: "code", Convert: func(n *html.Node, tree *parse.Tree) (*ast.Node, lute.HTML2MdAction) {
+			return nil, lute.HTML2MdText
+		}},
+		{Tag: "p", Convert: func(n *html.Node, tree *parse.Tree) (*ast.Node, lute.HTML2MdAction) {
+			return nil, lute.HTML2MdDefault
+		}},
+	}
+	for _, rule := range rules {
+		if err := luteEngine.RegisterHTML2MdRule(rule); nil != err {
+			t.Fatalf("register rule failed: %s", err)
+		}
+	}
+
+	from := `<div class="note"><p>a <span class="highlight">b</span> <abbr title="x">HTML</abbr> <code>*c*</code></p></div><div class="ad sponsor"><p>buy</p></div><p>end</p>`
+	expected := "> a **b** <abbr title=\"x\">HTML</abbr> \\*c\\*\n\nend\n"
+	md := luteEngine.HTML2Md(from)
+	if expected != md {
+		t.Fatalf("html2md with rules failed\nexpected\n\t%q\ngot\n\t%q", expected, md)
+	}
+}
@@ -18,6 +18,7 @@ import (
 
 var html2MdTests = []parseTest{
 
+	{"253", "<!DOCTYPE html><html><head><title>t</title></head><body><p>foo <b>bar</b></p></body></html>", "foo **bar**\n"},
 	{"252", "<p>foo</p><p><span class=\"mjx-chtml MJXc-display\" data-formula=\"\\vec{S} = \\begin{pmatrix} I\\\\Q\\\\U\\\\V\\end{pmatrix}\"><span id=\"MathJax-Element-1-Frame\" class=\"mjx-chtml MathJax_CHTML\" tabindex=\"0\" data-mathml=\"&lt;math xmlns=&quot;http://www.w3.org/1998/Math/MathML&quot; display=&quot;block&quot;&gt;&lt;mrow class=&quot;MJX-TeXAtom-ORD&quot;&gt;&lt;mover&gt;&lt;mi&gt;S&lt;/mi&gt;&lt;mo stretchy=&quot;false&quot;&gt;&amp;#x2192;&lt;/mo&gt;&lt;/mover&gt;&lt;/mrow&gt;&lt;mo&gt;=&lt;/mo&gt;&lt;mrow&gt;&lt;mo&gt;(&lt;/mo&gt;&lt;mtable rowspacing=&quot;4pt&quot; columnspacing=&quot;1em&quot;&gt;&lt;mtr&gt;&lt;mtd&gt;&lt;mi&gt;I&lt;/mi&gt;&lt;/mtd&gt;&lt;/mtr&gt;&lt;mtr&gt;&lt;mtd&gt;&lt;mi&gt;Q&lt;/mi&gt;&lt;/mtd&gt;&lt;/mtr&gt;&lt;mtr&gt;&lt;mtd&gt;&lt;mi&gt;U&lt;/mi&gt;&lt;/mtd&gt;&lt;/mtr&gt;&lt;mtr&gt;&lt;mtd&gt;&lt;mi&gt;V&lt;/mi&gt;&lt;/mtd&gt;&lt;/mtr&gt;&lt;/mtable&gt;&lt;mo&gt;)&lt;/mo&gt;&lt;/mrow&gt;&lt;/math&gt;\" role=\"presentation\"><span class=\"MJX_Assistive_MathML MJX_Assistive_MathML_Block\" role=\"presentation\"><math xmlns=\"http://www.w3.org/1998/Math/MathML\" display=\"block\"><mrow class=\"MJX-TeXAtom-ORD\"><mover><mi>S</mi><mo stretchy=\"false\">→</mo></mover></mrow><mo>=</mo><mrow><mo>(</mo><mtable rowspacing=\"4pt\" columnspacing=\"1em\"><mtr><mtd><mi>I</mi></mtd></mtr><mtr><mtd><mi>Q</mi></mtd></mtr><mtr><mtd><mi>U</mi></mtd></mtr><mtr><mtd><mi>V</mi></mtd></mtr></mtable><mo>)</mo></mrow></math></span></span></span><script type=\"math/tex; mode=display\" id=\"MathJax-Element-1\">\\vec{S} = \\begin{pmatrix} I\\\\Q\\\\U\\\\V\\end{pmatrix}</script></p><p>bar</p>", "foo\n\n$$\n\\vec{S} = \\begin{pmatrix} I\\\\Q\\\\U\\\\V\\end{pmatrix}\n$$\n\nbar\n"},
 	{"251", "<div>如果发现不一致，请按照<strong>旧设备（能正常同步的那台）</strong>&ZeroWidthSpace; 的设置，在新设备上修改为相同的配置，然后重新尝试同步。</div>", "如果发现不一致，请按照**旧设备（能正常同步的那台）** 的设置，在新设备上修改为相同的配置，然后重新尝试同步。\n"},
 	{"250", "<em><sup>foo</sup></em> <sup><em>bar</em></sup>", "*^foo^* ^*bar*^\n"},
@@ -0,0 +1,100 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+)
+
+var headingIDSluggerTests = map[string][]parseTest{
+	"github": {
+		{"2", "## -- a  -  b --\n", "<h2 id=\"---a-----b---\">-- a  -  b --</h2>\n"},
+		{"1", "# C++ & Go_lang 中文\n\n### Release {#My_ID}\n\n## 123\n", "<h1 id=\"c--go_lang-中文\">C++ &amp; Go_lang 中文</h1>\n<h3 id=\"My_ID\">Release</h3>\n<h2 id=\"123\">123</h2>\n"},
+		{"0", "# Hello, World!\n\n## Hello, World!\n\n## Hello, World!\n", "<h1 id=\"hello-world\">Hello, World!</h1>\n<h2 id=\"hello-world-1\">Hello, World!</h2>\n<h2 id=\"hello-world-2\">Hello, World!</h2>\n"},
+	},
+	"gitlab": {
+		{"2", "## -- a  -  b --\n", "<h2 id=\"-a-b-\">-- a  -  b --</h2>\n"},
+		{"1", "# C++ & Go_lang 中文\n\n### Release {#My_ID}\n\n## 123\n", "<h1 id=\"c-go_lang-中文\">C++ &amp; Go_lang 中文</h1>\n<h3 id=\"My_ID\">Release</h3>\n<h2 id=\"anchor-123\">123</h2>\n"},
+		{"0", "# Hello, World!\n\n## Hello, World!\n\n## Hello, World!\n", "<h1 id=\"hello-world\">Hello, World!</h1>\n<h2 id=\"hello-world-1\">Hello, World!</h2>\n<h2 id=\"hello-world-2\">Hello, World!</h2>\n"},
+	},
+	"hugo": {
+		{"2", "## ***\n\n## !!!\n", "<h2 id=\"heading\">***</h2>\n<h2 id=\"heading-1\">!!!</h2>\n"},
+		{"1", "# C++ & Go_lang 中文\n\n### Release {#My_ID}\n\n## 123\n", "<h1 id=\"c--go_lang-中文\">C++ &amp; Go_lang 中文</h1>\n<h3 id=\"My_ID\">Release</h3>\n<h2 id=\"123\">123</h2>\n"},
+		{"0", "# Hello, World!\n\n## Hello, World!\n\n## Hello, World!\n", "<h1 id=\"hello-world\">Hello, World!</h1>\n<h2 id=\"hello-world-1\">Hello, World!</h2>\n<h2 id=\"hello-world-2\">Hello, World!</h2>\n"},
+	},
+	"pandoc": {
+		{"2", "## 1. Intro v1.0\n\n## 123\n\n## 456\n", "<h2 id=\"intro-v1.0\">1. Intro v1.0</h2>\n<h2 id=\"section\">123</h2>\n<h2 id=\"section-1\">456</h2>\n"},
+		{"1", "# C++ & Go_lang 中文\n\n### Release {#My_ID}\n", "<h1 id=\"c-go_lang-中文\">C++ &amp; Go_lang 中文</h1>\n<h3 id=\"My_ID\">Release</h3>\n"},
+		{"0", "# Hello, World!\n\n## Hello, World!\n\n## Hello, World!\n", "<h1 id=\"hello-world\">Hello, World!</h1>\n<h2 id=\"hello-world-1\">Hello, World!</h2>\n<h2 id=\"hello-world-2\">Hello, World!</h2>\n"},
+	},
+	"lute": {
+		{"0", "# Hello, World!\n\n## Hello, World!\n\n### Release {#My_ID}\n", "<h1 id=\"Hello--World-\">Hello, World!</h1>\n<h2 id=\"Hello--World--\">Hello, World!</h2>\n<h3 id=\"My-ID\">Release</h3>\n"},
+	},
+}
+
+func TestHeadingIDSlugger(t *testing.T) {
+	for name, tests := range headingIDSluggerTests {
+		luteEngine := lute.New()
+		luteEngine.SetHeadingID(true)
+		luteEngine.SetHeadingIDSluggerName(name)
+		for _, test := range tests {
+			html := luteEngine.MarkdownStr(test.name, test.from)
+			if test.to != html {
+				t.Fatalf("test case [%s %s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", name, test.name, test.to, html, test.from)
+			}
+		}
+	}
+}
+
+var headingIDSluggerToCTests = []parseTest{
+
+	{"0", "[toc]\n\n# Foo Bar\n\n## Foo Bar\n", "<div class=\"vditor-toc\" data-block=\"0\" data-type=\"toc-block\" contenteditable=\"false\"><ul><li><span data-target-id=\"foo-bar\">Foo Bar</span><ul><li><span data-target-id=\"foo-bar-1\">Foo Bar</span></li></ul></li></ul></div>\n<h1 id=\"foo-bar\">Foo Bar<a id=\"vditorAnchor-foo-bar\" class=\"vditor-anchor\" href=\"#foo-bar\"><svg viewBox=\"0 0 16 16\" version=\"1.1\" width=\"16\" height=\"16\"><path fill-rule=\"evenodd\" d=\"M4 9h1v1H4c-1.5 0-3-1.69-3-3.5S2.55 3 4 3h4c1.45 0 3 1.69 3 3.5 0 1.41-.91 2.72-2 3.25V8.59c.58-.45 1-1.27 1-2.09C10 5.22 8.98 4 8 4H4c-.98 0-2 1.22-2 2.5S3 9 4 9zm9-3h-1v1h1c1 0 2 1.22 2 2.5S13.98 12 13 12H9c-.98 0-2-1.22-2-2.5 0-.83.42-1.64 1-2.09V6.25c-1.09.53-2 1.84-2 3.25C6 11.31 7.55 13 9 13h4c1.45 0 3-1.69 3-3.5S14.5 6 13 6z\"></path></svg></a></h1>\n<h2 id=\"foo-bar-1\">Foo Bar<a id=\"vditorAnchor-foo-bar-1\" class=\"vditor-anchor\" href=\"#foo-bar-1\"><svg viewBox=\"0 0 16 16\" version=\"1.1\" width=\"16\" height=\"16\"><path fill-rule=\"evenodd\" d=\"M4 9h1v1H4c-1.5 0-3-1.69-3-3.5S2.55 3 4 3h4c1.45 0 3 1.69 3 3.5 0 1.41-.91 2.72-2 3.25V8.59c.58-.45 1-1.27 1-2.09C10 5.22 8.98 4 8 4H4c-.98 0-2 1.22-2 2.5S3 9 4 9zm9-3h-1v1h1c1 0 2 1.22 2 2.5S13.98 12 13 12H9c-.98 0-2-1.22-2-2.5 0-.83.42-1.64 1-2.09V6.25c-1.09.53-2 1.84-2 3.25C6 11.31 7.55 13 9 13h4c1.45 0 3-1.69 3-3.5S14.5 6 13 6z\"></path></svg></a></h2>\n"},
+}
+
+func TestHeadingIDSluggerToC(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetHeadingID(true)
+	luteEngine.SetToC(true)
+	luteEngine.SetHeadingAnchor(true)
+	luteEngine.SetHeadingIDSluggerName("GitHub")
+	for _, test := range headingIDSluggerToCTests {
+		html := luteEngine.MarkdownStr(test.name, test.from)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+}
+
+type upperSlugger struct{}
+
+func (upperSlugger) Slug(text string) string {
+	return strings.ToUpper(strings.ReplaceAll(text, " ", "_"))
+}
+
+func (upperSlugger) Unique(id string, used map[string]bool) string {
+	for ; used[id]; id += "_" {
+	}
+	return id
+}
+
+func TestHeadingIDSluggerCustom(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetHeadingID(true)
+	luteEngine.SetHeadingIDSlugger(upperSlugger{})
+	html := luteEngine.MarkdownStr("", "# foo bar\n\n## foo bar\n")
+	expected := "<h1 id=\"FOO_BAR\">foo bar</h1>\n<h2 id=\"FOO_BAR_\">foo bar</h2>\n"
+	if expected != html {
+		t.Fatalf("custom slugger failed\nexpected\n\t%q\ngot\n\t%q", expected, html)
+	}
+}
@@ -0,0 +1,70 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+)
+
+var idGeneratorTests = []parseTest{
+
+	{"1", "> foo\n\n* bar\n", "<div data-node-id=\"20240101000000-0000003\" data-node-index=\"1\" data-type=\"NodeBlockquote\" class=\"bq\" updated=\"20240101000000\"><div data-node-id=\"20240101000000-0000004\" data-type=\"NodeParagraph\" class=\"p\" updated=\"20240101000000\"><div contenteditable=\"true\" spellcheck=\"false\">foo</div><div class=\"protyle-attr\" contenteditable=\"false\">\u200b</div></div><div class=\"protyle-attr\" contenteditable=\"false\">\u200b</div></div><div data-subtype=\"u\" data-node-id=\"20240101000000-0000005\" data-node-index=\"2\" data-type=\"NodeList\" class=\"list\" updated=\"20240101000000\"><div data-marker=\"*\" data-subtype=\"u\" data-node-id=\"20240101000000-0000001\" data-type=\"NodeListItem\" class=\"li\" updated=\"20240101000000\"><div class=\"protyle-action\" draggable=\"true\"><svg><use xlink:href=\"#iconDot\"></use></svg></div><div data-node-id=\"20240101000000-0000006\" data-type=\"NodeParagraph\" class=\"p\" updated=\"20240101000000\"><div contenteditable=\"true\" spellcheck=\"false\">bar</div><div class=\"protyle-attr\" contenteditable=\"false\">\u200b</div></div><div class=\"protyle-attr\" contenteditable=\"false\">\u200b</div></div><div class=\"protyle-attr\" contenteditable=\"false\">\u200b</div></div>"},
+	{"0", "foo\n\nbar\n", "<div data-node-id=\"20240101000000-0000002\" data-node-index=\"1\" data-type=\"NodeParagraph\" class=\"p\" updated=\"20240101000000\"><div contenteditable=\"true\" spellcheck=\"false\">foo</div><div class=\"protyle-attr\" contenteditable=\"false\">\u200b</div></div><div data-node-id=\"20240101000000-0000003\" data-node-index=\"2\" data-type=\"NodeParagraph\" class=\"p\" updated=\"20240101000000\"><div contenteditable=\"true\" spellcheck=\"false\">bar</div><div class=\"protyle-attr\" contenteditable=\"false\">\u200b</div></div>"},
+}
+
+func TestIDGenerator(t *testing.T) {
+	for _, test := range idGeneratorTests {
+		luteEngine := lute.New()
+		luteEngine.SetProtyleWYSIWYG(true)
+		luteEngine.SetKramdownIAL(true)
+		seq := 0
+		luteEngine.SetIDGenerator(ast.IDGeneratorFunc(func(node *ast.Node) string {
+			seq++
+			id := strconv.Itoa(seq)
+			return "20240101000000-" + "0000000"[len(id):] + id
+		}))
+
+		html := luteEngine.Md2BlockDOM(test.from, false)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+}
+
+func TestSeededIDGenerator(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	md := "# foo\n\nbar\n\n* baz\n"
+	var outputs []string
+	for i := 0; i < 2; i++ {
+		luteEngine := lute.New()
+		luteEngine.SetProtyleWYSIWYG(true)
+		luteEngine.SetKramdownIAL(true)
+		luteEngine.SetIDGenerator(ast.NewSeededIDGenerator(42, created))
+		outputs = append(outputs, luteEngine.Md2BlockDOM(md, false))
+	}
+	if outputs[0] != outputs[1] {
+		t.Fatalf("seeded id generator is not deterministic\n\t%q\n\t%q", outputs[0], outputs[1])
+	}
+
+	generator := ast.NewSeededIDGenerator(42, created)
+	id := generator.NewNodeID(nil)
+	if !ast.IsNodeIDPattern(id) || "20240101000000" != id[:14] {
+		t.Fatalf("unexpected seeded id [%s]", id)
+	}
+	if id == generator.NewNodeID(nil) {
+		t.Fatalf("seeded id generator generated duplicated id [%s]", id)
+	}
+}
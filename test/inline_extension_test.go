@@ -0,0 +1,180 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/html"
+	"github.com/88250/lute/lex"
+	"github.com/88250/lute/parse"
+)
+
+const (
+	nodeWikiLink  = ast.NodeExtInline
+	nodeMention   = ast.NodeExtInline + 1
+	nodeShortcode = ast.NodeExtInline + 2
+)
+
+var inlineExtensionTests = []parseTest{
+
+	{"6", "{#foo} {{< x", "<p>{#foo} {{&lt; x</p>\n"},
+	{"5", "# foo {#bar}\n", "<h1 id=\"bar\">foo</h1>\n"},
+	{"4", "a {{< youtube id=\"1\" >}} b\n", "<p>a <shortcode name=\"youtube id=&quot;1&quot;\"></shortcode> b</p>\n"},
+	{"3", "foo@bar.com @ @bob!\n", "<p><a href=\"mailto:foo@bar.com\">foo@bar.com</a> @ <a class=\"mention\" href=\"/u/bob\">@bob</a>!</p>\n"},
+	{"2", "*see @bob*\n", "<p><em>see <a class=\"mention\" href=\"/u/bob\">@bob</a></em></p>\n"},
+	{"1", "[[Page\n[link](/url) [[Page]\n", "<p>[[Page<br />\n<a href=\"/url\">link</a> [[Page]</p>\n"},
+	{"0", "foo [[Page]] **[[Other Page]]**\n", "<p>foo <a class=\"wiki\" href=\"Page\">Page</a> <strong><a class=\"wiki\" href=\"Other Page\">Other Page</a></strong></p>\n"},
+}
+
+func TestInlineExtension(t *testing.T) {
+	luteEngine := newInlineExtensionLute(t)
+	luteEngine.SetHeadingID(true)
+	for _, test := range inlineExtensionTests {
+		html := luteEngine.MarkdownStr(test.name, test.from)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+}
+
+func TestInlineExtensionFormat(t *testing.T) {
+	luteEngine := newInlineExtensionLute(t)
+	luteEngine.FormatRendererFuncs[nodeWikiLink] = func(n *ast.Node, entering bool) (string, ast.WalkStatus) {
+		if !entering {
+			return "", ast.WalkContinue
+		}
+		return "[[" + string(n.Tokens) + "]]", ast.WalkSkipChildren
+	}
+	luteEngine.FormatRendererFuncs[nodeMention] = func(n *ast.Node, entering bool) (string, ast.WalkStatus) {
+		if !entering {
+			return "", ast.WalkContinue
+		}
+		return "@" + string(n.Tokens), ast.WalkSkipChildren
+	}
+
+	formatted := luteEngine.FormatStr("", "- **see** [[Page]] and @bob\n")
+	if "- **see** [[Page]] and @bob\n" != formatted {
+		t.Fatalf("unexpected format result %q", formatted)
+	}
+}
+
+func TestInlineExtensionSourcePos(t *testing.T) {
+	luteEngine := newInlineExtensionLute(t)
+	luteEngine.SetSourcePos(true)
+	tree := parse.Parse("", []byte("foo [[Page]]\n"), luteEngine.ParseOptions)
+	wikiLink := tree.Root.FirstChild.LastChild
+	if nodeWikiLink != wikiLink.Type || "1:5-1:13" != wikiLink.Position.String() {
+		t.Fatalf("unexpected wiki link node [%s %s]", wikiLink.Type, wikiLink.Position)
+	}
+}
+
+func TestRegisterInlineExtension(t *testing.T) {
+	luteEngine := lute.New()
+	if err := luteEngine.RegisterInlineExtension(&parse.InlineExtension{Trigger: '@'}); nil == err {
+		t.Fatalf("extension without parse function should not be registered")
+	}
+	if err := luteEngine.RegisterInlineExtension(&parse.InlineExtension{Trigger: '\n', Parse: parseMention}); nil == err {
+		t.Fatalf("newline should not be used as trigger")
+	}
+
+	low := &parse.InlineExtension{Trigger: '@', Parse: parseMention}
+	high := &parse.InlineExtension{Trigger: '@', Parse: parseMention, Priority: 2}
+	mid := &parse.InlineExtension{Trigger: '@', Parse: parseMention, Priority: 1}
+	for _, ext := range []*parse.InlineExtension{low, high, mid} {
+		if err := luteEngine.RegisterInlineExtension(ext); nil != err {
+			t.Fatalf("register extension failed: %s", err)
+		}
+	}
+	exts := luteEngine.ParseOptions.InlineExtensions
+	if 3 != len(exts) || high != exts[0] || mid != exts[1] || low != exts[2] {
+		t.Fatalf("inline extensions should be sorted by priority")
+	}
+}
+
+func newInlineExtensionLute(t *testing.T) *lute.Lute {
+	ret := lute.New()
+	exts := []*parse.InlineExtension{
+		{Trigger: lex.ItemOpenBracket, Parse: parseWikiLink, Priority: 1},
+		{Trigger: '@', Parse: parseMention},
+		{Trigger: lex.ItemOpenBrace, Parse: parseShortcode, Priority: 1},
+	}
+	for _, ext := range exts {
+		if err := ret.RegisterInlineExtension(ext); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	ret.Md2HTMLRendererFuncs[nodeWikiLink] = func(n *ast.Node, entering bool) (string, ast.WalkStatus) {
+		if !entering {
+			return "", ast.WalkContinue
+		}
+		page := string(html.EscapeHTML(n.Tokens))
+		return "<a class=\"wiki\" href=\"" + page + "\">" + page + "</a>", ast.WalkSkipChildren
+	}
+	ret.Md2HTMLRendererFuncs[nodeMention] = func(n *ast.Node, entering bool) (string, ast.WalkStatus) {
+		if !entering {
+			return "", ast.WalkContinue
+		}
+		return "<a class=\"mention\" href=\"/u/" + string(n.Tokens) + "\">@" + string(n.Tokens) + "</a>", ast.WalkSkipChildren
+	}
+	ret.Md2HTMLRendererFuncs[nodeShortcode] = func(n *ast.Node, entering bool) (string, ast.WalkStatus) {
+		if !entering {
+			return "", ast.WalkContinue
+		}
+		return "<shortcode name=\"" + string(html.EscapeHTML(n.Tokens)) + "\"></shortcode>", ast.WalkSkipChildren
+	}
+	return ret
+}
+
+func parseWikiLink(t *parse.Tree, block *ast.Node, ctx *parse.InlineContext) *ast.Node {
+	remains := ctx.Remains()
+	if !bytes.HasPrefix(remains, []byte("[[")) {
+		return nil
+	}
+	end := bytes.Index(remains, []byte("]]"))
+	if 2 >= end || 0 <= bytes.IndexByte(remains[:end], lex.ItemNewline) {
+		return nil
+	}
+	ctx.Advance(end + 2)
+	return &ast.Node{Type: nodeWikiLink, Tokens: remains[2:end]}
+}
+
+func parseMention(t *parse.Tree, block *ast.Node, ctx *parse.InlineContext) *ast.Node {
+	if pos := ctx.Pos(); 0 < pos && !lex.IsWhitespace(ctx.Tokens()[pos-1]) && !lex.IsASCIIPunct(ctx.Tokens()[pos-1]) {
+		return nil // 排除邮件地址
+	}
+	remains := ctx.Remains()
+	i := 1
+	for ; i < len(remains) && (lex.IsASCIILetterNum(remains[i]) || '_' == remains[i]); i++ {
+	}
+	if 1 == i {
+		return nil
+	}
+	ctx.Advance(i)
+	return &ast.Node{Type: nodeMention, Tokens: remains[1:i]}
+}
+
+func parseShortcode(t *parse.Tree, block *ast.Node, ctx *parse.InlineContext) *ast.Node {
+	remains := ctx.Remains()
+	if !bytes.HasPrefix(remains, []byte("{{<")) {
+		return nil
+	}
+	end := bytes.Index(remains, []byte(">}}"))
+	if 0 > end {
+		return nil
+	}
+	ctx.Advance(end + 3)
+	return &ast.Node{Type: nodeShortcode, Tokens: bytes.TrimSpace(remains[3:end])}
+}
@@ -0,0 +1,50 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"testing"
+
+	"github.com/88250/lute"
+)
+
+var jiraTests = []parseTest{
+
+	{"13", "![a *b* `c`](x.png)\n\nfoo ![a *b* c](https://b3log.org/x.png) bar\n", "!x.png|alt=a b c!\n\nfoo !https://b3log.org/x.png|alt=a b c! bar\n"},
+	{"12", "---\ntitle: foo\n---\n\n# Title\n", "{code:yaml}\ntitle: foo\n{code}\n\nh1. Title\n"},
+	{"11", "```go\n{code}{noformat}\n```\n\n$$\n{noformat}\n$$\n", "{code:go}\n{\u200bcode}{noformat}\n{code}\n\n{code:none}\n{noformat}\n{code}\n"},
+	{"10", "```\na {noformat} b\n```\n\n```go\n{code:java}\n{code}\n```\n", "{code:none}\na {noformat} b\n{code}\n\n{noformat}\n{code:java}\n{code}\n{noformat}\n"},
+	{"9", "# Intro {#intro}\n\n## 中文\n\nsee [intro](#intro) and [b3log](https://b3log.org) <https://ld246.com>\n", "h1. {anchor:intro}Intro\n\nh2. 中文\n\nsee [intro|#intro] and [b3log|https://b3log.org] [https://ld246.com]\n"},
+	{"8", "**foo**bar *baz* `a_b` $E=mc^2$ 2 * 3 a_b foo_ {x} [y] a|b ~~del~~\n", "{*}foo{*}bar _baz_ {{a_b}} {{E=mc^2}} 2 * 3 a_b foo\\_ \\{x\\} \\[y\\] a\\|b -del-\n"},
+	{"7", "line one  \nline two\nthree\n", "line one\nline two\nthree\n"},
+	{"6", "> quote\n>\n> > nested\n\n- a\n- b\n\n1. x\n   1. y\n      - z\n", "{quote}\nquote\nnested\n{quote}\n\n* a\n* b\n\n# x\n## y\n##* z\n"},
+	{"5", "> [!WARNING]\n> be careful\n\n> [!NOTE] Custom\n> hi\n", "{note:title=Warning}\nbe careful\n{note}\n\n{info:title=Custom}\nhi\n{info}\n"},
+	{"4", "- [x] done\n- [ ] todo\n\n  para\n  - [ ] sub\n", "* (/) done\n* (x) todo \\\\ para\n** (x) sub\n"},
+	{"3", "| a | b |\n|:-:|--:|\n| 1 | x\\|y |\n| | **z** |\n", "||a||b||\n|1|x\\|y|\n| |*z*|\n"},
+	{"2", "```go\nfoo\n```\n\n```\nplain\n```\n\n$$\nx^2\n$$\n\n<div>html</div>\n", "{code:go}\nfoo\n{code}\n\n{noformat}\nplain\n{noformat}\n\n{noformat}\nx^2\n{noformat}\n\n{code:html}\n<div>html</div>\n{code}\n"},
+	{"1", "foo[^1] bar[^2]\n\n[^1]: *note*\n[^2]: two\n", "foo{^}1{^} bar{^}2{^}\n\n----\n{^}1{^} _note_\n{^}2{^} two\n"},
+	{"0", "![Figure](img/foo.png)\n\nfoo ![a](https://b3log.org/a.png) bar\n\n---\n\nend\n", "!img/foo.png|alt=Figure!\n\nfoo !https://b3log.org/a.png|alt=a! bar\n\n----\n\nend\n"},
+}
+
+func TestJira(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetCallout(true)
+
+	for _, test := range jiraTests {
+		jira, err := luteEngine.Jira(test.name, []byte(test.from))
+		if nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		if test.to != string(jira) {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, string(jira), test.from)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/render"
+)
+
+var latexTests = []parseTest{
+
+	{"11", "![a *b* `c`](x.png)\n\nfoo ![a *b* c](https://b3log.org/x.png) bar\n", "\\begin{figure}[htbp]\n\\centering\n\\includegraphics[width=\\linewidth,keepaspectratio]{x.png}\n\\caption{a b c}\n\\end{figure}\n\nfoo \\href{https://b3log.org/x.png}{a b c} bar\n"},
+	{"10", "foo[^1] bar[^1]\n\n[^1]: *note*\n\n    two\n", "foo\\footnote{\\label{fn:1}\\emph{note}\n\ntwo} bar\\footref{fn:1}\n"},
+	{"9", "> [!WARNING]\n> be careful\n", "\\begin{tcolorbox}[colback=orange!5!white,colframe=orange!75!black,title={WARNING}]\nbe careful\n\\end{tcolorbox}\n"},
+	{"8", "| a | b |\n|:-:|--:|\n| 1 | 2 |\n", "\\begin{center}\n\\begin{tabular}{|c|r|}\n\\hline\n\\textbf{a} & \\textbf{b} \\\\\n\\hline\n1 & 2 \\\\\n\\hline\n\\end{tabular}\n\\end{center}\n"},
+	{"7", "```go\nfoo\n```\n\n```python\nbar\n```\n", "\\begin{lstlisting}\nfoo\n\\end{lstlisting}\n\n\\begin{lstlisting}[language=Python]\nbar\n\\end{lstlisting}\n"},
+	{"6", "$$\nx^2\n$$\n\n$$\n\\begin{align}\na &= b\n\\end{align}\n$$\n", "\\[\nx^2\n\\]\n\n\\begin{align}\na &= b\n\\end{align}\n"},
+	{"5", "![Figure](img/foo%20bar.png)\n\nfoo ![a](https://b3log.org/a.png)\n", "\\begin{figure}[htbp]\n\\centering\n\\includegraphics[width=\\linewidth,keepaspectratio]{img/foo bar.png}\n\\caption{Figure}\n\\end{figure}\n\nfoo \\href{https://b3log.org/a.png}{a}\n"},
+	{"4", "3. foo\n4. bar\n   - [x] baz\n", "\\begin{enumerate}\n\\setcounter{enumi}{2}\n\\item foo\n\\item bar\n\\begin{itemize}\n\\item[$\\boxtimes$] baz\n\\end{itemize}\n\\end{enumerate}\n"},
+	{"3", "# Intro {#intro}\n\nsee [intro](#intro) and [b3log](https://b3log.org/?a=50%25#top) <https://ld246.com>\n", "\\section{Intro}\\label{intro}\n\nsee \\hyperref[intro]{intro} and \\href{https://b3log.org/?a=50\\%25\\#top}{b3log} \\url{https://ld246.com}\n"},
+	{"2", "**foo** *bar* ~~baz~~ `a_b` $E=mc^2$\n", "\\textbf{foo} \\emph{bar} \\sout{baz} \\texttt{a\\_b} \\(E=mc^2\\)\n"},
+	{"1", "> 50% & #1 $5 a_b ~ ^ \\\\ {}\n", "\\begin{quote}\n50\\% \\& \\#1 \\$5 a\\_b \\textasciitilde{} \\textasciicircum{} \\textbackslash{} \\{\\}\n\\end{quote}\n"},
+	{"0", "# foo\n\n## bar\n\n###### baz\n", "\\section{foo}\\label{foo}\n\n\\subsection{bar}\\label{bar}\n\n\\subparagraph{baz}\\label{baz}\n"},
+}
+
+func TestLaTeX(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetCallout(true)
+	latexOptions := render.NewLaTeXOptions()
+	latexOptions.Template = ""
+
+	for _, test := range latexTests {
+		latex, err := luteEngine.LaTeX(test.name, []byte(test.from), latexOptions)
+		if nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		if test.to != string(latex) {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, latex, test.from)
+		}
+	}
+}
+
+func TestLaTeXTemplate(t *testing.T) {
+	luteEngine := lute.New()
+	latexOptions := render.NewLaTeXOptions()
+	latexOptions.Minted = true
+	latexOptions.LongTable = true
+
+	latex, err := luteEngine.LaTeX("", []byte("~~foo~~ [bar](https://b3log.org)\n\n```go\nbaz\n```\n\n| a |\n|---|\n| 1 |\n"), latexOptions)
+	if nil != err {
+		t.Fatalf("render latex failed: %s", err)
+	}
+	expected := "\\documentclass{article}\n\\usepackage[utf8]{inputenc}\n\\usepackage[T1]{fontenc}\n" +
+		"\\usepackage[normalem]{ulem}\n\\usepackage{longtable}\n\\usepackage{minted}\n\\usepackage{hyperref}\n\\begin{document}\n\n" +
+		"\\sout{foo} \\href{https://b3log.org}{bar}\n\n\\begin{minted}{go}\nbaz\n\\end{minted}\n\n" +
+		"\\begin{longtable}{|l|}\n\\hline\n\\textbf{a} \\\\\n\\hline\n\\endhead\n1 \\\\\n\\hline\n\\end{longtable}\n\n\\end{document}\n"
+	if expected != string(latex) {
+		t.Fatalf("expected\n\t%q\ngot\n\t%q", expected, latex)
+	}
+
+	latexOptions.Template = "$packages$\n---\n$body$"
+	latex, _ = luteEngine.LaTeX("", []byte("$x$\n"), latexOptions)
+	if !strings.HasPrefix(string(latex), "\\usepackage{amsmath}\n---\n\\(x\\)") {
+		t.Fatalf("unexpected latex\n%s", latex)
+	}
+}
@@ -0,0 +1,94 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/lint"
+	"github.com/88250/lute/parse"
+)
+
+var lintTests = []parseTest{
+
+	{"6", "```go\ncode  \n", "1:1-2:7 error LT001/no-unclosed-code-fence code fence \"```\" is not closed\n"},
+	{"5", "[ok][y] [a][x] and [^1]\n\n[^2]: unused\n\n[y]: https://y\n[z]: https://z\n", "1:8-1:24 error MD052/reference-links-images link reference label \"x\" is not defined\n1:8-1:24 error MD052/reference-links-images footnote label \"1\" is not defined\n3:1-3:13 warning MD053/link-image-reference-definitions footnote definition \"2\" is unused\n6:1-6:15 warning MD053/link-image-reference-definitions link reference definition \"z\" is unused\n"},
+	{"4", "[empty]() [hash](#) ![](a.png) ![alt](b.png)\n", "1:1-1:10 warning MD042/no-empty-links link destination is empty\n1:11-1:20 warning MD042/no-empty-links link destination is empty\n1:21-1:31 warning MD045/no-alt-text image has no alternate text\n"},
+	{"3", "see https://b3log.org and <https://ld246.com>   \nfoo  \nbar\n", "1:5-1:22 info MD034/no-bare-urls bare URL \"https://b3log.org\" should be a link\n1:46-1:49 info MD009/no-trailing-spaces 3 trailing whitespace characters\n"},
+	{"2", "* a\n* b\n\n- c\n", "4:1-4:4 warning MD004/ul-style unordered list marker should be \"*\" but got \"-\"\n"},
+	{"1", "# Title\n\n### Skip\n\n## Title\n", "3:1-3:9 warning MD001/heading-increment heading level should be 2 but got 3\n5:1-5:9 warning MD024/no-duplicate-heading-id duplicate heading id \"Title\"\n"},
+	{"0", "# Title\n\n## Foo\n\n* [link](https://b3log.org)\n", ""},
+}
+
+func TestLint(t *testing.T) {
+	luteEngine := lute.New()
+	for _, test := range lintTests {
+		buf := &strings.Builder{}
+		for _, diagnostic := range luteEngine.Lint(test.name, []byte(test.from)) {
+			buf.WriteString(diagnostic.String() + "\n")
+		}
+		if result := buf.String(); test.to != result {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, result, test.from)
+		}
+	}
+}
+
+var lintFixTests = []parseTest{
+
+	{"5", "```go\ncode\n", "```go\ncode\n```\n"},
+	{"4", "[ok][y]\n\n[^2]: unused\n\n[y]: https://y\n[z]: https://z\n", "[ok][y]\n\n[y]: https://y\n"},
+	{"3", "[empty]() [hash](#) ![alt](b.png)\n", "empty hash ![alt](b.png)\n"},
+	{"2", "see https://b3log.org   \nfoo  \nbar\n", "see [https://b3log.org](https://b3log.org)\nfoo\nbar\n"},
+	{"1", "* a\n* b\n\n- c\n\n+ d\n", "* a\n* b\n* c\n* d\n"},
+	{"0", "# A\n\n### B\n\n#### C\n\n# D\n", "# A\n\n## B\n\n### C\n\n# D\n"},
+}
+
+func TestLintFix(t *testing.T) {
+	luteEngine := lute.New()
+	for _, test := range lintFixTests {
+		fixed, _ := luteEngine.LintFix(test.name, []byte(test.from))
+		if result := string(fixed); test.to != result {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, result, test.from)
+		}
+	}
+}
+
+func TestLintFixTightList(t *testing.T) {
+	luteEngine := lute.New()
+	for _, from := range []string{"* a\n- b\n", "* a\n* b\n\n- c\n\n+ d\n", "- a\n\n1. x\n\n* b\n"} {
+		fixed, _ := luteEngine.LintFix("", []byte(from))
+		// 统一列表标记符后列表项仍然是紧凑的，除了列表合并外 HTML 应该保持不变
+		before, after := listItemsHTML(luteEngine.MarkdownStr("", from)), listItemsHTML(luteEngine.MarkdownStr("", string(fixed)))
+		if before != after {
+			t.Fatalf("fix [%q] changed html\nexpected\n\t%q\ngot\n\t%q", from, before, after)
+		}
+	}
+}
+
+func listItemsHTML(html string) string {
+	return strings.NewReplacer("<ul>\n", "", "</ul>\n", "").Replace(html)
+}
+
+func TestLintWithoutSourcePos(t *testing.T) {
+	luteEngine := lute.New()
+	tree := parse.Parse("", []byte("# A\n\n### B\n\n[x]()  \n"), luteEngine.ParseOptions)
+	diagnostics := lint.New().Lint(tree, nil)
+	if 2 != len(diagnostics) {
+		t.Fatalf("expected 2 diagnostics but got %d", len(diagnostics))
+	}
+	for _, diagnostic := range diagnostics {
+		if nil != diagnostic.Position || nil == diagnostic.Node {
+			t.Fatalf("unexpected diagnostic [%s]", diagnostic)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/render"
+)
+
+var mdastTests = []parseTest{
+
+	{"6", "![[[foo](uri1)](uri2)](uri3)\n", `{"children":[{"children":[{"alt":"[foo](uri2)","title":null,"type":"image","url":"uri3"}],"type":"paragraph"}],"type":"root"}`},
+	{"5", "![a [b](c) d][r]\n\n[r]: /u\n", `{"children":[{"children":[{"alt":"a b d","title":null,"type":"image","url":"/u"}],"type":"paragraph"},{"identifier":"r","label":"r","title":null,"type":"definition","url":"/u"}],"type":"root"}`},
+	{"4", "# Intro {#intro}\n\n*a* **b** ~~c~~ `d` $e$\nnext  \nbreak\n", `{"children":[{"children":[{"type":"text","value":"Intro"}],"data":{"hProperties":{"id":"intro"}},"depth":1,"type":"heading"},{"children":[{"children":[{"type":"text","value":"a"}],"type":"emphasis"},{"type":"text","value":" "},{"children":[{"type":"text","value":"b"}],"type":"strong"},{"type":"text","value":" "},{"children":[{"type":"text","value":"c"}],"type":"delete"},{"type":"text","value":" "},{"type":"inlineCode","value":"d"},{"type":"text","value":" "},{"type":"inlineMath","value":"e"},{"type":"text","value":"\nnext"},{"type":"break"},{"type":"text","value":"break"}],"type":"paragraph"}],"type":"root"}`},
+	{"3", "- [x] done\n- [ ] todo\n", `{"children":[{"children":[{"checked":true,"children":[{"children":[{"type":"text","value":"done"}],"type":"paragraph"}],"spread":false,"type":"listItem"},{"checked":false,"children":[{"children":[{"type":"text","value":"todo"}],"type":"paragraph"}],"spread":false,"type":"listItem"}],"ordered":false,"spread":false,"start":null,"type":"list"}],"type":"root"}`},
+	{"2", "[m][n] ![i](j \"k\")\n\n[n]: /url \"T\"\n", `{"children":[{"children":[{"children":[{"type":"text","value":"m"}],"identifier":"n","label":"n","referenceType":"full","type":"linkReference"},{"type":"text","value":" "},{"alt":"i","title":"k","type":"image","url":"j"}],"type":"paragraph"},{"identifier":"n","label":"n","title":"T","type":"definition","url":"/url"}],"type":"root"}`},
+	{"1", "| a | b |\n|:-|-:|\n| 1 | 2 |\n\n```go\ncode\n```\n\n$$\nx\n$$\n", `{"children":[{"align":["left","right"],"children":[{"children":[{"children":[{"type":"text","value":"a"}],"type":"tableCell"},{"children":[{"type":"text","value":"b"}],"type":"tableCell"}],"type":"tableRow"},{"children":[{"children":[{"type":"text","value":"1"}],"type":"tableCell"},{"children":[{"type":"text","value":"2"}],"type":"tableCell"}],"type":"tableRow"}],"type":"table"},{"lang":"go","meta":null,"type":"code","value":"code"},{"meta":null,"type":"math","value":"x"}],"type":"root"}`},
+	{"0", "foo[^1]\n\n[^1]: note\n", `{"children":[{"children":[{"type":"text","value":"foo"},{"identifier":"1","label":"1","type":"footnoteReference"}],"type":"paragraph"},{"children":[{"children":[{"type":"text","value":"note"}],"type":"paragraph"}],"identifier":"1","label":"1","type":"footnoteDefinition"}],"type":"root"}`},
+}
+
+func TestMdast(t *testing.T) {
+	luteEngine := lute.New()
+
+	for _, test := range mdastTests {
+		mdast, err := luteEngine.Mdast(test.name, []byte(test.from))
+		if nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		if test.to != string(mdast) {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%s\ngot\n\t%s\noriginal markdown text\n\t%q", test.name, test.to, string(mdast), test.from)
+		}
+	}
+}
+
+var mdastRoundTripTests = []parseTest{
+
+	{"6", "foo ==m== ~sub~ ^sup^ *em*{: style=\"x\"}\n{: id=\"20200101000000-aaaaaaa\" custom-x=\"y\"}\n\n# h {#hid}\n{: id=\"20200101000000-bbbbbbb\"}\n", ""},
+	{"5", "3. {: id=\"20200101000000-aaaaaaa\"}a\n   {: id=\"20200101000000-bbbbbbb\"}\n4. {: id=\"20200101000000-ccccccc\"}b\n5. {: id=\"20200101000000-ddddddd\"}c\n{: id=\"20200101000000-eeeeeee\"}\n", ""},
+	{"4", "| a | b |\n| - | - |\n| 1 | 2 \\| x `c\\|d` |\n", ""},
+
+	{"3", "---\ntitle: x\n---\n\n# Intro {#intro}\n\n*a* **b** ~~c~~ `d` $e$ [f *g*](h \"t\") ![i](j \"k\") [m][n] x[^1]\n\n[n]: /url\n\n1. [X] a\n\n* c\n\n  d\n\n| a | b |\n| :- | -: |\n| 1 | 2 |\n\n---\n\n> q\n\n[^1]: foot\n    more\n", ""},
+	{"2", "{{{row\nfoo ((20200101000000-abcdefg \"ref\")) ==m== #tag#\n{: id=\"20200101000000-aaaaaaa\"}\n\nbar^sup^\n{: id=\"20200101000000-bbbbbbb\"}\n\n}}}\n{: id=\"20200101000000-ccccccc\"}\n\n\n{: id=\"20200101000000-ddddddd\" title=\"doc\" type=\"doc\"}\n", ""},
+	{"1", "> [!NOTE]\n> callout\n", ""},
+	{"0", "```go\ncode\n```\n\n$$\nx\n$$\n\n<div>\nh\n</div>\n", ""},
+}
+
+func TestMdastRoundTrip(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetCallout(true)
+	luteEngine.SetKramdownIAL(true)
+	luteEngine.SetSuperBlock(true)
+	luteEngine.SetBlockRef(true)
+	luteEngine.SetTag(true)
+	luteEngine.SetMark(true)
+	luteEngine.SetSup(true)
+	luteEngine.SetSub(true)
+
+	for _, test := range mdastRoundTripTests {
+		expected := luteEngine.FormatStr(test.name, test.from)
+		mdast, err := luteEngine.Mdast(test.name, []byte(expected))
+		if nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		tree, err := luteEngine.Mdast2Tree(test.name, mdast)
+		if nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		md, err := render.RenderE(render.NewFormatRenderer(tree, luteEngine.RenderOptions, luteEngine.ParseOptions))
+		if nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		if expected != string(md) {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\nmdast\n\t%s", test.name, expected, string(md), mdast)
+		}
+	}
+}
+
+func TestMdastIAL(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetKramdownIAL(true)
+	luteEngine.SetMark(true)
+
+	source := "1. {: id=\"20200101000000-aaaaaaa\"}a ==m==\n2. {: id=\"20200101000000-bbbbbbb\"}b\n{: id=\"20200101000000-ccccccc\" custom-x=\"y\"}\n"
+	mdast, err := luteEngine.Mdast("", []byte(source))
+	if nil != err {
+		t.Fatalf("render mdast failed: %s", err)
+	}
+
+	var root map[string]interface{}
+	if err = json.Unmarshal(mdast, &root); nil != err {
+		t.Fatalf("unmarshal mdast failed: %s", err)
+	}
+	var walk func(node map[string]interface{})
+	walk = func(node map[string]interface{}) {
+		typ := node["type"].(string)
+		if strings.HasSuffix(typ, "Marker") || strings.HasPrefix(typ, "lute:kramdown") {
+			t.Fatalf("unexpected mdast node [%s]\n\t%s", typ, mdast)
+		}
+		children, _ := node["children"].([]interface{})
+		for _, child := range children {
+			c := child.(map[string]interface{})
+			if "list" == typ && "listItem" != c["type"] {
+				t.Fatalf("unexpected list child [%s]\n\t%s", c["type"], mdast)
+			}
+			walk(c)
+		}
+	}
+	walk(root)
+
+	list := root["children"].([]interface{})[0].(map[string]interface{})
+	properties := list["data"].(map[string]interface{})["hProperties"].(map[string]interface{})
+	if "20200101000000-ccccccc" != properties["id"] || "y" != properties["custom-x"] {
+		t.Fatalf("unexpected list properties %v", properties)
+	}
+
+	tree, err := luteEngine.Mdast2Tree("", mdast)
+	if nil != err {
+		t.Fatalf("parse mdast failed: %s", err)
+	}
+	if list := tree.Root.FirstChild; "20200101000000-ccccccc" != list.ID || "20200101000000-bbbbbbb" != list.FirstChild.Next.Next.ID || 2 != list.FirstChild.Next.Next.ListData.Num {
+		t.Fatalf("unexpected list [%s] item [%s] num [%d]", list.ID, list.FirstChild.Next.Next.ID, list.FirstChild.Next.Next.ListData.Num)
+	}
+}
+
+func TestMdast2Tree(t *testing.T) {
+	luteEngine := lute.New()
+
+	// remark 输出的语法树，包含位置信息和链接引用
+	mdast := `{"type":"root","children":[{"type":"paragraph","children":[{"type":"linkReference","identifier":"b3log","label":"B3log","referenceType":"collapsed","children":[{"type":"text","value":"B3log","position":{"start":{"line":1,"column":2,"offset":1},"end":{"line":1,"column":7,"offset":6}}}]},{"type":"text","value":" and\n"},{"type":"emphasis","children":[{"type":"text","value":"foo"}]},{"type":"html","value":"<br>"}],"position":{"start":{"line":1,"column":1,"offset":0},"end":{"line":2,"column":6,"offset":20}}},{"type":"definition","identifier":"b3log","label":"B3log","url":"https://b3log.org","title":null},{"type":"containerDirective","name":"note","children":[{"type":"paragraph","children":[{"type":"text","value":"unknown"}]}]}]}`
+	tree, err := luteEngine.Mdast2Tree("", []byte(mdast))
+	if nil != err {
+		t.Fatalf("parse mdast failed: %s", err)
+	}
+	if p := tree.Root.FirstChild.Position; nil == p || "1:1-2:6" != p.String() {
+		t.Fatalf("unexpected paragraph position [%s]", p)
+	}
+
+	html := string(render.NewHtmlRenderer(tree, luteEngine.RenderOptions, luteEngine.ParseOptions).Render())
+	expected := "<p><a href=\"https://b3log.org\">B3log</a> and<br />\n<em>foo</em><br></p>\n<p>unknown</p>\n"
+	if expected != html {
+		t.Fatalf("mdast to html failed\nexpected\n\t%q\ngot\n\t%q", expected, html)
+	}
+
+	// 链接引用的子节点保留行级结构
+	source := "[link *foo* ![m](x.png)][ref]\n\n[ref]: /url\n"
+	refMdast, _ := luteEngine.Mdast("", []byte(source))
+	if tree, err = luteEngine.Mdast2Tree("", refMdast); nil != err {
+		t.Fatalf("parse mdast failed: %s", err)
+	}
+	html = string(render.NewHtmlRenderer(tree, luteEngine.RenderOptions, luteEngine.ParseOptions).Render())
+	if expected = luteEngine.MarkdownStr("", source); expected != html {
+		t.Fatalf("mdast to html failed\nexpected\n\t%q\ngot\n\t%q", expected, html)
+	}
+
+	if _, err = luteEngine.Mdast2Tree("", []byte(`{"type":"paragraph"}`)); nil == err {
+		t.Fatalf("parse non-root mdast should fail")
+	}
+	if _, err = luteEngine.Mdast2Tree("", []byte(`{"type":`)); nil == err {
+		t.Fatalf("parse invalid mdast should fail")
+	}
+}
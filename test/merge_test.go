@@ -0,0 +1,86 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/merge"
+	"github.com/88250/lute/parse"
+)
+
+type mergeTest struct {
+	name      string
+	base      string
+	ours      string
+	theirs    string
+	expected  string
+	conflicts int
+}
+
+var mergeTests = []mergeTest{
+
+	{"9", "# title\n\nfoo\n\nbar\n\nbaz\n", "# title\n\nbar\n\nbaz\n", "# title\n\nbar\n\nbaz\n\nfoo\n", "# title\n\nbar\n\nbaz\n\n<<<<<<< ours\n||||||| base\nfoo\n=======\nfoo\n>>>>>>> theirs\n", 1},
+	{"8", "# title\n\nfoo\n\nbar\n\nbaz\n", "# title\n\nbar\n\nbaz\n\nfoo\n", "# title\n\nbar\n\nbaz\n", "# title\n\nbar\n\nbaz\n\n<<<<<<< ours\nfoo\n||||||| base\nfoo\n=======\n>>>>>>> theirs\n", 1},
+	{"7", "foo\n\nbar\n", "foo\n\nbar\n", "foo\n\nbar\n", "foo\n\nbar\n", 0},
+	{"6", "# title\n\nfoo\n\nbar\n\nbaz\n", "# title\n\nbar\n\nbaz\n\nfoo\n", "# title\n\nfoo\n\nbar\n\nbaz 2\n", "# title\n\nbar\n\nbaz 2\n\nfoo\n", 0},
+	{"5", "# title\n\nfoo\n\nbar\n\nbaz\n", "# title\n\nfoo\n\nbar\n\nbaz\n\nnew1\n", "new0\n\n# title\n\nfoo\n\nbar\n\nbaz\n", "new0\n\n# title\n\nfoo\n\nbar\n\nbaz\n\nnew1\n", 0},
+	{"4", "# title\n\nfoo\n\nbar\n\nbaz\n", "# title\n\nfoo\n\nbar\n\nbaz\n\nnew1\n", "# title\n\nfoo\n\nbar\n\nbaz\n\nnew2\n", "# title\n\nfoo\n\nbar\n\nbaz\n\n<<<<<<< ours\nnew1\n||||||| base\n=======\nnew2\n>>>>>>> theirs\n", 1},
+	{"3", "# title\n\nfoo\n\nbar\n\nbaz\n", "# title\n\nbar\n\nbaz\n", "# title\n\nfoo x\n\nbar\n\nbaz\n", "# title\n\n<<<<<<< ours\n||||||| base\nfoo\n=======\nfoo x\n>>>>>>> theirs\n\nbar\n\nbaz\n", 1},
+	{"2", "# title\n\nfoo\n\nbar\n\nbaz\n", "# title\n\nfoo ours\n\nbar\n\nbaz\n", "# title\n\nfoo theirs\n\nbar\n\nbaz\n", "# title\n\n<<<<<<< ours\nfoo ours\n||||||| base\nfoo\n=======\nfoo theirs\n>>>>>>> theirs\n\nbar\n\nbaz\n", 1},
+	{"1", "# title\n\nfoo\n\nbar\n\nbaz\n", "# title\n\nfoo same\n\nbar\n\nbaz\n", "# title\n\nfoo same\n\nbar\n\nbaz\n", "# title\n\nfoo same\n\nbar\n\nbaz\n", 0},
+	{"0", "# title\n\nfoo\n\nbar\n\nbaz\n", "# title\n\nfoo changed\n\nbar\n\nbaz\n", "# title\n\nfoo\n\nbar\n\nbaz changed\n", "# title\n\nfoo changed\n\nbar\n\nbaz changed\n", 0},
+}
+
+func TestMerge(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetGitConflict(true)
+
+	for _, test := range mergeTests {
+		merged, conflicts := luteEngine.Merge([]byte(test.base), []byte(test.ours), []byte(test.theirs))
+		if test.expected != string(merged) || test.conflicts != conflicts {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q (%d conflicts)\ngot\n\t%q (%d conflicts)", test.name, test.expected, test.conflicts, merged, conflicts)
+		}
+
+		// 合并结果中的冲突标记可以被重新解析为冲突节点
+		tree := parse.Parse("", merged, luteEngine.ParseOptions)
+		if n := len(tree.Root.ChildrenByType(ast.NodeGitConflict)); conflicts != n {
+			t.Fatalf("test case [%s] failed: expected %d conflict nodes, got %d", test.name, conflicts, n)
+		}
+	}
+}
+
+func TestMergeID(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetKramdownIAL(true)
+	luteEngine.SetKramdownBlockIAL(true)
+
+	base := parse.Parse("", []byte("foo\n{: id=\"1\"}\n\nbar\n{: id=\"2\"}\n\nbaz\n{: id=\"3\"}\n"), luteEngine.ParseOptions)
+	ours := parse.Parse("", []byte("baz\n{: id=\"3\"}\n\nfoo\n{: id=\"1\"}\n\nbar ours\n{: id=\"2\"}\n"), luteEngine.ParseOptions)
+	theirs := parse.Parse("", []byte("qux\n{: id=\"1\"}\n\nbar\n{: id=\"2\"}\n\nbaz\n{: id=\"3\"}\n"), luteEngine.ParseOptions)
+	tree, conflicts := merge.Merge(base, ours, theirs)
+	if 0 != conflicts {
+		t.Fatalf("unexpected conflicts %d", conflicts)
+	}
+
+	var blocks []string
+	for n := tree.Root.FirstChild; nil != n; n = n.Next {
+		if ast.NodeParagraph == n.Type {
+			blocks = append(blocks, n.ID+":"+n.Text())
+		}
+	}
+	if s := strings.Join(blocks, " "); "3:baz 1:qux 2:bar ours" != s {
+		t.Fatalf("unexpected merged blocks [%s]", s)
+	}
+}
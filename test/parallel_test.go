@@ -10,41 +10,122 @@
 
 package test
 
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/parse"
+)
+
+// TestParallel 在多个 goroutine 中共用一个引擎，需要通过 go test -race 运行才能检测出数据竞争。
+func TestParallel(t *testing.T) {
+	data0, err := os.ReadFile("../test/commonmark-spec.md")
+	if nil != err {
+		t.Fatalf("read test text failed: " + err.Error())
+	}
+
+	data1, err := os.ReadFile("../test/case1.md")
+	if nil != err {
+		t.Fatalf("read test text failed: " + err.Error())
+	}
+
+	luteEngine := lute.New()
+	luteEngine.PutEmojis(map[string]string{"parallel": "🚀"})
+	luteEngine.PutTerms(map[string]string{"parallel": "Parallel"})
+	expected0 := string(luteEngine.Markdown("", data0))
+	expected1 := string(luteEngine.Markdown("", data1))
+	expectedFormat := string(luteEngine.Format("", data1))
+	blockDOM := luteEngine.Md2BlockDOM(":parallel: **foo**\n", false)
+	expectedStdMd := luteEngine.BlockDOM2StdMd(blockDOM)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if html := string(luteEngine.Markdown("", data0)); !sameHTML(expected0, html) {
+				t.Errorf("unexpected html of commonmark-spec.md")
+			}
+		}()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if html := string(luteEngine.Markdown("", data1)); !sameHTML(expected1, html) {
+				t.Errorf("unexpected html of case1.md")
+			}
+			if md := string(luteEngine.Format("", data1)); expectedFormat != md {
+				t.Errorf("unexpected format result of case1.md")
+			}
+			buf := &bytes.Buffer{}
+			if err := luteEngine.MarkdownTo(buf, bytes.NewReader(data1)); nil != err || 1 > buf.Len() {
+				t.Errorf("markdown to failed: %v", err)
+			}
+		}()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if spin := luteEngine.SpinBlockDOM(blockDOM); !strings.Contains(spin, "🚀") {
+				t.Errorf("unexpected spin result %s", spin)
+			}
+			if md := luteEngine.BlockDOM2StdMd(blockDOM); expectedStdMd != md {
+				t.Errorf("unexpected std markdown %s", md)
+			}
+			if md, err := luteEngine.HTML2Markdown("<p><strong>foo</strong> bar</p>"); nil != err || "**foo** bar\n" != md {
+				t.Errorf("unexpected markdown %q: %v", md, err)
+			}
+			if emojis := luteEngine.GetEmojis(); "🚀" != emojis["parallel"] {
+				t.Errorf("emoji should be put")
+			}
+			if s := luteEngine.RemoveEmoji("foo 🚀"); "foo" != s {
+				t.Errorf("unexpected %q", s)
+			}
+		}()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tree := parse.Parse("", data1, luteEngine.ParseOptions)
+			if md, err := lute.FormatNodeSync(tree.Root, luteEngine.ParseOptions, luteEngine.RenderOptions); nil != err || "" == md {
+				t.Errorf("format node failed: %v", err)
+			}
+			if md, err := lute.ProtyleExportMdNodeSync(tree.Root, luteEngine.ParseOptions, luteEngine.RenderOptions); nil != err || "" == md {
+				t.Errorf("export node failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if emojis := lute.New().GetEmojis(); "" != emojis["parallel"] {
+		t.Fatalf("emojis put to an engine should not affect other engines")
+	}
+	if terms := lute.New().GetTerms(); "" != terms["parallel"] {
+		t.Fatalf("terms put to an engine should not affect other engines")
+	}
+}
+
+var (
+	highlightedCode = regexp.MustCompile(`(?s)(<code class="[^"]*highlight-chroma">)(.*?)(</code>)`)
+	htmlTag         = regexp.MustCompile("<[^>]*>")
+)
+
+// sameHTML 判断 HTML actual 是否和 expected 一致。
 //
-//import (
-//	"os"
-//	"sync"
-//	"testing"
-//
-//	"github.com/88250/lute"
-//)
-//
-//func TestParallel(t *testing.T) {
-//	data0, err := os.ReadFile("../test/commonmark-spec.md")
-//	if nil != err {
-//		t.Fatalf("read test text failed: " + err.Error())
-//	}
-//
-//	data1, err := os.ReadFile("../test/case1.md")
-//	if nil != err {
-//		t.Fatalf("read test text failed: " + err.Error())
-//	}
-//
-//	wg := sync.WaitGroup{}
-//	for i := 0; i < 50; i++ {
-//		wg.Add(1)
-//		go func() {
-//			defer wg.Done()
-//			luteEngine := lute.New()
-//			luteEngine.Markdown("", data0)
-//		}()
-//
-//		wg.Add(1)
-//		go func() {
-//			defer wg.Done()
-//			luteEngine := lute.New()
-//			luteEngine.Markdown("", data1)
-//		}()
-//	}
-//	wg.Wait()
-//}
+// chroma 的每次正则匹配有 250ms 超时，超时的规则会被跳过，剩余的字符输出为错误记号，因此开启 -race 后执行较慢时高亮的代码块中
+// 记号的划分可能不同。这里仅允许高亮代码块 <code> 中的 <span> 不同，代码文本和代码块以外的内容都需要完全一致。
+func sameHTML(expected, actual string) bool {
+	return expected == actual || withoutHighlightSpans(expected) == withoutHighlightSpans(actual)
+}
+
+func withoutHighlightSpans(s string) string {
+	return highlightedCode.ReplaceAllStringFunc(s, func(code string) string {
+		m := highlightedCode.FindStringSubmatch(code)
+		return m[1] + htmlTag.ReplaceAllString(m[2], "") + m[3]
+	})
+}
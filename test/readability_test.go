@@ -0,0 +1,51 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"testing"
+
+	"github.com/88250/lute"
+)
+
+var readabilityTests = []parseTest{
+
+	{"2", `<!DOCTYPE html><html><head><title>How Lute Parses Markdown - B3log Blog</title><meta property="og:title" content="How Lute Parses Markdown"><meta name="author" content="Liang Ding"><meta property="article:published_time" content="2024-05-01T08:00:00Z"><style>body{color:red}</style><script>var a = 1;</script></head><body>
+<header class="site-header"><a href="/">Home</a> <a href="/blog">Blog</a></header>
+<nav><ul><li><a href="/a">A</a></li><li><a href="/b">B</a></li></ul></nav>
+<div id="cookie-banner">We use cookies, please accept them to continue.</div>
+<main><article class="post">
+<h1>How Lute Parses Markdown</h1>
+<p class="byline">By Liang Ding</p>
+<p>Lute is a structured Markdown engine, it parses Markdown into an abstract syntax tree, then renders the tree into HTML, Markdown or other formats.</p>
+<p>The parser works in two phases, block parsing and inline parsing, following the CommonMark specification closely, with extensions for GFM and more.</p>
+<div class="share-buttons"><a href="https://twitter.com">Share on Twitter</a> <a href="https://facebook.com">Share on Facebook</a></div>
+<pre><code class="language-go">lute.New()</code></pre>
+<p>Finally, the renderers walk the tree, producing output for each node type, and users can override renderer functions for customization.</p>
+</article>
+<aside class="sidebar"><h3>Related posts</h3><ul><li><a href="/x">X post</a></li></ul></aside>
+</main>
+<footer><p>Copyright 2024, B3log. All rights reserved, more text here and there.</p></footer>
+</body></html>`, "---\ntitle: \"How Lute Parses Markdown\"\nauthor: \"Liang Ding\"\ndate: \"2024-05-01T08:00:00Z\"\n---\n# How Lute Parses Markdown\n\nLute is a structured Markdown engine, it parses Markdown into an abstract syntax tree, then renders the tree into HTML, Markdown or other formats.\n\nThe parser works in two phases, block parsing and inline parsing, following the CommonMark specification closely, with extensions for GFM and more.\n\n```go\nlute.New()\n```\n\nFinally, the renderers walk the tree, producing output for each node type, and users can override renderer functions for customization.\n"},
+	{"1", `<html><head><title>"Quoted" Title | Site</title></head><body><div class="menu"><a href="/">Home</a></div><div class="content"><h1>"Quoted" Title</h1><p><span rel="author">Daniel</span> <time datetime="2023-01-02">Jan 2</time></p><div><p>第一段正文，内容足够长，用于测试中文网页的正文提取，这里有逗号，还有更多的逗号，以及更多内容。</p><p>第二段正文，同样需要足够长的内容，这样才能让候选元素获得足够的分数，从而被选为正文。</p></div></div><div class="comments"><p>Nice post, thanks for sharing this with us!</p></div></body></html>`, "---\ntitle: \"\\\"Quoted\\\" Title\"\nauthor: \"Daniel\"\ndate: \"2023-01-02\"\n---\n# \"Quoted\" Title\n\nJan 2\n\n第一段正文，内容足够长，用于测试中文网页的正文提取，这里有逗号，还有更多的逗号，以及更多内容。\n\n第二段正文，同样需要足够长的内容，这样才能让候选元素获得足够的分数，从而被选为正文。\n"},
+	{"0", `<p>short</p>`, "short\n"},
+}
+
+func TestReadability(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetHTML2MarkdownReadability(true)
+	for _, test := range readabilityTests {
+		md := luteEngine.HTML2Md(test.from)
+		if test.to != md {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal html\n\t%q", test.name, test.to, md, test.from)
+		}
+	}
+}
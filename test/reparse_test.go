@@ -0,0 +1,282 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/render"
+)
+
+type reparseTest struct {
+	name     string
+	from     string
+	edit     *parse.Edit
+	reused   string // 编辑后应该被复用的节点的文本内容
+	expected string
+}
+
+var reparseTests = []reparseTest{
+
+	{"16", "n\n\n[9]:/3\no", &parse.Edit{Start: 7, End: 9, Text: []byte("\n\n")}, "", "n\n\n[9]:\n\n\no"},
+	{"15", "b\n|\n-|", &parse.Edit{Start: 4, End: 5, Text: []byte("1. ")}, "", "b\n|\n1. |"},
+	{"14", "|\n|\n-|", &parse.Edit{Start: 4, End: 5, Text: []byte("<div>\n")}, "", "|\n|\n<div>\n|"},
+	{"13", "foo\n\n---\n\nbar\n", &parse.Edit{Start: 10, End: 13, Text: []byte("baz")}, "foo", "foo\n\n---\n\nbaz\n"},
+
+	{"12", "[foo]: /url\n\nbar\n", &parse.Edit{Start: 13, End: 13, Text: []byte("[baz]: /baz\n\n")}, "", "[foo]: /url\n\n[baz]: /baz\n\nbar\n"},
+	{"11", "bar\n\nbaz\n\n[foo]: /url\n", &parse.Edit{Start: 0, End: 3, Text: []byte("[foo]")}, "baz", "[foo]\n\nbaz\n\n[foo]: /url\n"},
+	{"10", "foo\r\n\r\nbar\r\n\r\nbaz\r\n", &parse.Edit{Start: 7, End: 10, Text: []byte("b\r\nar")}, "baz", "foo\r\n\r\nb\r\nar\r\n\r\nbaz\r\n"},
+	{"9", "foo\n\nbar\n", &parse.Edit{Start: 9, End: 9, Text: []byte("\nbaz\n")}, "foo", "foo\n\nbar\n\nbaz\n"},
+	{"8", "foo\n\nbar\n", &parse.Edit{Start: 0, End: 0, Text: []byte("# baz\n\n")}, "bar", "# baz\n\nfoo\n\nbar\n"},
+	{"7", "- foo\n\n  bar\n\nbaz\n\nqux\n", &parse.Edit{Start: 14, End: 14, Text: []byte("  ")}, "qux", "- foo\n\n  bar\n\n  baz\n\nqux\n"},
+	{"6", "foo\n\nbar\n\nbaz\n", &parse.Edit{Start: 9, End: 9, Text: []byte("---\n")}, "baz", "foo\n\nbar\n---\n\nbaz\n"},
+	{"5", "foo\n\nbar\n\nbaz\n", &parse.Edit{Start: 3, End: 4}, "baz", "foo\nbar\n\nbaz\n"},
+	{"4", "foo\n\nbar\n\nbaz\n\nqux\n", &parse.Edit{Start: 5, End: 5, Text: []byte("```\n")}, "", "foo\n\n```\nbar\n\nbaz\n\nqux\n"},
+	{"3", "foo\n\n```\nbar\n\nbaz\n```\n\nqux\n", &parse.Edit{Start: 5, End: 9}, "", "foo\n\nbar\n\nbaz\n```\n\nqux\n"},
+	{"2", "# foo\n\n> bar\n> baz\n\nqux *quux*\n", &parse.Edit{Start: 15, End: 18, Text: []byte("**bazz**")}, "qux ", "# foo\n\n> bar\n> **bazz**\n\nqux *quux*\n"},
+	{"1", "foo\n\nbar\n\nbaz\n", &parse.Edit{Start: 14, End: 14, Text: []byte("qux\n")}, "foo", "foo\n\nbar\n\nbaz\nqux\n"},
+	{"0", "foo\n\nbar baz\n\nqux\n", &parse.Edit{Start: 8, End: 8, Text: []byte(" [link](/url)")}, "qux", "foo\n\nbar [link](/url) baz\n\nqux\n"},
+}
+
+func TestReparse(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetSourcePos(true)
+	luteEngine.SetCodeSyntaxHighlight(false)
+
+	for _, test := range reparseTests {
+		source := []byte(test.from)
+		tree := parse.Parse("", append([]byte{}, source...), luteEngine.ParseOptions)
+		setBlockIDs(tree)
+		reused := findText(tree.Root, test.reused)
+
+		newSource, err := tree.Reparse(source, test.edit)
+		if nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		if test.expected != string(newSource) {
+			t.Fatalf("test case [%s] failed\nexpected source\n\t%q\ngot\n\t%q", test.name, test.expected, newSource)
+		}
+
+		expected := parse.Parse("", []byte(test.expected), luteEngine.ParseOptions)
+		if e, g := reparseDump(expected.Root), reparseDump(tree.Root); e != g {
+			t.Fatalf("test case [%s] failed\nexpected\n%s\ngot\n%s", test.name, e, g)
+		}
+		if e, g := renderHTML(expected), renderHTML(tree); e != g {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q", test.name, e, g)
+		}
+		if nil != reused && reused != findText(tree.Root, test.reused) {
+			t.Fatalf("test case [%s] failed: node [%s] should be reused", test.name, test.reused)
+		}
+	}
+}
+
+func TestReparseEveryOffset(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetSourcePos(true)
+	luteEngine.SetCodeSyntaxHighlight(false)
+	sources := []string{
+		"# foo\n\nbar\nbaz\n===\n\n> qux\nquux\n\n- a\n\n  b\n- c\n\n```\ncode\n\n```\n\n| a | b |\n| - | - |\n| 1 | 2 |\n\n[link] *em*\n\n    indented\n\n[link]: /url\n",
+		"foo\r\n\r\n1. bar\r\n   baz\r\n\r\n<div>\r\nqux\r\n\r\n***\r\n> `code`\r\n",
+	}
+	for _, source := range sources {
+		var edits []*parse.Edit
+		for i := 0; i < len(source); i++ {
+			edits = append(edits, &parse.Edit{Start: i, End: i + 1}, &parse.Edit{Start: i, End: i, Text: []byte("\n")}, &parse.Edit{Start: i, End: i, Text: []byte("  ")}, &parse.Edit{Start: i, End: i, Text: []byte("```\n")})
+		}
+
+		for _, edit := range edits {
+			tree := parse.Parse("", []byte(source), luteEngine.ParseOptions)
+			newSource, err := tree.Reparse([]byte(source), edit)
+			if nil != err {
+				t.Fatal(err)
+			}
+			expected := parse.Parse("", append([]byte{}, newSource...), luteEngine.ParseOptions)
+			if e, g := reparseDump(expected.Root), reparseDump(tree.Root); e != g {
+				t.Fatalf("edit %+v on %q failed\nexpected\n%s\ngot\n%s", edit, source, e, g)
+			}
+			if e, g := renderHTML(expected), renderHTML(tree); e != g {
+				t.Fatalf("edit %+v on %q failed\nexpected\n\t%q\ngot\n\t%q", edit, source, e, g)
+			}
+		}
+	}
+}
+
+func TestReparseRandom(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetSourcePos(true)
+	luteEngine.SetCodeSyntaxHighlight(false)
+	blocks := []string{"foo", "bar baz", "---", "===", "# qux", "| a | b |", "| - | - |", "-|", "|", "[foo]: /url", "[foo] [bar]", "[bar]: /bar \"t\"",
+		"> quote", "- item", "1. item", "  lazy", "```", "<div>", "    code", "$$", "title: x", "***"}
+	texts := []string{"", "\n", "\n\n", "|", "-|", "---", "===", "[foo]: /u", "[foo]", "1. ", "> ", "<div>\n", "```\n", "  ", "x", "\r\n"}
+
+	random := rand.New(rand.NewSource(0))
+	for i := 0; i < 3000; i++ {
+		buf := strings.Builder{}
+		for j := random.Intn(8); 0 <= j; j-- {
+			buf.WriteString(blocks[random.Intn(len(blocks))])
+			buf.WriteString([]string{"\n", "\n\n"}[random.Intn(2)])
+		}
+		source := buf.String()
+		start := random.Intn(len(source) + 1)
+		end := start + random.Intn(len(source)-start+1)%4
+		edit := &parse.Edit{Start: start, End: end, Text: []byte(texts[random.Intn(len(texts))])}
+
+		tree := parse.Parse("", []byte(source), luteEngine.ParseOptions)
+		newSource, err := tree.Reparse([]byte(source), edit)
+		if nil != err {
+			t.Fatal(err)
+		}
+		expected := parse.Parse("", append([]byte{}, newSource...), luteEngine.ParseOptions)
+		if e, g := renderHTML(expected), renderHTML(tree); e != g {
+			t.Fatalf("edit %+v on %q failed\nexpected\n\t%q\ngot\n\t%q", edit, source, e, g)
+		}
+		if e, g := reparseDump(expected.Root), reparseDump(tree.Root); e != g {
+			t.Fatalf("edit %+v on %q failed\nexpected\n%s\ngot\n%s", edit, source, e, g)
+		}
+	}
+}
+
+func TestReparseKramdownBlockIAL(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetSourcePos(true)
+	luteEngine.SetKramdownBlockIAL(true)
+	luteEngine.SetCodeSyntaxHighlight(false)
+	luteEngine.SetIDGenerator(ast.IDGeneratorFunc(func(*ast.Node) string { return "20200101000000-aaaaaaa" }))
+	source := "# foo\n{: id=\"20200101000000-0000001\"}\n\nbar\n{: id=\"20200101000000-0000002\"}\n\n- baz\n  {: id=\"20200101000000-0000003\"}\n{: id=\"20200101000000-0000004\"}\n\nqux\n"
+
+	var edits []*parse.Edit
+	for i := 0; i < len(source); i++ {
+		edits = append(edits, &parse.Edit{Start: i, End: i + 1}, &parse.Edit{Start: i, End: i, Text: []byte("\n")}, &parse.Edit{Start: i, End: i, Text: []byte("x")})
+	}
+	for _, edit := range edits {
+		tree := parse.Parse("", []byte(source), luteEngine.ParseOptions)
+		newSource, err := tree.Reparse([]byte(source), edit)
+		if nil != err {
+			t.Fatal(err)
+		}
+		expected := parse.Parse("", append([]byte{}, newSource...), luteEngine.ParseOptions)
+		// 编辑破坏 IAL 后渲染出的属性顺序不固定，所以这里不比较 HTML
+		if e, g := ialDump(expected.Root), ialDump(tree.Root); e != g {
+			t.Fatalf("edit %+v on %q failed\nexpected\n%s\ngot\n%s", edit, source, e, g)
+		}
+	}
+
+	// 增量解析时块级 IAL 中的 ID 保持不变，未受影响的块被复用
+	tree := parse.Parse("", []byte(source), luteEngine.ParseOptions)
+	reused := findText(tree.Root, "qux")
+	if _, err := tree.Reparse([]byte(source), &parse.Edit{Start: 39, End: 39, Text: []byte("new ")}); nil != err {
+		t.Fatal(err)
+	}
+	if ids := blockIDs(tree); "20200101000000-0000001  20200101000000-0000002  20200101000000-0000004  20200101000000-aaaaaaa " != ids {
+		t.Fatalf("unexpected ids [%s]", ids)
+	}
+	if html := renderHTML(tree); !strings.Contains(html, "<p id=\"20200101000000-0000002\">new bar</p>") {
+		t.Fatalf("unexpected html [%s]", html)
+	}
+	if reused != findText(tree.Root, "qux") {
+		t.Fatalf("node [qux] should be reused")
+	}
+}
+
+func TestReparseID(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetSourcePos(true)
+	source := []byte("foo\n\nbar\n\nbaz\n")
+	tree := parse.Parse("", append([]byte{}, source...), luteEngine.ParseOptions)
+	setBlockIDs(tree)
+
+	// 类型和数量不变时重新解析的块沿用原来的 ID
+	source, err := tree.Reparse(source, &parse.Edit{Start: 5, End: 5, Text: []byte("new ")})
+	if nil != err {
+		t.Fatal(err)
+	}
+	if ids := blockIDs(tree); "0 1 2" != ids {
+		t.Fatalf("unexpected ids [%s]", ids)
+	}
+
+	// 新增的块没有 ID
+	if _, err = tree.Reparse(source, &parse.Edit{Start: 13, End: 13, Text: []byte("\n# qux\n")}); nil != err {
+		t.Fatal(err)
+	}
+	if ids := blockIDs(tree); "0   2" != ids {
+		t.Fatalf("unexpected ids [%s]", ids)
+	}
+
+	if _, err = tree.Reparse(source, &parse.Edit{Start: 3, End: 100}); nil == err {
+		t.Fatalf("invalid edit range should be rejected")
+	}
+}
+
+func setBlockIDs(tree *parse.Tree) {
+	i := 0
+	for n := tree.Root.FirstChild; nil != n; n = n.Next {
+		n.ID = strconv.Itoa(i)
+		i++
+	}
+}
+
+func blockIDs(tree *parse.Tree) string {
+	var ids []string
+	for n := tree.Root.FirstChild; nil != n; n = n.Next {
+		ids = append(ids, n.ID)
+	}
+	return strings.Join(ids, " ")
+}
+
+// findText 返回内容为 text 的文本节点。
+func findText(root *ast.Node, text string) (ret *ast.Node) {
+	if "" == text {
+		return
+	}
+	ast.Walk(root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering && ast.NodeText == n.Type && text == string(n.Tokens) {
+			ret = n
+			return ast.WalkStop
+		}
+		return ast.WalkContinue
+	})
+	return
+}
+
+func reparseDump(root *ast.Node) string {
+	buf := &strings.Builder{}
+	ast.Walk(root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.WalkContinue
+		}
+		buf.WriteString(n.Type.String() + " " + n.Position.String())
+		if nil != n.Position {
+			buf.WriteString(" [" + strconv.Itoa(n.Position.StartOffset) + ", " + strconv.Itoa(n.Position.EndOffset) + ")")
+		}
+		buf.WriteString("\n")
+		return ast.WalkContinue
+	})
+	return buf.String()
+}
+
+func renderHTML(tree *parse.Tree) string {
+	return string(render.NewHtmlRenderer(tree, lute.New().RenderOptions, tree.Context.ParseOption).Render())
+}
+
+func ialDump(root *ast.Node) string {
+	buf := &strings.Builder{}
+	buf.WriteString(reparseDump(root))
+	for n := root.FirstChild; nil != n; n = n.Next {
+		buf.WriteString(n.Type.String() + " " + string(n.Tokens) + "\n")
+	}
+	return buf.String()
+}
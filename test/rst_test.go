@@ -0,0 +1,50 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"testing"
+
+	"github.com/88250/lute"
+)
+
+var rstTests = []parseTest{
+
+	{"13", "![a *b* `c`](x.png)\n\nfoo ![a *b* c](https://b3log.org/x.png) bar\n", ".. image:: x.png\n   :alt: a b c\n\nfoo |image1| bar\n\n.. |image1| image:: https://b3log.org/x.png\n   :alt: a b c\n"},
+	{"12", "---\ntitle: foo\nnote: |\n  bar\n---\n\n# Title\n", "..\n   title: foo\n   note: |\n     bar\n\n.. _Title:\n\nTitle\n=====\n"},
+	{"11", "## 表情 ｆｕｌｌ\n\n#### a\\_b &amp; c\n", ".. _表情-ｆｕｌｌ:\n\n表情 ｆｕｌｌ\n-------------\n\n.. _`ab--c`:\n\na\\_b & c\n^^^^^^^^\n"},
+	{"10", "# a `b_c` **粗体** ![x](y.png)\n\nSetext 中文\n---\n", ".. _`a--粗体-x`:\n\na ``b_c`` **粗体** |image1|\n===========================\n\n.. _Setext-中文:\n\nSetext 中文\n-----------\n\n.. |image1| image:: y.png\n   :alt: x\n"},
+	{"9", "# Intro {#intro}\n\n## 中文\n\nsee [intro](#intro) and [b3log](https://b3log.org) <https://ld246.com>\n", ".. _intro:\n\nIntro\n=====\n\n.. _中文:\n\n中文\n----\n\nsee `intro <intro_>`__ and `b3log <https://b3log.org>`__ https://ld246.com\n"},
+	{"8", "**foo**bar *baz* `a_b` $E=mc^2$ 2 * 3 a_b foo_ `x`\n", "**foo**\\ bar *baz* ``a_b`` :math:`E=mc^2` 2 \\* 3 a_b foo\\_ ``x``\n"},
+	{"7", "line one  \nline two\n", "| line one\n| line two\n"},
+	{"6", "> quote\n\n- a\n- b\n\n> after list\n", "    quote\n\n- a\n- b\n\n..\n\n    after list\n"},
+	{"5", "> [!WARNING]\n> be careful\n\n> [!NOTE] Custom\n> hi\n", ".. warning::\n\n   be careful\n\n.. admonition:: Custom\n   :class: note\n\n   hi\n"},
+	{"4", "3. foo\n4. bar\n   - [x] baz\n\n     para\n", "3. foo\n4. bar\n\n   - [x] baz\n\n     para\n"},
+	{"3", "| a | b |\n|:-:|--:|\n| 1 | x\\|y |\n", ".. list-table::\n   :header-rows: 1\n\n   * - a\n     - b\n   * - 1\n     - x\\|y\n"},
+	{"2", "```go\nfoo\n```\n\n$$\nx^2\n$$\n\n<div>html</div>\n", ".. code:: go\n\n   foo\n\n.. math::\n\n   x^2\n\n.. raw:: html\n\n   <div>html</div>\n"},
+	{"1", "foo[^1] bar[^1]\n\n[^1]: *note*\n\n    two\n", "foo\\ [1]_ bar\\ [1]_\n\n.. [1] *note*\n\n   two\n"},
+	{"0", "![Figure](img/foo.png)\n\nfoo ![a](https://b3log.org/a.png) bar\n\n---\n\nend\n", ".. image:: img/foo.png\n   :alt: Figure\n\nfoo |image1| bar\n\n----\n\nend\n\n.. |image1| image:: https://b3log.org/a.png\n   :alt: a\n"},
+}
+
+func TestRST(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetCallout(true)
+
+	for _, test := range rstTests {
+		rst, err := luteEngine.RST(test.name, []byte(test.from))
+		if nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		if test.to != string(rst) {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, rst, test.from)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+)
+
+var sourcePosTests = []parseTest{
+
+	{"10", "# a\r\n\r\n> b\r\n> c\r\n", "NodeDocument 1:1-4:6\nNodeHeading 1:1-1:4\nNodeHeadingC8hMarker 1:1-1:3\nNodeText 1:3-1:4\nNodeBlockquote 3:1-4:4\nNodeBlockquoteMarker 3:1-3:3\nNodeParagraph 3:3-4:4\nNodeText 3:3-3:4\nNodeSoftBreak 3:4-4:1\nNodeText 4:3-4:4\n"},
+	{"9", "> [a\\]b]:\n>   <u v> \"t\"\n> [c]: /d\n", "NodeDocument 1:1-3:11\nNodeBlockquote 1:1-3:10\nNodeBlockquoteMarker 1:1-1:3\nNodeLinkRefDefBlock 1:3-2:14\nNodeLinkRefDef 1:3-2:14\nNodeLink 1:3-2:14\nNodeOpenBracket 1:3-1:4\nNodeLinkText 1:4-1:8\nNodeCloseBracket 1:8-1:9\nNodeOpenParen 2:5-2:5\nNodeLinkDest 2:5-2:10\nNodeLinkTitle 2:12-2:13\nNodeCloseParen 2:14-2:14\nNodeLinkRefDefBlock 3:3-3:10\nNodeLinkRefDef 3:3-3:10\nNodeLink 3:3-3:10\nNodeOpenBracket 3:3-3:4\nNodeLinkText 3:4-3:5\nNodeCloseBracket 3:5-3:6\nNodeOpenParen 3:8-3:8\nNodeLinkDest 3:8-3:10\nNodeCloseParen 3:10-3:10\n"},
+	{"8", "* a\n- b\n\nsee foo\n", "NodeDocument 1:1-4:9\nNodeList 1:1-1:4\nNodeListItem 1:1-1:4\nNodeParagraph 1:3-1:4\nNodeText 1:3-1:4\nNodeList 2:1-2:4\nNodeListItem 2:1-2:4\nNodeParagraph 2:3-2:4\nNodeText 2:3-2:4\nNodeParagraph 4:1-4:8\nNodeText 4:1-4:8\n"},
+	{"7", "- b\n\npara\n", "NodeDocument 1:1-3:6\nNodeList 1:1-1:4\nNodeListItem 1:1-1:4\nNodeParagraph 1:3-1:4\nNodeText 1:3-1:4\nNodeParagraph 3:1-3:5\nNodeText 3:1-3:5\n"},
+	{"6", "> foo\n>\n> - [x] bar\n", "NodeDocument 1:1-3:13\nNodeBlockquote 1:1-3:12\nNodeBlockquoteMarker 1:1-1:3\nNodeParagraph 1:3-1:6\nNodeText 1:3-1:6\nNodeList 3:3-3:12\nNodeListItem 3:3-3:12\nNodeParagraph 3:5-3:12\nNodeTaskListItemMarker 3:5-3:8\nNodeText 3:8-3:12\n"},
+	{"5", "[foo]: /url\n\nSee [foo] and ![img](a.png)\n", "NodeDocument 1:1-3:29\nNodeLinkRefDefBlock 1:1-1:12\nNodeLinkRefDef 1:1-1:12\nNodeLink 1:1-1:12\nNodeOpenBracket 1:1-1:2\nNodeLinkText 1:2-1:5\nNodeCloseBracket 1:5-1:6\nNodeOpenParen 1:8-1:8\nNodeLinkDest 1:8-1:12\nNodeCloseParen 1:12-1:12\nNodeParagraph 3:1-3:28\nNodeText 3:1-3:5\nNodeLink 3:5-3:10\nNodeOpenBracket 3:5-3:6\nNodeLinkText 3:6-3:9\nNodeCloseBracket 3:9-3:10\nNodeOpenParen 3:5-3:10\nNodeLinkDest 3:5-3:10\nNodeCloseParen 3:5-3:10\nNodeText 3:10-3:15\nNodeImage 3:15-3:28\nNodeBang 3:15-3:16\nNodeOpenBracket 3:16-3:17\nNodeLinkText 3:17-3:20\nNodeCloseBracket 3:20-3:21\nNodeOpenParen 3:21-3:22\nNodeLinkDest 3:22-3:27\nNodeCloseParen 3:27-3:28\n"},
+	{"4", "| a | b |\n| --- | --- |\n| 1 | 2 |\n", "NodeDocument 1:1-3:11\nNodeTable 1:1-3:10\nNodeTableHead 1:3-1:8\nNodeTableRow 1:3-1:8\nNodeTableCell 1:3-1:4\nNodeText 1:3-1:4\nNodeTableCell 1:7-1:8\nNodeText 1:7-1:8\nNodeTableRow 3:3-3:8\nNodeTableCell 3:3-3:4\nNodeText 3:3-3:4\nNodeTableCell 3:7-3:8\nNodeText 3:7-3:8\n"},
+	{"3", "foo\r\nbar  \r\n\r\n```go\r\nfmt\r\n```\r\n", "NodeDocument 1:1-6:6\nNodeParagraph 1:1-2:6\nNodeText 1:1-1:4\nNodeSoftBreak 1:4-2:1\nNodeText 2:1-2:4\nNodeCodeBlock 4:1-6:4\nNodeCodeBlockFenceOpenMarker 4:1-4:4\nNodeCodeBlockFenceInfoMarker 4:4-4:6\nNodeCodeBlockCode 5:1-6:1\nNodeCodeBlockFenceCloseMarker 6:1-6:4\n"},
+	{"2", "- a\n- b\n\n  c\n", "NodeDocument 1:1-4:5\nNodeList 1:1-4:4\nNodeListItem 1:1-1:4\nNodeParagraph 1:3-1:4\nNodeText 1:3-1:4\nNodeListItem 2:1-4:4\nNodeParagraph 2:3-2:4\nNodeText 2:3-2:4\nNodeParagraph 4:3-4:4\nNodeText 4:3-4:4\n"},
+	{"1", "foo *bar* **baz** `code` [link](/url \"t\")\n", "NodeDocument 1:1-1:43\nNodeParagraph 1:1-1:42\nNodeText 1:1-1:5\nNodeEmphasis 1:5-1:10\nNodeEmA6kOpenMarker 1:5-1:6\nNodeText 1:6-1:9\nNodeEmA6kCloseMarker 1:9-1:10\nNodeText 1:10-1:11\nNodeStrong 1:11-1:18\nNodeStrongA6kOpenMarker 1:11-1:13\nNodeText 1:13-1:16\nNodeStrongA6kCloseMarker 1:16-1:18\nNodeText 1:18-1:19\nNodeCodeSpan 1:19-1:25\nNodeCodeSpanOpenMarker 1:19-1:20\nNodeCodeSpanContent 1:20-1:24\nNodeCodeSpanCloseMarker 1:24-1:25\nNodeText 1:25-1:26\nNodeLink 1:26-1:42\nNodeOpenBracket 1:26-1:27\nNodeLinkText 1:27-1:31\nNodeCloseBracket 1:31-1:32\nNodeOpenParen 1:32-1:33\nNodeLinkDest 1:33-1:37\nNodeLinkSpace 1:37-1:38\nNodeLinkTitle 1:39-1:40\nNodeCloseParen 1:41-1:42\n"},
+	{"0", "# Hello *world*\n\nfoo\n", "NodeDocument 1:1-3:5\nNodeHeading 1:1-1:16\nNodeHeadingC8hMarker 1:1-1:3\nNodeText 1:3-1:9\nNodeEmphasis 1:9-1:16\nNodeEmA6kOpenMarker 1:9-1:10\nNodeText 1:10-1:15\nNodeEmA6kCloseMarker 1:15-1:16\nNodeParagraph 3:1-3:4\nNodeText 3:1-3:4\n"},
+}
+
+func TestSourcePos(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetSourcePos(true)
+
+	for _, test := range sourcePosTests {
+		tree := parse.Parse("", []byte(test.from), luteEngine.ParseOptions)
+		if out := sourcePosString(tree.Root); test.to != out {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, out, test.from)
+		}
+	}
+}
+
+func TestSourcePosInline(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetSourcePos(true)
+
+	tree := parse.Inline("", []byte("foo\r\n*bar*"), luteEngine.ParseOptions)
+	emphasis := tree.Root.FirstChild.LastChild
+	if ast.NodeEmphasis != emphasis.Type || "2:1-2:6" != emphasis.Position.String() || 5 != emphasis.Position.StartOffset {
+		t.Fatalf("unexpected position [%s] on node [%s]", emphasis.Position, emphasis.Type)
+	}
+}
+
+func TestSourcePosCRLF(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetSourcePos(true)
+
+	input := "# a\r\n\r\n> b\r\n> c\r\n"
+	tree := parse.Parse("", []byte(input), luteEngine.ParseOptions)
+	expected := map[ast.NodeType]string{ast.NodeDocument: input, ast.NodeHeading: "# a", ast.NodeBlockquote: "> b\r\n> c", ast.NodeSoftBreak: "\r\n"}
+	ast.Walk(tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if text, ok := expected[n.Type]; entering && ok {
+			if got := input[n.Position.StartOffset:n.Position.EndOffset]; text != got {
+				t.Fatalf("unexpected source [%q] of node [%s], expected [%q]", got, n.Type, text)
+			}
+		}
+		return ast.WalkContinue
+	})
+}
+
+func TestSourcePosDisabled(t *testing.T) {
+	luteEngine := lute.New()
+	tree := parse.Parse("", []byte("foo *bar*\n"), luteEngine.ParseOptions)
+	ast.Walk(tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if entering && nil != n.Position {
+			t.Fatalf("unexpected position [%s] on node [%s]", n.Position, n.Type)
+		}
+		return ast.WalkContinue
+	})
+}
+
+func sourcePosString(root *ast.Node) string {
+	buf := &strings.Builder{}
+	ast.Walk(root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.WalkContinue
+		}
+		buf.WriteString(n.Type.String() + " " + n.Position.String() + "\n")
+		return ast.WalkContinue
+	})
+	return buf.String()
+}
@@ -0,0 +1,158 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+)
+
+var streamTests = []parseTest{
+
+	{"7", "[foo]\n\n[foo]: /url\n", "<p><a href=\"/url\">foo</a></p>\n"},
+	{"6", "foo\r\nbar\rbaz\r\n\r\n# qux\r\n", "<p>foo<br />\nbar<br />\nbaz</p>\n<h1 id=\"qux\">qux</h1>\n"},
+	{"5", "> [foo]: /url\n\n[foo]\n", "<blockquote>\n</blockquote>\n<p><a href=\"/url\">foo</a></p>\n"},
+	{"4", "[^1]: note\n\nfoo[^1] bar[^1]\n", "<p>foo<sup class=\"footnotes-ref\" id=\"footnotes-ref-1\"><a href=\"#footnotes-def-1\">1</a></sup> bar<sup class=\"footnotes-ref\" id=\"footnotes-ref-1:2\"><a href=\"#footnotes-def-1\">1</a></sup></p>\n<div class=\"footnotes-defs-div\"><hr class=\"footnotes-defs-hr\" />\n<ol class=\"footnotes-defs-ol\"><li id=\"footnotes-def-1\"><p>note <a href=\"#footnotes-ref-1\" class=\"vditor-footnotes__goto-ref\">↩</a> <a href=\"#footnotes-ref-1:2\" class=\"vditor-footnotes__goto-ref\">↩</a></p>\n</li>\n</ol></div>"},
+	{"3", "[foo]: /url \"title\"\n\n[foo] and [Foo][]\n", "<p><a href=\"/url\" title=\"title\">foo</a> and <a href=\"/url\" title=\"title\">Foo</a></p>\n"},
+	{"2", "| a | b |\n| - | - |\n| 1 | 2 |\n\nfoo\n===\n", "<table>\n<thead>\n<tr>\n<th>a</th>\n<th>b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>1</td>\n<td>2</td>\n</tr>\n</tbody>\n</table>\n<h1 id=\"foo\">foo</h1>\n"},
+	{"1", "- a\n\n  b\n- c\n\n```go\nfoo\n\nbar\n```\n", "<ul>\n<li>\n<p>a</p>\n<p>b</p>\n</li>\n<li>\n<p>c</p>\n</li>\n</ul>\n<pre><code class=\"language-go\">foo\n\nbar\n</code></pre>\n"},
+	{"0", "# foo\n\n*bar*\n", "<h1 id=\"foo\">foo</h1>\n<p><em>bar</em></p>\n"},
+}
+
+func TestMarkdownTo(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetHeadingID(true)
+	luteEngine.SetCodeSyntaxHighlight(false)
+	for _, test := range streamTests {
+		buf := &bytes.Buffer{}
+		if err := luteEngine.MarkdownTo(buf, iotest.OneByteReader(strings.NewReader(test.from))); nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		if html := buf.String(); test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+}
+
+func TestMarkdownToForwardRefs(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetCodeSyntaxHighlight(false)
+	sources := []string{
+		"[foo] and ![bar][]\n\n# [foo][bar]\n\n[foo]: /foo\n[bar]: /bar.png \"t\"\n",
+		"foo[^1] bar[^2]\n\nbaz[^1]\n\n[^2]: two\n[^1]: one[^2]\n\nqux[^2]\n",
+		"[x] is not a reference\n\n- [ ] task\n\n| a | b |\n| - | - |\n| [foo] | 1 |\n\n> [foo]: /url\n\n[foo]\n",
+		"[foo]: /url\n\n[foo] [bar]\n\n```\n[bar]\n```\n\n[bar]: /bar\n",
+	}
+	for i, source := range sources {
+		buf := &bytes.Buffer{}
+		if err := luteEngine.MarkdownTo(buf, iotest.OneByteReader(strings.NewReader(source))); nil != err {
+			t.Fatalf("test case [%d] failed: %s", i, err)
+		}
+		if expected := luteEngine.MarkdownStr("", source); expected != buf.String() {
+			t.Fatalf("test case [%d] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", i, expected, buf.String(), source)
+		}
+	}
+}
+
+func TestMarkdownToSourcePos(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetHeadingID(true)
+	luteEngine.SetCodeSyntaxHighlight(false)
+	luteEngine.ParseOptions.SourcePos = true
+	luteEngine.ParseOptions.KramdownBlockIAL = true
+	luteEngine.ParseOptions.KramdownSpanIAL = true
+	for _, test := range streamTests {
+		buf := &bytes.Buffer{}
+		if err := luteEngine.MarkdownTo(buf, strings.NewReader(test.from)); nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		if html := buf.String(); test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+	if !luteEngine.ParseOptions.SourcePos || !luteEngine.ParseOptions.KramdownBlockIAL {
+		t.Fatalf("stream parsing should not modify engine options")
+	}
+}
+
+func TestMarkdownToSpec(t *testing.T) {
+	data, err := os.ReadFile("commonmark-spec.json")
+	if nil != err {
+		t.Fatalf("read spec test cases failed: " + err.Error())
+	}
+	var testcases []testcase
+	if err = json.Unmarshal(data, &testcases); nil != err {
+		t.Fatalf("read spec test caes failed: " + err.Error())
+	}
+
+	luteEngine := lute.New()
+	luteEngine.ParseOptions.GFMTaskListItem = false
+	luteEngine.ParseOptions.GFMTable = false
+	luteEngine.ParseOptions.GFMAutoLink = false
+	luteEngine.ParseOptions.GFMStrikethrough = false
+	luteEngine.RenderOptions.SoftBreak2HardBreak = false
+	luteEngine.RenderOptions.CodeSyntaxHighlight = false
+	luteEngine.ParseOptions.HeadingID = false
+	luteEngine.RenderOptions.HeadingID = false
+	luteEngine.RenderOptions.AutoSpace = false
+	luteEngine.RenderOptions.FixTermTypo = false
+	luteEngine.ParseOptions.Emoji = false
+	luteEngine.ParseOptions.YamlFrontMatter = false
+
+	for _, test := range testcases {
+		testName := test.Section + " " + strconv.Itoa(test.Example)
+		buf := &bytes.Buffer{}
+		if err = luteEngine.MarkdownTo(buf, strings.NewReader(test.Markdown)); nil != err {
+			t.Fatalf("test case [%s] failed: %s", testName, err)
+		}
+		if html := buf.String(); test.HTML != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", testName, test.HTML, html, test.Markdown)
+		}
+	}
+}
+
+func TestParseStream(t *testing.T) {
+	var blocks []ast.NodeType
+	tree, err := parse.ParseStream("", strings.NewReader("[foo]: /url\n\n# foo\n\n> bar\n"), lute.New().ParseOptions, func(tree *parse.Tree, block *ast.Node) error {
+		blocks = append(blocks, block.Type)
+		if nil != block.Next && block.Next.Close && ast.NodeLinkRefDefBlock != block.Type {
+			t.Fatalf("block [%s] should be handled after previous block", block.Next.Type)
+		}
+		return nil
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	if 3 != len(blocks) || ast.NodeLinkRefDefBlock != blocks[0] || ast.NodeHeading != blocks[1] || ast.NodeBlockquote != blocks[2] {
+		t.Fatalf("unexpected blocks %v", blocks)
+	}
+	if tree.Root.FirstChild != tree.Root.LastChild || ast.NodeLinkRefDefBlock != tree.Root.FirstChild.Type {
+		t.Fatalf("only definitions should be kept")
+	}
+
+	stop := errors.New("stop")
+	_, err = parse.ParseStream("", strings.NewReader("foo\n\nbar\n"), lute.New().ParseOptions, func(tree *parse.Tree, block *ast.Node) error {
+		return stop
+	})
+	if stop != err {
+		t.Fatalf("handler error should be returned: %v", err)
+	}
+}
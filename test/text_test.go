@@ -0,0 +1,75 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/render"
+)
+
+var textTests = []parseTest{
+
+	{"11", "> [!TIP]\n> foo\n", "│ 💡 Tip\n│ foo\n"},
+	{"10", "foo[^1] bar[^2]\n\n[^1]: first\n[^2]: second\n\n    more\n", "foo[1] bar[2]\n\n──────────\n[1] first\n[2] second\n\n    more\n"},
+	{"9", "| a | b |\n|:-:|--:|\n| 中文 | 1 |\n", "┌──────┬───┐\n│  a   │ b │\n├──────┼───┤\n│ 中文 │ 1 │\n└──────┴───┘\n"},
+	{"8", "foo\n\n---\n\nbar\n", "foo\n\n────────────────────────────────────────\n\nbar\n"},
+	{"7", "```go\nif a {\n\tb\n}\n```\n", "    if a {\n    \tb\n    }\n"},
+	{"6", "- [x] done\n- [ ] todo\n", "[x] done\n[ ] todo\n"},
+	{"5", "3. foo\n4. bar baz qux quux corge grault garply waldo\n   - nested\n     - deep\n", "3. foo\n4. bar baz qux quux corge grault garply\n   waldo\n   ◦ nested\n     ▪ deep\n"},
+	{"4", "> foo bar baz qux quux corge grault garply\n>\n> bar\n", "│ foo bar baz qux quux corge grault\n│ garply\n│\n│ bar\n"},
+	{"3", "中文内容需要在任意两个汉字之间折行，English words 不能被截断。\n", "中文内容需要在任意两个汉字之间折行，\nEnglish words 不能被截断。\n"},
+	{"2", "**foo** *bar* `baz` [link](https://b3log.org) <https://ld246.com> ![img](a.png)\n", "foo bar `baz` link (https://b3log.org)\nhttps://ld246.com [image: img]\n"},
+	{"1", "# foo\n\n## bar\n\n### baz\n", "foo\n===\n\nbar\n---\n\n### baz\n"},
+	{"0", "foo bar baz qux quux corge grault garply waldo fred plugh xyzzy thud\n", "foo bar baz qux quux corge grault garply\nwaldo fred plugh xyzzy thud\n"},
+}
+
+func TestText(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetCallout(true)
+	textOptions := render.NewTextOptions()
+	textOptions.Width = 40
+
+	for _, test := range textTests {
+		text, err := luteEngine.Text(test.name, []byte(test.from), textOptions)
+		if nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		if test.to != string(text) {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, text, test.from)
+		}
+	}
+}
+
+var textANSITests = []parseTest{
+
+	{"2", "foo bar baz qux quux corge **grault garply waldo** fred\n", "foo bar baz qux quux corge \x1b[1mgrault garply\x1b[0m\n\x1b[1mwaldo\x1b[0m fred\n"},
+	{"1", "`code` [link](https://b3log.org)\n", "\x1b[33mcode\x1b[0m \x1b[4;34mlink\x1b[0m (https://b3log.org)\n"},
+	{"0", "# foo *bar*\n", "\x1b[1;36mfoo \x1b[3mbar\x1b[0m\x1b[1;36m\x1b[0m\n=======\n"},
+}
+
+func TestTextANSI(t *testing.T) {
+	luteEngine := lute.New()
+	textOptions := render.NewTextOptions()
+	textOptions.Width = 40
+	textOptions.ANSI = true
+
+	for _, test := range textANSITests {
+		text, err := luteEngine.Text(test.name, []byte(test.from), textOptions)
+		if nil != err {
+			t.Fatalf("test case [%s] failed: %s", test.name, err)
+		}
+		if test.to != string(text) {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, text, test.from)
+		}
+	}
+}
@@ -33,3 +33,86 @@ func TestToC(t *testing.T) {
 		}
 	}
 }
+
+var tocParamsTests = []parseTest{
+
+	{"7", "[toc is great]\n", "<p>[toc is great]</p>\n"},
+	{"6", "[TOC of chapter 1]\n", "<p>[TOC of chapter 1]</p>\n"},
+	{"5", "[toc levels=7]\n", "<p>[toc levels=7]</p>\n"},
+	{"4", "[toc numbered=yes]\n", "<p>[toc numbered=yes]</p>\n"},
+	{"3", "[toc foo [bar]]\n", "<p>[toc foo [bar]]</p>\n"},
+	{"2", "[TOC]\n\n## A\n{: class=\"no-toc\"}\n\n## B\n{: toc=\"false\"}\n\n## C\n", "<div class=\"vditor-toc\" data-block=\"0\" data-type=\"toc-block\" contenteditable=\"false\"><ul><li><span data-target-id=\"C\">C</span></li></ul></div>\n<h2 id=\"A\">A</h2>\n<h2 id=\"B\">B</h2>\n<h2 id=\"C\">C</h2>\n"},
+	{"1", "[toc levels=2]\n\n# X\n\n## Y\n\n### Z\n", "<div class=\"vditor-toc\" data-block=\"0\" data-type=\"toc-block\" contenteditable=\"false\"><ul><li><span data-target-id=\"Y\">Y</span></li></ul></div>\n<h1 id=\"X\">X</h1>\n<h2 id=\"Y\">Y</h2>\n<h3 id=\"Z\">Z</h3>\n"},
+	{"0", "[toc levels=2-3 numbered]\n\n# Doc\n\n## A\n\n### A1\n\n#### A1a\n\n## B\n\n### B1\n", "<div class=\"vditor-toc\" data-block=\"0\" data-type=\"toc-block\" contenteditable=\"false\"><ul><li><span data-target-id=\"A\">1 A</span><ul><li><span data-target-id=\"A1\">1.1 A1</span></li></ul></li><li><span data-target-id=\"B\">2 B</span><ul><li><span data-target-id=\"B1\">2.1 B1</span></li></ul></li></ul></div>\n<h1 id=\"Doc\">Doc</h1>\n<h2 id=\"A\">A</h2>\n<h3 id=\"A1\">A1</h3>\n<h4 id=\"A1a\">A1a</h4>\n<h2 id=\"B\">B</h2>\n<h3 id=\"B1\">B1</h3>\n"},
+}
+
+func TestToCParams(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetHeadingID(true)
+	luteEngine.SetToC(true)
+	luteEngine.SetKramdownIAL(true)
+
+	for _, test := range tocParamsTests {
+		html := luteEngine.MarkdownStr(test.name, test.from)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+}
+
+var tocNumberHeadingsTests = []parseTest{
+
+	{"0", "[toc]\n\n# Doc\n\n## A\n\n### A1\n\n## B\n", "<div class=\"vditor-toc\" data-block=\"0\" data-type=\"toc-block\" contenteditable=\"false\"><ul><li><span data-target-id=\"A\">1 A</span><ul><li><span data-target-id=\"A1\">1.1 A1</span></li></ul></li><li><span data-target-id=\"B\">2 B</span></li></ul></div>\n<h1 id=\"Doc\">Doc</h1>\n<h2 id=\"A\">1 A</h2>\n<h3 id=\"A1\">1.1 A1</h3>\n<h2 id=\"B\">2 B</h2>\n"},
+}
+
+func TestToCNumberHeadings(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetToC(true)
+	luteEngine.SetToCLevels(2, 3)
+	luteEngine.SetToCNumbering(true)
+	luteEngine.SetToCNumberHeadings(true)
+
+	for _, test := range tocNumberHeadingsTests {
+		html := luteEngine.MarkdownStr(test.name, test.from)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+}
+
+var tocFormatTests = []parseTest{
+
+	{"1", "[TOC]\n\n# A\n", "[toc]\n\n# A\n"},
+	{"0", "[toc levels=2-3 numbered]\n\n# A\n", "[toc levels=2-3 numbered]\n\n# A\n"},
+}
+
+func TestToCFormat(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetToC(true)
+
+	for _, test := range tocFormatTests {
+		formatted := luteEngine.FormatStr(test.name, test.from)
+		if test.to != formatted {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, formatted, test.from)
+		}
+	}
+}
+
+var tocParamsNumberHeadingsTests = []parseTest{
+
+	{"0", "[toc levels=2-3 numbered]\n\n# A\n\n## B\n\n### C\n\n#### D\n", "<div class=\"vditor-toc\" data-block=\"0\" data-type=\"toc-block\" contenteditable=\"false\"><ul><li><span data-target-id=\"B\">1 B</span><ul><li><span data-target-id=\"C\">1.1 C</span></li></ul></li></ul></div>\n<h1 id=\"A\">A</h1>\n<h2 id=\"B\">1 B</h2>\n<h3 id=\"C\">1.1 C</h3>\n<h4 id=\"D\">D</h4>\n"},
+}
+
+func TestToCParamsNumberHeadings(t *testing.T) {
+	// 标题编号需要和 [toc] 参数生成的目录编号一致
+	luteEngine := lute.New()
+	luteEngine.SetToC(true)
+	luteEngine.SetToCNumberHeadings(true)
+
+	for _, test := range tocParamsNumberHeadingsTests {
+		html := luteEngine.MarkdownStr(test.name, test.from)
+		if test.to != html {
+			t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", test.name, test.to, html, test.from)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+	"github.com/88250/lute/render"
+)
+
+func TestTreeJSONSpec(t *testing.T) {
+	bytes, err := os.ReadFile("commonmark-spec.json")
+	if nil != err {
+		t.Fatalf("read spec test cases failed: " + err.Error())
+	}
+
+	var testcases []testcase
+	if err = json.Unmarshal(bytes, &testcases); nil != err {
+		t.Fatalf("read spec test caes failed: " + err.Error())
+	}
+
+	luteEngine := lute.New()
+	luteEngine.SetKramdownIAL(true)
+	luteEngine.SetSourcePos(true)
+	for _, test := range testcases {
+		testName := test.Section + " " + strconv.Itoa(test.Example)
+		testTreeJSON(t, luteEngine, testName, test.Markdown)
+	}
+}
+
+var treeJSONTests = []parseTest{
+
+	{"3", "{{{row\nfoo ((20200101000000-abcdefg \"ref\")) ==m== #tag# ^sup^\n{: id=\"20200101000000-aaaaaaa\"}\n\n- [x] bar\n{: id=\"20200101000000-bbbbbbb\"}\n\n}}}\n{: id=\"20200101000000-ccccccc\"}\n\n\n{: id=\"20200101000000-ddddddd\" title=\"doc\" type=\"doc\"}\n", ""},
+	{"2", "foo[^1] bar[^1]\n\n[^1]: note\n\n[link][ref] ![img](/i.png \"t\")\n\n[ref]: /url\n", ""},
+	{"1", "> [!TIP] title\n> callout\n\n| a | b |\n|:-|-:|\n| 1 | 2 |\n\n~~~go info\ncode\n~~~\n\n$$\nx\n$$\n", ""},
+	{"0", "---\ntitle: x\n---\n\n# heading {#id}\n\n1) a\n2) b\n\n* c\n\n  d\n\n\xff\xfe\n", ""},
+}
+
+func TestTreeJSON(t *testing.T) {
+	luteEngine := lute.New()
+	luteEngine.SetKramdownIAL(true)
+	luteEngine.SetSuperBlock(true)
+	luteEngine.SetBlockRef(true)
+	luteEngine.SetTag(true)
+	luteEngine.SetMark(true)
+	luteEngine.SetSup(true)
+	luteEngine.SetCallout(true)
+
+	for _, test := range treeJSONTests {
+		testTreeJSON(t, luteEngine, test.name, test.from)
+	}
+}
+
+func TestUnmarshalTreeError(t *testing.T) {
+	options := lute.New().ParseOptions
+	if _, err := parse.UnmarshalTree([]byte(`{"Spec":"0","Root":{"Type":"NodeDocument"}}`), options); nil == err {
+		t.Fatalf("unmarshal tree with unsupported spec should fail")
+	}
+	if _, err := parse.UnmarshalTree([]byte(`{"Spec":"`+parse.TreeSpec+`","Root":{"Type":"NodeFoo"}}`), options); nil == err {
+		t.Fatalf("unmarshal tree with unknown node type should fail")
+	}
+	if _, err := parse.UnmarshalTree([]byte(`{"Spec":`), options); nil == err {
+		t.Fatalf("unmarshal invalid json should fail")
+	}
+}
+
+func testTreeJSON(t *testing.T, luteEngine *lute.Lute, name, markdown string) {
+	tree := parse.Parse(name, []byte(markdown), luteEngine.ParseOptions)
+	tree.ID, tree.Box, tree.Path = "20200101000000-ddddddd", "box", "/path.sy"
+	data, err := json.Marshal(tree)
+	if nil != err {
+		t.Fatalf("test case [%s] marshal tree failed: %s", name, err)
+	}
+	got, err := parse.UnmarshalTree(data, luteEngine.ParseOptions)
+	if nil != err {
+		t.Fatalf("test case [%s] unmarshal tree failed: %s", name, err)
+	}
+	if tree.Name != got.Name || tree.ID != got.ID || tree.Box != got.Box || tree.Path != got.Path {
+		t.Fatalf("test case [%s] tree attributes mismatch", name)
+	}
+	if msg := diffNode(tree.Root, got.Root); "" != msg {
+		t.Fatalf("test case [%s] failed: %s\noriginal markdown text\n\t%q", name, msg, markdown)
+	}
+
+	expected := string(render.NewHtmlRenderer(tree, luteEngine.RenderOptions, luteEngine.ParseOptions).Render())
+	html := string(render.NewHtmlRenderer(got, luteEngine.RenderOptions, luteEngine.ParseOptions).Render())
+	if expected != html {
+		t.Fatalf("test case [%s] failed\nexpected\n\t%q\ngot\n\t%q\noriginal markdown text\n\t%q", name, expected, html, markdown)
+	}
+}
+
+// normalizeEmptyBytes 将节点上除 Tokens 以外的空字节数组字段统一为 nil，这些字段序列化时不区分 nil 和空。
+func normalizeEmptyBytes(node *ast.Node) {
+	v := reflect.ValueOf(node).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if f := v.Field(i); "Tokens" != v.Type().Field(i).Name && reflect.Slice == f.Kind() && 0 == f.Len() {
+			f.Set(reflect.Zero(f.Type()))
+		}
+	}
+	if nil != node.ListData && 0 == len(node.ListData.Marker) {
+		listData := *node.ListData
+		listData.Marker = nil
+		node.ListData = &listData
+	}
+}
+
+// diffNode 比较两棵语法树的节点，完全相同时返回 ""。
+func diffNode(expected, got *ast.Node) string {
+	for e, g := expected, got; nil != e || nil != g; e, g = e.Next, g.Next {
+		if nil == e || nil == g {
+			return "children count mismatch under [" + expected.Parent.Type.String() + "]"
+		}
+
+		if len(e.FootnotesRefs) != len(g.FootnotesRefs) {
+			return "footnotes refs mismatch of [" + e.Type.String() + "]"
+		}
+		for i := range e.FootnotesRefs {
+			if e.FootnotesRefs[i].FootnotesRefId != g.FootnotesRefs[i].FootnotesRefId || g.FootnotesRefs[i].Parent == nil {
+				return "footnotes refs mismatch of [" + e.Type.String() + "]"
+			}
+		}
+
+		ec, gc := *e, *g
+		for _, n := range []*ast.Node{&ec, &gc} {
+			n.Parent, n.Previous, n.Next, n.FirstChild, n.LastChild, n.FootnotesRefs = nil, nil, nil, nil, nil, nil
+			normalizeEmptyBytes(n)
+		}
+		if !reflect.DeepEqual(ec, gc) {
+			return "node mismatch\nexpected\n\t" + e.Type.String() + " " + strconv.Quote(string(e.Tokens)) + "\ngot\n\t" + g.Type.String() + " " + strconv.Quote(string(g.Tokens))
+		}
+
+		if nil != e.FirstChild || nil != g.FirstChild {
+			if nil == e.FirstChild || nil == g.FirstChild {
+				return "children count mismatch under [" + e.Type.String() + "]"
+			}
+			if msg := diffNode(e.FirstChild, g.FirstChild); "" != msg {
+				return msg
+			}
+		}
+	}
+	return ""
+}
@@ -36,10 +36,12 @@ func ParseHTML(htmlStr string) *html.Node {
 	if nil != err {
 		return nil
 	}
-	if "html" != doc.FirstChild.Data {
-		return doc
+	for c := doc.FirstChild; nil != c; c = c.NextSibling {
+		if html.ElementNode == c.Type && atom.Html == c.DataAtom {
+			return c.LastChild // doc.html.body
+		}
 	}
-	return doc.FirstChild.LastChild // doc.html.body
+	return doc
 }
 
 func GetTextMarkTextDataWithoutEscapeQuote(n *html.Node) (content string) {
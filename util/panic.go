@@ -35,3 +35,8 @@ func RecoverPanic(err *error) {
 		}
 	}
 }
+
+// Stack 返回当前 goroutine 的调用栈。
+func Stack() []byte {
+	return debug.Stack()
+}
@@ -15,3 +15,8 @@ package util
 // Recover recovers a panic.
 func RecoverPanic(err *error) {
 }
+
+// Stack 返回当前 goroutine 的调用栈，JavaScript 环境下不支持。
+func Stack() []byte {
+	return nil
+}
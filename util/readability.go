@@ -0,0 +1,424 @@
+// Lute - 一款结构化的 Markdown 引擎，支持 Go 和 JavaScript
+// Copyright (c) 2019-present, b3log.org
+//
+// Lute is licensed under Mulan PSL v2.
+// You can use this software according to the terms and conditions of the Mulan PSL v2.
+// You may obtain a copy of Mulan PSL v2 at:
+//         http://license.coscl.org.cn/MulanPSL2
+// THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+// See the Mulan PSL v2 for more details.
+
+package util
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/88250/lute/html"
+	"github.com/88250/lute/html/atom"
+)
+
+// Article 描述了从网页中提取的正文及其元数据。
+type Article struct {
+	Title     string     // 标题
+	Byline    string     // 作者
+	Published string     // 发布时间
+	Content   *html.Node // 正文，转换时使用其子节点
+}
+
+// ExtractArticle 从网页 DOM 树 root 中提取正文和元数据，提取过程会修改 root。
+//
+// 提取方式参考 Mozilla Readability：先剔除导航栏、页脚、Cookie 提示等明显不属于正文的元素，然后按照段落的文本长度和逗号数量为其祖先元素打分，
+// 得分按照链接密度进行衰减，最后选择得分最高的元素以及与其得分相近的兄弟元素作为正文。找不到合适的元素时使用剔除后的 body 作为正文。
+func ExtractArticle(root *html.Node) (ret *Article) {
+	ret = &Article{}
+	ret.Title, ret.Byline, ret.Published = articleMeta(root)
+
+	body := articleBody(root)
+	byline := articleByline(body)
+	if nil != byline && "" == ret.Byline {
+		ret.Byline = articleText(byline)
+	}
+	if "" == ret.Published {
+		if time := domFirst(body, func(n *html.Node) bool { return atom.Time == n.DataAtom && "" != DomAttrValue(n, "datetime") }); nil != time {
+			ret.Published = strings.TrimSpace(DomAttrValue(time, "datetime"))
+		}
+	}
+	removeUnlikely(body, byline)
+
+	ret.Content = body
+	top, scores := topCandidate(body)
+	if nil == top {
+		return
+	}
+
+	content := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	threshold := scores[top] * 0.2
+	if 10 > threshold {
+		threshold = 10
+	}
+	var siblings []*html.Node
+	for s := top.Parent.FirstChild; nil != s; s = s.NextSibling {
+		if s == top || (html.ElementNode == s.Type && threshold <= scores[s]) || articleParagraph(s) {
+			siblings = append(siblings, s)
+		}
+	}
+	for _, s := range siblings {
+		s.Unlink()
+		content.AppendChild(s)
+	}
+	cleanConditionally(content)
+	ret.Content = content
+	return
+}
+
+// articleMeta 从 meta 和 title 元素中读取标题、作者和发布时间。
+func articleMeta(root *html.Node) (title, byline, published string) {
+	metas := map[string]string{}
+	var docTitle string
+	var h1s []string
+	domWalk(root, func(n *html.Node) bool {
+		switch n.DataAtom {
+		case atom.Meta:
+			key := DomAttrValue(n, "property")
+			if "" == key {
+				key = DomAttrValue(n, "name")
+			}
+			if "" == key {
+				key = DomAttrValue(n, "itemprop")
+			}
+			key = strings.ToLower(strings.TrimSpace(key))
+			if content := strings.TrimSpace(DomAttrValue(n, "content")); "" != key && "" != content && "" == metas[key] {
+				metas[key] = content
+			}
+		case atom.Title:
+			if "" == docTitle {
+				docTitle = articleText(n)
+			}
+		case atom.H1:
+			h1s = append(h1s, articleText(n))
+		}
+		return true
+	})
+
+	title = firstNonEmpty(metas["og:title"], metas["twitter:title"], metas["dc.title"])
+	if "" == title {
+		title = docTitle
+		// <title> 一般会带上网站名称，比如“标题 - 网站”，如果页面中唯一的 h1 是其中的一部分则使用 h1
+		if 1 == len(h1s) && "" != h1s[0] && strings.Contains(docTitle, h1s[0]) {
+			title = h1s[0]
+		}
+	}
+
+	byline = firstNonEmpty(metas["author"], metas["dc.creator"], metas["twitter:creator"])
+	if author := metas["article:author"]; "" == byline && !strings.Contains(author, "://") {
+		byline = author
+	}
+	published = firstNonEmpty(metas["article:published_time"], metas["datepublished"], metas["date"], metas["pubdate"], metas["publishdate"], metas["dc.date"], metas["dcterms.date"])
+	return
+}
+
+// articleByline 查找正文中的作者元素。
+func articleByline(body *html.Node) *html.Node {
+	return domFirst(body, func(n *html.Node) bool {
+		if "author" != DomAttrValue(n, "rel") && "author" != DomAttrValue(n, "itemprop") {
+			matchString := classAndID(n)
+			if !strings.Contains(matchString, "byline") && !strings.Contains(matchString, "author") {
+				return false
+			}
+		}
+		text := articleText(n)
+		return "" != text && 100 > utf8.RuneCountInString(text)
+	})
+}
+
+func articleBody(root *html.Node) *html.Node {
+	if body := domFirst(root, func(n *html.Node) bool { return atom.Body == n.DataAtom }); nil != body {
+		return body
+	}
+	return root
+}
+
+// articleDropAtoms 为不属于正文的元素。
+var articleDropAtoms = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Link: true, atom.Template: true, atom.Nav: true, atom.Footer: true,
+	atom.Aside: true, atom.Form: true, atom.Button: true, atom.Select: true, atom.Textarea: true, atom.Dialog: true, atom.Menu: true,
+}
+
+// articleDropRoles 为不属于正文的 ARIA 角色。
+var articleDropRoles = map[string]bool{
+	"navigation": true, "banner": true, "contentinfo": true, "complementary": true, "dialog": true, "alertdialog": true, "menu": true, "menubar": true,
+}
+
+// articleUnlikely 为不太可能是正文的类名或者 ID 关键字，articleMaybe 为可能是正文的关键字，两者都匹配时保留元素。
+var articleUnlikely = []string{
+	"banner", "breadcrumb", "combx", "comment", "community", "cookie", "consent", "disqus", "footer", "gdpr", "header", "menu", "navbar",
+	"navigation", "pagination", "pager", "popup", "modal", "related", "remark", "replies", "rss", "share", "sharing", "shoutbox", "sidebar",
+	"skyscraper", "social", "sponsor", "subscribe", "newsletter", "advert", "promo", "signup", "login",
+}
+
+var articleMaybe = []string{"article", "body", "column", "content", "main", "post"}
+
+// articleNegative 和 articlePositive 为打分时使用的类名或者 ID 关键字。
+var articleNegative = append([]string{"hidden", "meta", "outbrain", "shopping", "tags", "tool", "widget", "byline", "author", "ad-"}, articleUnlikely...)
+
+var articlePositive = []string{"article", "body", "content", "entry", "hentry", "h-entry", "main", "page", "post", "text", "blog", "story"}
+
+// removeUnlikely 剔除不属于正文的元素以及作者元素 byline。
+func removeUnlikely(body, byline *html.Node) {
+	var unlinks []*html.Node
+	domWalk(body, func(n *html.Node) bool {
+		if html.CommentNode == n.Type {
+			unlinks = append(unlinks, n)
+			return false
+		}
+		if html.ElementNode != n.Type || n == body {
+			return true
+		}
+		if n == byline || articleDropAtoms[n.DataAtom] || articleDropRoles[DomAttrValue(n, "role")] || articleHidden(n) {
+			unlinks = append(unlinks, n)
+			return false
+		}
+		if atom.A != n.DataAtom {
+			matchString := classAndID(n)
+			if containsAny(matchString, articleUnlikely) && !containsAny(matchString, articleMaybe) {
+				unlinks = append(unlinks, n)
+				return false
+			}
+		}
+		return true
+	})
+	for _, n := range unlinks {
+		n.Unlink()
+	}
+}
+
+func articleHidden(n *html.Node) bool {
+	if ExistDomAttr(n, "hidden") || "true" == DomAttrValue(n, "aria-hidden") {
+		return true
+	}
+	style := strings.ReplaceAll(strings.ToLower(DomAttrValue(n, "style")), " ", "")
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+// topCandidate 为正文候选元素打分，返回得分最高的元素以及所有元素的得分。
+func topCandidate(body *html.Node) (top *html.Node, scores map[*html.Node]float64) {
+	scores = map[*html.Node]float64{}
+	var candidates []*html.Node
+	domWalk(body, func(n *html.Node) bool {
+		switch n.DataAtom {
+		case atom.P, atom.Pre, atom.Td, atom.Section, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		case atom.Div:
+			if hasBlockChild(n) {
+				return true
+			}
+		default:
+			return true
+		}
+
+		text := articleText(n)
+		length := utf8.RuneCountInString(text)
+		if 25 > length {
+			return true
+		}
+
+		score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，"))
+		if bonus := length / 100; 3 < bonus {
+			score += 3
+		} else {
+			score += float64(bonus)
+		}
+
+		level := 0
+		for ancestor := n.Parent; nil != ancestor && ancestor != body.Parent && 3 > level; ancestor = ancestor.Parent {
+			if html.ElementNode != ancestor.Type {
+				break
+			}
+			if _, ok := scores[ancestor]; !ok {
+				scores[ancestor] = initScore(ancestor)
+				candidates = append(candidates, ancestor)
+			}
+			switch level {
+			case 0:
+				scores[ancestor] += score
+			case 1:
+				scores[ancestor] += score / 2
+			default:
+				scores[ancestor] += score / float64(level*3)
+			}
+			level++
+		}
+		return true
+	})
+
+	for _, candidate := range candidates {
+		scores[candidate] *= 1 - linkDensity(candidate)
+		if nil == top || scores[top] < scores[candidate] {
+			top = candidate
+		}
+	}
+	if nil == top || body == top || atom.Html == top.DataAtom || nil == top.Parent {
+		return nil, scores
+	}
+	return
+}
+
+func initScore(n *html.Node) (ret float64) {
+	switch n.DataAtom {
+	case atom.Div:
+		ret = 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		ret = 3
+	case atom.Address, atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li, atom.Form:
+		ret = -3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		ret = -5
+	}
+	return ret + classWeight(n)
+}
+
+func classWeight(n *html.Node) (ret float64) {
+	for _, s := range []string{strings.ToLower(DomAttrValue(n, "class")), strings.ToLower(DomAttrValue(n, "id"))} {
+		if "" == s {
+			continue
+		}
+		if containsAny(s, articleNegative) {
+			ret -= 25
+		}
+		if containsAny(s, articlePositive) {
+			ret += 25
+		}
+	}
+	return
+}
+
+// articleParagraph 判断正文元素的兄弟元素 n 是否是需要一并提取的段落。
+func articleParagraph(n *html.Node) bool {
+	if atom.P != n.DataAtom {
+		return false
+	}
+	text := articleText(n)
+	length := utf8.RuneCountInString(text)
+	density := linkDensity(n)
+	if 80 < length {
+		return 0.25 > density
+	}
+	return 0 < length && 0 == density && (strings.Contains(text, ". ") || strings.Contains(text, "。"))
+}
+
+// cleanConditionally 剔除正文中负面类名或者链接密度过高的容器元素。
+func cleanConditionally(content *html.Node) {
+	var unlinks []*html.Node
+	domWalk(content, func(n *html.Node) bool {
+		switch n.DataAtom {
+		case atom.Div, atom.Section, atom.Ul, atom.Ol, atom.Table:
+		default:
+			return true
+		}
+
+		if 0 > classWeight(n) {
+			unlinks = append(unlinks, n)
+			return false
+		}
+		if DomExistChildByType(n, atom.Img, atom.Pre, atom.Video, atom.Iframe) {
+			return true
+		}
+		if 0.5 < linkDensity(n) {
+			unlinks = append(unlinks, n)
+			return false
+		}
+		return true
+	})
+	for _, n := range unlinks {
+		n.Unlink()
+	}
+}
+
+// linkDensity 返回元素 n 中链接文本占全部文本的比例。
+func linkDensity(n *html.Node) float64 {
+	length := utf8.RuneCountInString(articleText(n))
+	if 1 > length {
+		return 0
+	}
+
+	linkLength := 0
+	domWalk(n, func(c *html.Node) bool {
+		if atom.A == c.DataAtom {
+			linkLength += utf8.RuneCountInString(articleText(c))
+			return false
+		}
+		return true
+	})
+	return float64(linkLength) / float64(length)
+}
+
+func hasBlockChild(n *html.Node) bool {
+	for c := n.FirstChild; nil != c; c = c.NextSibling {
+		switch c.DataAtom {
+		case atom.Address, atom.Article, atom.Aside, atom.Blockquote, atom.Dl, atom.Div, atom.Figure, atom.Footer, atom.Form, atom.H1, atom.H2, atom.H3,
+			atom.H4, atom.H5, atom.H6, atom.Header, atom.Hr, atom.Main, atom.Nav, atom.Ol, atom.P, atom.Pre, atom.Section, atom.Table, atom.Ul:
+			return true
+		}
+	}
+	return false
+}
+
+// articleText 返回元素 n 的文本内容，连续的空白会被合并为一个空格。
+func articleText(n *html.Node) string {
+	buf := &strings.Builder{}
+	domWalk(n, func(c *html.Node) bool {
+		if html.TextNode == c.Type {
+			buf.WriteString(c.Data)
+			buf.WriteByte(' ')
+		}
+		return atom.Script != c.DataAtom && atom.Style != c.DataAtom
+	})
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+func classAndID(n *html.Node) string {
+	return strings.ToLower(DomAttrValue(n, "class") + " " + DomAttrValue(n, "id"))
+}
+
+func containsAny(s string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(s, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstNonEmpty(strs ...string) string {
+	for _, s := range strs {
+		if "" != s {
+			return s
+		}
+	}
+	return ""
+}
+
+// domWalk 先序遍历 n 及其子孙节点，visitor 返回 false 时不再遍历该节点的子节点。
+func domWalk(n *html.Node, visitor func(n *html.Node) bool) {
+	if !visitor(n) {
+		return
+	}
+	for c := n.FirstChild; nil != c; c = c.NextSibling {
+		domWalk(c, visitor)
+	}
+}
+
+// domFirst 返回 n 的子孙节点中第一个满足 match 的节点。
+func domFirst(n *html.Node, match func(n *html.Node) bool) (ret *html.Node) {
+	domWalk(n, func(c *html.Node) bool {
+		if nil != ret {
+			return false
+		}
+		if c != n && match(c) {
+			ret = c
+			return false
+		}
+		return true
+	})
+	return
+}
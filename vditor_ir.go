@@ -74,6 +74,11 @@ func (lute *Lute) Md2VditorIRDOM(markdown string) (vHTML string) {
 	return
 }
 
+// Md2VditorIRDOME 和 Md2VditorIRDOM 一样将 markdown 转换为 Vditor Instant-Rendering DOM，但是转换过程中发生 panic 时返回 *parse.Error 而不会导致程序崩溃。
+func (lute *Lute) Md2VditorIRDOME(markdown string) (vHTML string, err error) {
+	return recoverE(func() string { return lute.Md2VditorIRDOM(markdown) })
+}
+
 // VditorIRDOM2Md 将 Vditor Instant-Rendering DOM 转换为 markdown，用于从即时渲染模式切换至源码模式。
 func (lute *Lute) VditorIRDOM2Md(htmlStr string) (markdown string) {
 	htmlStr = strings.ReplaceAll(htmlStr, editor.Zwsp, "")
@@ -195,6 +200,8 @@ func (lute *Lute) genASTByVditorIRDOM(n *html.Node, tree *parse.Tree) {
 		} else if "toc-block" == dataType {
 			node := &ast.Node{Type: ast.NodeToC}
 			tree.Context.Tip.AppendChild(node)
+		} else if "git-conflict" == dataType {
+			lute.genASTByVditorGitConflictDOM(n, tree, lute.genASTByVditorIRDOM)
 		} else {
 			text := util.DomText(n)
 			if editor.Caret+"\n" == text { // 处理 FireFox 某些情况下产生的分段
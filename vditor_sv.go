@@ -64,3 +64,8 @@ func (lute *Lute) Md2VditorSVDOM(markdown string) (vHTML string) {
 	vHTML = strings.ReplaceAll(string(output), editor.Caret, "<wbr>")
 	return
 }
+
+// Md2VditorSVDOME 和 Md2VditorSVDOM 一样将 markdown 转换为 Vditor Split-View DOM，但是转换过程中发生 panic 时返回 *parse.Error 而不会导致程序崩溃。
+func (lute *Lute) Md2VditorSVDOME(markdown string) (vHTML string, err error) {
+	return recoverE(func() string { return lute.Md2VditorSVDOM(markdown) })
+}
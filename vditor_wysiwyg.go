@@ -78,6 +78,11 @@ func (lute *Lute) Md2VditorDOM(markdown string) (vHTML string) {
 	return
 }
 
+// Md2VditorDOME 和 Md2VditorDOM 一样将 markdown 转换为 Vditor DOM，但是转换过程中发生 panic 时返回 *parse.Error 而不会导致程序崩溃。
+func (lute *Lute) Md2VditorDOME(markdown string) (vHTML string, err error) {
+	return recoverE(func() string { return lute.Md2VditorDOM(markdown) })
+}
+
 // VditorDOM2Md 将 Vditor DOM 转换为 markdown，用于从所见即所得模式切换至源码模式。
 func (lute *Lute) VditorDOM2Md(htmlStr string) (markdown string) {
 	htmlStr = strings.ReplaceAll(htmlStr, editor.Zwsp, "")
@@ -114,6 +119,11 @@ func (lute *Lute) HTML2Md(html string) (markdown string) {
 	return
 }
 
+// HTML2MdE 和 HTML2Md 一样将 HTML 转换为 markdown，但是转换过程中发生 panic 时返回 *parse.Error 而不会导致程序崩溃。
+func (lute *Lute) HTML2MdE(html string) (markdown string, err error) {
+	return lute.HTML2MarkdownE(html)
+}
+
 func (lute *Lute) vditorDOM2Md(htmlStr string) (markdown string) {
 	// 删掉插入符
 	htmlStr = strings.ReplaceAll(htmlStr, editor.FrontEndCaret, "")
@@ -726,13 +736,13 @@ func (lute *Lute) adjustVditorDOMListItemInP(n *html.Node) {
 	case atom.Li:
 		// li 换行时 id 重复需要重新生成
 		if nil != n.PrevSibling && util.DomAttrValue(n.PrevSibling, "data-node-id") == util.DomAttrValue(n, "data-node-id") {
-			lute.setDOMAttrValue(n, "data-node-id", ast.NewNodeID())
+			lute.setDOMAttrValue(n, "data-node-id", lute.ParseOptions.NewNodeID(nil))
 		}
 		// 松散 li 换行时和上一个 li.last id 重复
 		if nil != n.PrevSibling && nil != n.FirstChild {
 			id := util.DomAttrValue(n.FirstChild, "data-node-id") // id 为空的话是行级节点，列表项行级排版自动换行问题 https://github.com/siyuan-note/siyuan/issues/379
 			if "" != id && nil != n.PrevSibling.LastChild && util.DomAttrValue(n.PrevSibling.LastChild, "data-node-id") == id {
-				lute.setDOMAttrValue(n.FirstChild, "data-node-id", ast.NewNodeID())
+				lute.setDOMAttrValue(n.FirstChild, "data-node-id", lute.ParseOptions.NewNodeID(nil))
 			}
 		}
 
@@ -929,6 +939,8 @@ func (lute *Lute) genASTByVditorDOM(n *html.Node, tree *parse.Tree) {
 		} else if "toc-block" == dataType {
 			node := &ast.Node{Type: ast.NodeToC}
 			tree.Context.Tip.AppendChild(node)
+		} else if "git-conflict" == dataType {
+			lute.genASTByVditorGitConflictDOM(n, tree, lute.genASTByVditorDOM)
 		}
 		return
 	}
@@ -2028,3 +2040,34 @@ func (lute *Lute) suffixSpaces(text string) (ret string) {
 	}
 	return
 }
+
+// genASTByVditorGitConflictDOM 将 Git 冲突 DOM 节点 n 转换为 ast.NodeGitConflict 节点，冲突各部分的内容使用 genAST 进行转换。
+func (lute *Lute) genASTByVditorGitConflictDOM(n *html.Node, tree *parse.Tree, genAST func(n *html.Node, tree *parse.Tree)) {
+	node := &ast.Node{Type: ast.NodeGitConflict}
+	tree.Context.Tip.AppendChild(node)
+	closeMarker := ""
+	for c := n.FirstChild; nil != c; c = c.NextSibling {
+		section := &ast.Node{}
+		marker := util.DomAttrValue(c, "data-marker")
+		switch util.DomAttrValue(c, "data-type") {
+		case "git-conflict-ours":
+			section.Type = ast.NodeGitConflictOurs
+			node.AppendChild(&ast.Node{Type: ast.NodeGitConflictOpenMarker, Tokens: []byte(marker)})
+		case "git-conflict-base":
+			section.Type = ast.NodeGitConflictBase
+			section.Tokens = []byte(marker)
+		case "git-conflict-theirs":
+			section.Type = ast.NodeGitConflictTheirs
+			closeMarker = marker
+		default:
+			continue
+		}
+		node.AppendChild(section)
+		tree.Context.Tip = section
+		for cc := c.FirstChild; nil != cc; cc = cc.NextSibling {
+			genAST(cc, tree)
+		}
+	}
+	node.AppendChild(&ast.Node{Type: ast.NodeGitConflictCloseMarker, Tokens: []byte(closeMarker)})
+	tree.Context.Tip = node.Parent
+}